@@ -1,17 +1,38 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
 	"github.com/hashicorp/go-uuid"
 )
 
+// AccessPolicyHeader identifies the access policy a request is made under,
+// for actions that need to be granted by one.
+const AccessPolicyHeader = "X-Dadcorp-Access-Policy"
+
+// accessDeniedError is the error returned when the request's access policy
+// doesn't grant the action being attempted.
+var accessDeniedError = []api.RequestError{{Header: AccessPolicyHeader, Slug: api.RequestErrAccessDenied}}
+
+// unauthenticatedError is the error returned when an unauthenticated request
+// tries to set up an access policy that can override policy sets. Anyone can
+// name any policy in AccessPolicyHeader, so only authenticated callers can
+// hand out overrides.
+var unauthenticatedError = []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}
+
 type AccessPolicy struct {
-	ID         string      `json:"id"`
-	Type       string      `json:"type"`
-	PolicyData interface{} `json:"policyData"`
+	ID           string      `json:"id"`
+	Organization string      `json:"organization"`
+	Project      string      `json:"project"`
+	Type         string      `json:"type"`
+	PolicyData   interface{} `json:"policyData"`
+	DeletedAt    *time.Time  `json:"deletedAt,omitempty"`
 }
 
 type TerraformPolicy struct {
@@ -44,8 +65,116 @@ type ConsulPolicy struct {
 	Delete    bool   `json:"delete"`
 }
 
+// requestAccessPolicy returns the access policy named by the request's
+// AccessPolicyHeader. It returns ErrAccessPolicyNotFound if the header isn't
+// set, or doesn't name an access policy in the request's project.
+func (a API) requestAccessPolicy(r *http.Request) (AccessPolicy, error) {
+	id := r.Header.Get(AccessPolicyHeader)
+	if id == "" {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	return a.Storer.GetAccessPolicy(requestScope(r), id)
+}
+
+// decodePolicyData copies the access policy's PolicyData into dst. The data
+// is one of the policy structs when it was set by a request, but a generic
+// map when it was read back from a snapshot, so it goes through JSON either
+// way.
+func (ap AccessPolicy) decodePolicyData(dst interface{}) error {
+	b, err := json.Marshal(ap.PolicyData)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// terraformPolicy returns the access policy's TerraformPolicy, if it is a
+// Terraform policy for the workspace identified by workspaceID.
+func (ap AccessPolicy) terraformPolicy(workspaceID string) (TerraformPolicy, bool) {
+	if ap.Type != "terraform" {
+		return TerraformPolicy{}, false
+	}
+	var tf TerraformPolicy
+	err := ap.decodePolicyData(&tf)
+	if err != nil || !strings.EqualFold(tf.WorkspaceID, workspaceID) {
+		return TerraformPolicy{}, false
+	}
+	return tf, true
+}
+
+// vaultPolicy returns the access policy's VaultPolicy, if it is a Vault
+// policy for the cluster identified by clusterID.
+func (ap AccessPolicy) vaultPolicy(clusterID string) (VaultPolicy, bool) {
+	if ap.Type != "vault" {
+		return VaultPolicy{}, false
+	}
+	var vault VaultPolicy
+	err := ap.decodePolicyData(&vault)
+	if err != nil || !strings.EqualFold(vault.ClusterID, clusterID) {
+		return VaultPolicy{}, false
+	}
+	return vault, true
+}
+
+// covers reports whether the policy applies to the secret at path. A Vault
+// policy covers every path that starts with its Key, so an empty Key covers
+// the whole cluster.
+func (vault VaultPolicy) covers(path string) bool {
+	return strings.HasPrefix(path, vault.Key)
+}
+
+// nomadPolicy returns the access policy's NomadPolicy, if it is a Nomad
+// policy for the cluster identified by clusterID.
+func (ap AccessPolicy) nomadPolicy(clusterID string) (NomadPolicy, bool) {
+	if ap.Type != "nomad" {
+		return NomadPolicy{}, false
+	}
+	var nomad NomadPolicy
+	err := ap.decodePolicyData(&nomad)
+	if err != nil || !strings.EqualFold(nomad.ClusterID, clusterID) {
+		return NomadPolicy{}, false
+	}
+	return nomad, true
+}
+
+// consulPolicy returns the access policy's ConsulPolicy, if it is a Consul
+// policy for the cluster identified by clusterID.
+func (ap AccessPolicy) consulPolicy(clusterID string) (ConsulPolicy, bool) {
+	if ap.Type != "consul" {
+		return ConsulPolicy{}, false
+	}
+	var consul ConsulPolicy
+	err := ap.decodePolicyData(&consul)
+	if err != nil || !strings.EqualFold(consul.ClusterID, clusterID) {
+		return ConsulPolicy{}, false
+	}
+	return consul, true
+}
+
+// overridesPolicySets reports whether the access policy lets requests made
+// under it override failed policy set checks. It returns an error if the
+// policy's data can't be decoded.
+func (ap AccessPolicy) overridesPolicySets() (bool, error) {
+	if ap.Type != "terraform" {
+		return false, nil
+	}
+	var tf TerraformPolicy
+	err := ap.decodePolicyData(&tf)
+	if err != nil {
+		return false, err
+	}
+	return tf.OverridePolicies, nil
+}
+
+// covers reports whether the policy applies to key. A Consul policy covers
+// every key that starts with its Key, so an empty Key covers the whole
+// cluster.
+func (consul ConsulPolicy) covers(key string) bool {
+	return strings.HasPrefix(key, consul.Key)
+}
+
 func (a API) handleGetAccessPolicy(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetAccessPolicy(trout.RequestVars(r).Get("id"))
+	ap, err := a.Storer.GetAccessPolicy(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrAccessPolicyNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -64,6 +193,9 @@ func (a API) handlePostAccessPolicy(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	ap.DeletedAt = nil
+	ap.Organization = trout.RequestVars(r).Get("org")
+	ap.Project = trout.RequestVars(r).Get("project")
 	if ap.ID == "" {
 		ap.ID, err = uuid.GenerateUUID()
 		if err != nil {
@@ -277,6 +409,15 @@ func (a API) handlePostAccessPolicy(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/type", Slug: api.RequestErrInvalidValue}}})
 		return
 	}
+	overrides, err := ap.overridesPolicySets()
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/policyData", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if overrides && !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: unauthenticatedError})
+		return
+	}
 	err = a.Storer.CreateAccessPolicy(ap)
 	if err != nil {
 		if err == ErrAccessPolicyAlreadyExists {
@@ -296,6 +437,9 @@ func (a API) handlePutAccessPolicy(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	ap.DeletedAt = nil
+	ap.Organization = trout.RequestVars(r).Get("org")
+	ap.Project = trout.RequestVars(r).Get("project")
 	if ap.ID != "" && ap.ID != trout.RequestVars(r).Get("id") {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 		return
@@ -506,6 +650,15 @@ func (a API) handlePutAccessPolicy(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/type", Slug: api.RequestErrInvalidValue}}})
 		return
 	}
+	overrides, err := ap.overridesPolicySets()
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/policyData", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if overrides && !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: unauthenticatedError})
+		return
+	}
 	err = a.Storer.UpdateAccessPolicy(ap)
 	if err != nil {
 		if err == ErrAccessPolicyNotFound {
@@ -519,21 +672,148 @@ func (a API) handlePutAccessPolicy(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a API) handleDeleteAccessPolicy(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetAccessPolicy(trout.RequestVars(r).Get("id"))
+	ap, err := a.Storer.GetAccessPolicy(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.Storer.DeleteAccessPolicy(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AccessPolicies: []AccessPolicy{ap}})
+}
+
+func (a API) handleGetAccessPolicyVersions(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	_, err := a.Storer.GetAccessPolicy(requestScope(r), id)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	versions, err := a.Storer.ListVersions("accessPolicy", id)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = fillVersionChanges(versions)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Versions: versions})
+}
+
+func (a API) handlePostAccessPolicyVersionRestore(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	// the restored policy could hand out overrides, like a newly created one
+	ap, err := a.Storer.GetAccessPolicyVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	overrides, err := ap.overridesPolicySets()
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/policyData", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if overrides && !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: unauthenticatedError})
+		return
+	}
+	ap, err = a.Storer.RestoreAccessPolicyVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AccessPolicies: []AccessPolicy{ap}})
+}
+
+func (a API) handleListAccessPolicies(w http.ResponseWriter, r *http.Request) {
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deleted", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	results, err := a.Storer.ListAccessPolicies(requestScope(r), includeDeleted)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AccessPolicies: results})
+}
+
+func (a API) handlePostAccessPolicyUndelete(w http.ResponseWriter, r *http.Request) {
+	// undeleting a policy puts any overrides it hands out back in effect
+	ap, err := a.Storer.GetDeletedAccessPolicy(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrAccessPolicyNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteAccessPolicy(trout.RequestVars(r).Get("id"))
+	overrides, err := ap.overridesPolicySets()
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/policyData", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if overrides && !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: unauthenticatedError})
+		return
+	}
+	ap, err = a.Storer.UndeleteAccessPolicy(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrAccessPolicyNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -18,50 +18,370 @@ func (a API) Server(baseURL string) http.Handler {
 	// get information about which regions support which products
 	router.Endpoint("/regions").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetRegions))
 
+	// list the Terraform versions workspaces can use
+	router.Endpoint("/terraform/versions").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleListTerraformVersions))
+	// read a Terraform version from the catalog
+	router.Endpoint("/terraform/versions/{version}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetTerraformVersion))
+
+	// list organizations
+	router.Endpoint("/orgs").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleListOrganizations))
+	// create organization
+	router.Endpoint("/orgs").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostOrganization))
+	// read organization
+	router.Endpoint("/orgs/{org}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetOrganization))
+	// update organization
+	router.Endpoint("/orgs/{org}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutOrganization))
+	// delete organization
+	router.Endpoint("/orgs/{org}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteOrganization))
+
+	// list projects
+	router.Endpoint("/orgs/{org}/projects").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleListProjects))
+	// create project
+	router.Endpoint("/orgs/{org}/projects").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostProject))
+	// read project
+	router.Endpoint(projectPath).Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetProject))
+	// update project
+	router.Endpoint(projectPath).Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutProject))
+	// delete project
+	router.Endpoint(projectPath).Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteProject))
+
+	// list Vault clusters
+	router.Endpoint(projectPath + "/vault/clusters").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListVaultClusters)))
 	// create Vault cluster
-	router.Endpoint("/vault/clusters").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostVaultCluster))
+	router.Endpoint(projectPath + "/vault/clusters").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultCluster)))
 	// read Vault cluster
-	router.Endpoint("/vault/clusters/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetVaultCluster))
+	router.Endpoint(projectPath + "/vault/clusters/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetVaultCluster)))
 	// update Vault cluster
-	router.Endpoint("/vault/clusters/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutVaultCluster))
+	router.Endpoint(projectPath + "/vault/clusters/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutVaultCluster)))
 	// delete Vault cluster
-	router.Endpoint("/vault/clusters/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteVaultCluster))
+	router.Endpoint(projectPath + "/vault/clusters/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteVaultCluster)))
+	// list Vault cluster versions
+	router.Endpoint(projectPath + "/vault/clusters/{id}/versions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetVaultClusterVersions)))
+	// restore Vault cluster version
+	router.Endpoint(projectPath + "/vault/clusters/{id}/versions/{version}/restore").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultClusterVersionRestore)))
+	// undelete Vault cluster
+	router.Endpoint(projectPath + "/vault/clusters/{id}/undelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultClusterUndelete)))
+	// initialize Vault cluster
+	router.Endpoint(projectPath + "/vault/clusters/{id}/init").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultClusterInit)))
+	// provide an unseal key to a Vault cluster
+	router.Endpoint(projectPath + "/vault/clusters/{id}/unseal").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultClusterUnseal)))
+	// seal Vault cluster
+	router.Endpoint(projectPath + "/vault/clusters/{id}/seal").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultClusterSeal)))
+	// read Vault secret
+	router.Prefix(projectPath + "/vault/clusters/{id}/kv").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetVaultSecret)))
+	// write Vault secret
+	router.Prefix(projectPath + "/vault/clusters/{id}/kv").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutVaultSecret)))
+	// delete Vault secret versions
+	router.Prefix(projectPath + "/vault/clusters/{id}/kv").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteVaultSecretVersions)))
+	// undelete Vault secret versions
+	router.Prefix(projectPath + "/vault/clusters/{id}/kvUndelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultSecretUndelete)))
+	// destroy Vault secret versions
+	router.Prefix(projectPath + "/vault/clusters/{id}/kvDestroy").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultSecretDestroy)))
+	// read or list Vault secret metadata
+	router.Prefix(projectPath + "/vault/clusters/{id}/kvMetadata").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetVaultSecretMetadata)))
+	// delete Vault secret and all its versions
+	router.Prefix(projectPath + "/vault/clusters/{id}/kvMetadata").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteVaultSecretMetadata)))
+	// list Vault leases
+	router.Endpoint(projectPath + "/vault/clusters/{id}/leases").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListVaultLeases)))
+	// issue Vault lease
+	router.Endpoint(projectPath + "/vault/clusters/{id}/leases").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultLease)))
+	// get Vault lease
+	router.Endpoint(projectPath + "/vault/clusters/{id}/leases/{lease}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetVaultLease)))
+	// revoke Vault lease
+	router.Endpoint(projectPath + "/vault/clusters/{id}/leases/{lease}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteVaultLease)))
+	// renew Vault lease
+	router.Endpoint(projectPath + "/vault/clusters/{id}/leases/{lease}/renew").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostVaultLeaseRenew)))
 
+	// list Terraform workspaces
+	router.Endpoint(projectPath + "/terraform/workspaces").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformWorkspaces)))
 	// create Terraform workspace
-	router.Endpoint("/terraform/workspaces").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostTerraformWorkspace))
+	router.Endpoint(projectPath + "/terraform/workspaces").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspace)))
 	// read Terraform workspace
-	router.Endpoint("/terraform/workspaces/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetTerraformWorkspace))
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformWorkspace)))
 	// update Terraform workspace
-	router.Endpoint("/terraform/workspaces/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutTerraformWorkspace))
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformWorkspace)))
 	// delete Terraform workspace
-	router.Endpoint("/terraform/workspaces/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteTerraformWorkspace))
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformWorkspace)))
+	// list Terraform workspace versions
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/versions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformWorkspaceVersions)))
+	// restore Terraform workspace version
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/versions/{version}/restore").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceVersionRestore)))
+	// undelete Terraform workspace
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/undelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceUndelete)))
+	// lock Terraform workspace
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/lock").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceLock)))
+	// unlock Terraform workspace
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/unlock").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceUnlock)))
+	// force unlock Terraform workspace, regardless of who holds the lock
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/forceUnlock").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceForceUnlock)))
+	// list Terraform runs
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformRuns)))
+	// queue Terraform run
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRun)))
+	// read Terraform run
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs/{run}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformRun)))
+	// apply planned Terraform run
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs/{run}/apply").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRunApply)))
+	// discard Terraform run
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs/{run}/discard").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRunDiscard)))
+	// cancel Terraform run
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs/{run}/cancel").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRunCancel)))
+	// override failed Terraform run policy checks
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runs/{run}/override").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRunOverride)))
+	// list Terraform workspace run triggers
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runTriggers").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformRunTriggers)))
+	// create Terraform workspace run trigger
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/runTriggers").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformRunTrigger)))
+	// list Terraform state versions
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/stateVersions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformStateVersions)))
+	// upload Terraform state version
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/stateVersions").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformStateVersion)))
+	// read Terraform state version
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/stateVersions/{stateVersion}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformStateVersion)))
+	// read current Terraform state version
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/current-state-version").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformCurrentStateVersion)))
+	// read current Terraform state outputs
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/current-state-version/outputs").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformCurrentStateVersionOutputs)))
+	// list Terraform workspace variables
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/vars").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformWorkspaceVariables)))
+	// create Terraform workspace variable
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/vars").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformWorkspaceVariable)))
+	// read Terraform workspace variable
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/vars/{var}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformWorkspaceVariable)))
+	// update Terraform workspace variable
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/vars/{var}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformWorkspaceVariable)))
+	// delete Terraform workspace variable
+	router.Endpoint(projectPath + "/terraform/workspaces/{id}/vars/{var}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformWorkspaceVariable)))
+
+	// read Terraform run trigger
+	router.Endpoint(projectPath + "/terraform/runTriggers/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformRunTrigger)))
+	// delete Terraform run trigger
+	router.Endpoint(projectPath + "/terraform/runTriggers/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformRunTrigger)))
+
+	// list Terraform policy sets
+	router.Endpoint(projectPath + "/terraform/policySets").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformPolicySets)))
+	// create Terraform policy set
+	router.Endpoint(projectPath + "/terraform/policySets").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformPolicySet)))
+	// read Terraform policy set
+	router.Endpoint(projectPath + "/terraform/policySets/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformPolicySet)))
+	// update Terraform policy set
+	router.Endpoint(projectPath + "/terraform/policySets/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformPolicySet)))
+	// delete Terraform policy set
+	router.Endpoint(projectPath + "/terraform/policySets/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformPolicySet)))
+
+	// list Terraform variable sets
+	router.Endpoint(projectPath + "/terraform/varsets").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformVariableSets)))
+	// create Terraform variable set
+	router.Endpoint(projectPath + "/terraform/varsets").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformVariableSet)))
+	// read Terraform variable set
+	router.Endpoint(projectPath + "/terraform/varsets/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformVariableSet)))
+	// update Terraform variable set
+	router.Endpoint(projectPath + "/terraform/varsets/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformVariableSet)))
+	// delete Terraform variable set
+	router.Endpoint(projectPath + "/terraform/varsets/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformVariableSet)))
+	// list Terraform variable set variables
+	router.Endpoint(projectPath + "/terraform/varsets/{id}/vars").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformVariableSetVariables)))
+	// create Terraform variable set variable
+	router.Endpoint(projectPath + "/terraform/varsets/{id}/vars").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformVariableSetVariable)))
+	// read Terraform variable set variable
+	router.Endpoint(projectPath + "/terraform/varsets/{id}/vars/{var}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformVariableSetVariable)))
+	// update Terraform variable set variable
+	router.Endpoint(projectPath + "/terraform/varsets/{id}/vars/{var}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformVariableSetVariable)))
+	// delete Terraform variable set variable
+	router.Endpoint(projectPath + "/terraform/varsets/{id}/vars/{var}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformVariableSetVariable)))
 
+	// list Terraform agent pools
+	router.Endpoint(projectPath + "/terraform/agentPools").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformAgentPools)))
+	// create Terraform agent pool
+	router.Endpoint(projectPath + "/terraform/agentPools").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformAgentPool)))
+	// read Terraform agent pool
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformAgentPool)))
+	// update Terraform agent pool
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformAgentPool)))
+	// delete Terraform agent pool
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformAgentPool)))
+	// list Terraform agent pool tokens
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}/tokens").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformAgentTokens)))
+	// create Terraform agent pool token
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}/tokens").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformAgentToken)))
+	// read Terraform agent pool token
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}/tokens/{token}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformAgentToken)))
+	// revoke Terraform agent pool token
+	router.Endpoint(projectPath + "/terraform/agentPools/{id}/tokens/{token}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformAgentToken)))
+
+	// list received VCS webhook events
+	router.Endpoint(projectPath + "/terraform/webhooks/vcs").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformVCSEvents)))
+	// receive VCS push webhook
+	router.Endpoint(projectPath + "/terraform/webhooks/vcs").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformVCSWebhook)))
+	// read received VCS webhook event
+	router.Endpoint(projectPath + "/terraform/webhooks/vcs/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformVCSEvent)))
+
+	// list Terraform OAuth clients
+	router.Endpoint(projectPath + "/terraform/oauthClients").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformOAuthClients)))
+	// create Terraform OAuth client
+	router.Endpoint(projectPath + "/terraform/oauthClients").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostTerraformOAuthClient)))
+	// read Terraform OAuth client
+	router.Endpoint(projectPath + "/terraform/oauthClients/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformOAuthClient)))
+	// update Terraform OAuth client
+	router.Endpoint(projectPath + "/terraform/oauthClients/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutTerraformOAuthClient)))
+	// delete Terraform OAuth client
+	router.Endpoint(projectPath + "/terraform/oauthClients/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteTerraformOAuthClient)))
+	// list Terraform OAuth client tokens
+	router.Endpoint(projectPath + "/terraform/oauthClients/{id}/tokens").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListTerraformOAuthTokens)))
+	// read Terraform OAuth client token
+	router.Endpoint(projectPath + "/terraform/oauthClients/{id}/tokens/{token}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetTerraformOAuthToken)))
+
+	// list Consul clusters
+	router.Endpoint(projectPath + "/consul/clusters").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListConsulClusters)))
 	// create Consul cluster
-	router.Endpoint("/consul/clusters").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostConsulCluster))
+	router.Endpoint(projectPath + "/consul/clusters").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulCluster)))
 	// read Consul cluster
-	router.Endpoint("/consul/clusters/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetConsulCluster))
+	router.Endpoint(projectPath + "/consul/clusters/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulCluster)))
 	// update Consul cluster
-	router.Endpoint("/consul/clusters/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutConsulCluster))
+	router.Endpoint(projectPath + "/consul/clusters/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutConsulCluster)))
 	// delete Consul cluster
-	router.Endpoint("/consul/clusters/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteConsulCluster))
+	router.Endpoint(projectPath + "/consul/clusters/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteConsulCluster)))
+	// list Consul cluster versions
+	router.Endpoint(projectPath + "/consul/clusters/{id}/versions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulClusterVersions)))
+	// restore Consul cluster version
+	router.Endpoint(projectPath + "/consul/clusters/{id}/versions/{version}/restore").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulClusterVersionRestore)))
+	// undelete Consul cluster
+	router.Endpoint(projectPath + "/consul/clusters/{id}/undelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulClusterUndelete)))
+	// read Consul key, or list Consul keys with recurse
+	router.Prefix(projectPath + "/consul/clusters/{id}/kv").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulKey)))
+	// write Consul key
+	router.Prefix(projectPath + "/consul/clusters/{id}/kv").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutConsulKey)))
+	// delete Consul key, or Consul keys with recurse
+	router.Prefix(projectPath + "/consul/clusters/{id}/kv").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteConsulKey)))
+	// list Consul sessions
+	router.Endpoint(projectPath + "/consul/clusters/{id}/sessions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListConsulSessions)))
+	// create Consul session
+	router.Endpoint(projectPath + "/consul/clusters/{id}/sessions").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulSession)))
+	// get Consul session
+	router.Endpoint(projectPath + "/consul/clusters/{id}/sessions/{session}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulSession)))
+	// destroy Consul session
+	router.Endpoint(projectPath + "/consul/clusters/{id}/sessions/{session}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteConsulSession)))
+	// renew Consul session
+	router.Endpoint(projectPath + "/consul/clusters/{id}/sessions/{session}/renew").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulSessionRenew)))
+	// list Consul catalog services
+	router.Endpoint(projectPath + "/consul/clusters/{id}/services").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListConsulCatalogServices)))
+	// register Consul catalog service
+	router.Endpoint(projectPath + "/consul/clusters/{id}/services").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostConsulCatalogService)))
+	// read Consul catalog service
+	router.Endpoint(projectPath + "/consul/clusters/{id}/services/{service}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulCatalogService)))
+	// deregister Consul catalog service
+	router.Endpoint(projectPath + "/consul/clusters/{id}/services/{service}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteConsulCatalogService)))
+	// update Consul health check status
+	router.Endpoint(projectPath + "/consul/clusters/{id}/services/{service}/checks/{check}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutConsulHealthCheck)))
+	// read Consul service health
+	router.Endpoint(projectPath + "/consul/clusters/{id}/health/{name}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulServiceHealth)))
+	// look up Consul service in DNS
+	router.Endpoint(projectPath + "/consul/clusters/{id}/dns").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetConsulDNS)))
 
+	// list Nomad clusters
+	router.Endpoint(projectPath + "/nomad/clusters").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadClusters)))
 	// create Nomad cluster
-	router.Endpoint("/nomad/clusters").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostNomadCluster))
+	router.Endpoint(projectPath + "/nomad/clusters").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadCluster)))
 	// read Nomad cluster
-	router.Endpoint("/nomad/clusters/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetNomadCluster))
+	router.Endpoint(projectPath + "/nomad/clusters/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetNomadCluster)))
 	// update Nomad cluster
-	router.Endpoint("/nomad/clusters/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutNomadCluster))
+	router.Endpoint(projectPath + "/nomad/clusters/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutNomadCluster)))
 	// delete Nomad cluster
-	router.Endpoint("/nomad/clusters/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteNomadCluster))
+	router.Endpoint(projectPath + "/nomad/clusters/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteNomadCluster)))
+	// list Nomad cluster versions
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/versions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetNomadClusterVersions)))
+	// restore Nomad cluster version
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/versions/{version}/restore").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadClusterVersionRestore)))
+	// undelete Nomad cluster
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/undelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadClusterUndelete)))
+	// list Nomad cluster members
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/members").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadMembers)))
+	// list Nomad node pools
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodePools").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadNodePools)))
+	// create a Nomad node pool
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodePools").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadNodePool)))
+	// retrieve a Nomad node pool
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodePools/{pool}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetNomadNodePool)))
+	// update a Nomad node pool
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodePools/{pool}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutNomadNodePool)))
+	// delete a Nomad node pool
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodePools/{pool}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteNomadNodePool)))
+	// list Nomad client nodes
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadNodes)))
+	// register a Nomad client node
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadNode)))
+	// retrieve a Nomad client node
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes/{node}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetNomadNode)))
+	// deregister a Nomad client node
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes/{node}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteNomadNode)))
+	// start or cancel draining a Nomad client node
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes/{node}/drain").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadNodeDrain)))
+	// set whether a Nomad client node is eligible for new allocations
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/nodes/{node}/eligibility").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadNodeEligibility)))
+	// list Nomad jobs
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/jobs").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadJobs)))
+	// submit Nomad job
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/jobs").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostNomadJob)))
+	// read Nomad job
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/jobs/{job}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetNomadJob)))
+	// cancel Nomad job
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/jobs/{job}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteNomadJob)))
+	// list Nomad job allocations
+	router.Endpoint(projectPath + "/nomad/clusters/{id}/jobs/{job}/allocations").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListNomadAllocations)))
 
+	// list IP pools
+	router.Endpoint(projectPath + "/ip/pools").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListIPPools)))
+	// create IP pool
+	router.Endpoint(projectPath + "/ip/pools").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostIPPool)))
+	// read IP pool
+	router.Endpoint(projectPath + "/ip/pools/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetIPPool)))
+	// update IP pool
+	router.Endpoint(projectPath + "/ip/pools/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutIPPool)))
+	// delete IP pool
+	router.Endpoint(projectPath + "/ip/pools/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteIPPool)))
+	// list IP addresses
+	router.Endpoint(projectPath + "/ip/addresses").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListIPAddresses)))
+	// allocate IP address
+	router.Endpoint(projectPath + "/ip/addresses").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostIPAddress)))
+	// read IP address
+	router.Endpoint(projectPath + "/ip/addresses/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetIPAddress)))
+	// update IP address
+	router.Endpoint(projectPath + "/ip/addresses/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutIPAddress)))
+	// release IP address
+	router.Endpoint(projectPath + "/ip/addresses/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteIPAddress)))
+	// attach IP address to a cluster
+	router.Endpoint(projectPath + "/ip/addresses/{id}/attach").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostIPAddressAttach)))
+	// detach IP address from its cluster
+	router.Endpoint(projectPath + "/ip/addresses/{id}/detach").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostIPAddressDetach)))
+
+	// list access policies
+	router.Endpoint(projectPath + "/accessPolicies").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleListAccessPolicies)))
 	// create access policy
-	router.Endpoint("/accessPolicies").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostAccessPolicy))
+	router.Endpoint(projectPath + "/accessPolicies").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostAccessPolicy)))
 	// read access policy
-	router.Endpoint("/accessPolicies/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetAccessPolicy))
+	router.Endpoint(projectPath + "/accessPolicies/{id}").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetAccessPolicy)))
 	// update access policy
-	router.Endpoint("/accessPolicies/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutAccessPolicy))
+	router.Endpoint(projectPath + "/accessPolicies/{id}").Methods(http.MethodPut).Handler(a.inProject(http.HandlerFunc(a.handlePutAccessPolicy)))
 	// delete access policy
-	router.Endpoint("/accessPolicies/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteAccessPolicy))
+	router.Endpoint(projectPath + "/accessPolicies/{id}").Methods(http.MethodDelete).Handler(a.inProject(http.HandlerFunc(a.handleDeleteAccessPolicy)))
+	// list access policy versions
+	router.Endpoint(projectPath + "/accessPolicies/{id}/versions").Methods(http.MethodGet).Handler(a.inProject(http.HandlerFunc(a.handleGetAccessPolicyVersions)))
+	// restore access policy version
+	router.Endpoint(projectPath + "/accessPolicies/{id}/versions/{version}/restore").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostAccessPolicyVersionRestore)))
+	// undelete access policy
+	router.Endpoint(projectPath + "/accessPolicies/{id}/undelete").Methods(http.MethodPost).Handler(a.inProject(http.HandlerFunc(a.handlePostAccessPolicyUndelete)))
+
+	// download a snapshot of the whole database
+	router.Endpoint("/admin/snapshot").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetSnapshot))
+	// replace the whole database with a snapshot
+	router.Endpoint("/admin/snapshot/restore").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostSnapshotRestore))
+
+	// add a Terraform version to the catalog
+	router.Endpoint("/admin/terraform/versions").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostTerraformVersion))
+	// update a Terraform version in the catalog
+	router.Endpoint("/admin/terraform/versions/{version}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutTerraformVersion))
+	// remove a Terraform version from the catalog
+	router.Endpoint("/admin/terraform/versions/{version}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteTerraformVersion))
 
 	return api.NegotiateMiddleware(router)
 }
@@ -75,12 +395,43 @@ func isAuthenticated(r *http.Request) bool {
 }
 
 type Response struct {
-	Regions             []Region             `json:"regions,omitempty"`
-	VaultClusters       []VaultCluster       `json:"vaultClusters,omitempty"`
-	TerraformWorkspaces []TerraformWorkspace `json:"terraformWorkspaces,omitempty"`
-	ConsulClusters      []ConsulCluster      `json:"consulClusters,omitempty"`
-	NomadClusters       []NomadCluster       `json:"nomadClusters,omitempty"`
-	AccessPolicies      []AccessPolicy       `json:"accessPolicies,omitempty"`
-	Errors              []api.RequestError   `json:"errors,omitempty"`
-	Status              int                  `json:"-"`
+	Regions                []Region                `json:"regions,omitempty"`
+	Organizations          []Organization          `json:"organizations,omitempty"`
+	Projects               []Project               `json:"projects,omitempty"`
+	VaultClusters          []VaultCluster          `json:"vaultClusters,omitempty"`
+	VaultClusterKeys       []VaultClusterKeys      `json:"vaultClusterKeys,omitempty"`
+	VaultSecrets           []VaultSecret           `json:"vaultSecrets,omitempty"`
+	VaultSecretVersions    []VaultSecretVersion    `json:"vaultSecretVersions,omitempty"`
+	VaultLeases            []VaultLease            `json:"vaultLeases,omitempty"`
+	TerraformWorkspaces    []TerraformWorkspace    `json:"terraformWorkspaces,omitempty"`
+	TerraformRuns          []TerraformRun          `json:"terraformRuns,omitempty"`
+	TerraformRunTriggers   []TerraformRunTrigger   `json:"terraformRunTriggers,omitempty"`
+	TerraformStateVersions []TerraformStateVersion `json:"terraformStateVersions,omitempty"`
+	TerraformStateOutputs  []TerraformStateOutput  `json:"terraformStateOutputs,omitempty"`
+	TerraformVCSEvents     []TerraformVCSEvent     `json:"terraformVCSEvents,omitempty"`
+	TerraformVariables     []TerraformVariable     `json:"terraformVariables,omitempty"`
+	TerraformVariableSets  []TerraformVariableSet  `json:"terraformVariableSets,omitempty"`
+	TerraformPolicySets    []TerraformPolicySet    `json:"terraformPolicySets,omitempty"`
+	TerraformAgentPools    []TerraformAgentPool    `json:"terraformAgentPools,omitempty"`
+	TerraformAgentTokens   []TerraformAgentToken   `json:"terraformAgentTokens,omitempty"`
+	TerraformOAuthClients  []TerraformOAuthClient  `json:"terraformOAuthClients,omitempty"`
+	TerraformOAuthTokens   []TerraformOAuthToken   `json:"terraformOAuthTokens,omitempty"`
+	TerraformVersions      []TerraformVersion      `json:"terraformVersions,omitempty"`
+	ConsulClusters         []ConsulCluster         `json:"consulClusters,omitempty"`
+	ConsulKVPairs          []ConsulKVPair          `json:"consulKVPairs,omitempty"`
+	ConsulSessions         []ConsulSession         `json:"consulSessions,omitempty"`
+	ConsulCatalogServices  []ConsulCatalogService  `json:"consulCatalogServices,omitempty"`
+	ConsulDNSAnswers       []ConsulDNSAnswer       `json:"consulDNSAnswers,omitempty"`
+	NomadClusters          []NomadCluster          `json:"nomadClusters,omitempty"`
+	NomadMembers           []NomadMember           `json:"nomadMembers,omitempty"`
+	NomadNodePools         []NomadNodePool         `json:"nomadNodePools,omitempty"`
+	NomadNodes             []NomadNode             `json:"nomadNodes,omitempty"`
+	NomadJobs              []NomadJob              `json:"nomadJobs,omitempty"`
+	NomadAllocations       []NomadAllocation       `json:"nomadAllocations,omitempty"`
+	IPPools                []IPPool                `json:"ipPools,omitempty"`
+	IPAddresses            []IPAddress             `json:"ipAddresses,omitempty"`
+	AccessPolicies         []AccessPolicy          `json:"accessPolicies,omitempty"`
+	Versions               []Version               `json:"versions,omitempty"`
+	Errors                 []api.RequestError      `json:"errors,omitempty"`
+	Status                 int                     `json:"-"`
 }
@@ -1,23 +1,52 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"dadcorp.dev/api"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		os.Exit(snapshotCommand(os.Args[2:]))
+	}
+
+	retention := flag.Duration("retention", 7*24*time.Hour, "how long deleted resources can be undeleted before they are purged")
+	purgeInterval := flag.Duration("purge-interval", time.Minute, "how often to purge deleted resources that are past the retention window")
+	runInterval := flag.Duration("run-interval", 5*time.Second, "how often Terraform runs move on to their next status")
+	leaseInterval := flag.Duration("lease-interval", 10*time.Second, "how often to remove expired Vault leases")
+	sessionInterval := flag.Duration("session-interval", 10*time.Second, "how often to invalidate expired Consul sessions")
+	jobInterval := flag.Duration("job-interval", 5*time.Second, "how often Nomad jobs and allocations move on to their next status")
+	joinInterval := flag.Duration("join-interval", time.Second, "how often to check for Nomad clusters due to retry joining other clusters")
+	organization := flag.String("organization", "dadcorp", "organization to create at startup, if it doesn't exist; empty to create none")
+	project := flag.String("project", "demo", "project to create in the organization at startup, if it doesn't exist; empty to create none")
+	flag.Parse()
+
 	storer, err := api.NewStorer()
 	if err != nil {
 		log.Println("Error setting up storer:", err.Error())
 		os.Exit(1)
 	}
+	err = seedProject(storer, *organization, *project)
+	if err != nil {
+		log.Println("Error creating default organization and project:", err.Error())
+		os.Exit(1)
+	}
 	a := api.API{
 		Storer: storer,
 	}
 
+	go purgeDeleted(storer, *retention, *purgeInterval)
+	go stepTerraformRuns(storer, *runInterval)
+	go expireVaultLeases(storer, *leaseInterval)
+	go expireConsulSessions(storer, *sessionInterval)
+	go stepNomadJobs(storer, *jobInterval)
+	go retryNomadJoins(storer, *joinInterval)
+
 	http.Handle("/", a.Server(""))
 	err = http.ListenAndServe(":12345", nil)
 	if err != nil {
@@ -25,3 +54,118 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// seedProject creates the organization org and its project project, unless
+// they already exist, so a fresh dadcorpd has somewhere to put resources.
+// Every resource belongs to a project, and only admins can create them.
+func seedProject(storer *api.Storer, org, project string) error {
+	if org == "" {
+		return nil
+	}
+	err := storer.CreateOrganization(api.Organization{ID: org, Name: org})
+	if err != nil && err != api.ErrOrganizationAlreadyExists {
+		return err
+	}
+	if project == "" {
+		return nil
+	}
+	err = storer.CreateProject(api.Project{ID: project, Organization: org, Name: project})
+	if err != nil && err != api.ErrProjectAlreadyExists {
+		return err
+	}
+	return nil
+}
+
+// purgeDeleted permanently removes resources that were deleted more than
+// retention ago, checking every interval.
+func purgeDeleted(storer *api.Storer, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		purged, err := storer.PurgeDeleted(time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Error purging deleted resources:", err.Error())
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted resources", purged)
+		}
+	}
+}
+
+// stepTerraformRuns simulates Terraform runs making progress, moving each run
+// on to its next status every interval.
+func stepTerraformRuns(storer *api.Storer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := storer.StepTerraformRuns()
+		if err != nil {
+			log.Println("Error stepping Terraform runs:", err.Error())
+		}
+	}
+}
+
+// expireVaultLeases removes Vault leases once they've expired, checking every
+// interval.
+func expireVaultLeases(storer *api.Storer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired, err := storer.ExpireVaultLeases(time.Now())
+		if err != nil {
+			log.Println("Error expiring Vault leases:", err.Error())
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d Vault leases", expired)
+		}
+	}
+}
+
+// expireConsulSessions invalidates Consul sessions once their TTLs have run
+// out, releasing or deleting the keys they hold, checking every interval.
+func expireConsulSessions(storer *api.Storer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired, err := storer.ExpireConsulSessions(time.Now())
+		if err != nil {
+			log.Println("Error expiring Consul sessions:", err.Error())
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d Consul sessions", expired)
+		}
+	}
+}
+
+// stepNomadJobs simulates the Nomad scheduler, moving each job and its
+// allocations on to their next status every interval.
+func stepNomadJobs(storer *api.Storer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := storer.StepNomadJobs()
+		if err != nil {
+			log.Println("Error stepping Nomad jobs:", err.Error())
+		}
+	}
+}
+
+// retryNomadJoins simulates Nomad clusters retrying their retry_join
+// addresses, checking every interval for clusters whose retry is due.
+func retryNomadJoins(storer *api.Storer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		joined, err := storer.StepNomadJoins(time.Now())
+		if err != nil {
+			log.Println("Error retrying Nomad joins:", err.Error())
+			continue
+		}
+		if joined > 0 {
+			log.Printf("Joined %d Nomad clusters", joined)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"dadcorp.dev/api"
+)
+
+const snapshotUsage = `Usage: dadcorpd snapshot save [options] FILE
+       dadcorpd snapshot restore [options] FILE
+
+Save a snapshot of a running dadcorpd's database to FILE, or replace the
+database of a running dadcorpd with the snapshot in FILE.
+
+Options:
+`
+
+// snapshotCommand runs the snapshot subcommand with the passed arguments,
+// returning the exit code.
+func snapshotCommand(args []string) int {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	addr := flags.String("addr", "http://localhost:12345", "address of the dadcorpd to snapshot or restore")
+	username := flags.String("username", "admin", "username to authenticate as")
+	password := flags.String("password", os.Getenv("DADCORPD_PASSWORD"), "password to authenticate with (defaults to $DADCORPD_PASSWORD)")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), snapshotUsage)
+		flags.PrintDefaults()
+	}
+	if len(args) < 1 {
+		flags.Usage()
+		return 2
+	}
+	action := args[0]
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	file := flags.Arg(0)
+	baseURL := strings.TrimSuffix(*addr, "/")
+
+	switch action {
+	case "save":
+		err = saveSnapshot(baseURL+"/admin/snapshot", *username, *password, file)
+	case "restore":
+		err = restoreSnapshot(baseURL+"/admin/snapshot/restore", *username, *password, file)
+	default:
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+func saveSnapshot(u, username, password, file string) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response %s: %s", resp.Status, body)
+	}
+	// make sure what we got is a snapshot we'd be able to restore
+	_, err = api.ReadSnapshot(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error verifying snapshot: %w", err)
+	}
+	err = ioutil.WriteFile(file, body, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return nil
+}
+
+func restoreSnapshot(u, username, password, file string) error {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %w", err)
+	}
+	// catch corrupt snapshots before bothering the server with them
+	_, err = api.ReadSnapshot(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error verifying snapshot: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response %s: %s", resp.Status, msg)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,11 +11,14 @@ import (
 )
 
 type ConsulCluster struct {
-	ID        string                 `json:"id"`
-	Name      string                 `json:"name"`
-	BindAddr  string                 `json:"bindAddr"`
-	Addresses ConsulClusterAddresses `json:"addresses"`
-	Ports     ConsulClusterPorts     `json:"ports"`
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	Name         string                 `json:"name"`
+	BindAddr     string                 `json:"bindAddr"`
+	Addresses    ConsulClusterAddresses `json:"addresses"`
+	Ports        ConsulClusterPorts     `json:"ports"`
+	DeletedAt    *time.Time             `json:"deletedAt,omitempty"`
 }
 
 type ConsulClusterAddresses struct {
@@ -73,8 +78,107 @@ func (cluster *ConsulCluster) FillDefaults() {
 	}
 }
 
+// consulPort is one of a cluster's ports, with the name of the field that
+// sets it.
+type consulPort struct {
+	field string
+	port  int
+}
+
+// consulPortRange is one of a cluster's port ranges, with the names of the
+// fields that set it.
+type consulPortRange struct {
+	minField, maxField string
+	min, max           *int
+}
+
+func (r consulPortRange) contains(port int) bool {
+	return r.min != nil && r.max != nil && *r.min <= port && port <= *r.max
+}
+
+func (r consulPortRange) overlaps(other consulPortRange) bool {
+	return *r.min <= *other.max && *other.min <= *r.max
+}
+
+// portErrors returns the problems with the cluster's ports, which need to
+// be between 1 and 65535 and different from each other. The sidecar and
+// expose ranges are optional, but if either end of one is set both need to
+// be, with the min no greater than the max, and the ranges can't overlap
+// each other or any of the other ports.
+func (cluster ConsulCluster) portErrors() []api.RequestError {
+	var errs []api.RequestError
+	inRange := func(port int) bool {
+		return port >= 1 && port <= maxPort
+	}
+	ports := []consulPort{
+		{"dns", cluster.Ports.DNS},
+		{"http", cluster.Ports.HTTP},
+		{"https", cluster.Ports.HTTPS},
+		{"grpc", cluster.Ports.GRPC},
+		{"serfLan", cluster.Ports.SerfLAN},
+		{"serfWan", cluster.Ports.SerfWAN},
+		{"server", cluster.Ports.Server},
+	}
+	ranges := []consulPortRange{
+		{"sidecarMinPort", "sidecarMaxPort", cluster.Ports.SidecarMinPort, cluster.Ports.SidecarMaxPort},
+		{"exposeMinPort", "exposeMaxPort", cluster.Ports.ExposeMinPort, cluster.Ports.ExposeMaxPort},
+	}
+	var valid []consulPortRange
+	for _, r := range ranges {
+		if r.min == nil && r.max == nil {
+			continue
+		}
+		ok := true
+		for _, end := range []struct {
+			field string
+			port  *int
+		}{{r.minField, r.min}, {r.maxField, r.max}} {
+			if end.port == nil {
+				errs = append(errs, api.RequestError{Field: "/ports/" + end.field, Slug: api.RequestErrMissing})
+				ok = false
+			} else if !inRange(*end.port) {
+				errs = append(errs, api.RequestError{Field: "/ports/" + end.field, Slug: api.RequestErrInvalidValue})
+				ok = false
+			}
+		}
+		if ok && *r.min > *r.max {
+			errs = append(errs, api.RequestError{Field: "/ports/" + r.maxField, Slug: api.RequestErrInvalidValue})
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+		for _, other := range valid {
+			if r.overlaps(other) {
+				errs = append(errs, api.RequestError{Field: "/ports/" + r.minField, Slug: api.RequestErrConflict})
+				ok = false
+				break
+			}
+		}
+		if ok {
+			valid = append(valid, r)
+		}
+	}
+	seen := map[int]bool{}
+	for _, p := range ports {
+		if !inRange(p.port) {
+			errs = append(errs, api.RequestError{Field: "/ports/" + p.field, Slug: api.RequestErrInvalidValue})
+			continue
+		}
+		conflict := seen[p.port]
+		for _, r := range valid {
+			conflict = conflict || r.contains(p.port)
+		}
+		if conflict {
+			errs = append(errs, api.RequestError{Field: "/ports/" + p.field, Slug: api.RequestErrConflict})
+		}
+		seen[p.port] = true
+	}
+	return errs
+}
+
 func (a API) handleGetConsulCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetConsulCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetConsulCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrConsulClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -93,6 +197,9 @@ func (a API) handlePostConsulCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	if cluster.ID == "" {
 		cluster.ID, err = uuid.GenerateUUID()
 		if err != nil {
@@ -105,12 +212,20 @@ func (a API) handlePostConsulCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
+	if errs := cluster.portErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	err = a.Storer.CreateConsulCluster(cluster)
 	if err != nil {
 		if err == ErrConsulClusterAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if err == ErrConsulClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -124,6 +239,9 @@ func (a API) handlePutConsulCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	if cluster.ID != "" && cluster.ID != trout.RequestVars(r).Get("id") {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 		return
@@ -133,12 +251,20 @@ func (a API) handlePutConsulCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
+	if errs := cluster.portErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	err = a.Storer.UpdateConsulCluster(cluster)
 	if err != nil {
 		if err == ErrConsulClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrConsulClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -146,7 +272,7 @@ func (a API) handlePutConsulCluster(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a API) handleDeleteConsulCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetConsulCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetConsulCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrConsulClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -155,7 +281,7 @@ func (a API) handleDeleteConsulCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteConsulCluster(trout.RequestVars(r).Get("id"))
+	err = a.Storer.DeleteConsulCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrConsulClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -166,3 +292,92 @@ func (a API) handleDeleteConsulCluster(w http.ResponseWriter, r *http.Request) {
 	}
 	api.Encode(w, r, http.StatusOK, Response{ConsulClusters: []ConsulCluster{cluster}})
 }
+
+func (a API) handleGetConsulClusterVersions(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	_, err := a.Storer.GetConsulCluster(requestScope(r), id)
+	if err != nil {
+		if err == ErrConsulClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	versions, err := a.Storer.ListVersions("consulCluster", id)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = fillVersionChanges(versions)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Versions: versions})
+}
+
+func (a API) handlePostConsulClusterVersionRestore(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	cluster, err := a.Storer.RestoreConsulClusterVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
+	if err != nil {
+		if err == ErrConsulClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrConsulClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulClusters: []ConsulCluster{cluster}})
+}
+
+func (a API) handleListConsulClusters(w http.ResponseWriter, r *http.Request) {
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deleted", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	results, err := a.Storer.ListConsulClusters(requestScope(r), includeDeleted)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulClusters: results})
+}
+
+func (a API) handlePostConsulClusterUndelete(w http.ResponseWriter, r *http.Request) {
+	cluster, err := a.Storer.UndeleteConsulCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrConsulClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrConsulClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulClusters: []ConsulCluster{cluster}})
+}
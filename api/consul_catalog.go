@@ -0,0 +1,414 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// ConsulCatalogService is an instance of a service registered in a Consul
+// cluster's catalog. ID identifies the instance and defaults to Name; many
+// instances can share a Name. Status is the worst status of the instance's
+// checks, or passing if it has none.
+type ConsulCatalogService struct {
+	ID           string              `json:"id"`
+	Organization string              `json:"organization"`
+	Project      string              `json:"project"`
+	ClusterID    string              `json:"clusterID"`
+	Name         string              `json:"name"`
+	Tags         []string            `json:"tags,omitempty"`
+	Address      string              `json:"address"`
+	Port         int                 `json:"port,omitempty"`
+	Checks       []ConsulHealthCheck `json:"checks,omitempty"`
+	Status       string              `json:"status"`
+	CreateIndex  uint64              `json:"createIndex"`
+	ModifyIndex  uint64              `json:"modifyIndex"`
+}
+
+// ConsulHealthCheck is a health check of a service instance. New checks are
+// critical unless they're registered with another status, and their status
+// is updated through the API.
+type ConsulHealthCheck struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Notes  string `json:"notes,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+// ConsulHealthCheckUpdate is the body of a request to update a health
+// check's status.
+type ConsulHealthCheckUpdate struct {
+	Status string `json:"status"`
+	Output string `json:"output"`
+}
+
+// ConsulDNSAnswer is the answer to a DNS lookup of a service, as the
+// cluster's DNS interface would give it. Server is the address of that
+// interface, built from the cluster's Addresses.DNS and Ports.DNS.
+type ConsulDNSAnswer struct {
+	Server   string            `json:"server"`
+	Question string            `json:"question"`
+	Type     string            `json:"type"`
+	Records  []ConsulDNSRecord `json:"records"`
+}
+
+// ConsulDNSRecord is a single record in a ConsulDNSAnswer. Port is only set
+// on SRV records.
+type ConsulDNSRecord struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
+
+const (
+	consulHealthPassing  = "passing"
+	consulHealthWarning  = "warning"
+	consulHealthCritical = "critical"
+
+	// consulDNSDomain is the domain service lookups are made under.
+	consulDNSDomain = ".service.consul"
+)
+
+// consulDNSLabel matches names that can be used as DNS labels, which service
+// names need to be to be looked up.
+var consulDNSLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// consulHealthSeverity orders health statuses from best to worst.
+var consulHealthSeverity = map[string]int{
+	consulHealthPassing:  0,
+	consulHealthWarning:  1,
+	consulHealthCritical: 2,
+}
+
+// validConsulAddress reports whether address is an IP address or a
+// hostname.
+func validConsulAddress(address string) bool {
+	if net.ParseIP(address) != nil {
+		return true
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(address, "."), ".") {
+		if !consulDNSLabel.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// FillDefaults sets the instance's ID to its Name and its checks' IDs to
+// their names if they're not set, and new checks to critical.
+func (service *ConsulCatalogService) FillDefaults() {
+	if service.ID == "" {
+		service.ID = service.Name
+	}
+	for pos := range service.Checks {
+		if service.Checks[pos].ID == "" {
+			service.Checks[pos].ID = service.Checks[pos].Name
+		}
+		if service.Checks[pos].Status == "" {
+			service.Checks[pos].Status = consulHealthCritical
+		}
+	}
+}
+
+// validationErrors returns the problems with a service instance being
+// registered.
+func (service ConsulCatalogService) validationErrors() []api.RequestError {
+	var errs []api.RequestError
+	if service.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	} else if !consulDNSLabel.MatchString(service.Name) {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrInvalidFormat})
+	}
+	if strings.Contains(service.ID, "/") {
+		errs = append(errs, api.RequestError{Field: "/id", Slug: api.RequestErrInvalidFormat})
+	}
+	for pos, tag := range service.Tags {
+		if tag == "" {
+			errs = append(errs, api.RequestError{Field: "/tags/" + strconv.Itoa(pos), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	if service.Address != "" && !validConsulAddress(service.Address) {
+		errs = append(errs, api.RequestError{Field: "/address", Slug: api.RequestErrInvalidFormat})
+	}
+	if service.Port < 0 || service.Port > maxPort {
+		errs = append(errs, api.RequestError{Field: "/port", Slug: api.RequestErrInvalidValue})
+	}
+	checkIDs := map[string]bool{}
+	for pos, check := range service.Checks {
+		field := "/checks/" + strconv.Itoa(pos)
+		if check.Name == "" {
+			errs = append(errs, api.RequestError{Field: field + "/name", Slug: api.RequestErrMissing})
+		}
+		if _, ok := consulHealthSeverity[check.Status]; !ok {
+			errs = append(errs, api.RequestError{Field: field + "/status", Slug: api.RequestErrInvalidValue})
+		}
+		if check.ID != "" && checkIDs[check.ID] {
+			errs = append(errs, api.RequestError{Field: field + "/id", Slug: api.RequestErrConflict})
+		}
+		checkIDs[check.ID] = true
+	}
+	return errs
+}
+
+// aggregateStatus sets the instance's Status to the worst status of its
+// checks.
+func (service *ConsulCatalogService) aggregateStatus() {
+	service.Status = consulHealthPassing
+	for _, check := range service.Checks {
+		if consulHealthSeverity[check.Status] > consulHealthSeverity[service.Status] {
+			service.Status = check.Status
+		}
+	}
+}
+
+// hasTag reports whether the instance has tag, ignoring case like DNS does.
+func (service ConsulCatalogService) hasTag(tag string) bool {
+	for _, t := range service.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortConsulCatalogServices sorts service instances by name, then ID.
+func sortConsulCatalogServices(services []ConsulCatalogService) {
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Name == services[j].Name {
+			return services[i].ID < services[j].ID
+		}
+		return services[i].Name < services[j].Name
+	})
+}
+
+// parseConsulDNSName parses a service lookup in the form
+// [TAG.]SERVICE.service.consul, with or without a trailing dot.
+func parseConsulDNSName(name string) (service, tag string, ok bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if !strings.HasSuffix(name, consulDNSDomain) {
+		return "", "", false
+	}
+	labels := strings.Split(strings.TrimSuffix(name, consulDNSDomain), ".")
+	switch len(labels) {
+	case 1:
+		service = labels[0]
+	case 2:
+		tag, service = labels[0], labels[1]
+		if tag == "" {
+			return "", "", false
+		}
+	default:
+		return "", "", false
+	}
+	if !consulDNSLabel.MatchString(service) {
+		return "", "", false
+	}
+	return service, tag, true
+}
+
+// consulDNSRecords returns the records answering a lookup of type for
+// question, from the instances that aren't critical. Instances with
+// hostnames for addresses get CNAME records in A and AAAA lookups.
+func consulDNSRecords(question, recordType string, services []ConsulCatalogService) []ConsulDNSRecord {
+	records := []ConsulDNSRecord{}
+	for _, service := range services {
+		if service.Status == consulHealthCritical {
+			continue
+		}
+		ip := net.ParseIP(service.Address)
+		switch {
+		case recordType == "SRV":
+			records = append(records, ConsulDNSRecord{Name: question, Type: "SRV", Address: service.Address, Port: service.Port})
+		case ip == nil:
+			records = append(records, ConsulDNSRecord{Name: question, Type: "CNAME", Address: service.Address})
+		case recordType == "A" && ip.To4() != nil:
+			records = append(records, ConsulDNSRecord{Name: question, Type: "A", Address: service.Address})
+		case recordType == "AAAA" && ip.To4() == nil:
+			records = append(records, ConsulDNSRecord{Name: question, Type: "AAAA", Address: service.Address})
+		}
+	}
+	return records
+}
+
+func encodeConsulCatalogError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrConsulClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrConsulCatalogServiceNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "service", Slug: api.RequestErrNotFound}}})
+	case ErrConsulHealthCheckNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "check", Slug: api.RequestErrNotFound}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+func (a API) handleListConsulCatalogServices(w http.ResponseWriter, r *http.Request) {
+	services, err := a.Storer.ListConsulCatalogServices(requestScope(r), trout.RequestVars(r).Get("id"), "")
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	if services == nil {
+		services = []ConsulCatalogService{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulCatalogServices: services})
+}
+
+// handlePostConsulCatalogService registers a service instance, replacing
+// any instance already registered with the same ID.
+func (a API) handlePostConsulCatalogService(w http.ResponseWriter, r *http.Request) {
+	var service ConsulCatalogService
+	err := api.Decode(r, &service)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	service.FillDefaults()
+	if errs := service.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	service.Organization = trout.RequestVars(r).Get("org")
+	service.Project = trout.RequestVars(r).Get("project")
+	service.ClusterID = trout.RequestVars(r).Get("id")
+	service, created, err := a.Storer.RegisterConsulCatalogService(service)
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	api.Encode(w, r, status, Response{ConsulCatalogServices: []ConsulCatalogService{service}})
+}
+
+func (a API) handleGetConsulCatalogService(w http.ResponseWriter, r *http.Request) {
+	service, err := a.Storer.GetConsulCatalogService(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("service"))
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulCatalogServices: []ConsulCatalogService{service}})
+}
+
+// handleDeleteConsulCatalogService deregisters a service instance.
+func (a API) handleDeleteConsulCatalogService(w http.ResponseWriter, r *http.Request) {
+	service, err := a.Storer.DeregisterConsulCatalogService(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("service"))
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulCatalogServices: []ConsulCatalogService{service}})
+}
+
+// handlePutConsulHealthCheck updates the status of one of a service
+// instance's health checks.
+func (a API) handlePutConsulHealthCheck(w http.ResponseWriter, r *http.Request) {
+	var update ConsulHealthCheckUpdate
+	err := api.Decode(r, &update)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if _, ok := consulHealthSeverity[update.Status]; !ok {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/status", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	vars := trout.RequestVars(r)
+	service, err := a.Storer.UpdateConsulHealthCheck(requestScope(r), vars.Get("id"), vars.Get("service"), vars.Get("check"), update)
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulCatalogServices: []ConsulCatalogService{service}})
+}
+
+// handleGetConsulServiceHealth returns every instance of the named service,
+// with its health. If the passing query param is set, only passing
+// instances are returned.
+func (a API) handleGetConsulServiceHealth(w http.ResponseWriter, r *http.Request) {
+	var passing bool
+	if v := r.URL.Query().Get("passing"); v != "" {
+		var err error
+		passing, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "passing", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	services, err := a.Storer.ListConsulCatalogServices(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("name"))
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	results := []ConsulCatalogService{}
+	for _, service := range services {
+		if passing && service.Status != consulHealthPassing {
+			continue
+		}
+		results = append(results, service)
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulCatalogServices: results})
+}
+
+// handleGetConsulDNS answers a DNS lookup of a service the way the cluster's
+// DNS interface would, leaving out critical instances. The name query param
+// is the name to look up, in the form [TAG.]SERVICE.service.consul, and the
+// type query param is A, AAAA, or SRV, defaulting to A.
+func (a API) handleGetConsulDNS(w http.ResponseWriter, r *http.Request) {
+	question := r.URL.Query().Get("name")
+	if question == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	name, tag, ok := parseConsulDNSName(question)
+	if !ok {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "name", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	recordType := strings.ToUpper(r.URL.Query().Get("type"))
+	if recordType == "" {
+		recordType = "A"
+	}
+	if recordType != "A" && recordType != "AAAA" && recordType != "SRV" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "type", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	cluster, err := a.Storer.GetConsulCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	services, err := a.Storer.ListConsulCatalogServices(requestScope(r), cluster.ID, name)
+	if err != nil {
+		encodeConsulCatalogError(w, r, err)
+		return
+	}
+	var matching []ConsulCatalogService
+	for _, service := range services {
+		if tag != "" && !service.hasTag(tag) {
+			continue
+		}
+		matching = append(matching, service)
+	}
+	if len(matching) < 1 {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "name", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulDNSAnswers: []ConsulDNSAnswer{{
+		Server:   net.JoinHostPort(cluster.Addresses.DNS, strconv.Itoa(cluster.Ports.DNS)),
+		Question: question,
+		Type:     recordType,
+		Records:  consulDNSRecords(question, recordType, matching),
+	}}})
+}
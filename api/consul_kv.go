@@ -0,0 +1,427 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+// ConsulKVPair is a key and its value in a Consul cluster's KV store.
+// CreateIndex and ModifyIndex come from a counter each cluster increments
+// whenever its KV store or sessions change, and ModifyIndex is what
+// check-and-set writes compare against. LockIndex counts the number of times
+// a session has acquired the key, and Session is the session holding it, if
+// any.
+type ConsulKVPair struct {
+	ClusterID    string `json:"clusterID"`
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	Flags        uint64 `json:"flags"`
+	CreateIndex  uint64 `json:"createIndex"`
+	ModifyIndex  uint64 `json:"modifyIndex"`
+	LockIndex    uint64 `json:"lockIndex"`
+	Session      string `json:"session,omitempty"`
+}
+
+// ConsulKVWrite is the body of a request to write a key. If CAS is set, the
+// write only succeeds if it matches the key's ModifyIndex, with 0 meaning
+// the key must not exist yet. Acquire writes the key while taking its lock
+// for a session, and Release writes it while giving the lock up.
+type ConsulKVWrite struct {
+	Value   string  `json:"value"`
+	Flags   uint64  `json:"flags"`
+	CAS     *uint64 `json:"cas,omitempty"`
+	Acquire string  `json:"acquire,omitempty"`
+	Release string  `json:"release,omitempty"`
+}
+
+// ConsulSession is a session in a Consul cluster, which can hold locks on
+// keys. When a session is destroyed or its TTL runs out, the keys it holds
+// are either released or deleted, depending on its Behavior.
+type ConsulSession struct {
+	ID           string     `json:"id"`
+	Organization string     `json:"organization"`
+	Project      string     `json:"project"`
+	ClusterID    string     `json:"clusterID"`
+	Name         string     `json:"name"`
+	TTL          string     `json:"ttl,omitempty"`
+	Behavior     string     `json:"behavior"`
+	CreateIndex  uint64     `json:"createIndex"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// consulIndex is the counter a Consul cluster's KV store and sessions take
+// their indexes from.
+type consulIndex struct {
+	ClusterID string
+	Index     uint64
+}
+
+const (
+	consulSessionBehaviorRelease = "release"
+	consulSessionBehaviorDelete  = "delete"
+)
+
+// expired reports whether the session's TTL had run out at now.
+func (session ConsulSession) expired(now time.Time) bool {
+	return session.ExpiresAt != nil && !session.ExpiresAt.After(now)
+}
+
+// consulKey returns the key a request to the KV routes is for. Keys can
+// contain slashes, so they can't be a route variable; the key is everything
+// after the route's prefix.
+func consulKey(r *http.Request) string {
+	marker := "/consul/clusters/" + trout.RequestVars(r).Get("id") + "/kv/"
+	i := strings.Index(r.URL.Path, marker)
+	if i < 0 {
+		return ""
+	}
+	return r.URL.Path[i+len(marker):]
+}
+
+// validConsulKey reports whether key can be stored. Keys can't be empty or
+// start with a slash.
+func validConsulKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/")
+}
+
+// parseConsulRecurse parses the recurse query param of a request.
+func parseConsulRecurse(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("recurse")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// requestConsulPolicy returns the request's access policy, if it is a
+// Consul policy for the cluster the request is for. If it isn't, an error
+// response is written and false is returned.
+func (a API) requestConsulPolicy(w http.ResponseWriter, r *http.Request) (ConsulPolicy, bool) {
+	ap, err := a.requestAccessPolicy(r)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+			return ConsulPolicy{}, false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return ConsulPolicy{}, false
+	}
+	consul, ok := ap.consulPolicy(trout.RequestVars(r).Get("id"))
+	if !ok {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return ConsulPolicy{}, false
+	}
+	return consul, true
+}
+
+// consulAccess checks that the request's access policy is a Consul policy
+// for the cluster that covers key and grants the permission checked by
+// allowed. If it doesn't, an error response is written and false is
+// returned.
+func (a API) consulAccess(w http.ResponseWriter, r *http.Request, key string, allowed func(ConsulPolicy) bool) bool {
+	consul, ok := a.requestConsulPolicy(w, r)
+	if !ok {
+		return false
+	}
+	if !consul.covers(key) || !allowed(consul) {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return false
+	}
+	return true
+}
+
+func canReadConsulKeys(consul ConsulPolicy) bool   { return consul.Read }
+func canWriteConsulKeys(consul ConsulPolicy) bool  { return consul.Write }
+func canDeleteConsulKeys(consul ConsulPolicy) bool { return consul.Delete }
+
+// encodeConsulKVError writes the response for an error returned by one of
+// the Storer's Consul KV or session methods.
+func encodeConsulKVError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrConsulClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrConsulKVPairNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "key", Slug: api.RequestErrNotFound}}})
+	case ErrConsulKVCASMismatch:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/cas", Slug: api.RequestErrConflict}}})
+	case ErrConsulKVLockHeld:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/acquire", Slug: api.RequestErrConflict}}})
+	case ErrConsulKVLockNotHeld:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrConflict}}})
+	case ErrConsulSessionNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "session", Slug: api.RequestErrNotFound}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+// handleGetConsulKey returns a key. If the recurse query param is set, it
+// returns every key that starts with the key the request is for, and that
+// the request's access policy can read, instead.
+func (a API) handleGetConsulKey(w http.ResponseWriter, r *http.Request) {
+	key := consulKey(r)
+	recurse, err := parseConsulRecurse(r)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "recurse", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if recurse {
+		a.listConsulKeys(w, r, key)
+		return
+	}
+	if !validConsulKey(key) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "key", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if !a.consulAccess(w, r, key, canReadConsulKeys) {
+		return
+	}
+	pair, err := a.Storer.GetConsulKVPair(requestScope(r), trout.RequestVars(r).Get("id"), key)
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulKVPairs: []ConsulKVPair{pair}})
+}
+
+func (a API) listConsulKeys(w http.ResponseWriter, r *http.Request, prefix string) {
+	consul, ok := a.requestConsulPolicy(w, r)
+	if !ok {
+		return
+	}
+	if !consul.Read {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return
+	}
+	pairs, err := a.Storer.ListConsulKVPairs(requestScope(r), trout.RequestVars(r).Get("id"), prefix)
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	results := []ConsulKVPair{}
+	for _, pair := range pairs {
+		if !consul.covers(pair.Key) {
+			continue
+		}
+		results = append(results, pair)
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulKVPairs: results})
+}
+
+// handlePutConsulKey writes a key, creating it if it doesn't exist yet.
+func (a API) handlePutConsulKey(w http.ResponseWriter, r *http.Request) {
+	key := consulKey(r)
+	if !validConsulKey(key) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "key", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var write ConsulKVWrite
+	err := api.Decode(r, &write)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if write.Acquire != "" && write.Release != "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrConflict}}})
+		return
+	}
+	if !a.consulAccess(w, r, key, canWriteConsulKeys) {
+		return
+	}
+	pair, err := a.Storer.WriteConsulKVPair(requestScope(r), trout.RequestVars(r).Get("id"), key, write)
+	if err != nil {
+		if err == ErrConsulSessionNotFound {
+			field := "/acquire"
+			if write.Release != "" {
+				field = "/release"
+			}
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: field, Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulKVPairs: []ConsulKVPair{pair}})
+}
+
+// handleDeleteConsulKey deletes a key, or every key that starts with it if
+// the recurse query param is set. If the cas query param is set, the key is
+// only deleted if it matches the key's ModifyIndex.
+func (a API) handleDeleteConsulKey(w http.ResponseWriter, r *http.Request) {
+	key := consulKey(r)
+	recurse, err := parseConsulRecurse(r)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "recurse", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if !recurse && !validConsulKey(key) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "key", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var cas *uint64
+	if s := r.URL.Query().Get("cas"); s != "" {
+		if recurse {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "cas", Slug: api.RequestErrConflict}}})
+			return
+		}
+		index, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "cas", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+		cas = &index
+	}
+	if !a.consulAccess(w, r, key, canDeleteConsulKeys) {
+		return
+	}
+	pairs, err := a.Storer.DeleteConsulKVPairs(requestScope(r), trout.RequestVars(r).Get("id"), key, recurse, cas)
+	if err != nil {
+		if err == ErrConsulKVCASMismatch {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "cas", Slug: api.RequestErrConflict}}})
+			return
+		}
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulKVPairs: pairs})
+}
+
+// consulSessionAccess checks that the request's access policy is a Consul
+// policy for the cluster that can write keys, which sessions need to be
+// managed. Sessions aren't tied to keys, so the policy's Key doesn't matter.
+func (a API) consulSessionAccess(w http.ResponseWriter, r *http.Request) bool {
+	consul, ok := a.requestConsulPolicy(w, r)
+	if !ok {
+		return false
+	}
+	if !consul.Write {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return false
+	}
+	return true
+}
+
+func (a API) handleListConsulSessions(w http.ResponseWriter, r *http.Request) {
+	if !a.consulSessionAccess(w, r) {
+		return
+	}
+	sessions, err := a.Storer.ListConsulSessions(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []ConsulSession{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulSessions: sessions})
+}
+
+// handlePostConsulSession creates a session. Sessions without a TTL last
+// until they're destroyed.
+func (a API) handlePostConsulSession(w http.ResponseWriter, r *http.Request) {
+	var session ConsulSession
+	err := api.Decode(r, &session)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if session.Behavior == "" {
+		session.Behavior = consulSessionBehaviorRelease
+	}
+	if session.Behavior != consulSessionBehaviorRelease && session.Behavior != consulSessionBehaviorDelete {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/behavior", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if session.TTL != "" {
+		ttl, err := time.ParseDuration(session.TTL)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/ttl", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+		if ttl <= 0 {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/ttl", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+	}
+	if !a.consulSessionAccess(w, r) {
+		return
+	}
+	session.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	session.Organization = trout.RequestVars(r).Get("org")
+	session.Project = trout.RequestVars(r).Get("project")
+	session.ClusterID = trout.RequestVars(r).Get("id")
+	session, err = a.Storer.CreateConsulSession(session)
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{ConsulSessions: []ConsulSession{session}})
+}
+
+func (a API) handleGetConsulSession(w http.ResponseWriter, r *http.Request) {
+	if !a.consulSessionAccess(w, r) {
+		return
+	}
+	session, err := a.Storer.GetConsulSession(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("session"))
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulSessions: []ConsulSession{session}})
+}
+
+// handlePostConsulSessionRenew resets a session's TTL.
+func (a API) handlePostConsulSessionRenew(w http.ResponseWriter, r *http.Request) {
+	if !a.consulSessionAccess(w, r) {
+		return
+	}
+	session, err := a.Storer.RenewConsulSession(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("session"))
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulSessions: []ConsulSession{session}})
+}
+
+// handleDeleteConsulSession destroys a session, releasing or deleting the
+// keys it holds.
+func (a API) handleDeleteConsulSession(w http.ResponseWriter, r *http.Request) {
+	if !a.consulSessionAccess(w, r) {
+		return
+	}
+	session, err := a.Storer.DestroyConsulSession(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("session"))
+	if err != nil {
+		encodeConsulKVError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{ConsulSessions: []ConsulSession{session}})
+}
+
+// resetExpiry sets the session to expire its TTL after now. Sessions
+// without a TTL never expire.
+func (session *ConsulSession) resetExpiry(now time.Time) error {
+	if session.TTL == "" {
+		session.ExpiresAt = nil
+		return nil
+	}
+	ttl, err := time.ParseDuration(session.TTL)
+	if err != nil {
+		return err
+	}
+	expires := now.Add(ttl)
+	session.ExpiresAt = &expires
+	return nil
+}
@@ -0,0 +1,435 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	IPAttachmentVault  = "vault"
+	IPAttachmentConsul = "consul"
+	IPAttachmentNomad  = "nomad"
+)
+
+// IPPool is a block of addresses in a region that IP addresses are
+// allocated from. Pools in the same project and region can't overlap, so an
+// address is never handed out twice.
+type IPPool struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Project      string    `json:"project"`
+	Region       string    `json:"region"`
+	Name         string    `json:"name"`
+	CIDR         string    `json:"cidr"`
+	Description  string    `json:"description,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// IPAddress is an address allocated from an IPPool. Addresses asked for by
+// value are Reserved; the rest are the lowest free address in their pool.
+// AttachedTo is the cluster the address is attached to, if any.
+type IPAddress struct {
+	ID           string        `json:"id"`
+	Organization string        `json:"organization"`
+	Project      string        `json:"project"`
+	Region       string        `json:"region"`
+	PoolID       string        `json:"poolID"`
+	Address      string        `json:"address"`
+	Description  string        `json:"description,omitempty"`
+	Reserved     bool          `json:"reserved"`
+	AttachedTo   *IPAttachment `json:"attachedTo,omitempty"`
+	AllocatedAt  time.Time     `json:"allocatedAt"`
+}
+
+// IPAttachment identifies the Vault, Consul, or Nomad cluster an address is
+// attached to.
+type IPAttachment struct {
+	Type      string `json:"type"`
+	ClusterID string `json:"clusterID"`
+}
+
+// IPAddressUpdate is the body of a request to change an address. Everything
+// else about an address is fixed when it's allocated.
+type IPAddressUpdate struct {
+	Description string `json:"description"`
+}
+
+func (pool IPPool) validationErrors(authenticated bool) []api.RequestError {
+	var errs []api.RequestError
+	if pool.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	if pool.Region == "" {
+		errs = append(errs, api.RequestError{Field: "/region", Slug: api.RequestErrMissing})
+	} else if !validRegion(pool.Region, authenticated) {
+		errs = append(errs, api.RequestError{Field: "/region", Slug: api.RequestErrInvalidValue})
+	}
+	if pool.CIDR == "" {
+		errs = append(errs, api.RequestError{Field: "/cidr", Slug: api.RequestErrMissing})
+	} else if _, _, err := net.ParseCIDR(pool.CIDR); err != nil {
+		errs = append(errs, api.RequestError{Field: "/cidr", Slug: api.RequestErrInvalidFormat})
+	}
+	return errs
+}
+
+// normalize rewrites the pool's CIDR as the network it describes, so
+// 10.0.0.7/24 is stored as 10.0.0.0/24. The CIDR must already be valid.
+func (pool *IPPool) normalize() {
+	_, network, _ := net.ParseCIDR(pool.CIDR)
+	pool.CIDR = network.String()
+}
+
+// network returns the block of addresses the pool hands out.
+func (pool IPPool) network() *net.IPNet {
+	_, network, err := net.ParseCIDR(pool.CIDR)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+// usable reports whether ip can be allocated from the pool. The network
+// address is never handed out, and neither is the broadcast address of an
+// IPv4 block with room for hosts.
+func (pool IPPool) usable(ip net.IP) bool {
+	network := pool.network()
+	if network == nil || !network.Contains(ip) {
+		return false
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones < 2 {
+		return true
+	}
+	if ip.Equal(network.IP) {
+		return false
+	}
+	if bits == 32 && ip.Equal(lastIP(network)) {
+		return false
+	}
+	return true
+}
+
+func (attachment IPAttachment) validationErrors() []api.RequestError {
+	var errs []api.RequestError
+	switch attachment.Type {
+	case IPAttachmentVault, IPAttachmentConsul, IPAttachmentNomad:
+	case "":
+		errs = append(errs, api.RequestError{Field: "/type", Slug: api.RequestErrMissing})
+	default:
+		errs = append(errs, api.RequestError{Field: "/type", Slug: api.RequestErrInvalidValue})
+	}
+	if attachment.ClusterID == "" {
+		errs = append(errs, api.RequestError{Field: "/clusterID", Slug: api.RequestErrMissing})
+	}
+	return errs
+}
+
+// table returns the table the attached cluster is stored in.
+func (attachment IPAttachment) table() string {
+	switch attachment.Type {
+	case IPAttachmentVault:
+		return "vaultCluster"
+	case IPAttachmentConsul:
+		return "consulCluster"
+	case IPAttachmentNomad:
+		return "nomadCluster"
+	}
+	return ""
+}
+
+// validRegion reports whether id is one of the regions a user can see.
+func validRegion(id string, authenticated bool) bool {
+	for _, region := range getRegions(authenticated) {
+		if region.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// nextIP returns the address after ip.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for pos := len(next) - 1; pos >= 0; pos-- {
+		next[pos]++
+		if next[pos] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// lastIP returns the highest address in network.
+func lastIP(network *net.IPNet) net.IP {
+	last := make(net.IP, len(network.IP))
+	for pos := range network.IP {
+		last[pos] = network.IP[pos] | ^network.Mask[pos]
+	}
+	return last
+}
+
+// canonicalIP parses address and returns it in the form it's stored in, or
+// nil if it isn't an IP address.
+func canonicalIP(address string) net.IP {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+func encodeIPError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrIPPoolNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrIPPoolAlreadyExists:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+	case ErrIPPoolNameConflict:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+	case ErrIPPoolOverlap:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/cidr", Slug: api.RequestErrConflict}}})
+	case ErrIPPoolInUse:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+	case ErrIPAddressNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrIPAddressAlreadyExists:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+	case ErrIPAddressPoolInvalid:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/poolID", Slug: api.RequestErrInvalidValue}}})
+	case ErrIPAddressOutOfRange:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/address", Slug: api.RequestErrInvalidValue}}})
+	case ErrIPAddressInUse:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/address", Slug: api.RequestErrConflict}}})
+	case ErrIPPoolExhausted:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/poolID", Slug: api.RequestErrConflict}}})
+	case ErrIPAddressAttached:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+	case ErrIPAttachmentClusterNotFound:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/clusterID", Slug: api.RequestErrInvalidValue}}})
+	case ErrIPAttachmentRegionMismatch:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/clusterID", Slug: api.RequestErrConflict}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+func (a API) handleListIPPools(w http.ResponseWriter, r *http.Request) {
+	pools, err := a.Storer.ListIPPools(requestScope(r))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	if pools == nil {
+		pools = []IPPool{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPPools: pools})
+}
+
+func (a API) handlePostIPPool(w http.ResponseWriter, r *http.Request) {
+	var pool IPPool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	if pool.ID == "" {
+		pool.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	if errs := pool.validationErrors(isAuthenticated(r)); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	pool.normalize()
+	pool.CreatedAt = time.Now()
+	err = a.Storer.CreateIPPool(pool)
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{IPPools: []IPPool{pool}})
+}
+
+func (a API) handleGetIPPool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.GetIPPool(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPPools: []IPPool{pool}})
+}
+
+func (a API) handlePutIPPool(w http.ResponseWriter, r *http.Request) {
+	var pool IPPool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	if pool.ID != "" && pool.ID != trout.RequestVars(r).Get("id") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	pool.ID = trout.RequestVars(r).Get("id")
+	if errs := pool.validationErrors(isAuthenticated(r)); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	pool.normalize()
+	pool, err = a.Storer.UpdateIPPool(pool)
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPPools: []IPPool{pool}})
+}
+
+func (a API) handleDeleteIPPool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.DeleteIPPool(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPPools: []IPPool{pool}})
+}
+
+func (a API) handleListIPAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := a.Storer.ListIPAddresses(requestScope(r), r.URL.Query().Get("pool"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	if addresses == nil {
+		addresses = []IPAddress{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: addresses})
+}
+
+// handlePostIPAddress allocates an address. Requests that name an address
+// reserve it; the rest get the lowest free address in PoolID, or in the
+// region's pools in name order if PoolID isn't set.
+func (a API) handlePostIPAddress(w http.ResponseWriter, r *http.Request) {
+	var address IPAddress
+	err := api.Decode(r, &address)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	address.Organization = trout.RequestVars(r).Get("org")
+	address.Project = trout.RequestVars(r).Get("project")
+	if address.ID == "" {
+		address.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	var errs []api.RequestError
+	if address.Region == "" {
+		errs = append(errs, api.RequestError{Field: "/region", Slug: api.RequestErrMissing})
+	} else if !validRegion(address.Region, isAuthenticated(r)) {
+		errs = append(errs, api.RequestError{Field: "/region", Slug: api.RequestErrInvalidValue})
+	}
+	if address.Address != "" {
+		ip := canonicalIP(address.Address)
+		if ip == nil {
+			errs = append(errs, api.RequestError{Field: "/address", Slug: api.RequestErrInvalidFormat})
+		} else {
+			address.Address = ip.String()
+		}
+	}
+	if address.AttachedTo != nil {
+		errs = append(errs, api.RequestError{Field: "/attachedTo", Slug: api.RequestErrInvalidValue})
+	}
+	if len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	address.Reserved = address.Address != ""
+	address.AllocatedAt = time.Now()
+	address, err = a.Storer.AllocateIPAddress(address)
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{IPAddresses: []IPAddress{address}})
+}
+
+func (a API) handleGetIPAddress(w http.ResponseWriter, r *http.Request) {
+	address, err := a.Storer.GetIPAddress(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: []IPAddress{address}})
+}
+
+func (a API) handlePutIPAddress(w http.ResponseWriter, r *http.Request) {
+	var update IPAddressUpdate
+	err := api.Decode(r, &update)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	address, err := a.Storer.UpdateIPAddress(requestScope(r), trout.RequestVars(r).Get("id"), update)
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: []IPAddress{address}})
+}
+
+// handleDeleteIPAddress releases an address back to its pool, detaching it
+// from its cluster first if it's attached.
+func (a API) handleDeleteIPAddress(w http.ResponseWriter, r *http.Request) {
+	address, err := a.Storer.ReleaseIPAddress(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: []IPAddress{address}})
+}
+
+func (a API) handlePostIPAddressAttach(w http.ResponseWriter, r *http.Request) {
+	var attachment IPAttachment
+	err := api.Decode(r, &attachment)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := attachment.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	address, err := a.Storer.AttachIPAddress(requestScope(r), trout.RequestVars(r).Get("id"), attachment)
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: []IPAddress{address}})
+}
+
+func (a API) handlePostIPAddressDetach(w http.ResponseWriter, r *http.Request) {
+	address, err := a.Storer.DetachIPAddress(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeIPError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{IPAddresses: []IPAddress{address}})
+}
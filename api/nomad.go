@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,13 +11,16 @@ import (
 )
 
 type NomadCluster struct {
-	ID         string                `json:"id"`
-	Name       string                `json:"name"`
-	Datacenter string                `json:"datacenter"`
-	BindAddr   string                `json:"bindAddr"`
-	Advertise  NomadClusterAdvertise `json:"advertise"`
-	Ports      NomadClusterPorts     `json:"ports"`
-	Server     NomadClusterServer    `json:"server"`
+	ID           string                `json:"id"`
+	Organization string                `json:"organization"`
+	Project      string                `json:"project"`
+	Name         string                `json:"name"`
+	Datacenter   string                `json:"datacenter"`
+	BindAddr     string                `json:"bindAddr"`
+	Advertise    NomadClusterAdvertise `json:"advertise"`
+	Ports        NomadClusterPorts     `json:"ports"`
+	Server       NomadClusterServer    `json:"server"`
+	DeletedAt    *time.Time            `json:"deletedAt,omitempty"`
 }
 
 type NomadClusterAdvertise struct {
@@ -63,7 +68,7 @@ func (cluster *NomadCluster) FillDefaults() {
 }
 
 func (a API) handleGetNomadCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetNomadCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetNomadCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrNomadClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -82,6 +87,9 @@ func (a API) handlePostNomadCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	if cluster.ID == "" {
 		cluster.ID, err = uuid.GenerateUUID()
 		if err != nil {
@@ -98,12 +106,20 @@ func (a API) handlePostNomadCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
+	if errs := cluster.serverJoinErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	err = a.Storer.CreateNomadCluster(cluster)
 	if err != nil {
 		if err == ErrNomadClusterAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if err == ErrNomadClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -117,6 +133,9 @@ func (a API) handlePutNomadCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	if cluster.ID != "" && cluster.ID != trout.RequestVars(r).Get("id") {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 		return
@@ -130,12 +149,20 @@ func (a API) handlePutNomadCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
+	if errs := cluster.serverJoinErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	err = a.Storer.UpdateNomadCluster(cluster)
 	if err != nil {
 		if err == ErrNomadClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrNomadClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -143,7 +170,7 @@ func (a API) handlePutNomadCluster(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a API) handleDeleteNomadCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetNomadCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetNomadCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrNomadClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -152,7 +179,7 @@ func (a API) handleDeleteNomadCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteNomadCluster(trout.RequestVars(r).Get("id"))
+	err = a.Storer.DeleteNomadCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrNomadClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -163,3 +190,92 @@ func (a API) handleDeleteNomadCluster(w http.ResponseWriter, r *http.Request) {
 	}
 	api.Encode(w, r, http.StatusOK, Response{NomadClusters: []NomadCluster{cluster}})
 }
+
+func (a API) handleGetNomadClusterVersions(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	_, err := a.Storer.GetNomadCluster(requestScope(r), id)
+	if err != nil {
+		if err == ErrNomadClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	versions, err := a.Storer.ListVersions("nomadCluster", id)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = fillVersionChanges(versions)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Versions: versions})
+}
+
+func (a API) handlePostNomadClusterVersionRestore(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	cluster, err := a.Storer.RestoreNomadClusterVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
+	if err != nil {
+		if err == ErrNomadClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNomadClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadClusters: []NomadCluster{cluster}})
+}
+
+func (a API) handleListNomadClusters(w http.ResponseWriter, r *http.Request) {
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deleted", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	results, err := a.Storer.ListNomadClusters(requestScope(r), includeDeleted)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadClusters: results})
+}
+
+func (a API) handlePostNomadClusterUndelete(w http.ResponseWriter, r *http.Request) {
+	cluster, err := a.Storer.UndeleteNomadCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrNomadClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrNomadClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadClusters: []NomadCluster{cluster}})
+}
@@ -0,0 +1,552 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// NomadJob is a job submitted to a Nomad cluster. Submitting a job with the
+// ID of one already in the cluster replaces it and bumps its Version; the
+// scheduler then stops the old version's allocations and places the new
+// version's. Status is pending until the job's allocations are placed,
+// running while they are, and dead once they've all stopped, either because
+// the job was canceled or because it's a batch job that finished. The job's
+// allocations are only placed on nodes in its NodePool, unless it's the all
+// pool.
+type NomadJob struct {
+	ID                string            `json:"id"`
+	Organization      string            `json:"organization"`
+	Project           string            `json:"project"`
+	ClusterID         string            `json:"clusterID"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Datacenters       []string          `json:"datacenters"`
+	NodePool          string            `json:"nodePool"`
+	Constraints       []NomadConstraint `json:"constraints,omitempty"`
+	TaskGroups        []NomadTaskGroup  `json:"taskGroups"`
+	Version           uint64            `json:"version"`
+	Stop              bool              `json:"stop"`
+	Status            string            `json:"status"`
+	StatusDescription string            `json:"statusDescription,omitempty"`
+	StatusChangedAt   time.Time         `json:"statusChangedAt"`
+	SubmittedAt       time.Time         `json:"submittedAt"`
+}
+
+// NomadTaskGroup is a set of tasks that are placed together. The scheduler
+// places Count allocations of each group.
+type NomadTaskGroup struct {
+	Name        string            `json:"name"`
+	Count       int               `json:"count"`
+	Constraints []NomadConstraint `json:"constraints,omitempty"`
+	Tasks       []NomadTask       `json:"tasks"`
+}
+
+// NomadTask is a single task in a task group. The scheduler doesn't run
+// anything, so Driver and Config are only recorded, but Resources are
+// reserved on the node the task's allocation is placed on.
+type NomadTask struct {
+	Name      string            `json:"name"`
+	Driver    string            `json:"driver"`
+	Config    map[string]string `json:"config,omitempty"`
+	Resources NomadResources    `json:"resources"`
+}
+
+// NomadConstraint limits the nodes a job or task group can be placed on.
+// Attribute is a node attribute in the form ${node.datacenter}, and it's
+// compared against Value using Operator, which defaults to "=".
+type NomadConstraint struct {
+	Attribute string `json:"attribute"`
+	Operator  string `json:"operator"`
+	Value     string `json:"value,omitempty"`
+}
+
+// NomadAllocation is one instance of a job's task group, placed by the
+// scheduler. DesiredStatus is what the scheduler wants the allocation to be
+// doing, and ClientStatus is what it's actually doing. NodeID is the node
+// the allocation was placed on, and is empty if the cluster had no nodes
+// registered. Resources are the total of the task group's tasks'.
+type NomadAllocation struct {
+	ID                 string         `json:"id"`
+	Organization       string         `json:"organization"`
+	Project            string         `json:"project"`
+	ClusterID          string         `json:"clusterID"`
+	JobID              string         `json:"jobID"`
+	JobVersion         uint64         `json:"jobVersion"`
+	TaskGroup          string         `json:"taskGroup"`
+	Index              int            `json:"index"`
+	Name               string         `json:"name"`
+	NodeID             string         `json:"nodeID,omitempty"`
+	NodeName           string         `json:"nodeName,omitempty"`
+	Resources          NomadResources `json:"resources"`
+	DesiredStatus      string         `json:"desiredStatus"`
+	DesiredDescription string         `json:"desiredDescription,omitempty"`
+	ClientStatus       string         `json:"clientStatus"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	ModifiedAt         time.Time      `json:"modifiedAt"`
+}
+
+const (
+	nomadJobPending = "pending"
+	nomadJobRunning = "running"
+	nomadJobDead    = "dead"
+
+	nomadJobTypeService = "service"
+	nomadJobTypeBatch   = "batch"
+
+	nomadAllocDesiredRun  = "run"
+	nomadAllocDesiredStop = "stop"
+
+	nomadAllocPending  = "pending"
+	nomadAllocRunning  = "running"
+	nomadAllocComplete = "complete"
+	nomadAllocLost     = "lost"
+)
+
+// nomadConstraintOperators are the operators constraints can use.
+var nomadConstraintOperators = map[string]bool{
+	"=":            true,
+	"!=":           true,
+	"<":            true,
+	"<=":           true,
+	">":            true,
+	">=":           true,
+	"regexp":       true,
+	"set_contains": true,
+	"is_set":       true,
+	"is_not_set":   true,
+}
+
+// nomadAttribute matches constraint attributes, capturing the name of the
+// node attribute.
+var nomadAttribute = regexp.MustCompile(`^\$\{([a-zA-Z0-9._-]+)\}$`)
+
+func (job *NomadJob) setStatus(status, description string) {
+	job.Status = status
+	job.StatusDescription = description
+	job.StatusChangedAt = time.Now()
+}
+
+func (alloc *NomadAllocation) setClientStatus(status string) {
+	alloc.ClientStatus = status
+	alloc.ModifiedAt = time.Now()
+}
+
+// finished reports whether the allocation has stopped for good.
+func (alloc NomadAllocation) finished() bool {
+	return alloc.ClientStatus == nomadAllocComplete || alloc.ClientStatus == nomadAllocLost
+}
+
+// needsReplacing reports whether the allocation stopped because of its node
+// rather than its job, so the scheduler should place another in its stead.
+func (alloc NomadAllocation) needsReplacing() bool {
+	return alloc.finished() && (alloc.ClientStatus == nomadAllocLost || alloc.DesiredDescription == nomadAllocMigrating)
+}
+
+// FillDefaults sets the job's ID to its Name, its Type to service, its
+// NodePool to default, its task groups' counts to 1, its tasks' resources to
+// 100 MHz of CPU and 300 MB of memory, and its constraints' operators to "="
+// if they're not set.
+func (job *NomadJob) FillDefaults() {
+	if job.ID == "" {
+		job.ID = job.Name
+	}
+	if job.Type == "" {
+		job.Type = nomadJobTypeService
+	}
+	if job.NodePool == "" {
+		job.NodePool = nomadNodePoolDefault
+	}
+	fillNomadConstraintDefaults(job.Constraints)
+	for pos := range job.TaskGroups {
+		group := &job.TaskGroups[pos]
+		if group.Count == 0 {
+			group.Count = 1
+		}
+		fillNomadConstraintDefaults(group.Constraints)
+		for taskPos := range group.Tasks {
+			if group.Tasks[taskPos].Resources.CPU == 0 {
+				group.Tasks[taskPos].Resources.CPU = 100
+			}
+			if group.Tasks[taskPos].Resources.MemoryMB == 0 {
+				group.Tasks[taskPos].Resources.MemoryMB = 300
+			}
+		}
+	}
+}
+
+func fillNomadConstraintDefaults(constraints []NomadConstraint) {
+	for pos := range constraints {
+		if constraints[pos].Operator == "" {
+			constraints[pos].Operator = "="
+		}
+	}
+}
+
+// validationErrors returns the problems with a job being submitted. Whether
+// its datacenters include the cluster's and whether its node pool exists are
+// checked when it's stored.
+func (job NomadJob) validationErrors() []api.RequestError {
+	var errs []api.RequestError
+	if job.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	if strings.Contains(job.ID, "/") {
+		errs = append(errs, api.RequestError{Field: "/id", Slug: api.RequestErrInvalidFormat})
+	}
+	if job.Type != nomadJobTypeService && job.Type != nomadJobTypeBatch {
+		errs = append(errs, api.RequestError{Field: "/type", Slug: api.RequestErrInvalidValue})
+	}
+	for pos, dc := range job.Datacenters {
+		if dc == "" {
+			errs = append(errs, api.RequestError{Field: "/datacenters/" + strconv.Itoa(pos), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	errs = append(errs, nomadConstraintErrors("/constraints", job.Constraints)...)
+	if len(job.TaskGroups) < 1 {
+		errs = append(errs, api.RequestError{Field: "/taskGroups", Slug: api.RequestErrMissing})
+	}
+	groupNames := map[string]bool{}
+	for pos, group := range job.TaskGroups {
+		field := "/taskGroups/" + strconv.Itoa(pos)
+		if group.Name == "" {
+			errs = append(errs, api.RequestError{Field: field + "/name", Slug: api.RequestErrMissing})
+		} else if groupNames[group.Name] {
+			errs = append(errs, api.RequestError{Field: field + "/name", Slug: api.RequestErrConflict})
+		}
+		groupNames[group.Name] = true
+		if group.Count < 1 {
+			errs = append(errs, api.RequestError{Field: field + "/count", Slug: api.RequestErrInvalidValue})
+		}
+		errs = append(errs, nomadConstraintErrors(field+"/constraints", group.Constraints)...)
+		if len(group.Tasks) < 1 {
+			errs = append(errs, api.RequestError{Field: field + "/tasks", Slug: api.RequestErrMissing})
+		}
+		taskNames := map[string]bool{}
+		for taskPos, task := range group.Tasks {
+			taskField := field + "/tasks/" + strconv.Itoa(taskPos)
+			if task.Name == "" {
+				errs = append(errs, api.RequestError{Field: taskField + "/name", Slug: api.RequestErrMissing})
+			} else if taskNames[task.Name] {
+				errs = append(errs, api.RequestError{Field: taskField + "/name", Slug: api.RequestErrConflict})
+			}
+			taskNames[task.Name] = true
+			if task.Driver == "" {
+				errs = append(errs, api.RequestError{Field: taskField + "/driver", Slug: api.RequestErrMissing})
+			}
+			if task.Resources.CPU < 0 {
+				errs = append(errs, api.RequestError{Field: taskField + "/resources/cpu", Slug: api.RequestErrInvalidValue})
+			}
+			if task.Resources.MemoryMB < 0 {
+				errs = append(errs, api.RequestError{Field: taskField + "/resources/memoryMB", Slug: api.RequestErrInvalidValue})
+			}
+		}
+	}
+	return errs
+}
+
+// nomadConstraintErrors returns the problems with the constraints at field.
+func nomadConstraintErrors(field string, constraints []NomadConstraint) []api.RequestError {
+	var errs []api.RequestError
+	for pos, constraint := range constraints {
+		prefix := field + "/" + strconv.Itoa(pos)
+		if constraint.Attribute == "" {
+			errs = append(errs, api.RequestError{Field: prefix + "/attribute", Slug: api.RequestErrMissing})
+		} else if !nomadAttribute.MatchString(constraint.Attribute) {
+			errs = append(errs, api.RequestError{Field: prefix + "/attribute", Slug: api.RequestErrInvalidFormat})
+		}
+		if !nomadConstraintOperators[constraint.Operator] {
+			errs = append(errs, api.RequestError{Field: prefix + "/operator", Slug: api.RequestErrInvalidValue})
+		}
+		if constraint.Operator == "regexp" {
+			if _, err := regexp.Compile(constraint.Value); err != nil {
+				errs = append(errs, api.RequestError{Field: prefix + "/value", Slug: api.RequestErrInvalidFormat})
+			}
+		}
+	}
+	return errs
+}
+
+// nomadNodeAttributes returns the attributes constraints are checked
+// against when placing allocations in a cluster with no client nodes
+// registered. The cluster is treated as a single Linux node in the default
+// pool, with no limit on its resources.
+func nomadNodeAttributes(cluster NomadCluster) map[string]string {
+	return map[string]string{
+		"node.unique.id":   cluster.ID,
+		"node.unique.name": cluster.Name,
+		"node.datacenter":  cluster.Datacenter,
+		"node.pool":        nomadNodePoolDefault,
+		"attr.kernel.name": "linux",
+		"attr.cpu.arch":    "amd64",
+	}
+}
+
+// satisfiedBy reports whether a node with attrs meets the constraint.
+// Attributes the node doesn't have only satisfy "!=" and "is_not_set".
+func (constraint NomadConstraint) satisfiedBy(attrs map[string]string) bool {
+	match := nomadAttribute.FindStringSubmatch(constraint.Attribute)
+	if match == nil {
+		return false
+	}
+	val, ok := attrs[match[1]]
+	switch constraint.Operator {
+	case "is_set":
+		return ok
+	case "is_not_set":
+		return !ok
+	case "!=":
+		return !ok || val != constraint.Value
+	}
+	if !ok {
+		return false
+	}
+	switch constraint.Operator {
+	case "=":
+		return val == constraint.Value
+	case "<", "<=", ">", ">=":
+		return compareNomadValues(val, constraint.Value, constraint.Operator)
+	case "regexp":
+		re, err := regexp.Compile(constraint.Value)
+		return err == nil && re.MatchString(val)
+	case "set_contains":
+		have := map[string]bool{}
+		for _, item := range strings.Split(val, ",") {
+			have[strings.TrimSpace(item)] = true
+		}
+		for _, item := range strings.Split(constraint.Value, ",") {
+			if !have[strings.TrimSpace(item)] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compareNomadValues compares a and b using op, as numbers if they both are
+// and as strings if they aren't.
+func compareNomadValues(a, b, op string) bool {
+	cmp := strings.Compare(a, b)
+	af, aErr := strconv.ParseFloat(a, 64)
+	bf, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			cmp = -1
+		case af > bf:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// groupRunsOn reports whether a node with attrs meets the job's and the
+// task group's constraints.
+func (job NomadJob) groupRunsOn(group NomadTaskGroup, attrs map[string]string) bool {
+	for _, constraint := range append(append([]NomadConstraint{}, job.Constraints...), group.Constraints...) {
+		if !constraint.satisfiedBy(attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+// runsOn reports whether node is in one of the job's datacenters and its
+// node pool.
+func (job NomadJob) runsOn(node NomadNode) bool {
+	if job.NodePool != nomadNodePoolAll && job.NodePool != node.NodePool {
+		return false
+	}
+	for _, dc := range job.Datacenters {
+		if strings.EqualFold(dc, node.Datacenter) {
+			return true
+		}
+	}
+	return false
+}
+
+// resources returns the resources an allocation of the task group needs.
+func (group NomadTaskGroup) resources() NomadResources {
+	var total NomadResources
+	for _, task := range group.Tasks {
+		total = total.add(task.Resources)
+	}
+	return total
+}
+
+// sortNomadAllocations sorts allocations by job version, then task group,
+// then index.
+func sortNomadAllocations(allocs []NomadAllocation) {
+	sort.Slice(allocs, func(i, j int) bool {
+		if allocs[i].JobVersion != allocs[j].JobVersion {
+			return allocs[i].JobVersion < allocs[j].JobVersion
+		}
+		if allocs[i].TaskGroup != allocs[j].TaskGroup {
+			return allocs[i].TaskGroup < allocs[j].TaskGroup
+		}
+		return allocs[i].Index < allocs[j].Index
+	})
+}
+
+// requestNomadPolicy returns the request's access policy, if it is a Nomad
+// policy for the cluster the request is for. If it isn't, an error response
+// is written and false is returned.
+func (a API) requestNomadPolicy(w http.ResponseWriter, r *http.Request) (NomadPolicy, bool) {
+	ap, err := a.requestAccessPolicy(r)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+			return NomadPolicy{}, false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return NomadPolicy{}, false
+	}
+	nomad, ok := ap.nomadPolicy(trout.RequestVars(r).Get("id"))
+	if !ok {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return NomadPolicy{}, false
+	}
+	return nomad, true
+}
+
+// nomadAccess checks that the request's access policy is a Nomad policy
+// for the cluster that grants the permission checked by allowed. If it
+// doesn't, an error response is written and false is returned.
+func (a API) nomadAccess(w http.ResponseWriter, r *http.Request, allowed func(NomadPolicy) bool) bool {
+	nomad, ok := a.requestNomadPolicy(w, r)
+	if !ok {
+		return false
+	}
+	if !allowed(nomad) {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return false
+	}
+	return true
+}
+
+func canSubmitNomadJobs(nomad NomadPolicy) bool    { return nomad.SubmitJobs }
+func canReadNomadJobStatus(nomad NomadPolicy) bool { return nomad.ReadJobStatus }
+func canCancelNomadJobs(nomad NomadPolicy) bool    { return nomad.CancelJobs }
+
+func encodeNomadJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrNomadClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrNomadJobNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "job", Slug: api.RequestErrNotFound}}})
+	case ErrNomadJobDatacenterMismatch:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/datacenters", Slug: api.RequestErrInvalidValue}}})
+	case ErrNomadNodePoolNotFound:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/nodePool", Slug: api.RequestErrInvalidValue}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+func (a API) handleListNomadJobs(w http.ResponseWriter, r *http.Request) {
+	if !a.nomadAccess(w, r, canReadNomadJobStatus) {
+		return
+	}
+	jobs, err := a.Storer.ListNomadJobs(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeNomadJobError(w, r, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []NomadJob{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadJobs: jobs})
+}
+
+// handlePostNomadJob submits a job, replacing any job already in the
+// cluster with the same ID.
+func (a API) handlePostNomadJob(w http.ResponseWriter, r *http.Request) {
+	var job NomadJob
+	err := api.Decode(r, &job)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if !a.nomadAccess(w, r, canSubmitNomadJobs) {
+		return
+	}
+	job.FillDefaults()
+	if errs := job.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	job.Organization = trout.RequestVars(r).Get("org")
+	job.Project = trout.RequestVars(r).Get("project")
+	job.ClusterID = trout.RequestVars(r).Get("id")
+	job, created, err := a.Storer.SubmitNomadJob(job)
+	if err != nil {
+		encodeNomadJobError(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	api.Encode(w, r, status, Response{NomadJobs: []NomadJob{job}})
+}
+
+func (a API) handleGetNomadJob(w http.ResponseWriter, r *http.Request) {
+	if !a.nomadAccess(w, r, canReadNomadJobStatus) {
+		return
+	}
+	job, err := a.Storer.GetNomadJob(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("job"))
+	if err != nil {
+		encodeNomadJobError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadJobs: []NomadJob{job}})
+}
+
+// handleDeleteNomadJob cancels a job. Its allocations are stopped by the
+// scheduler, and it stays in the cluster as a dead job until it's submitted
+// again or the cluster is purged.
+func (a API) handleDeleteNomadJob(w http.ResponseWriter, r *http.Request) {
+	if !a.nomadAccess(w, r, canCancelNomadJobs) {
+		return
+	}
+	job, err := a.Storer.CancelNomadJob(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("job"))
+	if err != nil {
+		encodeNomadJobError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadJobs: []NomadJob{job}})
+}
+
+func (a API) handleListNomadAllocations(w http.ResponseWriter, r *http.Request) {
+	if !a.nomadAccess(w, r, canReadNomadJobStatus) {
+		return
+	}
+	allocs, err := a.Storer.ListNomadAllocations(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("job"))
+	if err != nil {
+		encodeNomadJobError(w, r, err)
+		return
+	}
+	if allocs == nil {
+		allocs = []NomadAllocation{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadAllocations: allocs})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// NomadMember is a server in the gossip pool a Nomad cluster belongs to.
+// Clusters join each other's pools using their ServerJoin settings, and
+// membership spreads, so every cluster in a pool has the same members.
+type NomadMember struct {
+	ClusterID  string `json:"clusterID"`
+	Name       string `json:"name"`
+	Datacenter string `json:"datacenter"`
+	Address    string `json:"address"`
+	Port       int    `json:"port"`
+	Status     string `json:"status"`
+}
+
+// nomadJoin is the state of a Nomad cluster's attempts to join other
+// clusters. Peers are the IDs of the clusters it has joined. RetryAttempts
+// counts the failed attempts to join using RetryJoin since the cluster's
+// ServerJoin settings last changed, and NextRetryAt is when the next one
+// is due; it's nil once the cluster has joined or run out of attempts.
+type nomadJoin struct {
+	ClusterID     string
+	Peers         []string
+	RetryAttempts int
+	NextRetryAt   *time.Time
+}
+
+const nomadMemberAlive = "alive"
+
+// serfAddress returns the address other servers reach the cluster's serf
+// port at: its advertised serf address, or its bind address if it doesn't
+// advertise one.
+func (cluster NomadCluster) serfAddress() string {
+	if cluster.Advertise.Serf != "" {
+		return cluster.Advertise.Serf
+	}
+	return cluster.BindAddr
+}
+
+// member returns the cluster as a member of a gossip pool.
+func (cluster NomadCluster) member() NomadMember {
+	return NomadMember{
+		ClusterID:  cluster.ID,
+		Name:       cluster.Name,
+		Datacenter: cluster.Datacenter,
+		Address:    cluster.serfAddress(),
+		Port:       cluster.Ports.Serf,
+		Status:     nomadMemberAlive,
+	}
+}
+
+// splitNomadJoinAddress splits a join address in the form HOST or HOST:PORT.
+// port is 0 if the address doesn't have one.
+func splitNomadJoinAddress(address string) (host string, port int, ok bool) {
+	if address == "" {
+		return "", 0, false
+	}
+	h, p, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, 0, true
+	}
+	port, err = strconv.Atoi(p)
+	if err != nil || h == "" || port < 1 || port > maxPort {
+		return "", 0, false
+	}
+	return h, port, true
+}
+
+// joinedBy reports whether address, a join address in the form HOST or
+// HOST:PORT, reaches the cluster's serf port.
+func (cluster NomadCluster) joinedBy(address string) bool {
+	host, port, ok := splitNomadJoinAddress(address)
+	if !ok || host != cluster.serfAddress() {
+		return false
+	}
+	return port == 0 || port == cluster.Ports.Serf
+}
+
+// serverJoinErrors returns the problems with the cluster's ServerJoin
+// settings. It should be called after FillDefaults.
+func (cluster NomadCluster) serverJoinErrors() []api.RequestError {
+	var errs []api.RequestError
+	join := cluster.Server.ServerJoin
+	for pos, address := range join.RetryJoin {
+		if _, _, ok := splitNomadJoinAddress(address); !ok {
+			errs = append(errs, api.RequestError{Field: "/server/serverJoin/retryJoin/" + strconv.Itoa(pos), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	for pos, address := range join.StartJoin {
+		if _, _, ok := splitNomadJoinAddress(address); !ok {
+			errs = append(errs, api.RequestError{Field: "/server/serverJoin/startJoin/" + strconv.Itoa(pos), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	if interval, err := time.ParseDuration(join.RetryInterval); err != nil || interval <= 0 {
+		errs = append(errs, api.RequestError{Field: "/server/serverJoin/retryInterval", Slug: api.RequestErrInvalidFormat})
+	}
+	if join.RetryMax < 0 {
+		errs = append(errs, api.RequestError{Field: "/server/serverJoin/retryMax", Slug: api.RequestErrInvalidValue})
+	}
+	return errs
+}
+
+// sortNomadMembers sorts members by name, then cluster ID.
+func sortNomadMembers(members []NomadMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Name == members[j].Name {
+			return members[i].ClusterID < members[j].ClusterID
+		}
+		return members[i].Name < members[j].Name
+	})
+}
+
+// handleListNomadMembers returns the members of the gossip pool the cluster
+// belongs to, including the cluster itself.
+func (a API) handleListNomadMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := a.Storer.ListNomadMembers(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrNomadClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadMembers: members})
+}
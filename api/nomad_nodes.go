@@ -0,0 +1,378 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// NomadNodePool is a named group of client nodes in a Nomad cluster. Jobs
+// are only placed on nodes in their NodePool. SchedulerAlgorithm is how the
+// scheduler picks between nodes in the pool: binpack fills up the busiest
+// node that has room first, and spread uses the least busy node. Every
+// cluster has the built-in default and all pools, which can't be changed;
+// nodes are in the default pool unless they say otherwise, and jobs in the
+// all pool can be placed on any node.
+type NomadNodePool struct {
+	Organization       string            `json:"organization"`
+	Project            string            `json:"project"`
+	ClusterID          string            `json:"clusterID"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	Meta               map[string]string `json:"meta,omitempty"`
+	SchedulerAlgorithm string            `json:"schedulerAlgorithm"`
+	CreatedAt          time.Time         `json:"createdAt"`
+	ModifiedAt         time.Time         `json:"modifiedAt"`
+}
+
+// NomadNode is a client node registered with a Nomad cluster, which the
+// scheduler places allocations on. Resources are the node's capacity, and
+// allocations are only placed on nodes with enough of it left. Nodes that
+// are ineligible or draining get no new allocations; draining a node also
+// migrates its allocations to other nodes, and when they've all stopped the
+// drain is done and the node is left ineligible.
+type NomadNode struct {
+	ID                    string         `json:"id"`
+	Organization          string         `json:"organization"`
+	Project               string         `json:"project"`
+	ClusterID             string         `json:"clusterID"`
+	Name                  string         `json:"name"`
+	Datacenter            string         `json:"datacenter"`
+	NodeClass             string         `json:"nodeClass,omitempty"`
+	NodePool              string         `json:"nodePool"`
+	Resources             NomadResources `json:"resources"`
+	Drain                 bool           `json:"drain"`
+	SchedulingEligibility string         `json:"schedulingEligibility"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	ModifiedAt            time.Time      `json:"modifiedAt"`
+}
+
+// NomadResources is an amount of CPU, in MHz, and memory, in MB, that a
+// node has or that a task or allocation needs.
+type NomadResources struct {
+	CPU      int `json:"cpu"`
+	MemoryMB int `json:"memoryMB"`
+}
+
+// NomadNodeDrainUpdate is the body of a request to start or cancel draining
+// a node.
+type NomadNodeDrainUpdate struct {
+	Enable bool `json:"enable"`
+}
+
+// NomadNodeEligibilityUpdate is the body of a request to change whether a
+// node is eligible for new allocations.
+type NomadNodeEligibilityUpdate struct {
+	Eligible bool `json:"eligible"`
+}
+
+const (
+	nomadNodePoolDefault = "default"
+	nomadNodePoolAll     = "all"
+
+	nomadSchedulerBinpack = "binpack"
+	nomadSchedulerSpread  = "spread"
+
+	nomadNodeEligible   = "eligible"
+	nomadNodeIneligible = "ineligible"
+
+	nomadAllocMigrating = "alloc is being migrated because its node is draining"
+	nomadAllocNodeGone  = "alloc is lost because its node was deregistered"
+)
+
+// nomadNodePoolName matches the names node pools can have.
+var nomadNodePoolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+
+// FillDefaults sets the pool's SchedulerAlgorithm to binpack if it's not
+// set.
+func (pool *NomadNodePool) FillDefaults() {
+	if pool.SchedulerAlgorithm == "" {
+		pool.SchedulerAlgorithm = nomadSchedulerBinpack
+	}
+}
+
+// validationErrors returns the problems with a node pool being created or
+// updated.
+func (pool NomadNodePool) validationErrors() []api.RequestError {
+	var errs []api.RequestError
+	switch {
+	case pool.Name == "":
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	case pool.Name == nomadNodePoolDefault || pool.Name == nomadNodePoolAll:
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrConflict})
+	case !nomadNodePoolName.MatchString(pool.Name):
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrInvalidFormat})
+	}
+	if pool.SchedulerAlgorithm != nomadSchedulerBinpack && pool.SchedulerAlgorithm != nomadSchedulerSpread {
+		errs = append(errs, api.RequestError{Field: "/schedulerAlgorithm", Slug: api.RequestErrInvalidValue})
+	}
+	return errs
+}
+
+// FillDefaults puts the node in the default pool, and gives it 4000 MHz of
+// CPU and 8192 MB of memory, if those aren't set. The node's datacenter
+// defaults to its cluster's, and is set when it's stored.
+func (node *NomadNode) FillDefaults() {
+	if node.NodePool == "" {
+		node.NodePool = nomadNodePoolDefault
+	}
+	if node.Resources.CPU == 0 {
+		node.Resources.CPU = 4000
+	}
+	if node.Resources.MemoryMB == 0 {
+		node.Resources.MemoryMB = 8192
+	}
+}
+
+// validationErrors returns the problems with a node being registered.
+// Whether its pool exists is checked when it's stored.
+func (node NomadNode) validationErrors() []api.RequestError {
+	var errs []api.RequestError
+	if node.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	if node.NodePool == nomadNodePoolAll {
+		errs = append(errs, api.RequestError{Field: "/nodePool", Slug: api.RequestErrInvalidValue})
+	}
+	if node.Resources.CPU < 0 {
+		errs = append(errs, api.RequestError{Field: "/resources/cpu", Slug: api.RequestErrInvalidValue})
+	}
+	if node.Resources.MemoryMB < 0 {
+		errs = append(errs, api.RequestError{Field: "/resources/memoryMB", Slug: api.RequestErrInvalidValue})
+	}
+	return errs
+}
+
+// schedulable reports whether new allocations can be placed on the node.
+func (node NomadNode) schedulable() bool {
+	return !node.Drain && node.SchedulingEligibility == nomadNodeEligible
+}
+
+// attributes returns the attributes constraints are checked against when
+// placing allocations on the node.
+func (node NomadNode) attributes() map[string]string {
+	attrs := map[string]string{
+		"node.unique.id":         node.ID,
+		"node.unique.name":       node.Name,
+		"node.datacenter":        node.Datacenter,
+		"node.pool":              node.NodePool,
+		"attr.kernel.name":       "linux",
+		"attr.cpu.arch":          "amd64",
+		"attr.cpu.totalcompute":  strconv.Itoa(node.Resources.CPU),
+		"attr.memory.totalbytes": strconv.Itoa(node.Resources.MemoryMB * 1024 * 1024),
+	}
+	if node.NodeClass != "" {
+		attrs["node.class"] = node.NodeClass
+	}
+	return attrs
+}
+
+// fits reports whether need fits in r.
+func (r NomadResources) fits(need NomadResources) bool {
+	return need.CPU <= r.CPU && need.MemoryMB <= r.MemoryMB
+}
+
+func (r NomadResources) add(other NomadResources) NomadResources {
+	return NomadResources{CPU: r.CPU + other.CPU, MemoryMB: r.MemoryMB + other.MemoryMB}
+}
+
+func (r NomadResources) sub(other NomadResources) NomadResources {
+	return NomadResources{CPU: r.CPU - other.CPU, MemoryMB: r.MemoryMB - other.MemoryMB}
+}
+
+func encodeNomadNodeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrNomadClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrNomadNodeNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "node", Slug: api.RequestErrNotFound}}})
+	case ErrNomadNodePoolNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrNotFound}}})
+	case ErrNomadNodePoolAlreadyExists:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+	case ErrNomadNodePoolInUse:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrConflict}}})
+	case ErrNomadNodeDraining:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/eligible", Slug: api.RequestErrConflict}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+func (a API) handleListNomadNodePools(w http.ResponseWriter, r *http.Request) {
+	pools, err := a.Storer.ListNomadNodePools(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	if pools == nil {
+		pools = []NomadNodePool{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodePools: pools})
+}
+
+func (a API) handlePostNomadNodePool(w http.ResponseWriter, r *http.Request) {
+	var pool NomadNodePool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	pool.FillDefaults()
+	if errs := pool.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	pool.ClusterID = trout.RequestVars(r).Get("id")
+	pool, err = a.Storer.CreateNomadNodePool(pool)
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{NomadNodePools: []NomadNodePool{pool}})
+}
+
+func (a API) handleGetNomadNodePool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.GetNomadNodePool(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("pool"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodePools: []NomadNodePool{pool}})
+}
+
+func (a API) handlePutNomadNodePool(w http.ResponseWriter, r *http.Request) {
+	var pool NomadNodePool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if pool.Name != "" && pool.Name != trout.RequestVars(r).Get("pool") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+		return
+	}
+	pool.Name = trout.RequestVars(r).Get("pool")
+	pool.FillDefaults()
+	if errs := pool.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	pool.ClusterID = trout.RequestVars(r).Get("id")
+	pool, err = a.Storer.UpdateNomadNodePool(pool)
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodePools: []NomadNodePool{pool}})
+}
+
+// handleDeleteNomadNodePool deletes a node pool, as long as no nodes are in
+// it and no jobs that aren't dead use it.
+func (a API) handleDeleteNomadNodePool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.DeleteNomadNodePool(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("pool"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodePools: []NomadNodePool{pool}})
+}
+
+func (a API) handleListNomadNodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := a.Storer.ListNomadNodes(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	if nodes == nil {
+		nodes = []NomadNode{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodes: nodes})
+}
+
+// handlePostNomadNode registers a client node with a cluster. New nodes are
+// eligible and not draining.
+func (a API) handlePostNomadNode(w http.ResponseWriter, r *http.Request) {
+	var node NomadNode
+	err := api.Decode(r, &node)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	node.FillDefaults()
+	if errs := node.validationErrors(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	node.Organization = trout.RequestVars(r).Get("org")
+	node.Project = trout.RequestVars(r).Get("project")
+	node.ClusterID = trout.RequestVars(r).Get("id")
+	node, err = a.Storer.RegisterNomadNode(node)
+	if err != nil {
+		if err == ErrNomadNodePoolNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/nodePool", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{NomadNodes: []NomadNode{node}})
+}
+
+func (a API) handleGetNomadNode(w http.ResponseWriter, r *http.Request) {
+	node, err := a.Storer.GetNomadNode(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("node"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodes: []NomadNode{node}})
+}
+
+// handleDeleteNomadNode deregisters a node. Its allocations are lost, and
+// the scheduler replaces them on other nodes.
+func (a API) handleDeleteNomadNode(w http.ResponseWriter, r *http.Request) {
+	node, err := a.Storer.DeregisterNomadNode(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("node"))
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodes: []NomadNode{node}})
+}
+
+func (a API) handlePostNomadNodeDrain(w http.ResponseWriter, r *http.Request) {
+	var update NomadNodeDrainUpdate
+	err := api.Decode(r, &update)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	node, err := a.Storer.DrainNomadNode(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("node"), update.Enable)
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodes: []NomadNode{node}})
+}
+
+func (a API) handlePostNomadNodeEligibility(w http.ResponseWriter, r *http.Request) {
+	var update NomadNodeEligibilityUpdate
+	err := api.Decode(r, &update)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	node, err := a.Storer.SetNomadNodeEligibility(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("node"), update.Eligible)
+	if err != nil {
+		encodeNomadNodeError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NomadNodes: []NomadNode{node}})
+}
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+// projectPath is the prefix of the path of every resource that belongs to a
+// project.
+const projectPath = "/orgs/{org}/projects/{project}"
+
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Project struct {
+	ID           string `json:"id"`
+	Organization string `json:"organization"`
+	Name         string `json:"name"`
+}
+
+// Scope identifies the project a resource belongs to.
+type Scope struct {
+	Organization string
+	Project      string
+}
+
+// Contains reports whether other identifies the same project as s.
+func (s Scope) Contains(other Scope) bool {
+	return strings.EqualFold(s.Organization, other.Organization) && strings.EqualFold(s.Project, other.Project)
+}
+
+func requestScope(r *http.Request) Scope {
+	return Scope{
+		Organization: trout.RequestVars(r).Get("org"),
+		Project:      trout.RequestVars(r).Get("project"),
+	}
+}
+
+// inProject wraps a handler for a resource that belongs to a project,
+// rejecting the request if the organization or project doesn't exist.
+func (a API) inProject(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := requestScope(r)
+		_, err := a.Storer.GetOrganization(scope.Organization)
+		if err != nil {
+			if err == ErrOrganizationNotFound {
+				api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+				return
+			}
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		_, err = a.Storer.GetProject(scope.Organization, scope.Project)
+		if err != nil {
+			if err == ErrProjectNotFound {
+				api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrNotFound}}})
+				return
+			}
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (a API) handleListOrganizations(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	orgs, err := a.Storer.ListOrganizations()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Organizations: orgs})
+}
+
+func (a API) handleGetOrganization(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	org, err := a.Storer.GetOrganization(trout.RequestVars(r).Get("org"))
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Organizations: []Organization{org}})
+}
+
+func (a API) handlePostOrganization(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var org Organization
+	err := api.Decode(r, &org)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if org.ID == "" {
+		org.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	if strings.Contains(org.ID, "/") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if org.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.CreateOrganization(org)
+	if err != nil {
+		if err == ErrOrganizationAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{Organizations: []Organization{org}})
+}
+
+func (a API) handlePutOrganization(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var org Organization
+	err := api.Decode(r, &org)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if org.ID != "" && org.ID != trout.RequestVars(r).Get("org") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	org.ID = trout.RequestVars(r).Get("org")
+	if org.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.UpdateOrganization(org)
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Organizations: []Organization{org}})
+}
+
+func (a API) handleDeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	org, err := a.Storer.GetOrganization(trout.RequestVars(r).Get("org"))
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.Storer.DeleteOrganization(org.ID)
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrOrganizationNotEmpty {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Organizations: []Organization{org}})
+}
+
+func (a API) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	org, err := a.Storer.GetOrganization(trout.RequestVars(r).Get("org"))
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	projects, err := a.Storer.ListProjects(org.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Projects: projects})
+}
+
+func (a API) handleGetProject(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	project, err := a.Storer.GetProject(trout.RequestVars(r).Get("org"), trout.RequestVars(r).Get("project"))
+	if err != nil {
+		if err == ErrProjectNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Projects: []Project{project}})
+}
+
+func (a API) handlePostProject(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var project Project
+	err := api.Decode(r, &project)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if project.Organization != "" && project.Organization != trout.RequestVars(r).Get("org") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/organization", Slug: api.RequestErrConflict}}})
+		return
+	}
+	project.Organization = trout.RequestVars(r).Get("org")
+	if project.ID == "" {
+		project.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	if strings.Contains(project.ID, "/") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if project.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.CreateProject(project)
+	if err != nil {
+		if err == ErrOrganizationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "org", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrProjectAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{Projects: []Project{project}})
+}
+
+func (a API) handlePutProject(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var project Project
+	err := api.Decode(r, &project)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if project.ID != "" && project.ID != trout.RequestVars(r).Get("project") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	if project.Organization != "" && project.Organization != trout.RequestVars(r).Get("org") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/organization", Slug: api.RequestErrConflict}}})
+		return
+	}
+	project.ID = trout.RequestVars(r).Get("project")
+	project.Organization = trout.RequestVars(r).Get("org")
+	if project.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.UpdateProject(project)
+	if err != nil {
+		if err == ErrProjectNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Projects: []Project{project}})
+}
+
+func (a API) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	project, err := a.Storer.GetProject(trout.RequestVars(r).Get("org"), trout.RequestVars(r).Get("project"))
+	if err != nil {
+		if err == ErrProjectNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.Storer.DeleteProject(project.Organization, project.ID)
+	if err != nil {
+		if err == ErrProjectNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrProjectNotEmpty {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "project", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Projects: []Project{project}})
+}
@@ -0,0 +1,656 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// SnapshotFormatVersion is the version of the snapshot archive format that
+// WriteSnapshot produces. ReadSnapshot refuses archives of any other version.
+// It needs bumping whenever a table is added to snapshotData, so an archive
+// from a binary that didn't have the table yet is refused instead of being
+// restored with the table silently empty.
+const SnapshotFormatVersion = 3
+
+var (
+	ErrSnapshotInvalidFormat      = errors.New("snapshot is not in a recognized format")
+	ErrSnapshotUnsupportedVersion = errors.New("snapshot format version is not supported")
+	ErrSnapshotChecksumMismatch   = errors.New("snapshot checksum does not match its contents")
+)
+
+// snapshotArchive is the envelope written, gzipped, to a snapshot. Checksum
+// is the hex-encoded SHA-256 of Data, exactly as it appears in the archive.
+type snapshotArchive struct {
+	FormatVersion int             `json:"formatVersion"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Checksum      string          `json:"checksum"`
+	Data          json.RawMessage `json:"data"`
+}
+
+type snapshotData struct {
+	Organizations          []Organization          `json:"organizations"`
+	Projects               []Project               `json:"projects"`
+	AccessPolicies         []AccessPolicy          `json:"accessPolicies"`
+	ConsulClusters         []ConsulCluster         `json:"consulClusters"`
+	VaultClusters          []VaultCluster          `json:"vaultClusters"`
+	VaultSecrets           []VaultSecret           `json:"vaultSecrets"`
+	VaultLeases            []VaultLease            `json:"vaultLeases"`
+	VaultSeals             []vaultSeal             `json:"vaultSeals"`
+	ConsulKVPairs          []ConsulKVPair          `json:"consulKVPairs"`
+	ConsulSessions         []ConsulSession         `json:"consulSessions"`
+	ConsulIndexes          []consulIndex           `json:"consulIndexes"`
+	ConsulCatalogServices  []ConsulCatalogService  `json:"consulCatalogServices"`
+	NomadJoins             []nomadJoin             `json:"nomadJoins"`
+	NomadNodePools         []NomadNodePool         `json:"nomadNodePools"`
+	NomadNodes             []NomadNode             `json:"nomadNodes"`
+	NomadJobs              []NomadJob              `json:"nomadJobs"`
+	NomadAllocations       []NomadAllocation       `json:"nomadAllocations"`
+	NomadClusters          []NomadCluster          `json:"nomadClusters"`
+	IPPools                []IPPool                `json:"ipPools"`
+	IPAddresses            []IPAddress             `json:"ipAddresses"`
+	TerraformWorkspaces    []TerraformWorkspace    `json:"terraformWorkspaces"`
+	TerraformRuns          []TerraformRun          `json:"terraformRuns"`
+	TerraformRunTriggers   []TerraformRunTrigger   `json:"terraformRunTriggers"`
+	TerraformStateVersions []TerraformStateVersion `json:"terraformStateVersions"`
+	TerraformVCSEvents     []TerraformVCSEvent     `json:"terraformVCSEvents"`
+	TerraformVariables     []TerraformVariable     `json:"terraformVariables"`
+	TerraformVariableSets  []TerraformVariableSet  `json:"terraformVariableSets"`
+	TerraformPolicySets    []TerraformPolicySet    `json:"terraformPolicySets"`
+	TerraformAgentPools    []TerraformAgentPool    `json:"terraformAgentPools"`
+	TerraformAgentTokens   []TerraformAgentToken   `json:"terraformAgentTokens"`
+	TerraformOAuthClients  []TerraformOAuthClient  `json:"terraformOAuthClients"`
+	TerraformOAuthTokens   []TerraformOAuthToken   `json:"terraformOAuthTokens"`
+	TerraformVersions      []TerraformVersion      `json:"terraformVersions"`
+	Versions               []Version               `json:"versions"`
+}
+
+// snapshotTables returns the JSON names of the tables in snapshotData.
+func snapshotTables() []string {
+	dataType := reflect.TypeOf(snapshotData{})
+	tables := make([]string, 0, dataType.NumField())
+	for pos := 0; pos < dataType.NumField(); pos++ {
+		tables = append(tables, dataType.Field(pos).Tag.Get("json"))
+	}
+	return tables
+}
+
+// WriteSnapshot writes every record in the Storer, including deleted records
+// and version history, to w as a snapshot archive.
+func (s *Storer) WriteSnapshot(w io.Writer) error {
+	txn := s.txn(false)
+	var data snapshotData
+	iter, err := txn.Get("organization", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.Organizations = append(data.Organizations, *record.(*Organization))
+	}
+	iter, err = txn.Get("project", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.Projects = append(data.Projects, *record.(*Project))
+	}
+	iter, err = txn.Get("accessPolicy", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.AccessPolicies = append(data.AccessPolicies, *record.(*AccessPolicy))
+	}
+	iter, err = txn.Get("consulCluster", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.ConsulClusters = append(data.ConsulClusters, *record.(*ConsulCluster))
+	}
+	iter, err = txn.Get("vaultCluster", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.VaultClusters = append(data.VaultClusters, *record.(*VaultCluster))
+	}
+	iter, err = txn.Get("vaultSecret", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.VaultSecrets = append(data.VaultSecrets, *record.(*VaultSecret))
+	}
+	iter, err = txn.Get("vaultLease", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.VaultLeases = append(data.VaultLeases, *record.(*VaultLease))
+	}
+	iter, err = txn.Get("vaultSeal", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.VaultSeals = append(data.VaultSeals, *record.(*vaultSeal))
+	}
+	iter, err = txn.Get("consulKVPair", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.ConsulKVPairs = append(data.ConsulKVPairs, *record.(*ConsulKVPair))
+	}
+	iter, err = txn.Get("consulSession", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.ConsulSessions = append(data.ConsulSessions, *record.(*ConsulSession))
+	}
+	iter, err = txn.Get("consulIndex", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.ConsulIndexes = append(data.ConsulIndexes, *record.(*consulIndex))
+	}
+	iter, err = txn.Get("consulCatalogService", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.ConsulCatalogServices = append(data.ConsulCatalogServices, *record.(*ConsulCatalogService))
+	}
+	iter, err = txn.Get("nomadJoin", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadJoins = append(data.NomadJoins, *record.(*nomadJoin))
+	}
+	iter, err = txn.Get("nomadNodePool", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadNodePools = append(data.NomadNodePools, *record.(*NomadNodePool))
+	}
+	iter, err = txn.Get("nomadNode", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadNodes = append(data.NomadNodes, *record.(*NomadNode))
+	}
+	iter, err = txn.Get("nomadJob", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadJobs = append(data.NomadJobs, *record.(*NomadJob))
+	}
+	iter, err = txn.Get("nomadAllocation", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadAllocations = append(data.NomadAllocations, *record.(*NomadAllocation))
+	}
+	iter, err = txn.Get("nomadCluster", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.NomadClusters = append(data.NomadClusters, *record.(*NomadCluster))
+	}
+	iter, err = txn.Get("ipPool", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.IPPools = append(data.IPPools, *record.(*IPPool))
+	}
+	iter, err = txn.Get("ipAddress", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.IPAddresses = append(data.IPAddresses, *record.(*IPAddress))
+	}
+	iter, err = txn.Get("terraformWorkspace", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformWorkspaces = append(data.TerraformWorkspaces, *record.(*TerraformWorkspace))
+	}
+	iter, err = txn.Get("terraformRun", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformRuns = append(data.TerraformRuns, *record.(*TerraformRun))
+	}
+	iter, err = txn.Get("terraformRunTrigger", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformRunTriggers = append(data.TerraformRunTriggers, *record.(*TerraformRunTrigger))
+	}
+	iter, err = txn.Get("terraformStateVersion", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformStateVersions = append(data.TerraformStateVersions, *record.(*TerraformStateVersion))
+	}
+	iter, err = txn.Get("terraformVCSEvent", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformVCSEvents = append(data.TerraformVCSEvents, *record.(*TerraformVCSEvent))
+	}
+	iter, err = txn.Get("terraformVersion", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformVersions = append(data.TerraformVersions, *record.(*TerraformVersion))
+	}
+	iter, err = txn.Get("terraformVariable", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformVariables = append(data.TerraformVariables, *record.(*TerraformVariable))
+	}
+	iter, err = txn.Get("terraformVariableSet", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformVariableSets = append(data.TerraformVariableSets, *record.(*TerraformVariableSet))
+	}
+	iter, err = txn.Get("terraformPolicySet", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformPolicySets = append(data.TerraformPolicySets, *record.(*TerraformPolicySet))
+	}
+	iter, err = txn.Get("terraformAgentPool", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformAgentPools = append(data.TerraformAgentPools, *record.(*TerraformAgentPool))
+	}
+	iter, err = txn.Get("terraformAgentToken", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformAgentTokens = append(data.TerraformAgentTokens, *record.(*TerraformAgentToken))
+	}
+	iter, err = txn.Get("terraformOAuthClient", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformOAuthClients = append(data.TerraformOAuthClients, *record.(*TerraformOAuthClient))
+	}
+	iter, err = txn.Get("terraformOAuthToken", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.TerraformOAuthTokens = append(data.TerraformOAuthTokens, *record.(*TerraformOAuthToken))
+	}
+	iter, err = txn.Get("version", "id")
+	if err != nil {
+		return err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		data.Versions = append(data.Versions, *record.(*Version))
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	gz := gzip.NewWriter(w)
+	err = json.NewEncoder(gz).Encode(snapshotArchive{
+		FormatVersion: SnapshotFormatVersion,
+		CreatedAt:     time.Now(),
+		Checksum:      hex.EncodeToString(sum[:]),
+		Data:          raw,
+	})
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadSnapshot verifies the snapshot archive read from r and loads it into a
+// new Storer. Nothing is loaded unless the whole archive is valid.
+func ReadSnapshot(r io.Reader) (*Storer, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrSnapshotInvalidFormat
+	}
+	var archive snapshotArchive
+	err = json.NewDecoder(gz).Decode(&archive)
+	if err != nil {
+		return nil, ErrSnapshotInvalidFormat
+	}
+	if archive.FormatVersion != SnapshotFormatVersion {
+		return nil, ErrSnapshotUnsupportedVersion
+	}
+	sum := sha256.Sum256(archive.Data)
+	if hex.EncodeToString(sum[:]) != archive.Checksum {
+		return nil, ErrSnapshotChecksumMismatch
+	}
+	// every table is written, even if it's empty, so a missing one means
+	// the archive wasn't written by this version of WriteSnapshot
+	var tables map[string]json.RawMessage
+	err = json.Unmarshal(archive.Data, &tables)
+	if err != nil {
+		return nil, ErrSnapshotInvalidFormat
+	}
+	for _, table := range snapshotTables() {
+		if _, ok := tables[table]; !ok {
+			return nil, ErrSnapshotInvalidFormat
+		}
+	}
+	var data snapshotData
+	err = json.Unmarshal(archive.Data, &data)
+	if err != nil {
+		return nil, ErrSnapshotInvalidFormat
+	}
+
+	storer, err := NewStorer()
+	if err != nil {
+		return nil, err
+	}
+	txn := storer.txn(true)
+	defer txn.Abort()
+	for pos := range data.Organizations {
+		err = txn.Insert("organization", &data.Organizations[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.Projects {
+		err = txn.Insert("project", &data.Projects[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.AccessPolicies {
+		err = txn.Insert("accessPolicy", &data.AccessPolicies[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.ConsulClusters {
+		err = txn.Insert("consulCluster", &data.ConsulClusters[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.VaultClusters {
+		err = txn.Insert("vaultCluster", &data.VaultClusters[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.VaultSecrets {
+		err = txn.Insert("vaultSecret", &data.VaultSecrets[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.VaultLeases {
+		err = txn.Insert("vaultLease", &data.VaultLeases[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.VaultSeals {
+		err = txn.Insert("vaultSeal", &data.VaultSeals[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.ConsulKVPairs {
+		err = txn.Insert("consulKVPair", &data.ConsulKVPairs[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.ConsulSessions {
+		err = txn.Insert("consulSession", &data.ConsulSessions[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.ConsulIndexes {
+		err = txn.Insert("consulIndex", &data.ConsulIndexes[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.ConsulCatalogServices {
+		err = txn.Insert("consulCatalogService", &data.ConsulCatalogServices[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadJoins {
+		err = txn.Insert("nomadJoin", &data.NomadJoins[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadNodePools {
+		err = txn.Insert("nomadNodePool", &data.NomadNodePools[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadNodes {
+		err = txn.Insert("nomadNode", &data.NomadNodes[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadJobs {
+		err = txn.Insert("nomadJob", &data.NomadJobs[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadAllocations {
+		err = txn.Insert("nomadAllocation", &data.NomadAllocations[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.NomadClusters {
+		err = txn.Insert("nomadCluster", &data.NomadClusters[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.IPPools {
+		err = txn.Insert("ipPool", &data.IPPools[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.IPAddresses {
+		err = txn.Insert("ipAddress", &data.IPAddresses[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformWorkspaces {
+		err = txn.Insert("terraformWorkspace", &data.TerraformWorkspaces[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformRuns {
+		err = txn.Insert("terraformRun", &data.TerraformRuns[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformRunTriggers {
+		err = txn.Insert("terraformRunTrigger", &data.TerraformRunTriggers[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformStateVersions {
+		err = txn.Insert("terraformStateVersion", &data.TerraformStateVersions[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformVCSEvents {
+		err = txn.Insert("terraformVCSEvent", &data.TerraformVCSEvents[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformVersions {
+		err = txn.Insert("terraformVersion", &data.TerraformVersions[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformVariableSets {
+		err = txn.Insert("terraformVariableSet", &data.TerraformVariableSets[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformPolicySets {
+		err = txn.Insert("terraformPolicySet", &data.TerraformPolicySets[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformVariables {
+		err = txn.Insert("terraformVariable", &data.TerraformVariables[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformAgentPools {
+		err = txn.Insert("terraformAgentPool", &data.TerraformAgentPools[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformAgentTokens {
+		err = txn.Insert("terraformAgentToken", &data.TerraformAgentTokens[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformOAuthClients {
+		err = txn.Insert("terraformOAuthClient", &data.TerraformOAuthClients[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.TerraformOAuthTokens {
+		err = txn.Insert("terraformOAuthToken", &data.TerraformOAuthTokens[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for pos := range data.Versions {
+		err = txn.Insert("version", &data.Versions[pos])
+		if err != nil {
+			return nil, err
+		}
+	}
+	txn.Commit()
+	return storer, nil
+}
+
+// Replace atomically swaps the contents of the Storer for the contents of
+// other. Writes that are in progress finish against the old contents before
+// the swap happens. other should not be used afterwards.
+func (s *Storer) Replace(other *Storer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	// holding a write transaction on the old database makes sure no
+	// other write is halfway through when we swap it out
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	s.db = other.db
+}
+
+func (a API) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var buf bytes.Buffer
+	err := a.Storer.WriteSnapshot(&buf)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="dadcorpd.snapshot"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (a API) handlePostSnapshotRestore(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	restored, err := ReadSnapshot(r.Body)
+	if err != nil {
+		if err == ErrSnapshotInvalidFormat {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+			return
+		}
+		if err == ErrSnapshotUnsupportedVersion {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/formatVersion", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrSnapshotChecksumMismatch {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/checksum", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	a.Storer.Replace(restored)
+	api.Encode(w, r, http.StatusOK, Response{})
+}
@@ -1,31 +1,165 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-uuid"
 )
 
 var (
-	ErrAccessPolicyNotFound            = errors.New("access policy not found")
-	ErrAccessPolicyAlreadyExists       = errors.New("access policy already exists")
-	ErrConsulClusterNotFound           = errors.New("consul cluster not found")
-	ErrConsulClusterAlreadyExists      = errors.New("consul cluster already exists")
-	ErrNomadClusterNotFound            = errors.New("nomad cluster not found")
-	ErrNomadClusterAlreadyExists       = errors.New("nomad cluster already exists")
-	ErrVaultClusterNotFound            = errors.New("vault cluster not found")
-	ErrVaultClusterAlreadyExists       = errors.New("vault cluster already exists")
-	ErrTerraformWorkspaceNotFound      = errors.New("terraform workspace not found")
-	ErrTerraformWorkspaceAlreadyExists = errors.New("terraform workspace already exists")
+	ErrOrganizationNotFound                 = errors.New("organization not found")
+	ErrOrganizationAlreadyExists            = errors.New("organization already exists")
+	ErrOrganizationNotEmpty                 = errors.New("organization still has projects")
+	ErrProjectNotFound                      = errors.New("project not found")
+	ErrProjectAlreadyExists                 = errors.New("project already exists")
+	ErrProjectNotEmpty                      = errors.New("project still has resources")
+	ErrAccessPolicyNotFound                 = errors.New("access policy not found")
+	ErrAccessPolicyAlreadyExists            = errors.New("access policy already exists")
+	ErrConsulClusterNotFound                = errors.New("consul cluster not found")
+	ErrConsulClusterAlreadyExists           = errors.New("consul cluster already exists")
+	ErrConsulClusterNameConflict            = errors.New("consul cluster name is already in use in the project")
+	ErrNomadClusterNotFound                 = errors.New("nomad cluster not found")
+	ErrNomadClusterAlreadyExists            = errors.New("nomad cluster already exists")
+	ErrNomadClusterNameConflict             = errors.New("nomad cluster name is already in use in the project")
+	ErrVaultClusterNotFound                 = errors.New("vault cluster not found")
+	ErrVaultClusterAlreadyExists            = errors.New("vault cluster already exists")
+	ErrVaultClusterNameConflict             = errors.New("vault cluster name is already in use in the project")
+	ErrVaultClusterAddressInvalid           = errors.New("vault cluster address must be a host:port address")
+	ErrVaultClusterAddressConflict          = errors.New("vault cluster address is already in use in the region")
+	ErrVaultClusterClusterAddressInvalid    = errors.New("vault cluster cluster address must be a host:port address")
+	ErrVaultClusterClusterAddressConflict   = errors.New("vault cluster cluster address is already in use in the region")
+	ErrVaultClusterAlreadyInitialized       = errors.New("vault cluster is already initialized")
+	ErrVaultClusterNotInitialized           = errors.New("vault cluster is not initialized")
+	ErrVaultClusterSealed                   = errors.New("vault cluster is sealed")
+	ErrVaultUnsealKeyInvalid                = errors.New("vault unseal key is not one of the cluster's unseal keys")
+	ErrVaultRootTokenInvalid                = errors.New("vault root token is not the cluster's root token")
+	ErrTerraformWorkspaceNotFound           = errors.New("terraform workspace not found")
+	ErrTerraformWorkspaceAlreadyExists      = errors.New("terraform workspace already exists")
+	ErrTerraformWorkspaceNameConflict       = errors.New("terraform workspace name is already in use in the project")
+	ErrVersionNotFound                      = errors.New("version not found")
+	ErrNotDeleted                           = errors.New("resource is not deleted")
+	ErrTerraformRunNotFound                 = errors.New("terraform run not found")
+	ErrTerraformRunAlreadyExists            = errors.New("terraform run already exists")
+	ErrTerraformRunInvalidTransition        = errors.New("terraform run can't make that transition from its current status")
+	ErrTerraformVariableNotFound            = errors.New("terraform variable not found")
+	ErrTerraformVariableKeyConflict         = errors.New("terraform variable key is already in use")
+	ErrTerraformVariableSetNotFound         = errors.New("terraform variable set not found")
+	ErrTerraformVariableSetAlreadyExists    = errors.New("terraform variable set already exists")
+	ErrTerraformVariableSetNameConflict     = errors.New("terraform variable set name is already in use in the project")
+	ErrTerraformAgentPoolNotFound           = errors.New("terraform agent pool not found")
+	ErrTerraformAgentPoolAlreadyExists      = errors.New("terraform agent pool already exists")
+	ErrTerraformAgentPoolNameConflict       = errors.New("terraform agent pool name is already in use in the project")
+	ErrTerraformAgentPoolInUse              = errors.New("terraform agent pool is still used by workspaces")
+	ErrTerraformAgentTokenNotFound          = errors.New("terraform agent token not found")
+	ErrTerraformOAuthClientNotFound         = errors.New("terraform OAuth client not found")
+	ErrTerraformOAuthClientAlreadyExists    = errors.New("terraform OAuth client already exists")
+	ErrTerraformOAuthClientNameConflict     = errors.New("terraform OAuth client name is already in use in the project")
+	ErrTerraformOAuthClientProviderInUse    = errors.New("terraform OAuth client service provider can't change while workspaces use it")
+	ErrTerraformOAuthTokenNotFound          = errors.New("terraform OAuth token not found")
+	ErrTerraformOAuthTokenInUse             = errors.New("terraform OAuth token is still used by workspaces")
+	ErrTerraformWorkspaceVCSIdentifier      = errors.New("terraform workspace VCS identifier isn't valid for its VCS provider")
+	ErrTerraformStateVersionNotFound        = errors.New("terraform state version not found")
+	ErrTerraformStateVersionAlreadyExists   = errors.New("terraform state version already exists")
+	ErrTerraformStateVersionSerialConflict  = errors.New("terraform state version serial must be greater than the current state's")
+	ErrTerraformStateVersionLineageConflict = errors.New("terraform state version lineage doesn't match the current state's")
+	ErrTerraformVCSEventNotFound            = errors.New("terraform VCS event not found")
+	ErrTerraformVCSEventAlreadyExists       = errors.New("terraform VCS event already exists")
+	ErrTerraformWorkspaceLocked             = errors.New("terraform workspace is locked by someone else")
+	ErrTerraformWorkspaceNotLocked          = errors.New("terraform workspace is not locked")
+	ErrTerraformVersionNotFound             = errors.New("terraform version not found in the catalog")
+	ErrTerraformVersionAlreadyExists        = errors.New("terraform version already exists in the catalog")
+	ErrTerraformVersionInUse                = errors.New("terraform version is still pinned by workspaces")
+	ErrTerraformVersionNotAllowed           = errors.New("terraform version isn't allowed by the catalog")
+	ErrTerraformPolicySetNotFound           = errors.New("terraform policy set not found")
+	ErrTerraformPolicySetAlreadyExists      = errors.New("terraform policy set already exists")
+	ErrTerraformPolicySetNameConflict       = errors.New("terraform policy set name is already in use in the project")
+	ErrTerraformRunTriggerNotFound          = errors.New("terraform run trigger not found")
+	ErrTerraformRunTriggerAlreadyExists     = errors.New("terraform run trigger already exists")
+	ErrTerraformRunTriggerSourceNotFound    = errors.New("terraform run trigger source workspace not found")
+	ErrTerraformRunTriggerCycle             = errors.New("terraform run trigger would create a cycle")
+	ErrVaultSecretNotFound                  = errors.New("vault secret not found")
+	ErrVaultSecretVersionNotFound           = errors.New("vault secret version not found")
+	ErrVaultSecretCASMismatch               = errors.New("vault secret check-and-set version doesn't match the current version")
+	ErrVaultLeaseNotFound                   = errors.New("vault lease not found")
+	ErrConsulKVPairNotFound                 = errors.New("consul key not found")
+	ErrConsulKVCASMismatch                  = errors.New("consul key check-and-set index doesn't match the key's modify index")
+	ErrConsulKVLockHeld                     = errors.New("consul key is locked by another session")
+	ErrConsulKVLockNotHeld                  = errors.New("consul key is not locked by the session")
+	ErrConsulSessionNotFound                = errors.New("consul session not found")
+	ErrConsulCatalogServiceNotFound         = errors.New("consul service not found in the catalog")
+	ErrConsulHealthCheckNotFound            = errors.New("consul health check not found")
+	ErrNomadJobNotFound                     = errors.New("nomad job not found")
+	ErrNomadJobDatacenterMismatch           = errors.New("nomad job datacenters don't include the cluster's datacenter")
+	ErrNomadNodePoolNotFound                = errors.New("nomad node pool not found")
+	ErrNomadNodePoolAlreadyExists           = errors.New("nomad node pool already exists")
+	ErrNomadNodePoolInUse                   = errors.New("nomad node pool still has nodes or jobs in it")
+	ErrNomadNodeNotFound                    = errors.New("nomad node not found")
+	ErrNomadNodeDraining                    = errors.New("nomad node is draining")
+	ErrIPPoolNotFound                       = errors.New("IP pool not found")
+	ErrIPPoolAlreadyExists                  = errors.New("IP pool already exists")
+	ErrIPPoolNameConflict                   = errors.New("IP pool name is already in use in the project")
+	ErrIPPoolOverlap                        = errors.New("IP pool overlaps another pool in the region")
+	ErrIPPoolInUse                          = errors.New("IP pool still has addresses allocated from it")
+	ErrIPPoolExhausted                      = errors.New("IP pool has no free addresses")
+	ErrIPAddressNotFound                    = errors.New("IP address not found")
+	ErrIPAddressAlreadyExists               = errors.New("IP address already exists")
+	ErrIPAddressPoolInvalid                 = errors.New("IP address pool isn't a pool in the address's region")
+	ErrIPAddressOutOfRange                  = errors.New("IP address isn't a usable address in any of the region's pools")
+	ErrIPAddressInUse                       = errors.New("IP address is already allocated")
+	ErrIPAddressAttached                    = errors.New("IP address is already attached to another cluster")
+	ErrIPAttachmentClusterNotFound          = errors.New("IP attachment cluster not found")
+	ErrIPAttachmentRegionMismatch           = errors.New("IP attachment cluster is in a different region")
 )
 
 type Storer struct {
+	// mu guards db, so a restored snapshot can be swapped in while
+	// requests are being served.
+	mu sync.RWMutex
 	db *memdb.MemDB
 }
 
 func NewStorer() (*Storer, error) {
 	db, err := memdb.NewMemDB(&memdb.DBSchema{
 		Tables: map[string]*memdb.TableSchema{
+			"organization": {
+				Name: "organization",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+				},
+			},
+			"project": {
+				Name: "project",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+							},
+						},
+					},
+					"organization": {
+						Name:    "organization",
+						Indexer: &memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+					},
+				},
+			},
 			"accessPolicy": {
 				Name: "accessPolicy",
 				Indexes: map[string]*memdb.IndexSchema{
@@ -34,6 +168,15 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
 				},
 			},
 			"nomadCluster": {
@@ -44,6 +187,15 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
 				},
 			},
 			"vaultCluster": {
@@ -54,6 +206,219 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"vaultSecret": {
+				Name: "vaultSecret",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Path"},
+							},
+						},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"vaultSeal": {
+				Name: "vaultSeal",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"vaultLease": {
+				Name: "vaultLease",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"consulKVPair": {
+				Name: "consulKVPair",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Key"},
+							},
+						},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+					"session": {
+						Name:         "session",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "Session", Lowercase: true},
+					},
+				},
+			},
+			"consulSession": {
+				Name: "consulSession",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"consulCatalogService": {
+				Name: "consulCatalogService",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "ID"},
+							},
+						},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+					"name": {
+						Name: "name",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Name", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"nomadJob": {
+				Name: "nomadJob",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "ID"},
+							},
+						},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"nomadJoin": {
+				Name: "nomadJoin",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"nomadNodePool": {
+				Name: "nomadNodePool",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Name"},
+							},
+						},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"nomadNode": {
+				Name: "nomadNode",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+				},
+			},
+			"nomadAllocation": {
+				Name: "nomadAllocation",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"cluster": {
+						Name:    "cluster",
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
+					"job": {
+						Name: "job",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "JobID"},
+							},
+						},
+					},
+				},
+			},
+			"consulIndex": {
+				Name: "consulIndex",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ClusterID", Lowercase: true},
+					},
 				},
 			},
 			"consulCluster": {
@@ -64,6 +429,15 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
 				},
 			},
 			"terraformWorkspace": {
@@ -74,6 +448,281 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"terraformRun": {
+				Name: "terraformRun",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"workspace": {
+						Name:    "workspace",
+						Indexer: &memdb.StringFieldIndex{Field: "WorkspaceID", Lowercase: true},
+					},
+				},
+			},
+			"terraformRunTrigger": {
+				Name: "terraformRunTrigger",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"workspace": {
+						Name:    "workspace",
+						Indexer: &memdb.StringFieldIndex{Field: "WorkspaceID", Lowercase: true},
+					},
+					"source": {
+						Name:    "source",
+						Indexer: &memdb.StringFieldIndex{Field: "SourceWorkspaceID", Lowercase: true},
+					},
+				},
+			},
+			"terraformStateVersion": {
+				Name: "terraformStateVersion",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"workspace": {
+						Name:    "workspace",
+						Indexer: &memdb.StringFieldIndex{Field: "WorkspaceID", Lowercase: true},
+					},
+				},
+			},
+			"terraformVCSEvent": {
+				Name: "terraformVCSEvent",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"terraformVersion": {
+				Name: "terraformVersion",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Version", Lowercase: true},
+					},
+				},
+			},
+			"terraformVariable": {
+				Name: "terraformVariable",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"workspace": {
+						Name:         "workspace",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "WorkspaceID", Lowercase: true},
+					},
+					"variableSet": {
+						Name:         "variableSet",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "VariableSetID", Lowercase: true},
+					},
+				},
+			},
+			"terraformVariableSet": {
+				Name: "terraformVariableSet",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"terraformPolicySet": {
+				Name: "terraformPolicySet",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"terraformAgentPool": {
+				Name: "terraformAgentPool",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"ipPool": {
+				Name: "ipPool",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"ipAddress": {
+				Name: "ipAddress",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+					"pool": {
+						Name:    "pool",
+						Indexer: &memdb.StringFieldIndex{Field: "PoolID", Lowercase: true},
+					},
+				},
+			},
+			"terraformAgentToken": {
+				Name: "terraformAgentToken",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"agentPool": {
+						Name:    "agentPool",
+						Indexer: &memdb.StringFieldIndex{Field: "AgentPoolID", Lowercase: true},
+					},
+				},
+			},
+			"terraformOAuthClient": {
+				Name: "terraformOAuthClient",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"project": {
+						Name: "project",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Organization", Lowercase: true},
+								&memdb.StringFieldIndex{Field: "Project", Lowercase: true},
+							},
+						},
+					},
+				},
+			},
+			"terraformOAuthToken": {
+				Name: "terraformOAuthToken",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"oauthClient": {
+						Name:    "oauthClient",
+						Indexer: &memdb.StringFieldIndex{Field: "OAuthClientID", Lowercase: true},
+					},
+				},
+			},
+			"version": {
+				Name: "version",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ResourceType"},
+								&memdb.StringFieldIndex{Field: "ResourceID", Lowercase: true},
+								&memdb.IntFieldIndex{Field: "Version"},
+							},
+						},
+					},
+					"resource": {
+						Name: "resource",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ResourceType"},
+								&memdb.StringFieldIndex{Field: "ResourceID", Lowercase: true},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -86,161 +735,5514 @@ func NewStorer() (*Storer, error) {
 	}, nil
 }
 
-func (s *Storer) GetAccessPolicy(id string) (AccessPolicy, error) {
-	txn := s.db.Txn(false)
-	ap, err := txn.First("accessPolicy", "id", id)
+func (s *Storer) txn(write bool) *memdb.Txn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Txn(write)
+}
+
+func (s *Storer) GetOrganization(id string) (Organization, error) {
+	txn := s.txn(false)
+	org, err := txn.First("organization", "id", id)
+	if err != nil {
+		return Organization{}, err
+	}
+	if org == nil {
+		return Organization{}, ErrOrganizationNotFound
+	}
+	return *org.(*Organization), nil
+}
+
+func (s *Storer) CreateOrganization(org Organization) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("organization", "id", org.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrOrganizationAlreadyExists
+	}
+	err = txn.Insert("organization", &org)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateOrganization(org Organization) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("organization", "id", org.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrOrganizationNotFound
+	}
+	err = txn.Insert("organization", &org)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteOrganization(id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("organization", "id", id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrOrganizationNotFound
+	}
+	project, err := txn.First("project", "organization", id)
+	if err != nil {
+		return err
+	}
+	if project != nil {
+		return ErrOrganizationNotEmpty
+	}
+	err = txn.Delete("organization", existing)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListOrganizations() ([]Organization, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("organization", "id")
+	if err != nil {
+		return nil, err
+	}
+	var results []Organization
+	for org := iter.Next(); org != nil; org = iter.Next() {
+		results = append(results, *org.(*Organization))
+	}
+	return results, nil
+}
+
+func (s *Storer) GetProject(org, id string) (Project, error) {
+	txn := s.txn(false)
+	project, err := txn.First("project", "id", org, id)
+	if err != nil {
+		return Project{}, err
+	}
+	if project == nil {
+		return Project{}, ErrProjectNotFound
+	}
+	return *project.(*Project), nil
+}
+
+func (s *Storer) CreateProject(project Project) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	org, err := txn.First("organization", "id", project.Organization)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return ErrOrganizationNotFound
+	}
+	exists, err := txn.First("project", "id", project.Organization, project.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrProjectAlreadyExists
+	}
+	err = txn.Insert("project", &project)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateProject(project Project) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("project", "id", project.Organization, project.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrProjectNotFound
+	}
+	err = txn.Insert("project", &project)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteProject removes a project. Projects that still have resources in
+// them, including deleted resources that haven't been purged yet, can't be
+// removed.
+func (s *Storer) DeleteProject(org, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("project", "id", org, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrProjectNotFound
+	}
+	for _, table := range []string{"accessPolicy", "consulCluster", "vaultCluster", "nomadCluster", "terraformWorkspace", "terraformVariableSet", "terraformPolicySet", "terraformAgentPool", "terraformOAuthClient", "ipPool"} {
+		resource, err := txn.First(table, "project", org, id)
+		if err != nil {
+			return err
+		}
+		if resource != nil {
+			return ErrProjectNotEmpty
+		}
+	}
+	// VCS events are only a log, so they shouldn't keep the project around
+	_, err = txn.DeleteAll("terraformVCSEvent", "project", org, id)
+	if err != nil {
+		return err
+	}
+	err = txn.Delete("project", existing)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListProjects(org string) ([]Project, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("project", "organization", org)
+	if err != nil {
+		return nil, err
+	}
+	var results []Project
+	for project := iter.Next(); project != nil; project = iter.Next() {
+		results = append(results, *project.(*Project))
+	}
+	return results, nil
+}
+
+func (s *Storer) GetAccessPolicy(scope Scope, id string) (AccessPolicy, error) {
+	txn := s.txn(false)
+	ap, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	if !recordVisible(scope, ap) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	return *ap.(*AccessPolicy), nil
+}
+
+func (s *Storer) CreateAccessPolicy(ap AccessPolicy) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("accessPolicy", "id", ap.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrAccessPolicyAlreadyExists
+	}
+	err = txn.Insert("accessPolicy", &ap)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "accessPolicy", ap.ID, ap)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateAccessPolicy(ap AccessPolicy) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("accessPolicy", "id", ap.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&ap), existing) {
+		return ErrAccessPolicyNotFound
+	}
+	err = txn.Insert("accessPolicy", &ap)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "accessPolicy", ap.ID, ap)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteAccessPolicy(scope Scope, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, existing) {
+		return ErrAccessPolicyNotFound
+	}
+	deleted := *existing.(*AccessPolicy)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	err = txn.Insert("accessPolicy", &deleted)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListAccessPolicies(scope Scope, includeDeleted bool) ([]AccessPolicy, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("accessPolicy", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []AccessPolicy
+	for ap := iter.Next(); ap != nil; ap = iter.Next() {
+		if !includeDeleted && ap.(*AccessPolicy).DeletedAt != nil {
+			continue
+		}
+		results = append(results, *ap.(*AccessPolicy))
+	}
+	return results, nil
+}
+
+// GetDeletedAccessPolicy returns the deleted access policy identified by id,
+// so it can be checked before it's undeleted.
+func (s *Storer) GetDeletedAccessPolicy(scope Scope, id string) (AccessPolicy, error) {
+	txn := s.txn(false)
+	existing, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	if existing.(*AccessPolicy).DeletedAt == nil {
+		return AccessPolicy{}, ErrNotDeleted
+	}
+	return *existing.(*AccessPolicy), nil
+}
+
+func (s *Storer) UndeleteAccessPolicy(scope Scope, id string) (AccessPolicy, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	if existing.(*AccessPolicy).DeletedAt == nil {
+		return AccessPolicy{}, ErrNotDeleted
+	}
+	ap := *existing.(*AccessPolicy)
+	ap.DeletedAt = nil
+	err = txn.Insert("accessPolicy", &ap)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) GetConsulCluster(scope Scope, id string) (ConsulCluster, error) {
+	txn := s.txn(false)
+	cluster, err := txn.First("consulCluster", "id", id)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	return *cluster.(*ConsulCluster), nil
+}
+
+func (s *Storer) CreateConsulCluster(cluster ConsulCluster) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("consulCluster", "id", cluster.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrConsulClusterAlreadyExists
+	}
+	taken, err := nameTaken(txn, "consulCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrConsulClusterNameConflict
+	}
+	err = txn.Insert("consulCluster", &cluster)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "consulCluster", cluster.ID, cluster)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateConsulCluster(cluster ConsulCluster) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("consulCluster", "id", cluster.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&cluster), existing) {
+		return ErrConsulClusterNotFound
+	}
+	taken, err := nameTaken(txn, "consulCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrConsulClusterNameConflict
+	}
+	err = txn.Insert("consulCluster", &cluster)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "consulCluster", cluster.ID, cluster)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteConsulCluster(scope Scope, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("consulCluster", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, existing) {
+		return ErrConsulClusterNotFound
+	}
+	deleted := *existing.(*ConsulCluster)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	err = txn.Insert("consulCluster", &deleted)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListConsulClusters(scope Scope, includeDeleted bool) ([]ConsulCluster, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("consulCluster", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []ConsulCluster
+	for cluster := iter.Next(); cluster != nil; cluster = iter.Next() {
+		if !includeDeleted && cluster.(*ConsulCluster).DeletedAt != nil {
+			continue
+		}
+		results = append(results, *cluster.(*ConsulCluster))
+	}
+	return results, nil
+}
+
+func (s *Storer) UndeleteConsulCluster(scope Scope, id string) (ConsulCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("consulCluster", "id", id)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	if existing.(*ConsulCluster).DeletedAt == nil {
+		return ConsulCluster{}, ErrNotDeleted
+	}
+	cluster := *existing.(*ConsulCluster)
+	cluster.DeletedAt = nil
+	taken, err := nameTaken(txn, "consulCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if taken {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	err = txn.Insert("consulCluster", &cluster)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) GetVaultCluster(scope Scope, id string) (VaultCluster, error) {
+	txn := s.txn(false)
+	cluster, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	return *cluster.(*VaultCluster), nil
+}
+
+// CreateVaultCluster stores a new Vault cluster, assigning free ports to any
+// of its listener addresses that use port 0.
+func (s *Storer) CreateVaultCluster(cluster VaultCluster) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("vaultCluster", "id", cluster.ID)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if exists != nil {
+		return VaultCluster{}, ErrVaultClusterAlreadyExists
+	}
+	taken, err := nameTaken(txn, "vaultCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if taken {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	err = assignVaultListener(txn, &cluster, VaultClusterTCPListener{})
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = recordVersion(txn, "vaultCluster", cluster.ID, cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+// UpdateVaultCluster replaces the Vault cluster identified by cluster.ID,
+// keeping its seal status. Listener addresses that use port 0 keep the port
+// they were assigned before if it's still free, or are assigned a new one.
+func (s *Storer) UpdateVaultCluster(cluster VaultCluster) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("vaultCluster", "id", cluster.ID)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(recordScope(&cluster), existing) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	cluster.SealStatus = existing.(*VaultCluster).SealStatus
+	taken, err := nameTaken(txn, "vaultCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if taken {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	err = assignVaultListener(txn, &cluster, existing.(*VaultCluster).TCPListener)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = recordVersion(txn, "vaultCluster", cluster.ID, cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) DeleteVaultCluster(scope Scope, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, existing) {
+		return ErrVaultClusterNotFound
+	}
+	deleted := *existing.(*VaultCluster)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	err = txn.Insert("vaultCluster", &deleted)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListVaultClusters(scope Scope, includeDeleted bool) ([]VaultCluster, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("vaultCluster", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []VaultCluster
+	for cluster := iter.Next(); cluster != nil; cluster = iter.Next() {
+		if !includeDeleted && cluster.(*VaultCluster).DeletedAt != nil {
+			continue
+		}
+		results = append(results, *cluster.(*VaultCluster))
+	}
+	return results, nil
+}
+
+func (s *Storer) UndeleteVaultCluster(scope Scope, id string) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	if existing.(*VaultCluster).DeletedAt == nil {
+		return VaultCluster{}, ErrNotDeleted
+	}
+	cluster := *existing.(*VaultCluster)
+	cluster.DeletedAt = nil
+	taken, err := nameTaken(txn, "vaultCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if taken {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	err = assignVaultListener(txn, &cluster, cluster.TCPListener)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) GetNomadCluster(scope Scope, id string) (NomadCluster, error) {
+	txn := s.txn(false)
+	cluster, err := txn.First("nomadCluster", "id", id)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	return *cluster.(*NomadCluster), nil
+}
+
+func (s *Storer) CreateNomadCluster(cluster NomadCluster) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("nomadCluster", "id", cluster.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrNomadClusterAlreadyExists
+	}
+	taken, err := nameTaken(txn, "nomadCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrNomadClusterNameConflict
+	}
+	err = txn.Insert("nomadCluster", &cluster)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "nomadCluster", cluster.ID, cluster)
+	if err != nil {
+		return err
+	}
+	err = startNomadJoin(txn, cluster, time.Now())
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateNomadCluster(cluster NomadCluster) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nomadCluster", "id", cluster.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&cluster), existing) {
+		return ErrNomadClusterNotFound
+	}
+	taken, err := nameTaken(txn, "nomadCluster", recordScope(&cluster), cluster.ID, cluster.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrNomadClusterNameConflict
+	}
+	err = txn.Insert("nomadCluster", &cluster)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "nomadCluster", cluster.ID, cluster)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(existing.(*NomadCluster).Server.ServerJoin, cluster.Server.ServerJoin) {
+		err = startNomadJoin(txn, cluster, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteNomadCluster(scope Scope, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nomadCluster", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, existing) {
+		return ErrNomadClusterNotFound
+	}
+	deleted := *existing.(*NomadCluster)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	err = txn.Insert("nomadCluster", &deleted)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListNomadClusters(scope Scope, includeDeleted bool) ([]NomadCluster, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("nomadCluster", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []NomadCluster
+	for cluster := iter.Next(); cluster != nil; cluster = iter.Next() {
+		if !includeDeleted && cluster.(*NomadCluster).DeletedAt != nil {
+			continue
+		}
+		results = append(results, *cluster.(*NomadCluster))
+	}
+	return results, nil
+}
+
+func (s *Storer) UndeleteNomadCluster(scope Scope, id string) (NomadCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nomadCluster", "id", id)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	if existing.(*NomadCluster).DeletedAt == nil {
+		return NomadCluster{}, ErrNotDeleted
+	}
+	cluster := *existing.(*NomadCluster)
+	cluster.DeletedAt = nil
+	taken, err := nameTaken(txn, "nomadCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if taken {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	err = txn.Insert("nomadCluster", &cluster)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	err = startNomadJoin(txn, cluster, time.Now())
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) GetTerraformWorkspace(scope Scope, id string) (TerraformWorkspace, error) {
+	txn := s.txn(false)
+	cluster, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	return *cluster.(*TerraformWorkspace), nil
+}
+
+func (s *Storer) CreateTerraformWorkspace(workspace TerraformWorkspace) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformWorkspace", "id", workspace.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformWorkspaceAlreadyExists
+	}
+	taken, err := nameTaken(txn, "terraformWorkspace", recordScope(&workspace), workspace.ID, workspace.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformWorkspaceNameConflict
+	}
+	err = agentPoolVisible(txn, recordScope(&workspace), workspace.AgentPoolID)
+	if err != nil {
+		return err
+	}
+	err = oauthTokenUsable(txn, recordScope(&workspace), workspace.VCSRepo)
+	if err != nil {
+		return err
+	}
+	err = terraformVersionAllowed(txn, workspace.TerraformVersion, "")
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return err
+	}
+	err = recordVersion(txn, "terraformWorkspace", workspace.ID, workspace)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UpdateTerraformWorkspace replaces the workspace's settings. If the
+// workspace is locked, only the lock's holder can update it; the lock itself
+// is left as it is.
+func (s *Storer) UpdateTerraformWorkspace(workspace TerraformWorkspace, holder string) (TerraformWorkspace, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", workspace.ID)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if !recordVisible(recordScope(&workspace), existing) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if existing.(*TerraformWorkspace).lockedAgainst(holder) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	workspace.setLock(existing.(*TerraformWorkspace).Lock)
+	taken, err := nameTaken(txn, "terraformWorkspace", recordScope(&workspace), workspace.ID, workspace.Name)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if taken {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	err = agentPoolVisible(txn, recordScope(&workspace), workspace.AgentPoolID)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = oauthTokenUsable(txn, recordScope(&workspace), workspace.VCSRepo)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = terraformVersionAllowed(txn, workspace.TerraformVersion, existing.(*TerraformWorkspace).TerraformVersion)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = recordVersion(txn, "terraformWorkspace", workspace.ID, workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+func (s *Storer) DeleteTerraformWorkspace(scope Scope, id string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, existing) {
+		return ErrTerraformWorkspaceNotFound
+	}
+	deleted := *existing.(*TerraformWorkspace)
+	now := time.Now()
+	deleted.DeletedAt = &now
+	err = txn.Insert("terraformWorkspace", &deleted)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) ListTerraformWorkspaces(scope Scope, includeDeleted bool) ([]TerraformWorkspace, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformWorkspace", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformWorkspace
+	for workspace := iter.Next(); workspace != nil; workspace = iter.Next() {
+		if !includeDeleted && workspace.(*TerraformWorkspace).DeletedAt != nil {
+			continue
+		}
+		results = append(results, *workspace.(*TerraformWorkspace))
+	}
+	return results, nil
+}
+
+func (s *Storer) UndeleteTerraformWorkspace(scope Scope, id string) (TerraformWorkspace, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if existing == nil || !scope.Contains(recordScope(existing)) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if existing.(*TerraformWorkspace).DeletedAt == nil {
+		return TerraformWorkspace{}, ErrNotDeleted
+	}
+	workspace := *existing.(*TerraformWorkspace)
+	workspace.DeletedAt = nil
+	taken, err := nameTaken(txn, "terraformWorkspace", scope, workspace.ID, workspace.Name)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if taken {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	err = agentPoolVisible(txn, scope, workspace.AgentPoolID)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = oauthTokenUsable(txn, scope, workspace.VCSRepo)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+// unversionedFields are the JSON fields of each resource type that aren't
+// kept in its version history. They're operational state rather than
+// configuration, so restoring a version mustn't change them, and diffs
+// between versions shouldn't show them changing.
+var unversionedFields = map[string][]string{
+	"vaultCluster": {"sealStatus"},
+}
+
+func recordVersion(txn *memdb.Txn, resourceType, id string, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if fields := unversionedFields[resourceType]; len(fields) > 0 {
+		var kept map[string]json.RawMessage
+		err = json.Unmarshal(data, &kept)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			delete(kept, field)
+		}
+		data, err = json.Marshal(kept)
+		if err != nil {
+			return err
+		}
+	}
+	iter, err := txn.Get("version", "resource", resourceType, id)
+	if err != nil {
+		return err
+	}
+	var latest int
+	for v := iter.Next(); v != nil; v = iter.Next() {
+		if v.(*Version).Version > latest {
+			latest = v.(*Version).Version
+		}
+	}
+	return txn.Insert("version", &Version{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Version:      latest + 1,
+		CreatedAt:    time.Now(),
+		Data:         data,
+	})
+}
+
+func versionData(txn *memdb.Txn, resourceType, id string, version int, target interface{}) error {
+	v, err := txn.First("version", "id", resourceType, id, version)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return ErrVersionNotFound
+	}
+	return json.Unmarshal(v.(*Version).Data, target)
+}
+
+func (s *Storer) ListVersions(resourceType, id string) ([]Version, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("version", "resource", resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for v := iter.Next(); v != nil; v = iter.Next() {
+		versions = append(versions, *v.(*Version))
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
+
+// GetAccessPolicyVersion returns the access policy identified by id as it
+// was at version, so it can be checked before it's restored.
+func (s *Storer) GetAccessPolicyVersion(scope Scope, id string, version int) (AccessPolicy, error) {
+	txn := s.txn(false)
+	existing, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	var ap AccessPolicy
+	err = versionData(txn, "accessPolicy", id, version, &ap)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	ap.Organization = existing.(*AccessPolicy).Organization
+	ap.Project = existing.(*AccessPolicy).Project
+	return ap, nil
+}
+
+func (s *Storer) RestoreAccessPolicyVersion(scope Scope, id string, version int) (AccessPolicy, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("accessPolicy", "id", id)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	var ap AccessPolicy
+	err = versionData(txn, "accessPolicy", id, version, &ap)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	ap.Organization = existing.(*AccessPolicy).Organization
+	ap.Project = existing.(*AccessPolicy).Project
+	err = txn.Insert("accessPolicy", &ap)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	err = recordVersion(txn, "accessPolicy", ap.ID, ap)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) RestoreConsulClusterVersion(scope Scope, id string, version int) (ConsulCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("consulCluster", "id", id)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	var cluster ConsulCluster
+	err = versionData(txn, "consulCluster", id, version, &cluster)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	cluster.Organization = existing.(*ConsulCluster).Organization
+	cluster.Project = existing.(*ConsulCluster).Project
+	taken, err := nameTaken(txn, "consulCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if taken {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	err = txn.Insert("consulCluster", &cluster)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	err = recordVersion(txn, "consulCluster", cluster.ID, cluster)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+// GetVaultClusterVersion returns the Vault cluster identified by id as it
+// was at version, so it can be validated before it's restored.
+func (s *Storer) GetVaultClusterVersion(scope Scope, id string, version int) (VaultCluster, error) {
+	txn := s.txn(false)
+	existing, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	var cluster VaultCluster
+	err = versionData(txn, "vaultCluster", id, version, &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	cluster.Organization = existing.(*VaultCluster).Organization
+	cluster.Project = existing.(*VaultCluster).Project
+	cluster.SealStatus = existing.(*VaultCluster).SealStatus
+	cluster.FillDefaults()
+	return cluster, nil
+}
+
+// RestoreVaultClusterVersion replaces the Vault cluster identified by id with
+// the cluster as it was at version, keeping its seal status.
+func (s *Storer) RestoreVaultClusterVersion(scope Scope, id string, version int) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	var cluster VaultCluster
+	err = versionData(txn, "vaultCluster", id, version, &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	cluster.Organization = existing.(*VaultCluster).Organization
+	cluster.Project = existing.(*VaultCluster).Project
+	cluster.SealStatus = existing.(*VaultCluster).SealStatus
+	cluster.FillDefaults()
+	taken, err := nameTaken(txn, "vaultCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if taken {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	err = assignVaultListener(txn, &cluster, existing.(*VaultCluster).TCPListener)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = recordVersion(txn, "vaultCluster", cluster.ID, cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) RestoreNomadClusterVersion(scope Scope, id string, version int) (NomadCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nomadCluster", "id", id)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	var cluster NomadCluster
+	err = versionData(txn, "nomadCluster", id, version, &cluster)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	cluster.Organization = existing.(*NomadCluster).Organization
+	cluster.Project = existing.(*NomadCluster).Project
+	taken, err := nameTaken(txn, "nomadCluster", scope, cluster.ID, cluster.Name)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if taken {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	err = txn.Insert("nomadCluster", &cluster)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	err = recordVersion(txn, "nomadCluster", cluster.ID, cluster)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	err = startNomadJoin(txn, cluster, time.Now())
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+func (s *Storer) RestoreTerraformWorkspaceVersion(scope Scope, id string, version int, holder string) (TerraformWorkspace, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if existing.(*TerraformWorkspace).lockedAgainst(holder) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	var workspace TerraformWorkspace
+	err = versionData(txn, "terraformWorkspace", id, version, &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	workspace.Organization = existing.(*TerraformWorkspace).Organization
+	workspace.Project = existing.(*TerraformWorkspace).Project
+	workspace.setLock(existing.(*TerraformWorkspace).Lock)
+	taken, err := nameTaken(txn, "terraformWorkspace", scope, workspace.ID, workspace.Name)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if taken {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	err = agentPoolVisible(txn, scope, workspace.AgentPoolID)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = oauthTokenUsable(txn, scope, workspace.VCSRepo)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = terraformVersionAllowed(txn, workspace.TerraformVersion, existing.(*TerraformWorkspace).TerraformVersion)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	err = recordVersion(txn, "terraformWorkspace", workspace.ID, workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+// softDeletedTables are the tables whose records are only marked as deleted
+// at first, so they can be undeleted until they're purged. They hold the
+// shared infrastructure a mistaken destroy would take down.
+//
+// Records in every other table are removed as soon as they're deleted. Most
+// of them belong to a record in one of these tables, like a workspace's
+// runs, variables and state versions or a cluster's secrets, leases, KV
+// pairs and jobs: they stay put while it's deleted, come back with it if
+// it's undeleted, and are removed with it by purgeDependents when it's
+// purged. The rest, like variable sets, policy sets, agent pools, OAuth
+// clients and IP pools and addresses, are removed for good, along with
+// anything that belongs to them, by their own Delete methods.
+var softDeletedTables = []string{"accessPolicy", "consulCluster", "vaultCluster", "nomadCluster", "terraformWorkspace"}
+
+// PurgeDeleted permanently removes every record in softDeletedTables that was
+// deleted before the passed time, along with its version history and the
+// records that belong to it. It returns the number of records removed, not
+// counting the ones that belonged to them.
+func (s *Storer) PurgeDeleted(before time.Time) (int, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	var purged int
+	for _, table := range softDeletedTables {
+		iter, err := txn.Get(table, "id")
+		if err != nil {
+			return 0, err
+		}
+		var expired []interface{}
+		for record := iter.Next(); record != nil; record = iter.Next() {
+			deletedAt := recordDeletedAt(record)
+			if deletedAt == nil || !deletedAt.Before(before) {
+				continue
+			}
+			expired = append(expired, record)
+		}
+		for _, record := range expired {
+			err = txn.Delete(table, record)
+			if err != nil {
+				return 0, err
+			}
+			_, err = txn.DeleteAll("version", "resource", table, recordID(record))
+			if err != nil {
+				return 0, err
+			}
+			err = purgeDependents(txn, table, recordID(record))
+			if err != nil {
+				return 0, err
+			}
+			purged++
+		}
+	}
+	txn.Commit()
+	return purged, nil
+}
+
+// purgeDependents removes the records that only make sense as part of the
+// record identified by table and id, which is being purged.
+func purgeDependents(txn *memdb.Txn, table, id string) error {
+	switch table {
+	case "consulCluster":
+		_, err := txn.DeleteAll("consulKVPair", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("consulSession", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("consulCatalogService", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("consulIndex", "id", id)
+		if err != nil {
+			return err
+		}
+		err = detachIPAddresses(txn, id)
+		if err != nil {
+			return err
+		}
+	case "nomadCluster":
+		_, err := txn.DeleteAll("nomadJob", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("nomadAllocation", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("nomadJoin", "id", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("nomadNode", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("nomadNodePool", "cluster", id)
+		if err != nil {
+			return err
+		}
+		err = detachIPAddresses(txn, id)
+		if err != nil {
+			return err
+		}
+	case "vaultCluster":
+		_, err := txn.DeleteAll("vaultSecret", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("vaultLease", "cluster", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("vaultSeal", "id", id)
+		if err != nil {
+			return err
+		}
+		err = detachIPAddresses(txn, id)
+		if err != nil {
+			return err
+		}
+	case "terraformWorkspace":
+		_, err := txn.DeleteAll("terraformRun", "workspace", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("terraformStateVersion", "workspace", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("terraformVariable", "workspace", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("terraformRunTrigger", "workspace", id)
+		if err != nil {
+			return err
+		}
+		_, err = txn.DeleteAll("terraformRunTrigger", "source", id)
+		if err != nil {
+			return err
+		}
+		err = detachVariableSets(txn, id)
+		if err != nil {
+			return err
+		}
+		err = detachPolicySets(txn, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordDeletedAt(record interface{}) *time.Time {
+	switch r := record.(type) {
+	case *AccessPolicy:
+		return r.DeletedAt
+	case *ConsulCluster:
+		return r.DeletedAt
+	case *VaultCluster:
+		return r.DeletedAt
+	case *NomadCluster:
+		return r.DeletedAt
+	case *TerraformWorkspace:
+		return r.DeletedAt
+	}
+	return nil
+}
+
+func recordID(record interface{}) string {
+	switch r := record.(type) {
+	case *AccessPolicy:
+		return r.ID
+	case *ConsulCluster:
+		return r.ID
+	case *VaultCluster:
+		return r.ID
+	case *NomadCluster:
+		return r.ID
+	case *TerraformWorkspace:
+		return r.ID
+	case *TerraformVariableSet:
+		return r.ID
+	case *TerraformPolicySet:
+		return r.ID
+	case *TerraformAgentPool:
+		return r.ID
+	case *TerraformOAuthClient:
+		return r.ID
+	case *IPPool:
+		return r.ID
+	case *IPAddress:
+		return r.ID
+	}
+	return ""
+}
+
+func recordScope(record interface{}) Scope {
+	switch r := record.(type) {
+	case *AccessPolicy:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *ConsulCluster:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *VaultCluster:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *NomadCluster:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *TerraformWorkspace:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *TerraformVariableSet:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *TerraformPolicySet:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *TerraformAgentPool:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *TerraformOAuthClient:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *IPPool:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	case *IPAddress:
+		return Scope{Organization: r.Organization, Project: r.Project}
+	}
+	return Scope{}
+}
+
+func recordName(record interface{}) string {
+	switch r := record.(type) {
+	case *ConsulCluster:
+		return r.Name
+	case *VaultCluster:
+		return r.Name
+	case *NomadCluster:
+		return r.Name
+	case *TerraformWorkspace:
+		return r.Name
+	case *TerraformVariableSet:
+		return r.Name
+	case *TerraformPolicySet:
+		return r.Name
+	case *TerraformAgentPool:
+		return r.Name
+	case *TerraformOAuthClient:
+		return r.Name
+	case *IPPool:
+		return r.Name
+	}
+	return ""
+}
+
+// recordVisible reports whether record exists, hasn't been deleted, and
+// belongs to the project identified by scope.
+func recordVisible(scope Scope, record interface{}) bool {
+	if record == nil || recordDeletedAt(record) != nil {
+		return false
+	}
+	return scope.Contains(recordScope(record))
+}
+
+// nameTaken reports whether a record in table other than the one identified
+// by id is already using name in the project identified by scope. Deleted
+// records don't hold on to their names.
+func nameTaken(txn *memdb.Txn, table string, scope Scope, id, name string) (bool, error) {
+	iter, err := txn.Get(table, "project", scope.Organization, scope.Project)
+	if err != nil {
+		return false, err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		if recordDeletedAt(record) != nil || strings.EqualFold(recordID(record), id) {
+			continue
+		}
+		if recordName(record) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Storer) GetTerraformRun(scope Scope, workspaceID, id string) (TerraformRun, error) {
+	txn := s.txn(false)
+	run, err := txn.First("terraformRun", "id", id)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+	if run == nil || !run.(*TerraformRun).in(scope, workspaceID) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	return *run.(*TerraformRun), nil
+}
+
+// CreateTerraformRun adds run to its workspace's queue. Unless the workspace
+// has QueueAllRuns set, any runs still waiting in the queue are discarded in
+// favour of the new one. If the workspace is locked, only the lock's holder
+// can queue runs.
+func (s *Storer) CreateTerraformRun(run TerraformRun, holder string) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := createTerraformRun(txn, run, holder)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func createTerraformRun(txn *memdb.Txn, run TerraformRun, holder string) error {
+	workspace, err := txn.First("terraformWorkspace", "id", run.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(Scope{Organization: run.Organization, Project: run.Project}, workspace) {
+		return ErrTerraformWorkspaceNotFound
+	}
+	if workspace.(*TerraformWorkspace).lockedAgainst(holder) {
+		return ErrTerraformWorkspaceLocked
+	}
+	exists, err := txn.First("terraformRun", "id", run.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformRunAlreadyExists
+	}
+	if !run.Speculative && !workspace.(*TerraformWorkspace).QueueAllRuns {
+		iter, err := txn.Get("terraformRun", "workspace", run.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		var superseded []TerraformRun
+		for queued := iter.Next(); queued != nil; queued = iter.Next() {
+			if queued.(*TerraformRun).Speculative || queued.(*TerraformRun).Status != TerraformRunPending {
+				continue
+			}
+			superseded = append(superseded, *queued.(*TerraformRun))
+		}
+		for pos := range superseded {
+			superseded[pos].setStatus(TerraformRunDiscarded, "superseded by run "+run.ID)
+			err = txn.Insert("terraformRun", &superseded[pos])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return txn.Insert("terraformRun", &run)
+}
+
+func (s *Storer) ListTerraformRuns(scope Scope, workspaceID string) ([]TerraformRun, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformRun", "workspace", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var runs []TerraformRun
+	for run := iter.Next(); run != nil; run = iter.Next() {
+		if !run.(*TerraformRun).in(scope, workspaceID) {
+			continue
+		}
+		runs = append(runs, *run.(*TerraformRun))
+	}
+	sortTerraformRuns(runs)
+	return runs, nil
+}
+
+// ApplyTerraformRun confirms a planned run, so it can be applied.
+func (s *Storer) ApplyTerraformRun(scope Scope, workspaceID, id string) (TerraformRun, error) {
+	return s.transitionTerraformRun(scope, workspaceID, id, TerraformRunApplying, "applied by user", func(run TerraformRun) bool {
+		return run.Status == TerraformRunPlanned && !run.Speculative
+	})
+}
+
+// DiscardTerraformRun throws away a run that hasn't started applying yet.
+func (s *Storer) DiscardTerraformRun(scope Scope, workspaceID, id string) (TerraformRun, error) {
+	return s.transitionTerraformRun(scope, workspaceID, id, TerraformRunDiscarded, "discarded by user", func(run TerraformRun) bool {
+		return run.Status == TerraformRunPending || run.Status == TerraformRunPolicyOverride || (run.Status == TerraformRunPlanned && !run.Speculative)
+	})
+}
+
+// OverrideTerraformRun lets a run that failed soft-mandatory policies
+// continue as if they had passed.
+func (s *Storer) OverrideTerraformRun(scope Scope, workspaceID, id string) (TerraformRun, error) {
+	return s.transitionTerraformRun(scope, workspaceID, id, TerraformRunPlanned, "policy checks overridden", func(run TerraformRun) bool {
+		return run.Status == TerraformRunPolicyOverride
+	})
+}
+
+// CancelTerraformRun stops a run that is planning or applying.
+func (s *Storer) CancelTerraformRun(scope Scope, workspaceID, id string) (TerraformRun, error) {
+	return s.transitionTerraformRun(scope, workspaceID, id, TerraformRunCanceled, "canceled by user", func(run TerraformRun) bool {
+		return run.Status == TerraformRunPlanning || run.Status == TerraformRunApplying
+	})
+}
+
+func (s *Storer) transitionTerraformRun(scope Scope, workspaceID, id, status, reason string, allowed func(TerraformRun) bool) (TerraformRun, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformRun", "id", id)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+	if existing == nil || !existing.(*TerraformRun).in(scope, workspaceID) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	run := *existing.(*TerraformRun)
+	if !allowed(run) {
+		return TerraformRun{}, ErrTerraformRunInvalidTransition
+	}
+	run.setStatus(status, reason)
+	err = txn.Insert("terraformRun", &run)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+	txn.Commit()
+	return run, nil
+}
+
+// StepTerraformRuns moves every run that isn't finished one step further
+// through its lifecycle. Each workspace works on one run at a time, in the
+// order they were created; speculative runs don't wait their turn, and stop
+// once they're planned. Runs whose workspace has gone away are errored.
+func (s *Storer) StepTerraformRuns() error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("terraformRun", "id")
+	if err != nil {
+		return err
+	}
+	byWorkspace := map[string][]TerraformRun{}
+	for run := iter.Next(); run != nil; run = iter.Next() {
+		if run.(*TerraformRun).Finished() {
+			continue
+		}
+		key := strings.ToLower(run.(*TerraformRun).WorkspaceID)
+		byWorkspace[key] = append(byWorkspace[key], *run.(*TerraformRun))
+	}
+	var applied []TerraformRun
+	for workspaceID, runs := range byWorkspace {
+		sortTerraformRuns(runs)
+		workspace, err := txn.First("terraformWorkspace", "id", workspaceID)
+		if err != nil {
+			return err
+		}
+		var busy bool
+		for _, run := range runs {
+			if !run.Speculative && run.Status != TerraformRunPending {
+				busy = true
+			}
+		}
+		for pos := range runs {
+			run := runs[pos]
+			before := run.Status
+			switch {
+			case workspace == nil || workspace.(*TerraformWorkspace).DeletedAt != nil:
+				run.setStatus(TerraformRunErrored, "workspace was deleted")
+			case run.Status == TerraformRunPending && run.Speculative:
+				run.setStatus(TerraformRunPlanning, "")
+			case run.Status == TerraformRunPending && !busy:
+				run.setStatus(TerraformRunPlanning, "")
+				busy = true
+			case run.Status == TerraformRunPlanning:
+				err = checkTerraformRunPolicies(txn, workspace.(*TerraformWorkspace), &run)
+				if err != nil {
+					return err
+				}
+			case run.Status == TerraformRunPlanned && run.AutoApply && !run.Speculative:
+				run.setStatus(TerraformRunApplying, "auto-applied")
+			case run.Status == TerraformRunApplying:
+				run.setStatus(TerraformRunApplied, "")
+			}
+			if run.Status == before {
+				continue
+			}
+			err = txn.Insert("terraformRun", &run)
+			if err != nil {
+				return err
+			}
+			if run.Status == TerraformRunApplied {
+				applied = append(applied, run)
+			}
+		}
+	}
+	// runs are only triggered once every workspace has been stepped, so
+	// the runs they supersede aren't overwritten by stale copies
+	for _, run := range applied {
+		err = triggerTerraformRuns(txn, run)
+		if err != nil {
+			return err
+		}
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) GetTerraformStateVersion(scope Scope, workspaceID, id string) (TerraformStateVersion, error) {
+	txn := s.txn(false)
+	version, err := txn.First("terraformStateVersion", "id", id)
+	if err != nil {
+		return TerraformStateVersion{}, err
+	}
+	if version == nil || !version.(*TerraformStateVersion).in(scope, workspaceID) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionNotFound
+	}
+	return *version.(*TerraformStateVersion), nil
+}
+
+// GetCurrentTerraformStateVersion returns the state version with the highest
+// serial in the workspace.
+func (s *Storer) GetCurrentTerraformStateVersion(scope Scope, workspaceID string) (TerraformStateVersion, error) {
+	txn := s.txn(false)
+	current, err := currentTerraformStateVersion(txn, scope, workspaceID)
+	if err != nil {
+		return TerraformStateVersion{}, err
+	}
+	if current == nil {
+		return TerraformStateVersion{}, ErrTerraformStateVersionNotFound
+	}
+	return *current, nil
+}
+
+// CreateTerraformStateVersion makes version the workspace's current state.
+// Its serial must be greater than the current state's, and it must have the
+// same lineage, so a stale or unrelated state can't replace the current one.
+func (s *Storer) CreateTerraformStateVersion(version TerraformStateVersion) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	workspace, err := txn.First("terraformWorkspace", "id", version.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	scope := Scope{Organization: version.Organization, Project: version.Project}
+	if !recordVisible(scope, workspace) {
+		return ErrTerraformWorkspaceNotFound
+	}
+	exists, err := txn.First("terraformStateVersion", "id", version.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformStateVersionAlreadyExists
+	}
+	current, err := currentTerraformStateVersion(txn, scope, version.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		if *version.Serial <= *current.Serial {
+			return ErrTerraformStateVersionSerialConflict
+		}
+		if current.Lineage != "" && version.Lineage != current.Lineage {
+			return ErrTerraformStateVersionLineageConflict
+		}
+	}
+	err = txn.Insert("terraformStateVersion", &version)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// ListTerraformStateVersions returns the workspace's state versions, newest
+// first.
+func (s *Storer) ListTerraformStateVersions(scope Scope, workspaceID string) ([]TerraformStateVersion, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformStateVersion", "workspace", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var versions []TerraformStateVersion
+	for version := iter.Next(); version != nil; version = iter.Next() {
+		if !version.(*TerraformStateVersion).in(scope, workspaceID) {
+			continue
+		}
+		versions = append(versions, *version.(*TerraformStateVersion))
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return *versions[i].Serial > *versions[j].Serial
+	})
+	return versions, nil
+}
+
+func currentTerraformStateVersion(txn *memdb.Txn, scope Scope, workspaceID string) (*TerraformStateVersion, error) {
+	iter, err := txn.Get("terraformStateVersion", "workspace", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	var current *TerraformStateVersion
+	for version := iter.Next(); version != nil; version = iter.Next() {
+		if !version.(*TerraformStateVersion).in(scope, workspaceID) {
+			continue
+		}
+		if current == nil || *version.(*TerraformStateVersion).Serial > *current.Serial {
+			current = version.(*TerraformStateVersion)
+		}
+	}
+	return current, nil
+}
+
+// CreateTerraformVCSEvent records event, and queues the runs it triggered.
+func (s *Storer) CreateTerraformVCSEvent(event TerraformVCSEvent, runs []TerraformRun) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformVCSEvent", "id", event.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformVCSEventAlreadyExists
+	}
+	for _, run := range runs {
+		err = createTerraformRun(txn, run, "")
+		if err != nil {
+			return err
+		}
+	}
+	err = txn.Insert("terraformVCSEvent", &event)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) GetTerraformVCSEvent(scope Scope, id string) (TerraformVCSEvent, error) {
+	txn := s.txn(false)
+	event, err := txn.First("terraformVCSEvent", "id", id)
+	if err != nil {
+		return TerraformVCSEvent{}, err
+	}
+	if event == nil || !scope.Contains(Scope{Organization: event.(*TerraformVCSEvent).Organization, Project: event.(*TerraformVCSEvent).Project}) {
+		return TerraformVCSEvent{}, ErrTerraformVCSEventNotFound
+	}
+	return *event.(*TerraformVCSEvent), nil
+}
+
+// ListTerraformVCSEvents returns the VCS events received by the project,
+// oldest first.
+func (s *Storer) ListTerraformVCSEvents(scope Scope) ([]TerraformVCSEvent, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformVCSEvent", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var events []TerraformVCSEvent
+	for event := iter.Next(); event != nil; event = iter.Next() {
+		events = append(events, *event.(*TerraformVCSEvent))
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].ReceivedAt.Equal(events[j].ReceivedAt) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+	})
+	return events, nil
+}
+
+// LockTerraformWorkspace locks the workspace, so only lock.Holder can update
+// it or queue runs in it until it's unlocked.
+func (s *Storer) LockTerraformWorkspace(scope Scope, id string, lock TerraformWorkspaceLock) (TerraformWorkspace, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	workspace := *existing.(*TerraformWorkspace)
+	if workspace.Lock != nil {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	workspace.setLock(&lock)
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+// UnlockTerraformWorkspace releases the workspace's lock. Unless force is
+// set, holder must be the lock's holder.
+func (s *Storer) UnlockTerraformWorkspace(scope Scope, id, holder string, force bool) (TerraformWorkspace, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformWorkspace", "id", id)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	workspace := *existing.(*TerraformWorkspace)
+	if workspace.Lock == nil {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotLocked
+	}
+	if !force && workspace.lockedAgainst(holder) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	workspace.setLock(nil)
+	err = txn.Insert("terraformWorkspace", &workspace)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+func sortTerraformRuns(runs []TerraformRun) {
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].ID < runs[j].ID
+		}
+		return runs[i].CreatedAt.Before(runs[j].CreatedAt)
+	})
+}
+
+// variableParentVisible returns the error to report if the workspace or
+// variable set that a variable belongs to doesn't exist in scope.
+func variableParentVisible(txn *memdb.Txn, scope Scope, workspaceID, variableSetID string) error {
+	if workspaceID != "" {
+		workspace, err := txn.First("terraformWorkspace", "id", workspaceID)
+		if err != nil {
+			return err
+		}
+		if !recordVisible(scope, workspace) {
+			return ErrTerraformWorkspaceNotFound
+		}
+		return nil
+	}
+	set, err := txn.First("terraformVariableSet", "id", variableSetID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, set) {
+		return ErrTerraformVariableSetNotFound
+	}
+	return nil
+}
+
+// variableKeyTaken reports whether a variable other than variable is already
+// using its key and category in the same workspace or variable set.
+func variableKeyTaken(txn *memdb.Txn, variable TerraformVariable) (bool, error) {
+	index, parentID := "workspace", variable.WorkspaceID
+	if variable.VariableSetID != "" {
+		index, parentID = "variableSet", variable.VariableSetID
+	}
+	iter, err := txn.Get("terraformVariable", index, parentID)
+	if err != nil {
+		return false, err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		existing := record.(*TerraformVariable)
+		if strings.EqualFold(existing.ID, variable.ID) {
+			continue
+		}
+		if existing.Key == variable.Key && existing.Category == variable.Category {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Storer) GetTerraformVariable(scope Scope, workspaceID, variableSetID, id string) (TerraformVariable, error) {
+	txn := s.txn(false)
+	variable, err := txn.First("terraformVariable", "id", id)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+	if variable == nil || !variable.(*TerraformVariable).in(scope, workspaceID, variableSetID) {
+		return TerraformVariable{}, ErrTerraformVariableNotFound
+	}
+	return *variable.(*TerraformVariable), nil
+}
+
+func (s *Storer) CreateTerraformVariable(variable TerraformVariable) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := variableParentVisible(txn, Scope{Organization: variable.Organization, Project: variable.Project}, variable.WorkspaceID, variable.VariableSetID)
+	if err != nil {
+		return err
+	}
+	taken, err := variableKeyTaken(txn, variable)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformVariableKeyConflict
+	}
+	err = txn.Insert("terraformVariable", &variable)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateTerraformVariable(variable TerraformVariable) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVariable", "id", variable.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || !existing.(*TerraformVariable).in(Scope{Organization: variable.Organization, Project: variable.Project}, variable.WorkspaceID, variable.VariableSetID) {
+		return ErrTerraformVariableNotFound
+	}
+	taken, err := variableKeyTaken(txn, variable)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformVariableKeyConflict
+	}
+	err = txn.Insert("terraformVariable", &variable)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteTerraformVariable removes a variable and returns it. Variables aren't
+// soft deleted; they go away as soon as they're deleted.
+func (s *Storer) DeleteTerraformVariable(scope Scope, workspaceID, variableSetID, id string) (TerraformVariable, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVariable", "id", id)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+	if existing == nil || !existing.(*TerraformVariable).in(scope, workspaceID, variableSetID) {
+		return TerraformVariable{}, ErrTerraformVariableNotFound
+	}
+	err = txn.Delete("terraformVariable", existing)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformVariable), nil
+}
+
+// ListTerraformVariables returns the variables set directly on the workspace
+// identified by workspaceID or, if workspaceID is empty, the variables in the
+// variable set identified by variableSetID.
+func (s *Storer) ListTerraformVariables(scope Scope, workspaceID, variableSetID string) ([]TerraformVariable, error) {
+	txn := s.txn(false)
+	err := variableParentVisible(txn, scope, workspaceID, variableSetID)
+	if err != nil {
+		return nil, err
+	}
+	index, parentID := "workspace", workspaceID
+	if workspaceID == "" {
+		index, parentID = "variableSet", variableSetID
+	}
+	iter, err := txn.Get("terraformVariable", index, parentID)
+	if err != nil {
+		return nil, err
+	}
+	var variables []TerraformVariable
+	for variable := iter.Next(); variable != nil; variable = iter.Next() {
+		if !variable.(*TerraformVariable).in(scope, workspaceID, variableSetID) {
+			continue
+		}
+		variables = append(variables, *variable.(*TerraformVariable))
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		if variables[i].Category != variables[j].Category {
+			return variables[i].Category > variables[j].Category
+		}
+		return variables[i].Key < variables[j].Key
+	})
+	return variables, nil
+}
+
+// workspacesVisible returns ErrTerraformWorkspaceNotFound if any of ids
+// doesn't identify a workspace in scope.
+func workspacesVisible(txn *memdb.Txn, scope Scope, ids []string) error {
+	for _, id := range ids {
+		workspace, err := txn.First("terraformWorkspace", "id", id)
+		if err != nil {
+			return err
+		}
+		if !recordVisible(scope, workspace) {
+			return ErrTerraformWorkspaceNotFound
+		}
+	}
+	return nil
+}
+
+// detachVariableSets removes the workspace identified by workspaceID from
+// every variable set it's attached to.
+func detachVariableSets(txn *memdb.Txn, workspaceID string) error {
+	iter, err := txn.Get("terraformVariableSet", "id")
+	if err != nil {
+		return err
+	}
+	var changed []TerraformVariableSet
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		set := *record.(*TerraformVariableSet)
+		workspaceIDs := make([]string, 0, len(set.WorkspaceIDs))
+		for _, id := range set.WorkspaceIDs {
+			if strings.EqualFold(id, workspaceID) {
+				continue
+			}
+			workspaceIDs = append(workspaceIDs, id)
+		}
+		if len(workspaceIDs) == len(set.WorkspaceIDs) {
+			continue
+		}
+		set.WorkspaceIDs = workspaceIDs
+		changed = append(changed, set)
+	}
+	for pos := range changed {
+		err = txn.Insert("terraformVariableSet", &changed[pos])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storer) GetTerraformVariableSet(scope Scope, id string) (TerraformVariableSet, error) {
+	txn := s.txn(false)
+	set, err := txn.First("terraformVariableSet", "id", id)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+	if !recordVisible(scope, set) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNotFound
+	}
+	return *set.(*TerraformVariableSet), nil
+}
+
+func (s *Storer) CreateTerraformVariableSet(set TerraformVariableSet) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformVariableSet", "id", set.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformVariableSetAlreadyExists
+	}
+	taken, err := nameTaken(txn, "terraformVariableSet", recordScope(&set), set.ID, set.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformVariableSetNameConflict
+	}
+	err = workspacesVisible(txn, recordScope(&set), set.WorkspaceIDs)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformVariableSet", &set)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateTerraformVariableSet(set TerraformVariableSet) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVariableSet", "id", set.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&set), existing) {
+		return ErrTerraformVariableSetNotFound
+	}
+	taken, err := nameTaken(txn, "terraformVariableSet", recordScope(&set), set.ID, set.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformVariableSetNameConflict
+	}
+	err = workspacesVisible(txn, recordScope(&set), set.WorkspaceIDs)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformVariableSet", &set)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteTerraformVariableSet removes a variable set, and all the variables in
+// it, and returns the removed set.
+func (s *Storer) DeleteTerraformVariableSet(scope Scope, id string) (TerraformVariableSet, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVariableSet", "id", id)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNotFound
+	}
+	err = txn.Delete("terraformVariableSet", existing)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+	_, err = txn.DeleteAll("terraformVariable", "variableSet", id)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformVariableSet), nil
+}
+
+func (s *Storer) ListTerraformVariableSets(scope Scope) ([]TerraformVariableSet, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformVariableSet", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformVariableSet
+	for set := iter.Next(); set != nil; set = iter.Next() {
+		results = append(results, *set.(*TerraformVariableSet))
+	}
+	return results, nil
+}
+
+// agentPoolVisible returns ErrTerraformAgentPoolNotFound if id is set but
+// doesn't identify an agent pool in the project identified by scope.
+func agentPoolVisible(txn *memdb.Txn, scope Scope, id string) error {
+	if id == "" {
+		return nil
+	}
+	pool, err := txn.First("terraformAgentPool", "id", id)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, pool) {
+		return ErrTerraformAgentPoolNotFound
+	}
+	return nil
+}
+
+func (s *Storer) GetTerraformAgentPool(scope Scope, id string) (TerraformAgentPool, error) {
+	txn := s.txn(false)
+	pool, err := txn.First("terraformAgentPool", "id", id)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+	if !recordVisible(scope, pool) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNotFound
+	}
+	return *pool.(*TerraformAgentPool), nil
+}
+
+func (s *Storer) CreateTerraformAgentPool(pool TerraformAgentPool) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformAgentPool", "id", pool.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformAgentPoolAlreadyExists
+	}
+	taken, err := nameTaken(txn, "terraformAgentPool", recordScope(&pool), pool.ID, pool.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformAgentPoolNameConflict
+	}
+	err = txn.Insert("terraformAgentPool", &pool)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateTerraformAgentPool(pool TerraformAgentPool) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformAgentPool", "id", pool.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&pool), existing) {
+		return ErrTerraformAgentPoolNotFound
+	}
+	taken, err := nameTaken(txn, "terraformAgentPool", recordScope(&pool), pool.ID, pool.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformAgentPoolNameConflict
+	}
+	err = txn.Insert("terraformAgentPool", &pool)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteTerraformAgentPool removes an agent pool, and all its tokens, and
+// returns the removed pool. Pools that are still used by a workspace,
+// including deleted workspaces that haven't been purged yet, can't be
+// removed.
+func (s *Storer) DeleteTerraformAgentPool(scope Scope, id string) (TerraformAgentPool, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformAgentPool", "id", id)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNotFound
+	}
+	iter, err := txn.Get("terraformWorkspace", "id")
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		if strings.EqualFold(record.(*TerraformWorkspace).AgentPoolID, id) {
+			return TerraformAgentPool{}, ErrTerraformAgentPoolInUse
+		}
+	}
+	err = txn.Delete("terraformAgentPool", existing)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+	_, err = txn.DeleteAll("terraformAgentToken", "agentPool", id)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformAgentPool), nil
+}
+
+func (s *Storer) ListTerraformAgentPools(scope Scope) ([]TerraformAgentPool, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformAgentPool", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformAgentPool
+	for pool := iter.Next(); pool != nil; pool = iter.Next() {
+		results = append(results, *pool.(*TerraformAgentPool))
+	}
+	return results, nil
+}
+
+func (s *Storer) GetTerraformAgentToken(scope Scope, agentPoolID, id string) (TerraformAgentToken, error) {
+	txn := s.txn(false)
+	token, err := txn.First("terraformAgentToken", "id", id)
+	if err != nil {
+		return TerraformAgentToken{}, err
+	}
+	if token == nil || !token.(*TerraformAgentToken).in(scope, agentPoolID) {
+		return TerraformAgentToken{}, ErrTerraformAgentTokenNotFound
+	}
+	return *token.(*TerraformAgentToken), nil
+}
+
+func (s *Storer) CreateTerraformAgentToken(token TerraformAgentToken) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := agentPoolVisible(txn, Scope{Organization: token.Organization, Project: token.Project}, token.AgentPoolID)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformAgentToken", &token)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteTerraformAgentToken revokes a token and returns the revoked token.
+func (s *Storer) DeleteTerraformAgentToken(scope Scope, agentPoolID, id string) (TerraformAgentToken, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformAgentToken", "id", id)
+	if err != nil {
+		return TerraformAgentToken{}, err
+	}
+	if existing == nil || !existing.(*TerraformAgentToken).in(scope, agentPoolID) {
+		return TerraformAgentToken{}, ErrTerraformAgentTokenNotFound
+	}
+	err = txn.Delete("terraformAgentToken", existing)
+	if err != nil {
+		return TerraformAgentToken{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformAgentToken), nil
+}
+
+// ListTerraformAgentTokens returns the tokens for an agent pool, oldest
+// first.
+func (s *Storer) ListTerraformAgentTokens(scope Scope, agentPoolID string) ([]TerraformAgentToken, error) {
+	txn := s.txn(false)
+	pool, err := txn.First("terraformAgentPool", "id", agentPoolID)
+	if err != nil {
+		return nil, err
+	}
+	if !recordVisible(scope, pool) {
+		return nil, ErrTerraformAgentPoolNotFound
+	}
+	iter, err := txn.Get("terraformAgentToken", "agentPool", agentPoolID)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformAgentToken
+	for token := iter.Next(); token != nil; token = iter.Next() {
+		results = append(results, *token.(*TerraformAgentToken))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+// oauthTokenUsable returns ErrTerraformOAuthTokenNotFound if repo uses an
+// OAuth token that isn't in the project identified by scope, and
+// ErrTerraformWorkspaceVCSIdentifier if repo's identifier doesn't make
+// sense for the VCS provider the token belongs to.
+func oauthTokenUsable(txn *memdb.Txn, scope Scope, repo TerraformWorkspaceVCSRepo) error {
+	if repo.OAuthTokenID == "" {
+		return nil
+	}
+	token, err := txn.First("terraformOAuthToken", "id", repo.OAuthTokenID)
+	if err != nil {
+		return err
+	}
+	if token == nil || !scope.Contains(Scope{Organization: token.(*TerraformOAuthToken).Organization, Project: token.(*TerraformOAuthToken).Project}) {
+		return ErrTerraformOAuthTokenNotFound
+	}
+	client, err := txn.First("terraformOAuthClient", "id", token.(*TerraformOAuthToken).OAuthClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return ErrTerraformOAuthTokenNotFound
+	}
+	if !validVCSIdentifier(client.(*TerraformOAuthClient).ServiceProvider, repo.Identifier) {
+		return ErrTerraformWorkspaceVCSIdentifier
+	}
+	return nil
+}
+
+// oauthTokensInUse reports whether any workspace, including deleted
+// workspaces that haven't been purged yet, uses one of the OAuth tokens
+// belonging to the OAuth client identified by oauthClientID.
+func oauthTokensInUse(txn *memdb.Txn, oauthClientID string) (bool, error) {
+	tokens, err := txn.Get("terraformOAuthToken", "oauthClient", oauthClientID)
+	if err != nil {
+		return false, err
+	}
+	ids := map[string]bool{}
+	for token := tokens.Next(); token != nil; token = tokens.Next() {
+		ids[strings.ToLower(token.(*TerraformOAuthToken).ID)] = true
+	}
+	workspaces, err := txn.Get("terraformWorkspace", "id")
+	if err != nil {
+		return false, err
+	}
+	for record := workspaces.Next(); record != nil; record = workspaces.Next() {
+		if ids[strings.ToLower(record.(*TerraformWorkspace).VCSRepo.OAuthTokenID)] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Storer) GetTerraformOAuthClient(scope Scope, id string) (TerraformOAuthClient, error) {
+	txn := s.txn(false)
+	client, err := txn.First("terraformOAuthClient", "id", id)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	if !recordVisible(scope, client) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNotFound
+	}
+	return *client.(*TerraformOAuthClient), nil
+}
+
+// CreateTerraformOAuthClient stores a new OAuth client, along with the OAuth
+// token it was created with.
+func (s *Storer) CreateTerraformOAuthClient(client TerraformOAuthClient, token TerraformOAuthToken) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformOAuthClient", "id", client.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformOAuthClientAlreadyExists
+	}
+	taken, err := nameTaken(txn, "terraformOAuthClient", recordScope(&client), client.ID, client.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformOAuthClientNameConflict
+	}
+	err = txn.Insert("terraformOAuthClient", &client)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformOAuthToken", &token)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UpdateTerraformOAuthClient updates an OAuth client and returns the updated
+// client. If client has an OAuthToken set, the client's OAuth token is
+// rotated to use it; the token keeps its ID, so workspaces using it don't
+// need to change.
+func (s *Storer) UpdateTerraformOAuthClient(client TerraformOAuthClient) (TerraformOAuthClient, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformOAuthClient", "id", client.ID)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	if !recordVisible(recordScope(&client), existing) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNotFound
+	}
+	taken, err := nameTaken(txn, "terraformOAuthClient", recordScope(&client), client.ID, client.Name)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	if taken {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNameConflict
+	}
+	if client.ServiceProvider != existing.(*TerraformOAuthClient).ServiceProvider {
+		inUse, err := oauthTokensInUse(txn, client.ID)
+		if err != nil {
+			return TerraformOAuthClient{}, err
+		}
+		if inUse {
+			return TerraformOAuthClient{}, ErrTerraformOAuthClientProviderInUse
+		}
+	}
+	client.OAuthTokenID = existing.(*TerraformOAuthClient).OAuthTokenID
+	if client.OAuthToken != "" {
+		existingToken, err := txn.First("terraformOAuthToken", "id", client.OAuthTokenID)
+		if err != nil {
+			return TerraformOAuthClient{}, err
+		}
+		if existingToken != nil {
+			token := *existingToken.(*TerraformOAuthToken)
+			token.Token = client.OAuthToken
+			token.UpdatedAt = time.Now()
+			err = txn.Insert("terraformOAuthToken", &token)
+			if err != nil {
+				return TerraformOAuthClient{}, err
+			}
+		}
+	}
+	client.OAuthToken = ""
+	err = txn.Insert("terraformOAuthClient", &client)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	txn.Commit()
+	return client, nil
+}
+
+// DeleteTerraformOAuthClient removes an OAuth client, and all its tokens,
+// and returns the removed client. OAuth clients with tokens that are still
+// used by a workspace can't be removed.
+func (s *Storer) DeleteTerraformOAuthClient(scope Scope, id string) (TerraformOAuthClient, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformOAuthClient", "id", id)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNotFound
+	}
+	inUse, err := oauthTokensInUse(txn, id)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	if inUse {
+		return TerraformOAuthClient{}, ErrTerraformOAuthTokenInUse
+	}
+	err = txn.Delete("terraformOAuthClient", existing)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	_, err = txn.DeleteAll("terraformOAuthToken", "oauthClient", id)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformOAuthClient), nil
+}
+
+func (s *Storer) ListTerraformOAuthClients(scope Scope) ([]TerraformOAuthClient, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformOAuthClient", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformOAuthClient
+	for client := iter.Next(); client != nil; client = iter.Next() {
+		results = append(results, *client.(*TerraformOAuthClient))
+	}
+	return results, nil
+}
+
+func (s *Storer) GetTerraformOAuthToken(scope Scope, oauthClientID, id string) (TerraformOAuthToken, error) {
+	txn := s.txn(false)
+	token, err := txn.First("terraformOAuthToken", "id", id)
+	if err != nil {
+		return TerraformOAuthToken{}, err
+	}
+	if token == nil || !token.(*TerraformOAuthToken).in(scope, oauthClientID) {
+		return TerraformOAuthToken{}, ErrTerraformOAuthTokenNotFound
+	}
+	return *token.(*TerraformOAuthToken), nil
+}
+
+// ListTerraformOAuthTokens returns the tokens for an OAuth client, oldest
+// first.
+func (s *Storer) ListTerraformOAuthTokens(scope Scope, oauthClientID string) ([]TerraformOAuthToken, error) {
+	txn := s.txn(false)
+	client, err := txn.First("terraformOAuthClient", "id", oauthClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !recordVisible(scope, client) {
+		return nil, ErrTerraformOAuthClientNotFound
+	}
+	iter, err := txn.Get("terraformOAuthToken", "oauthClient", oauthClientID)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformOAuthToken
+	for token := iter.Next(); token != nil; token = iter.Next() {
+		results = append(results, *token.(*TerraformOAuthToken))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+func (s *Storer) GetTerraformVersion(version string) (TerraformVersion, error) {
+	txn := s.txn(false)
+	existing, err := txn.First("terraformVersion", "id", version)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	if existing == nil {
+		return TerraformVersion{}, ErrTerraformVersionNotFound
+	}
+	return *existing.(*TerraformVersion), nil
+}
+
+func (s *Storer) CreateTerraformVersion(version TerraformVersion) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformVersion", "id", version.Version)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformVersionAlreadyExists
+	}
+	err = txn.Insert("terraformVersion", &version)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UpdateTerraformVersion changes the flags on a version in the catalog. Its
+// creation time is left as it is.
+func (s *Storer) UpdateTerraformVersion(version TerraformVersion) (TerraformVersion, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVersion", "id", version.Version)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	if existing == nil {
+		return TerraformVersion{}, ErrTerraformVersionNotFound
+	}
+	version.Version = existing.(*TerraformVersion).Version
+	version.CreatedAt = existing.(*TerraformVersion).CreatedAt
+	err = txn.Insert("terraformVersion", &version)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	txn.Commit()
+	return version, nil
+}
+
+// DeleteTerraformVersion removes a version from the catalog. Versions that
+// workspaces have pinned can't be removed; deprecate them instead.
+func (s *Storer) DeleteTerraformVersion(version string) (TerraformVersion, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformVersion", "id", version)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	if existing == nil {
+		return TerraformVersion{}, ErrTerraformVersionNotFound
+	}
+	v, err := parseSemver(existing.(*TerraformVersion).Version)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	iter, err := txn.Get("terraformWorkspace", "id")
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	for workspace := iter.Next(); workspace != nil; workspace = iter.Next() {
+		if workspace.(*TerraformWorkspace).DeletedAt != nil {
+			continue
+		}
+		constraints, err := parseTerraformVersionConstraints(workspace.(*TerraformWorkspace).TerraformVersion)
+		if err != nil || !exactTerraformVersion(constraints) {
+			continue
+		}
+		if constraints[0].version.compare(v) == 0 {
+			return TerraformVersion{}, ErrTerraformVersionInUse
+		}
+	}
+	err = txn.Delete("terraformVersion", existing)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformVersion), nil
+}
+
+// ListTerraformVersions returns the catalog, newest version first.
+func (s *Storer) ListTerraformVersions() ([]TerraformVersion, error) {
+	txn := s.txn(false)
+	return listTerraformVersions(txn)
+}
+
+func listTerraformVersions(txn *memdb.Txn) ([]TerraformVersion, error) {
+	iter, err := txn.Get("terraformVersion", "id")
+	if err != nil {
+		return nil, err
+	}
+	var versions []TerraformVersion
+	for version := iter.Next(); version != nil; version = iter.Next() {
+		versions = append(versions, *version.(*TerraformVersion))
+	}
+	// versions are validated before they're added to the catalog, so they
+	// always parse
+	sort.Slice(versions, func(i, j int) bool {
+		vi, _ := parseSemver(versions[i].Version)
+		vj, _ := parseSemver(versions[j].Version)
+		return vi.compare(vj) > 0
+	})
+	return versions, nil
+}
+
+// DefaultTerraformVersion returns the newest version in the catalog that is
+// neither beta nor deprecated, or an empty string if there isn't one.
+func (s *Storer) DefaultTerraformVersion() (string, error) {
+	versions, err := s.ListTerraformVersions()
+	if err != nil {
+		return "", err
+	}
+	for _, version := range versions {
+		if !version.Beta && !version.Deprecated {
+			return version.Version, nil
+		}
+	}
+	return "", nil
+}
+
+// terraformVersionAllowed returns ErrTerraformVersionNotAllowed if the
+// catalog doesn't allow a workspace currently using current to switch to
+// requested. Workspaces can always keep the version they already have.
+func terraformVersionAllowed(txn *memdb.Txn, requested, current string) error {
+	if requested == current {
+		return nil
+	}
+	versions, err := listTerraformVersions(txn)
+	if err != nil {
+		return err
+	}
+	if len(versions) < 1 {
+		return nil
+	}
+	constraints, err := parseTerraformVersionConstraints(requested)
+	if err != nil {
+		return ErrTerraformVersionNotAllowed
+	}
+	for _, version := range versions {
+		v, err := parseSemver(version.Version)
+		if err != nil {
+			return err
+		}
+		if !terraformVersionMatches(constraints, v) || version.Deprecated {
+			continue
+		}
+		if version.Beta && !exactTerraformVersion(constraints) {
+			continue
+		}
+		return nil
+	}
+	return ErrTerraformVersionNotAllowed
+}
+
+// checkTerraformRunPolicies checks run's plan against the policy sets
+// attached to workspace, records the results on run, and moves it on from
+// planning to whatever status the results call for.
+func checkTerraformRunPolicies(txn *memdb.Txn, workspace *TerraformWorkspace, run *TerraformRun) error {
+	iter, err := txn.Get("terraformPolicySet", "project", workspace.Organization, workspace.Project)
+	if err != nil {
+		return err
+	}
+	var sets []TerraformPolicySet
+	for set := iter.Next(); set != nil; set = iter.Next() {
+		for _, id := range set.(*TerraformPolicySet).WorkspaceIDs {
+			if strings.EqualFold(id, workspace.ID) {
+				sets = append(sets, *set.(*TerraformPolicySet))
+				break
+			}
+		}
+	}
+	sortTerraformPolicySets(sets)
+	// plans are validated when runs are created, so they always parse
+	plan, _ := parseTerraformPlan(run.Plan)
+	run.PolicyChecks = checkTerraformPolicies(sets, plan)
+	run.setStatus(terraformPolicyOutcome(*run, run.PolicyChecks))
+	return nil
+}
+
+// detachPolicySets removes the workspace identified by workspaceID from
+// every policy set it's attached to.
+func detachPolicySets(txn *memdb.Txn, workspaceID string) error {
+	iter, err := txn.Get("terraformPolicySet", "id")
+	if err != nil {
+		return err
+	}
+	var changed []TerraformPolicySet
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		set := *record.(*TerraformPolicySet)
+		workspaceIDs := make([]string, 0, len(set.WorkspaceIDs))
+		for _, id := range set.WorkspaceIDs {
+			if strings.EqualFold(id, workspaceID) {
+				continue
+			}
+			workspaceIDs = append(workspaceIDs, id)
+		}
+		if len(workspaceIDs) == len(set.WorkspaceIDs) {
+			continue
+		}
+		set.WorkspaceIDs = workspaceIDs
+		changed = append(changed, set)
+	}
+	for pos := range changed {
+		err = txn.Insert("terraformPolicySet", &changed[pos])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storer) GetTerraformPolicySet(scope Scope, id string) (TerraformPolicySet, error) {
+	txn := s.txn(false)
+	set, err := txn.First("terraformPolicySet", "id", id)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+	if !recordVisible(scope, set) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNotFound
+	}
+	return *set.(*TerraformPolicySet), nil
+}
+
+func (s *Storer) CreateTerraformPolicySet(set TerraformPolicySet) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("terraformPolicySet", "id", set.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrTerraformPolicySetAlreadyExists
+	}
+	taken, err := nameTaken(txn, "terraformPolicySet", recordScope(&set), set.ID, set.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformPolicySetNameConflict
+	}
+	err = workspacesVisible(txn, recordScope(&set), set.WorkspaceIDs)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformPolicySet", &set)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateTerraformPolicySet(set TerraformPolicySet) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformPolicySet", "id", set.ID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(recordScope(&set), existing) {
+		return ErrTerraformPolicySetNotFound
+	}
+	taken, err := nameTaken(txn, "terraformPolicySet", recordScope(&set), set.ID, set.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrTerraformPolicySetNameConflict
+	}
+	err = workspacesVisible(txn, recordScope(&set), set.WorkspaceIDs)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("terraformPolicySet", &set)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteTerraformPolicySet removes a policy set and returns it. Runs that
+// were already checked against it keep their results.
+func (s *Storer) DeleteTerraformPolicySet(scope Scope, id string) (TerraformPolicySet, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("terraformPolicySet", "id", id)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNotFound
+	}
+	err = txn.Delete("terraformPolicySet", existing)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+	txn.Commit()
+	return *existing.(*TerraformPolicySet), nil
+}
+
+func (s *Storer) ListTerraformPolicySets(scope Scope) ([]TerraformPolicySet, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("terraformPolicySet", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []TerraformPolicySet
+	for set := iter.Next(); set != nil; set = iter.Next() {
+		results = append(results, *set.(*TerraformPolicySet))
+	}
+	sortTerraformPolicySets(results)
+	return results, nil
+}
+
+// triggerTerraformRuns queues a run in every workspace with a run trigger
+// on run's workspace, now that run has been applied. Workspaces that are
+// deleted or locked are skipped.
+func triggerTerraformRuns(txn *memdb.Txn, run TerraformRun) error {
+	record, err := txn.First("terraformWorkspace", "id", run.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil
+	}
+	workspace := record.(*TerraformWorkspace)
+	iter, err := txn.Get("terraformRunTrigger", "source", workspace.ID)
+	if err != nil {
+		return err
+	}
+	var destinationIDs []string
+	for trigger := iter.Next(); trigger != nil; trigger = iter.Next() {
+		destinationIDs = append(destinationIDs, trigger.(*TerraformRunTrigger).WorkspaceID)
+	}
+	for _, destinationID := range destinationIDs {
+		destination, err := txn.First("terraformWorkspace", "id", destinationID)
+		if err != nil {
+			return err
+		}
+		if !recordVisible(recordScope(workspace), destination) || destination.(*TerraformWorkspace).lockedAgainst("") {
+			continue
+		}
+		triggered := TerraformRun{
+			Organization: workspace.Organization,
+			Project:      workspace.Project,
+			WorkspaceID:  destination.(*TerraformWorkspace).ID,
+			Message:      "Triggered by run " + run.ID + " in workspace " + workspace.Name,
+			AutoApply:    destination.(*TerraformWorkspace).AutoApply,
+			CreatedAt:    time.Now(),
+		}
+		triggered.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			return err
+		}
+		triggered.setStatus(TerraformRunPending, "")
+		err = createTerraformRun(txn, triggered, "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTriggerReaches reports whether a run applied in the workspace
+// identified by fromID would, through any chain of run triggers, end up
+// queueing a run in the workspace identified by toID.
+func runTriggerReaches(txn *memdb.Txn, fromID, toID string) (bool, error) {
+	seen := map[string]struct{}{}
+	queue := []string{strings.ToLower(fromID)}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == strings.ToLower(toID) {
+			return true, nil
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		iter, err := txn.Get("terraformRunTrigger", "source", id)
+		if err != nil {
+			return false, err
+		}
+		for trigger := iter.Next(); trigger != nil; trigger = iter.Next() {
+			queue = append(queue, strings.ToLower(trigger.(*TerraformRunTrigger).WorkspaceID))
+		}
+	}
+	return false, nil
+}
+
+func (s *Storer) CreateTerraformRunTrigger(trigger TerraformRunTrigger) error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	scope := Scope{Organization: trigger.Organization, Project: trigger.Project}
+	workspace, err := txn.First("terraformWorkspace", "id", trigger.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, workspace) {
+		return ErrTerraformWorkspaceNotFound
+	}
+	source, err := txn.First("terraformWorkspace", "id", trigger.SourceWorkspaceID)
+	if err != nil {
+		return err
+	}
+	if !recordVisible(scope, source) {
+		return ErrTerraformRunTriggerSourceNotFound
+	}
+	iter, err := txn.Get("terraformRunTrigger", "workspace", trigger.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	for existing := iter.Next(); existing != nil; existing = iter.Next() {
+		if strings.EqualFold(existing.(*TerraformRunTrigger).SourceWorkspaceID, trigger.SourceWorkspaceID) {
+			return ErrTerraformRunTriggerAlreadyExists
+		}
+	}
+	// the new trigger makes a cycle if a run in its workspace could
+	// already trigger a run in its source, including when they're the
+	// same workspace
+	cycle, err := runTriggerReaches(txn, trigger.WorkspaceID, trigger.SourceWorkspaceID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrTerraformRunTriggerCycle
+	}
+	err = txn.Insert("terraformRunTrigger", &trigger)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) GetTerraformRunTrigger(scope Scope, id string) (TerraformRunTrigger, error) {
+	txn := s.txn(false)
+	trigger, err := txn.First("terraformRunTrigger", "id", id)
+	if err != nil {
+		return TerraformRunTrigger{}, err
+	}
+	if trigger == nil || !scope.Contains(Scope{Organization: trigger.(*TerraformRunTrigger).Organization, Project: trigger.(*TerraformRunTrigger).Project}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerNotFound
+	}
+	return *trigger.(*TerraformRunTrigger), nil
+}
+
+// DeleteTerraformRunTrigger removes a run trigger and returns it.
+func (s *Storer) DeleteTerraformRunTrigger(scope Scope, id string) (TerraformRunTrigger, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	trigger, err := txn.First("terraformRunTrigger", "id", id)
+	if err != nil {
+		return TerraformRunTrigger{}, err
+	}
+	if trigger == nil || !scope.Contains(Scope{Organization: trigger.(*TerraformRunTrigger).Organization, Project: trigger.(*TerraformRunTrigger).Project}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerNotFound
+	}
+	err = txn.Delete("terraformRunTrigger", trigger)
+	if err != nil {
+		return TerraformRunTrigger{}, err
+	}
+	txn.Commit()
+	return *trigger.(*TerraformRunTrigger), nil
+}
+
+// ListTerraformRunTriggers returns the run triggers of the workspace
+// identified by workspaceID, oldest first. triggerType limits them to
+// TerraformRunTriggerInbound or TerraformRunTriggerOutbound triggers; if
+// it's empty, both are returned.
+func (s *Storer) ListTerraformRunTriggers(scope Scope, workspaceID, triggerType string) ([]TerraformRunTrigger, error) {
+	txn := s.txn(false)
+	var triggers []TerraformRunTrigger
+	for _, index := range []string{"workspace", "source"} {
+		if (index == "workspace" && triggerType == TerraformRunTriggerOutbound) || (index == "source" && triggerType == TerraformRunTriggerInbound) {
+			continue
+		}
+		iter, err := txn.Get("terraformRunTrigger", index, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for trigger := iter.Next(); trigger != nil; trigger = iter.Next() {
+			if !trigger.(*TerraformRunTrigger).in(scope, workspaceID) {
+				continue
+			}
+			triggers = append(triggers, *trigger.(*TerraformRunTrigger))
+		}
+	}
+	sort.Slice(triggers, func(i, j int) bool {
+		if triggers[i].CreatedAt.Equal(triggers[j].CreatedAt) {
+			return triggers[i].ID < triggers[j].ID
+		}
+		return triggers[i].CreatedAt.Before(triggers[j].CreatedAt)
+	})
+	return triggers, nil
+}
+
+// vaultSecretCluster returns an error if the Vault cluster identified by
+// clusterID doesn't exist in the project identified by scope, or is sealed.
+func vaultSecretCluster(txn *memdb.Txn, scope Scope, clusterID string) error {
+	_, err := vaultLeaseCluster(txn, scope, clusterID)
+	return err
+}
+
+// GetVaultSecret returns the secret at path in the Vault cluster identified
+// by clusterID, including the data of all its versions.
+func (s *Storer) GetVaultSecret(scope Scope, clusterID, path string) (VaultSecret, error) {
+	txn := s.txn(false)
+	err := vaultSecretCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	secret, err := txn.First("vaultSecret", "id", clusterID, path)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	if secret == nil {
+		return VaultSecret{}, ErrVaultSecretNotFound
+	}
+	return *secret.(*VaultSecret), nil
+}
+
+// WriteVaultSecret adds a new version to the secret at path in the Vault
+// cluster identified by clusterID, creating the secret if it doesn't exist.
+func (s *Storer) WriteVaultSecret(scope Scope, clusterID, path string, write VaultSecretWrite) (VaultSecret, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := vaultSecretCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	existing, err := txn.First("vaultSecret", "id", clusterID, path)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	now := time.Now()
+	secret := VaultSecret{
+		ClusterID:    clusterID,
+		Organization: scope.Organization,
+		Project:      scope.Project,
+		Path:         path,
+		CreatedAt:    now,
+	}
+	if existing != nil {
+		secret = *existing.(*VaultSecret)
+	}
+	if write.CAS != nil && *write.CAS != secret.CurrentVersion {
+		return VaultSecret{}, ErrVaultSecretCASMismatch
+	}
+	// version numbers keep counting up even if the latest versions were
+	// destroyed, so they're never reused
+	version := VaultSecretVersion{
+		Version:   len(secret.Versions) + 1,
+		Data:      write.Data,
+		CreatedAt: now,
+	}
+	secret.Versions = append(append([]VaultSecretVersion{}, secret.Versions...), version)
+	secret.CurrentVersion = version.Version
+	secret.UpdatedAt = now
+	err = txn.Insert("vaultSecret", &secret)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	txn.Commit()
+	return secret, nil
+}
+
+// changeVaultSecretVersions calls change on a copy of each of the listed
+// versions of the secret at path, or its current version if none are
+// listed, and stores the results. It returns ErrVaultSecretVersionNotFound if
+// any of the versions don't exist.
+func (s *Storer) changeVaultSecretVersions(scope Scope, clusterID, path string, versions []int, change func(*VaultSecretVersion)) (VaultSecret, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := vaultSecretCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	existing, err := txn.First("vaultSecret", "id", clusterID, path)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	if existing == nil {
+		return VaultSecret{}, ErrVaultSecretNotFound
+	}
+	secret := *existing.(*VaultSecret)
+	secret.Versions = append([]VaultSecretVersion{}, secret.Versions...)
+	if len(versions) < 1 {
+		versions = []int{secret.CurrentVersion}
+	}
+	for _, v := range versions {
+		if v < 1 || v > len(secret.Versions) {
+			return VaultSecret{}, ErrVaultSecretVersionNotFound
+		}
+		change(&secret.Versions[v-1])
+	}
+	secret.UpdatedAt = time.Now()
+	err = txn.Insert("vaultSecret", &secret)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	txn.Commit()
+	return secret, nil
+}
+
+// DeleteVaultSecretVersions soft-deletes versions of the secret at path,
+// defaulting to its current version if versions is empty. Their data is kept
+// so they can be undeleted.
+func (s *Storer) DeleteVaultSecretVersions(scope Scope, clusterID, path string, versions []int) (VaultSecret, error) {
+	now := time.Now()
+	return s.changeVaultSecretVersions(scope, clusterID, path, versions, func(version *VaultSecretVersion) {
+		if version.DeletedAt == nil && !version.Destroyed {
+			version.DeletedAt = &now
+		}
+	})
+}
+
+// UndeleteVaultSecretVersions restores soft-deleted versions of the secret at
+// path. Destroyed versions can't be restored, and are left alone.
+func (s *Storer) UndeleteVaultSecretVersions(scope Scope, clusterID, path string, versions []int) (VaultSecret, error) {
+	return s.changeVaultSecretVersions(scope, clusterID, path, versions, func(version *VaultSecretVersion) {
+		if !version.Destroyed {
+			version.DeletedAt = nil
+		}
+	})
+}
+
+// DestroyVaultSecretVersions permanently removes the data of versions of the
+// secret at path.
+func (s *Storer) DestroyVaultSecretVersions(scope Scope, clusterID, path string, versions []int) (VaultSecret, error) {
+	return s.changeVaultSecretVersions(scope, clusterID, path, versions, func(version *VaultSecretVersion) {
+		version.Data = nil
+		version.Destroyed = true
+	})
+}
+
+// DeleteVaultSecret removes the secret at path and all its versions, and
+// returns it.
+func (s *Storer) DeleteVaultSecret(scope Scope, clusterID, path string) (VaultSecret, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	err := vaultSecretCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	secret, err := txn.First("vaultSecret", "id", clusterID, path)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	if secret == nil {
+		return VaultSecret{}, ErrVaultSecretNotFound
+	}
+	err = txn.Delete("vaultSecret", secret)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	txn.Commit()
+	return *secret.(*VaultSecret), nil
+}
+
+// ListVaultSecrets returns the secrets in the Vault cluster identified by
+// clusterID whose paths start with prefix, sorted by path.
+func (s *Storer) ListVaultSecrets(scope Scope, clusterID, prefix string) ([]VaultSecret, error) {
+	txn := s.txn(false)
+	err := vaultSecretCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("vaultSecret", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []VaultSecret
+	for secret := iter.Next(); secret != nil; secret = iter.Next() {
+		if !strings.HasPrefix(secret.(*VaultSecret).Path, prefix) {
+			continue
+		}
+		results = append(results, *secret.(*VaultSecret))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results, nil
+}
+
+// vaultLeaseCluster returns the Vault cluster identified by clusterID, or an
+// error if it doesn't exist in the project identified by scope, or is
+// sealed.
+func vaultLeaseCluster(txn *memdb.Txn, scope Scope, clusterID string) (VaultCluster, error) {
+	cluster, err := txn.First("vaultCluster", "id", clusterID)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	if cluster.(*VaultCluster).SealStatus.Sealed {
+		return VaultCluster{}, ErrVaultClusterSealed
+	}
+	return *cluster.(*VaultCluster), nil
+}
+
+// vaultLease returns the unexpired lease identified by leaseID in the Vault
+// cluster identified by clusterID.
+func vaultLease(txn *memdb.Txn, clusterID, leaseID string, now time.Time) (VaultLease, error) {
+	lease, err := txn.First("vaultLease", "id", leaseID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	if lease == nil || !strings.EqualFold(lease.(*VaultLease).ClusterID, clusterID) || lease.(*VaultLease).expired(now) {
+		return VaultLease{}, ErrVaultLeaseNotFound
+	}
+	return *lease.(*VaultLease), nil
+}
+
+// IssueVaultLease stores a new lease for the Vault cluster identified by
+// lease.ClusterID. The lease lasts for ttl, or the cluster's DefaultLeaseTTL
+// if ttl is 0, but never longer than the cluster's MaxLeaseTTL.
+func (s *Storer) IssueVaultLease(lease VaultLease, ttl time.Duration) (VaultLease, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := vaultLeaseCluster(txn, Scope{Organization: lease.Organization, Project: lease.Project}, lease.ClusterID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	defaultTTL, maxTTL, err := cluster.leaseTTLs()
+	if err != nil {
+		return VaultLease{}, err
+	}
+	lease.IssuedAt = time.Now()
+	clampVaultLease(&lease, lease.IssuedAt, ttl, defaultTTL, maxTTL)
+	err = txn.Insert("vaultLease", &lease)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	txn.Commit()
+	return lease, nil
+}
+
+// GetVaultLease returns the unexpired lease identified by leaseID in the
+// Vault cluster identified by clusterID.
+func (s *Storer) GetVaultLease(scope Scope, clusterID, leaseID string) (VaultLease, error) {
+	txn := s.txn(false)
+	_, err := vaultLeaseCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	return vaultLease(txn, clusterID, leaseID, time.Now())
+}
+
+// ListVaultLeases returns the unexpired leases in the Vault cluster
+// identified by clusterID, soonest to expire first.
+func (s *Storer) ListVaultLeases(scope Scope, clusterID string) ([]VaultLease, error) {
+	txn := s.txn(false)
+	_, err := vaultLeaseCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("vaultLease", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var results []VaultLease
+	for lease := iter.Next(); lease != nil; lease = iter.Next() {
+		if lease.(*VaultLease).expired(now) {
+			continue
+		}
+		results = append(results, *lease.(*VaultLease))
+	}
+	sortVaultLeases(results)
+	return results, nil
+}
+
+// RenewVaultLease extends the lease identified by leaseID so it expires
+// increment from now, or the cluster's DefaultLeaseTTL from now if increment
+// is 0, but never more than the cluster's MaxLeaseTTL after it was issued.
+func (s *Storer) RenewVaultLease(scope Scope, clusterID, leaseID string, increment time.Duration) (VaultLease, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := vaultLeaseCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	defaultTTL, maxTTL, err := cluster.leaseTTLs()
+	if err != nil {
+		return VaultLease{}, err
+	}
+	now := time.Now()
+	lease, err := vaultLease(txn, clusterID, leaseID, now)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	clampVaultLease(&lease, now, increment, defaultTTL, maxTTL)
+	err = txn.Insert("vaultLease", &lease)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	txn.Commit()
+	return lease, nil
+}
+
+// RevokeVaultLease removes the lease identified by leaseID before it expires,
+// returning it as it was before it was revoked.
+func (s *Storer) RevokeVaultLease(scope Scope, clusterID, leaseID string) (VaultLease, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	_, err := vaultLeaseCluster(txn, scope, clusterID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	lease, err := vaultLease(txn, clusterID, leaseID, time.Now())
+	if err != nil {
+		return VaultLease{}, err
+	}
+	_, err = txn.DeleteAll("vaultLease", "id", lease.ID)
+	if err != nil {
+		return VaultLease{}, err
+	}
+	txn.Commit()
+	return lease, nil
+}
+
+// ExpireVaultLeases removes every lease that had expired at now, returning
+// the number of leases removed.
+func (s *Storer) ExpireVaultLeases(now time.Time) (int, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("vaultLease", "id")
+	if err != nil {
+		return 0, err
+	}
+	var expired []*VaultLease
+	for lease := iter.Next(); lease != nil; lease = iter.Next() {
+		if lease.(*VaultLease).expired(now) {
+			expired = append(expired, lease.(*VaultLease))
+		}
+	}
+	for _, lease := range expired {
+		err = txn.Delete("vaultLease", lease)
+		if err != nil {
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(expired), nil
+}
+
+// vaultSealCluster returns the Vault cluster identified by id and the
+// hashes of its keys, or an error if it doesn't exist in the project
+// identified by scope or hasn't been initialized.
+func vaultSealCluster(txn *memdb.Txn, scope Scope, id string) (VaultCluster, vaultSeal, error) {
+	existing, err := txn.First("vaultCluster", "id", id)
+	if err != nil {
+		return VaultCluster{}, vaultSeal{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return VaultCluster{}, vaultSeal{}, ErrVaultClusterNotFound
+	}
+	cluster := *existing.(*VaultCluster)
+	if !cluster.SealStatus.Initialized {
+		return VaultCluster{}, vaultSeal{}, ErrVaultClusterNotInitialized
+	}
+	seal, err := txn.First("vaultSeal", "id", cluster.ID)
+	if err != nil {
+		return VaultCluster{}, vaultSeal{}, err
+	}
+	if seal == nil {
+		return VaultCluster{}, vaultSeal{}, ErrVaultClusterNotInitialized
+	}
+	return cluster, *seal.(*vaultSeal), nil
+}
+
+// InitVaultCluster initializes the Vault cluster identified by
+// seal.ClusterID, which can then be unsealed with threshold of the keys
+// hashed in seal. The cluster starts out sealed.
+func (s *Storer) InitVaultCluster(scope Scope, seal vaultSeal, threshold int) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("vaultCluster", "id", seal.ClusterID)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !recordVisible(scope, existing) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	cluster := *existing.(*VaultCluster)
+	if cluster.SealStatus.Initialized {
+		return VaultCluster{}, ErrVaultClusterAlreadyInitialized
+	}
+	seal.ClusterID = cluster.ID
+	cluster.SealStatus = VaultClusterSealStatus{
+		Initialized:     true,
+		Sealed:          true,
+		SecretShares:    len(seal.KeyHashes),
+		SecretThreshold: threshold,
+	}
+	err = txn.Insert("vaultSeal", &seal)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+// UnsealVaultCluster adds the key hashed as keyHash to the keys provided to
+// the Vault cluster identified by id, unsealing it once it has its threshold
+// of different keys. An empty keyHash discards the keys provided so far.
+// Unsealing a cluster that isn't sealed does nothing.
+func (s *Storer) UnsealVaultCluster(scope Scope, id, keyHash string) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, seal, err := vaultSealCluster(txn, scope, id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if !cluster.SealStatus.Sealed {
+		return cluster, nil
+	}
+	if keyHash == "" {
+		seal.Provided = nil
+	} else {
+		var valid bool
+		for _, candidate := range seal.KeyHashes {
+			if candidate == keyHash {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return VaultCluster{}, ErrVaultUnsealKeyInvalid
+		}
+		var provided bool
+		for _, candidate := range seal.Provided {
+			if candidate == keyHash {
+				provided = true
+				break
+			}
+		}
+		if !provided {
+			seal.Provided = append(seal.Provided, keyHash)
+		}
+	}
+	cluster.SealStatus.UnsealProgress = len(seal.Provided)
+	if cluster.SealStatus.UnsealProgress >= cluster.SealStatus.SecretThreshold {
+		cluster.SealStatus.Sealed = false
+		cluster.SealStatus.UnsealProgress = 0
+		seal.Provided = nil
+	}
+	err = txn.Insert("vaultSeal", &seal)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+// SealVaultCluster seals the Vault cluster identified by id, as long as
+// tokenHash is the hash of its root token. Sealing a cluster that's already
+// sealed discards any unseal keys provided so far.
+func (s *Storer) SealVaultCluster(scope Scope, id, tokenHash string) (VaultCluster, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, seal, err := vaultSealCluster(txn, scope, id)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	if seal.RootTokenHash != tokenHash {
+		return VaultCluster{}, ErrVaultRootTokenInvalid
+	}
+	cluster.SealStatus.Sealed = true
+	cluster.SealStatus.UnsealProgress = 0
+	seal.Provided = nil
+	err = txn.Insert("vaultSeal", &seal)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	err = txn.Insert("vaultCluster", &cluster)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	txn.Commit()
+	return cluster, nil
+}
+
+// assignVaultListener returns an error if the cluster's TCP listener
+// addresses conflict with each other or with those of any other cluster in
+// the same region, assigning free ports to any that use port 0. The ports in
+// previous, the cluster's listener before this change, are reused where
+// possible. Deleted clusters don't hold on to their addresses.
+func assignVaultListener(txn *memdb.Txn, cluster *VaultCluster, previous VaultClusterTCPListener) error {
+	address, ok := parseVaultListenerAddress(cluster.TCPListener.Address)
+	if !ok {
+		return ErrVaultClusterAddressInvalid
+	}
+	clusterAddress, ok := parseVaultListenerAddress(cluster.TCPListener.ClusterAddress)
+	if !ok {
+		return ErrVaultClusterClusterAddressInvalid
+	}
+	iter, err := txn.Get("vaultCluster", "id")
+	if err != nil {
+		return err
+	}
+	var taken []vaultListenerAddress
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		other := record.(*VaultCluster)
+		if other.DeletedAt != nil || other.Region != cluster.Region || strings.EqualFold(other.ID, cluster.ID) {
+			continue
+		}
+		for _, a := range []string{other.TCPListener.Address, other.TCPListener.ClusterAddress} {
+			if addr, ok := parseVaultListenerAddress(a); ok {
+				taken = append(taken, addr)
+			}
+		}
+	}
+	address, ok = assignVaultListenerPort(address, taken, previous.Address, defaultVaultAddressPort)
+	if !ok {
+		return ErrVaultClusterAddressConflict
+	}
+	taken = append(taken, address)
+	clusterAddress, ok = assignVaultListenerPort(clusterAddress, taken, previous.ClusterAddress, defaultVaultClusterAddressPort)
+	if !ok {
+		return ErrVaultClusterClusterAddressConflict
+	}
+	cluster.TCPListener.Address = address.String()
+	cluster.TCPListener.ClusterAddress = clusterAddress.String()
+	return nil
+}
+
+// assignVaultListenerPort returns addr if it doesn't conflict with any of
+// the addresses in taken. If addr uses port 0, it's given the port from
+// previous if that's on the same host and free, or else the first free port
+// from first up. It returns false if there's a conflict or no free port.
+func assignVaultListenerPort(addr vaultListenerAddress, taken []vaultListenerAddress, previous string, first int) (vaultListenerAddress, bool) {
+	free := func(candidate vaultListenerAddress) bool {
+		for _, t := range taken {
+			if candidate.conflicts(t) {
+				return false
+			}
+		}
+		return true
+	}
+	if addr.Port != 0 {
+		return addr, free(addr)
+	}
+	if prev, ok := parseVaultListenerAddress(previous); ok && prev.Port != 0 && strings.EqualFold(prev.Host, addr.Host) {
+		candidate := vaultListenerAddress{Host: addr.Host, Port: prev.Port}
+		if free(candidate) {
+			return candidate, true
+		}
+	}
+	for port := first; port <= maxPort; port++ {
+		candidate := vaultListenerAddress{Host: addr.Host, Port: port}
+		if free(candidate) {
+			return candidate, true
+		}
+	}
+	return vaultListenerAddress{}, false
+}
+
+// consulKVCluster returns the Consul cluster identified by clusterID, or an
+// error if it doesn't exist in the project identified by scope.
+func consulKVCluster(txn *memdb.Txn, scope Scope, clusterID string) (ConsulCluster, error) {
+	cluster, err := txn.First("consulCluster", "id", clusterID)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	return *cluster.(*ConsulCluster), nil
+}
+
+// nextConsulIndex increments the index of the Consul cluster identified by
+// clusterID and returns it.
+func nextConsulIndex(txn *memdb.Txn, clusterID string) (uint64, error) {
+	index := consulIndex{ClusterID: clusterID}
+	existing, err := txn.First("consulIndex", "id", clusterID)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		index = *existing.(*consulIndex)
+	}
+	index.Index++
+	err = txn.Insert("consulIndex", &index)
+	if err != nil {
+		return 0, err
+	}
+	return index.Index, nil
+}
+
+// consulKVPair returns the key in the Consul cluster identified by
+// clusterID.
+func consulKVPair(txn *memdb.Txn, clusterID, key string) (ConsulKVPair, error) {
+	pair, err := txn.First("consulKVPair", "id", clusterID, key)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	if pair == nil {
+		return ConsulKVPair{}, ErrConsulKVPairNotFound
+	}
+	return *pair.(*ConsulKVPair), nil
+}
+
+// consulSession returns the unexpired session identified by sessionID in the
+// Consul cluster identified by clusterID.
+func consulSession(txn *memdb.Txn, clusterID, sessionID string, now time.Time) (ConsulSession, error) {
+	session, err := txn.First("consulSession", "id", sessionID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	if session == nil || !strings.EqualFold(session.(*ConsulSession).ClusterID, clusterID) || session.(*ConsulSession).expired(now) {
+		return ConsulSession{}, ErrConsulSessionNotFound
+	}
+	return *session.(*ConsulSession), nil
+}
+
+// invalidateConsulSession removes session, releasing the keys it holds, or
+// deleting them if the session's Behavior is delete.
+func invalidateConsulSession(txn *memdb.Txn, session ConsulSession) error {
+	iter, err := txn.Get("consulKVPair", "session", session.ID)
+	if err != nil {
+		return err
+	}
+	var held []ConsulKVPair
+	for pair := iter.Next(); pair != nil; pair = iter.Next() {
+		held = append(held, *pair.(*ConsulKVPair))
+	}
+	if len(held) > 0 {
+		index, err := nextConsulIndex(txn, session.ClusterID)
+		if err != nil {
+			return err
+		}
+		for _, pair := range held {
+			if session.Behavior == consulSessionBehaviorDelete {
+				_, err = txn.DeleteAll("consulKVPair", "id", pair.ClusterID, pair.Key)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			pair.Session = ""
+			pair.ModifyIndex = index
+			err = txn.Insert("consulKVPair", &pair)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = txn.DeleteAll("consulSession", "id", session.ID)
+	return err
+}
+
+// expireConsulSessions invalidates every session in the Consul cluster
+// identified by clusterID, or in every cluster if clusterID is empty, that
+// had expired at now. It returns the number of sessions invalidated.
+func expireConsulSessions(txn *memdb.Txn, clusterID string, now time.Time) (int, error) {
+	var iter memdb.ResultIterator
+	var err error
+	if clusterID == "" {
+		iter, err = txn.Get("consulSession", "id")
+	} else {
+		iter, err = txn.Get("consulSession", "cluster", clusterID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var expired []ConsulSession
+	for session := iter.Next(); session != nil; session = iter.Next() {
+		if session.(*ConsulSession).expired(now) {
+			expired = append(expired, *session.(*ConsulSession))
+		}
+	}
+	for _, session := range expired {
+		err = invalidateConsulSession(txn, session)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// GetConsulKVPair returns key from the Consul cluster identified by
+// clusterID.
+func (s *Storer) GetConsulKVPair(scope Scope, clusterID, key string) (ConsulKVPair, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	return consulKVPair(txn, clusterID, key)
+}
+
+// ListConsulKVPairs returns every key that starts with prefix in the Consul
+// cluster identified by clusterID, sorted by key.
+func (s *Storer) ListConsulKVPairs(scope Scope, clusterID, prefix string) ([]ConsulKVPair, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("consulKVPair", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []ConsulKVPair
+	for pair := iter.Next(); pair != nil; pair = iter.Next() {
+		if !strings.HasPrefix(pair.(*ConsulKVPair).Key, prefix) {
+			continue
+		}
+		results = append(results, *pair.(*ConsulKVPair))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Key < results[j].Key
+	})
+	return results, nil
+}
+
+// WriteConsulKVPair writes key in the Consul cluster identified by
+// clusterID, creating it if it doesn't exist. If write.CAS is set, the write
+// fails with ErrConsulKVCASMismatch unless it matches the key's ModifyIndex,
+// or is 0 and the key doesn't exist. If write.Acquire is set, the session it
+// identifies takes the key's lock, failing with ErrConsulKVLockHeld if
+// another session holds it; if write.Release is set, the session it
+// identifies gives the lock up, failing with ErrConsulKVLockNotHeld if it
+// doesn't hold it.
+func (s *Storer) WriteConsulKVPair(scope Scope, clusterID, key string, write ConsulKVWrite) (ConsulKVPair, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	now := time.Now()
+	_, err = expireConsulSessions(txn, cluster.ID, now)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	pair, err := consulKVPair(txn, cluster.ID, key)
+	exists := err == nil
+	if err != nil && err != ErrConsulKVPairNotFound {
+		return ConsulKVPair{}, err
+	}
+	if write.CAS != nil {
+		if (*write.CAS == 0 && exists) || (*write.CAS != 0 && (!exists || pair.ModifyIndex != *write.CAS)) {
+			return ConsulKVPair{}, ErrConsulKVCASMismatch
+		}
+	}
+	if !exists {
+		pair = ConsulKVPair{
+			ClusterID:    cluster.ID,
+			Organization: cluster.Organization,
+			Project:      cluster.Project,
+			Key:          key,
+		}
+	}
+	if write.Acquire != "" {
+		session, err := consulSession(txn, cluster.ID, write.Acquire, now)
+		if err != nil {
+			return ConsulKVPair{}, err
+		}
+		if pair.Session != "" && !strings.EqualFold(pair.Session, session.ID) {
+			return ConsulKVPair{}, ErrConsulKVLockHeld
+		}
+		if pair.Session == "" {
+			pair.LockIndex++
+		}
+		pair.Session = session.ID
+	}
+	if write.Release != "" {
+		session, err := consulSession(txn, cluster.ID, write.Release, now)
+		if err != nil {
+			return ConsulKVPair{}, err
+		}
+		if !strings.EqualFold(pair.Session, session.ID) {
+			return ConsulKVPair{}, ErrConsulKVLockNotHeld
+		}
+		pair.Session = ""
+	}
+	index, err := nextConsulIndex(txn, cluster.ID)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	if !exists {
+		pair.CreateIndex = index
+	}
+	pair.ModifyIndex = index
+	pair.Value = write.Value
+	pair.Flags = write.Flags
+	err = txn.Insert("consulKVPair", &pair)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	txn.Commit()
+	return pair, nil
+}
+
+// DeleteConsulKVPairs deletes key from the Consul cluster identified by
+// clusterID, or every key that starts with key if recurse is true, returning
+// the keys deleted. If cas is set, key is only deleted if cas matches its
+// ModifyIndex; otherwise ErrConsulKVCASMismatch is returned.
+func (s *Storer) DeleteConsulKVPairs(scope Scope, clusterID, key string, recurse bool, cas *uint64) ([]ConsulKVPair, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []ConsulKVPair
+	if recurse {
+		iter, err := txn.Get("consulKVPair", "cluster", cluster.ID)
+		if err != nil {
+			return nil, err
+		}
+		for pair := iter.Next(); pair != nil; pair = iter.Next() {
+			if strings.HasPrefix(pair.(*ConsulKVPair).Key, key) {
+				deleted = append(deleted, *pair.(*ConsulKVPair))
+			}
+		}
+	} else {
+		pair, err := consulKVPair(txn, cluster.ID, key)
+		if err != nil {
+			return nil, err
+		}
+		if cas != nil && pair.ModifyIndex != *cas {
+			return nil, ErrConsulKVCASMismatch
+		}
+		deleted = append(deleted, pair)
+	}
+	for _, pair := range deleted {
+		_, err = txn.DeleteAll("consulKVPair", "id", pair.ClusterID, pair.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(deleted) > 0 {
+		_, err = nextConsulIndex(txn, cluster.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		return deleted[i].Key < deleted[j].Key
+	})
+	txn.Commit()
+	return deleted, nil
+}
+
+// CreateConsulSession stores a new session for the Consul cluster
+// identified by session.ClusterID. Sessions with a TTL expire that long
+// after they're created or last renewed.
+func (s *Storer) CreateConsulSession(session ConsulSession) (ConsulSession, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	_, err := consulKVCluster(txn, Scope{Organization: session.Organization, Project: session.Project}, session.ClusterID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	session.CreateIndex, err = nextConsulIndex(txn, session.ClusterID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	session.CreatedAt = time.Now()
+	err = session.resetExpiry(session.CreatedAt)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	err = txn.Insert("consulSession", &session)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	txn.Commit()
+	return session, nil
+}
+
+// GetConsulSession returns the unexpired session identified by sessionID in
+// the Consul cluster identified by clusterID.
+func (s *Storer) GetConsulSession(scope Scope, clusterID, sessionID string) (ConsulSession, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	return consulSession(txn, clusterID, sessionID, time.Now())
+}
+
+// ListConsulSessions returns the unexpired sessions in the Consul cluster
+// identified by clusterID, oldest first.
+func (s *Storer) ListConsulSessions(scope Scope, clusterID string) ([]ConsulSession, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("consulSession", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var results []ConsulSession
+	for session := iter.Next(); session != nil; session = iter.Next() {
+		if session.(*ConsulSession).expired(now) {
+			continue
+		}
+		results = append(results, *session.(*ConsulSession))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreateIndex < results[j].CreateIndex
+	})
+	return results, nil
+}
+
+// RenewConsulSession resets the TTL of the session identified by sessionID.
+func (s *Storer) RenewConsulSession(scope Scope, clusterID, sessionID string) (ConsulSession, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	now := time.Now()
+	session, err := consulSession(txn, clusterID, sessionID, now)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	err = session.resetExpiry(now)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	err = txn.Insert("consulSession", &session)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	txn.Commit()
+	return session, nil
+}
+
+// DestroyConsulSession removes the session identified by sessionID,
+// releasing or deleting the keys it holds, and returns it as it was before it
+// was destroyed.
+func (s *Storer) DestroyConsulSession(scope Scope, clusterID, sessionID string) (ConsulSession, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	session, err := consulSession(txn, clusterID, sessionID, time.Now())
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	err = invalidateConsulSession(txn, session)
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	txn.Commit()
+	return session, nil
+}
+
+// ExpireConsulSessions invalidates every session that had expired at now,
+// releasing or deleting the keys they hold, and returns the number of
+// sessions invalidated.
+func (s *Storer) ExpireConsulSessions(now time.Time) (int, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	expired, err := expireConsulSessions(txn, "", now)
+	if err != nil {
+		return 0, err
+	}
+	txn.Commit()
+	return expired, nil
+}
+
+// consulCatalogService returns the service instance identified by serviceID
+// in the Consul cluster identified by clusterID.
+func consulCatalogService(txn *memdb.Txn, clusterID, serviceID string) (ConsulCatalogService, error) {
+	service, err := txn.First("consulCatalogService", "id", clusterID, serviceID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	if service == nil {
+		return ConsulCatalogService{}, ErrConsulCatalogServiceNotFound
+	}
+	return *service.(*ConsulCatalogService), nil
+}
+
+// RegisterConsulCatalogService stores a service instance in the catalog of
+// the Consul cluster identified by service.ClusterID, replacing any instance
+// with the same ID, and reports whether it's a new instance. Instances
+// without an Address use the cluster's BindAddr, or 127.0.0.1 if the cluster
+// doesn't have one that can be reached.
+func (s *Storer) RegisterConsulCatalogService(service ConsulCatalogService) (ConsulCatalogService, bool, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := consulKVCluster(txn, Scope{Organization: service.Organization, Project: service.Project}, service.ClusterID)
+	if err != nil {
+		return ConsulCatalogService{}, false, err
+	}
+	service.ClusterID = cluster.ID
+	existing, err := consulCatalogService(txn, cluster.ID, service.ID)
+	created := err == ErrConsulCatalogServiceNotFound
+	if err != nil && !created {
+		return ConsulCatalogService{}, false, err
+	}
+	if service.Address == "" {
+		service.Address = cluster.BindAddr
+		if ip := net.ParseIP(service.Address); ip == nil || ip.IsUnspecified() {
+			service.Address = "127.0.0.1"
+		}
+	}
+	index, err := nextConsulIndex(txn, cluster.ID)
+	if err != nil {
+		return ConsulCatalogService{}, false, err
+	}
+	service.CreateIndex = existing.CreateIndex
+	if created {
+		service.CreateIndex = index
+	}
+	service.ModifyIndex = index
+	service.aggregateStatus()
+	err = txn.Insert("consulCatalogService", &service)
+	if err != nil {
+		return ConsulCatalogService{}, false, err
+	}
+	txn.Commit()
+	return service, created, nil
+}
+
+// GetConsulCatalogService returns the service instance identified by
+// serviceID in the Consul cluster identified by clusterID.
+func (s *Storer) GetConsulCatalogService(scope Scope, clusterID, serviceID string) (ConsulCatalogService, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	return consulCatalogService(txn, clusterID, serviceID)
+}
+
+// ListConsulCatalogServices returns the service instances in the Consul
+// cluster identified by clusterID, sorted by name then ID. If name isn't
+// empty, only instances of the service with that name are returned.
+func (s *Storer) ListConsulCatalogServices(scope Scope, clusterID, name string) ([]ConsulCatalogService, error) {
+	txn := s.txn(false)
+	_, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var iter memdb.ResultIterator
+	if name == "" {
+		iter, err = txn.Get("consulCatalogService", "cluster", clusterID)
+	} else {
+		iter, err = txn.Get("consulCatalogService", "name", clusterID, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var results []ConsulCatalogService
+	for service := iter.Next(); service != nil; service = iter.Next() {
+		results = append(results, *service.(*ConsulCatalogService))
+	}
+	sortConsulCatalogServices(results)
+	return results, nil
+}
+
+// DeregisterConsulCatalogService removes the service instance identified by
+// serviceID from the catalog of the Consul cluster identified by clusterID,
+// returning it as it was before it was removed.
+func (s *Storer) DeregisterConsulCatalogService(scope Scope, clusterID, serviceID string) (ConsulCatalogService, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	service, err := consulCatalogService(txn, cluster.ID, serviceID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	_, err = txn.DeleteAll("consulCatalogService", "id", service.ClusterID, service.ID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	_, err = nextConsulIndex(txn, cluster.ID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	txn.Commit()
+	return service, nil
+}
+
+// UpdateConsulHealthCheck sets the status and output of the health check
+// identified by checkID on the service instance identified by serviceID,
+// and returns the instance with its new health.
+func (s *Storer) UpdateConsulHealthCheck(scope Scope, clusterID, serviceID, checkID string, update ConsulHealthCheckUpdate) (ConsulCatalogService, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := consulKVCluster(txn, scope, clusterID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	service, err := consulCatalogService(txn, cluster.ID, serviceID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	checks := make([]ConsulHealthCheck, len(service.Checks))
+	copy(checks, service.Checks)
+	found := false
+	for pos := range checks {
+		if checks[pos].ID == checkID {
+			checks[pos].Status = update.Status
+			checks[pos].Output = update.Output
+			found = true
+		}
+	}
+	if !found {
+		return ConsulCatalogService{}, ErrConsulHealthCheckNotFound
+	}
+	service.Checks = checks
+	service.aggregateStatus()
+	service.ModifyIndex, err = nextConsulIndex(txn, cluster.ID)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	err = txn.Insert("consulCatalogService", &service)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	txn.Commit()
+	return service, nil
+}
+
+// nomadJobCluster returns the Nomad cluster identified by clusterID, if it's
+// visible in scope.
+func nomadJobCluster(txn *memdb.Txn, scope Scope, clusterID string) (NomadCluster, error) {
+	cluster, err := txn.First("nomadCluster", "id", clusterID)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+	if !recordVisible(scope, cluster) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	return *cluster.(*NomadCluster), nil
+}
+
+// nomadJob returns the job identified by jobID in the Nomad cluster
+// identified by clusterID.
+func nomadJob(txn *memdb.Txn, clusterID, jobID string) (NomadJob, error) {
+	job, err := txn.First("nomadJob", "id", clusterID, jobID)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	if job == nil {
+		return NomadJob{}, ErrNomadJobNotFound
+	}
+	return *job.(*NomadJob), nil
+}
+
+// nomadJobAllocations returns the allocations of the job identified by jobID
+// in the Nomad cluster identified by clusterID, sorted by job version, then
+// task group, then index.
+func nomadJobAllocations(txn *memdb.Txn, clusterID, jobID string) ([]NomadAllocation, error) {
+	iter, err := txn.Get("nomadAllocation", "job", clusterID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	var results []NomadAllocation
+	for alloc := iter.Next(); alloc != nil; alloc = iter.Next() {
+		results = append(results, *alloc.(*NomadAllocation))
+	}
+	sortNomadAllocations(results)
+	return results, nil
+}
+
+// SubmitNomadJob stores job in its Nomad cluster as a pending job, replacing
+// any job with the same ID and returning whether it was created. If the job
+// has no datacenters it runs in the cluster's datacenter, and if it has
+// some, they must include the cluster's. Its node pool must exist in the
+// cluster.
+func (s *Storer) SubmitNomadJob(job NomadJob) (NomadJob, bool, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := nomadJobCluster(txn, Scope{Organization: job.Organization, Project: job.Project}, job.ClusterID)
+	if err != nil {
+		return NomadJob{}, false, err
+	}
+	job.ClusterID = cluster.ID
+	if len(job.Datacenters) < 1 {
+		job.Datacenters = []string{cluster.Datacenter}
+	}
+	var found bool
+	for _, dc := range job.Datacenters {
+		if strings.EqualFold(dc, cluster.Datacenter) {
+			found = true
+		}
+	}
+	if !found {
+		return NomadJob{}, false, ErrNomadJobDatacenterMismatch
+	}
+	_, err = nomadNodePool(txn, cluster.ID, job.NodePool)
+	if err != nil {
+		return NomadJob{}, false, err
+	}
+	existing, err := nomadJob(txn, cluster.ID, job.ID)
+	created := err == ErrNomadJobNotFound
+	if err != nil && !created {
+		return NomadJob{}, false, err
+	}
+	job.Version = 0
+	if !created {
+		job.Version = existing.Version + 1
+	}
+	job.Stop = false
+	job.SubmittedAt = time.Now()
+	job.setStatus(nomadJobPending, "")
+	err = txn.Insert("nomadJob", &job)
+	if err != nil {
+		return NomadJob{}, false, err
+	}
+	txn.Commit()
+	return job, created, nil
+}
+
+// GetNomadJob returns the job identified by jobID in the Nomad cluster
+// identified by clusterID.
+func (s *Storer) GetNomadJob(scope Scope, clusterID, jobID string) (NomadJob, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	return nomadJob(txn, clusterID, jobID)
+}
+
+// ListNomadJobs returns the jobs in the Nomad cluster identified by
+// clusterID, sorted by ID.
+func (s *Storer) ListNomadJobs(scope Scope, clusterID string) ([]NomadJob, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("nomadJob", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []NomadJob
+	for job := iter.Next(); job != nil; job = iter.Next() {
+		results = append(results, *job.(*NomadJob))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// ListNomadAllocations returns the allocations of the job identified by
+// jobID in the Nomad cluster identified by clusterID, sorted by job version,
+// then task group, then index.
+func (s *Storer) ListNomadAllocations(scope Scope, clusterID, jobID string) ([]NomadAllocation, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	job, err := nomadJob(txn, clusterID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return nomadJobAllocations(txn, job.ClusterID, job.ID)
+}
+
+// CancelNomadJob stops the job identified by jobID in the Nomad cluster
+// identified by clusterID. Its allocations are marked to be stopped, and
+// the scheduler stops them and marks the job dead. Canceling a job that's
+// already stopped does nothing.
+func (s *Storer) CancelNomadJob(scope Scope, clusterID, jobID string) (NomadJob, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	job, err := nomadJob(txn, cluster.ID, jobID)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	if job.Stop {
+		return job, nil
+	}
+	job.Stop = true
+	err = txn.Insert("nomadJob", &job)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	allocs, err := nomadJobAllocations(txn, job.ClusterID, job.ID)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	for pos := range allocs {
+		if allocs[pos].DesiredStatus == nomadAllocDesiredStop {
+			continue
+		}
+		allocs[pos].DesiredStatus = nomadAllocDesiredStop
+		allocs[pos].ModifiedAt = time.Now()
+		err = txn.Insert("nomadAllocation", &allocs[pos])
+		if err != nil {
+			return NomadJob{}, err
+		}
+	}
+	txn.Commit()
+	return job, nil
+}
+
+// StepNomadJobs simulates the scheduler of every Nomad cluster, moving each
+// job that isn't dead one step further. Allocations that should stop are
+// completed, pending allocations start running, and running allocations of
+// batch jobs complete. Pending jobs have allocations placed for each of
+// their task groups, if there are nodes for all of them, and running jobs
+// have allocations that were lost or migrated off draining nodes replaced.
+// Jobs are dead once they're stopped or they're batch jobs whose
+// allocations have all completed. Jobs whose cluster has gone away are dead,
+// and their allocations lost. Finally, nodes that are draining and have no
+// allocations left finish draining.
+func (s *Storer) StepNomadJobs() error {
+	txn := s.txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("nomadJob", "id")
+	if err != nil {
+		return err
+	}
+	var jobs []NomadJob
+	for job := iter.Next(); job != nil; job = iter.Next() {
+		if job.(*NomadJob).Status != nomadJobDead {
+			jobs = append(jobs, *job.(*NomadJob))
+		}
+	}
+	for _, job := range jobs {
+		err = stepNomadJob(txn, job)
+		if err != nil {
+			return err
+		}
+	}
+	err = finishNomadNodeDrains(txn)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func stepNomadJob(txn *memdb.Txn, job NomadJob) error {
+	before := job
+	cluster, err := txn.First("nomadCluster", "id", job.ClusterID)
+	if err != nil {
+		return err
+	}
+	clusterGone := cluster == nil || cluster.(*NomadCluster).DeletedAt != nil
+	allocs, err := nomadJobAllocations(txn, job.ClusterID, job.ID)
+	if err != nil {
+		return err
+	}
+	current := 0
+	for pos := range allocs {
+		alloc := &allocs[pos]
+		if alloc.finished() {
+			if alloc.JobVersion == job.Version {
+				current++
+			}
+			continue
+		}
+		switch {
+		case clusterGone:
+			alloc.DesiredStatus = nomadAllocDesiredStop
+			alloc.setClientStatus(nomadAllocLost)
+		case alloc.DesiredStatus == nomadAllocDesiredStop || alloc.JobVersion != job.Version:
+			alloc.DesiredStatus = nomadAllocDesiredStop
+			alloc.setClientStatus(nomadAllocComplete)
+		case alloc.ClientStatus == nomadAllocPending:
+			alloc.setClientStatus(nomadAllocRunning)
+		case alloc.ClientStatus == nomadAllocRunning && job.Type == nomadJobTypeBatch:
+			alloc.setClientStatus(nomadAllocComplete)
+		default:
+			continue
+		}
+		if alloc.JobVersion == job.Version && alloc.finished() {
+			current++
+		}
+		err = txn.Insert("nomadAllocation", alloc)
+		if err != nil {
+			return err
+		}
+	}
+	var placed int
+	for _, alloc := range allocs {
+		if alloc.JobVersion == job.Version {
+			placed++
+		}
+	}
+	if !clusterGone && !job.Stop && job.Status == nomadJobRunning {
+		replaced, err := replaceNomadAllocations(txn, *cluster.(*NomadCluster), job, allocs)
+		if err != nil {
+			return err
+		}
+		placed += replaced
+	}
+	switch {
+	case clusterGone:
+		job.setStatus(nomadJobDead, "cluster was deleted")
+	case job.Stop:
+		job.setStatus(nomadJobDead, "canceled")
+	case job.Status == nomadJobPending:
+		var slots []nomadSlot
+		for _, group := range job.TaskGroups {
+			for i := 0; i < group.Count; i++ {
+				slots = append(slots, nomadSlot{group: group, index: i})
+			}
+		}
+		planned, problem, err := planNomadAllocations(txn, *cluster.(*NomadCluster), job, slots)
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			if job.StatusDescription != problem {
+				job.setStatus(nomadJobPending, problem)
+			}
+			break
+		}
+		for pos := range planned {
+			err = txn.Insert("nomadAllocation", &planned[pos])
+			if err != nil {
+				return err
+			}
+		}
+		job.setStatus(nomadJobRunning, "")
+	case job.Type == nomadJobTypeBatch && placed > 0 && current == placed:
+		job.setStatus(nomadJobDead, "all allocations completed")
+	}
+	if job.Status == before.Status && job.StatusDescription == before.StatusDescription {
+		return nil
+	}
+	return txn.Insert("nomadJob", &job)
+}
+
+// nomadSlot is an allocation of a job's task group the scheduler needs to
+// place.
+type nomadSlot struct {
+	group NomadTaskGroup
+	index int
+}
+
+// nomadClusterNodes returns the client nodes registered with the Nomad
+// cluster identified by clusterID, sorted by name, then ID.
+func nomadClusterNodes(txn *memdb.Txn, clusterID string) ([]NomadNode, error) {
+	iter, err := txn.Get("nomadNode", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []NomadNode
+	for node := iter.Next(); node != nil; node = iter.Next() {
+		nodes = append(nodes, *node.(*NomadNode))
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Name == nodes[j].Name {
+			return nodes[i].ID < nodes[j].ID
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+// nomadNodeUsage returns the resources the allocations that haven't
+// finished in the Nomad cluster identified by clusterID are using on each
+// node, by node ID.
+func nomadNodeUsage(txn *memdb.Txn, clusterID string) (map[string]NomadResources, error) {
+	iter, err := txn.Get("nomadAllocation", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	usage := map[string]NomadResources{}
+	for alloc := iter.Next(); alloc != nil; alloc = iter.Next() {
+		a := alloc.(*NomadAllocation)
+		if a.finished() || a.NodeID == "" {
+			continue
+		}
+		usage[a.NodeID] = usage[a.NodeID].add(a.Resources)
+	}
+	return usage, nil
+}
+
+// planNomadAllocations picks a node for each of slots, returning the
+// pending allocations to create. If any of them can't be placed, nothing
+// is returned but a description of the problem. Allocations are only
+// placed on nodes that are eligible, not draining, in one of the job's
+// datacenters and its node pool, meet the constraints of the job and the
+// task group, and have enough resources left, chosen using the node pool's
+// scheduler algorithm. A cluster with no nodes registered is treated as a
+// single node in the default pool, using nomadNodeAttributes.
+func planNomadAllocations(txn *memdb.Txn, cluster NomadCluster, job NomadJob, slots []nomadSlot) ([]NomadAllocation, string, error) {
+	nodes, err := nomadClusterNodes(txn, cluster.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	usage, err := nomadNodeUsage(txn, cluster.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	pool, err := nomadNodePool(txn, cluster.ID, job.NodePool)
+	if err != nil {
+		return nil, "", err
+	}
+	var allocs []NomadAllocation
+	for _, slot := range slots {
+		need := slot.group.resources()
+		if len(nodes) < 1 {
+			if job.NodePool != nomadNodePoolDefault && job.NodePool != nomadNodePoolAll {
+				return nil, "no nodes in node pool " + job.NodePool, nil
+			}
+			if !job.groupRunsOn(slot.group, nomadNodeAttributes(cluster)) {
+				return nil, "no nodes satisfy the constraints of task group " + slot.group.Name, nil
+			}
+			alloc, err := newNomadAllocation(job, slot, NomadNode{}, need)
+			if err != nil {
+				return nil, "", err
+			}
+			allocs = append(allocs, alloc)
+			continue
+		}
+		var feasible bool
+		var best *NomadNode
+		var bestFree NomadResources
+		for pos := range nodes {
+			node := nodes[pos]
+			if !node.schedulable() || !job.runsOn(node) || !job.groupRunsOn(slot.group, node.attributes()) {
+				continue
+			}
+			feasible = true
+			free := node.Resources.sub(usage[node.ID])
+			if !free.fits(need) {
+				continue
+			}
+			if best == nil ||
+				(pool.SchedulerAlgorithm == nomadSchedulerSpread && free.CPU > bestFree.CPU) ||
+				(pool.SchedulerAlgorithm != nomadSchedulerSpread && free.CPU < bestFree.CPU) {
+				best = &nodes[pos]
+				bestFree = free
+			}
+		}
+		if !feasible {
+			return nil, "no eligible nodes satisfy the constraints of task group " + slot.group.Name, nil
+		}
+		if best == nil {
+			return nil, "no eligible nodes have enough resources for task group " + slot.group.Name, nil
+		}
+		usage[best.ID] = usage[best.ID].add(need)
+		alloc, err := newNomadAllocation(job, slot, *best, need)
+		if err != nil {
+			return nil, "", err
+		}
+		allocs = append(allocs, alloc)
+	}
+	return allocs, "", nil
+}
+
+// newNomadAllocation returns a pending allocation for slot on node, which
+// is empty if the cluster has no nodes registered.
+func newNomadAllocation(job NomadJob, slot nomadSlot, node NomadNode, resources NomadResources) (NomadAllocation, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return NomadAllocation{}, err
+	}
+	now := time.Now()
+	return NomadAllocation{
+		ID:            id,
+		Organization:  job.Organization,
+		Project:       job.Project,
+		ClusterID:     job.ClusterID,
+		JobID:         job.ID,
+		JobVersion:    job.Version,
+		TaskGroup:     slot.group.Name,
+		Index:         slot.index,
+		Name:          job.ID + "." + slot.group.Name + "[" + strconv.Itoa(slot.index) + "]",
+		NodeID:        node.ID,
+		NodeName:      node.Name,
+		Resources:     resources,
+		DesiredStatus: nomadAllocDesiredRun,
+		ClientStatus:  nomadAllocPending,
+		CreatedAt:     now,
+		ModifiedAt:    now,
+	}, nil
+}
+
+// replaceNomadAllocations places new allocations for the running job's
+// allocations that were lost or migrated off a draining node, returning how
+// many it placed. allocs are the job's allocations. If they can't all be
+// placed, none are, and they're tried again on the next step.
+func replaceNomadAllocations(txn *memdb.Txn, cluster NomadCluster, job NomadJob, allocs []NomadAllocation) (int, error) {
+	covered := map[string]bool{}
+	for _, alloc := range allocs {
+		if alloc.JobVersion == job.Version && !alloc.needsReplacing() {
+			covered[alloc.TaskGroup+"["+strconv.Itoa(alloc.Index)+"]"] = true
+		}
+	}
+	var slots []nomadSlot
+	for _, group := range job.TaskGroups {
+		for i := 0; i < group.Count; i++ {
+			if !covered[group.Name+"["+strconv.Itoa(i)+"]"] {
+				slots = append(slots, nomadSlot{group: group, index: i})
+			}
+		}
+	}
+	if len(slots) < 1 {
+		return 0, nil
+	}
+	planned, problem, err := planNomadAllocations(txn, cluster, job, slots)
+	if err != nil || problem != "" {
+		return 0, err
+	}
+	for pos := range planned {
+		err = txn.Insert("nomadAllocation", &planned[pos])
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(planned), nil
+}
+
+// finishNomadNodeDrains stops every draining node that has no allocations
+// left that haven't finished from draining, leaving it ineligible.
+func finishNomadNodeDrains(txn *memdb.Txn) error {
+	iter, err := txn.Get("nomadNode", "id")
+	if err != nil {
+		return err
+	}
+	var draining []NomadNode
+	for node := iter.Next(); node != nil; node = iter.Next() {
+		if node.(*NomadNode).Drain {
+			draining = append(draining, *node.(*NomadNode))
+		}
+	}
+	for pos := range draining {
+		usage, err := nomadNodeUsage(txn, draining[pos].ClusterID)
+		if err != nil {
+			return err
+		}
+		if _, ok := usage[draining[pos].ID]; ok {
+			continue
+		}
+		draining[pos].Drain = false
+		draining[pos].ModifiedAt = time.Now()
+		err = txn.Insert("nomadNode", &draining[pos])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nomadNodeAllocations returns the allocations on the node identified by
+// nodeID in the Nomad cluster identified by clusterID that haven't
+// finished.
+func nomadNodeAllocations(txn *memdb.Txn, clusterID, nodeID string) ([]NomadAllocation, error) {
+	iter, err := txn.Get("nomadAllocation", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []NomadAllocation
+	for alloc := iter.Next(); alloc != nil; alloc = iter.Next() {
+		a := alloc.(*NomadAllocation)
+		if !a.finished() && strings.EqualFold(a.NodeID, nodeID) {
+			results = append(results, *a)
+		}
+	}
+	return results, nil
+}
+
+// nomadNodePool returns the node pool called name in the Nomad cluster
+// identified by clusterID. The built-in default and all pools always exist.
+func nomadNodePool(txn *memdb.Txn, clusterID, name string) (NomadNodePool, error) {
+	if name == nomadNodePoolDefault || name == nomadNodePoolAll {
+		return NomadNodePool{ClusterID: clusterID, Name: name, SchedulerAlgorithm: nomadSchedulerBinpack}, nil
+	}
+	pool, err := txn.First("nomadNodePool", "id", clusterID, name)
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	if pool == nil {
+		return NomadNodePool{}, ErrNomadNodePoolNotFound
+	}
+	return *pool.(*NomadNodePool), nil
+}
+
+// ListNomadNodePools returns the node pools in the Nomad cluster identified
+// by clusterID, sorted by name. The built-in pools aren't included.
+func (s *Storer) ListNomadNodePools(scope Scope, clusterID string) ([]NomadNodePool, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := txn.Get("nomadNodePool", "cluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []NomadNodePool
+	for pool := iter.Next(); pool != nil; pool = iter.Next() {
+		results = append(results, *pool.(*NomadNodePool))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// GetNomadNodePool returns the node pool called name in the Nomad cluster
+// identified by clusterID.
+func (s *Storer) GetNomadNodePool(scope Scope, clusterID, name string) (NomadNodePool, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	return nomadNodePool(txn, clusterID, name)
+}
+
+// CreateNomadNodePool stores a new node pool in its Nomad cluster.
+func (s *Storer) CreateNomadNodePool(pool NomadNodePool) (NomadNodePool, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := nomadJobCluster(txn, Scope{Organization: pool.Organization, Project: pool.Project}, pool.ClusterID)
 	if err != nil {
-		return AccessPolicy{}, err
+		return NomadNodePool{}, err
 	}
-	if ap == nil {
-		return AccessPolicy{}, ErrAccessPolicyNotFound
+	_, err = nomadNodePool(txn, cluster.ID, pool.Name)
+	if err == nil {
+		return NomadNodePool{}, ErrNomadNodePoolAlreadyExists
 	}
-	return *ap.(*AccessPolicy), nil
+	if err != ErrNomadNodePoolNotFound {
+		return NomadNodePool{}, err
+	}
+	pool.ClusterID = cluster.ID
+	pool.CreatedAt = time.Now()
+	pool.ModifiedAt = pool.CreatedAt
+	err = txn.Insert("nomadNodePool", &pool)
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	txn.Commit()
+	return pool, nil
 }
 
-func (s *Storer) CreateAccessPolicy(ap AccessPolicy) error {
-	txn := s.db.Txn(true)
+// UpdateNomadNodePool replaces the description, meta, and scheduler
+// algorithm of an existing node pool.
+func (s *Storer) UpdateNomadNodePool(pool NomadNodePool) (NomadNodePool, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	exists, err := txn.First("accessPolicy", "id", ap.ID)
+	cluster, err := nomadJobCluster(txn, Scope{Organization: pool.Organization, Project: pool.Project}, pool.ClusterID)
 	if err != nil {
-		return err
+		return NomadNodePool{}, err
 	}
-	if exists != nil {
-		return ErrAccessPolicyAlreadyExists
+	existing, err := nomadNodePool(txn, cluster.ID, pool.Name)
+	if err != nil {
+		return NomadNodePool{}, err
 	}
-	err = txn.Insert("accessPolicy", &ap)
+	pool.ClusterID = cluster.ID
+	pool.CreatedAt = existing.CreatedAt
+	pool.ModifiedAt = time.Now()
+	err = txn.Insert("nomadNodePool", &pool)
 	if err != nil {
-		return err
+		return NomadNodePool{}, err
 	}
 	txn.Commit()
-	return nil
+	return pool, nil
 }
 
-func (s *Storer) UpdateAccessPolicy(ap AccessPolicy) error {
-	txn := s.db.Txn(true)
+// DeleteNomadNodePool removes the node pool called name from the Nomad
+// cluster identified by clusterID, returning it. Pools that have nodes in
+// them or jobs that aren't dead using them can't be deleted.
+func (s *Storer) DeleteNomadNodePool(scope Scope, clusterID, name string) (NomadNodePool, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("accessPolicy", "id", ap.ID)
+	cluster, err := nomadJobCluster(txn, scope, clusterID)
 	if err != nil {
-		return err
+		return NomadNodePool{}, err
 	}
-	if existing == nil {
-		return ErrAccessPolicyNotFound
+	if name == nomadNodePoolDefault || name == nomadNodePoolAll {
+		return NomadNodePool{}, ErrNomadNodePoolInUse
 	}
-	err = txn.Insert("accessPolicy", &ap)
+	pool, err := nomadNodePool(txn, cluster.ID, name)
 	if err != nil {
-		return err
+		return NomadNodePool{}, err
+	}
+	nodes, err := nomadClusterNodes(txn, cluster.ID)
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	for _, node := range nodes {
+		if node.NodePool == name {
+			return NomadNodePool{}, ErrNomadNodePoolInUse
+		}
+	}
+	iter, err := txn.Get("nomadJob", "cluster", cluster.ID)
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	for job := iter.Next(); job != nil; job = iter.Next() {
+		if job.(*NomadJob).NodePool == name && job.(*NomadJob).Status != nomadJobDead {
+			return NomadNodePool{}, ErrNomadNodePoolInUse
+		}
+	}
+	err = txn.Delete("nomadNodePool", &pool)
+	if err != nil {
+		return NomadNodePool{}, err
 	}
 	txn.Commit()
-	return nil
+	return pool, nil
+}
+
+// nomadNode returns the node identified by nodeID in the Nomad cluster
+// identified by clusterID.
+func nomadNode(txn *memdb.Txn, clusterID, nodeID string) (NomadNode, error) {
+	node, err := txn.First("nomadNode", "id", nodeID)
+	if err != nil {
+		return NomadNode{}, err
+	}
+	if node == nil || !strings.EqualFold(node.(*NomadNode).ClusterID, clusterID) {
+		return NomadNode{}, ErrNomadNodeNotFound
+	}
+	return *node.(*NomadNode), nil
+}
+
+// ListNomadNodes returns the client nodes registered with the Nomad cluster
+// identified by clusterID, sorted by name.
+func (s *Storer) ListNomadNodes(scope Scope, clusterID string) ([]NomadNode, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return nomadClusterNodes(txn, clusterID)
+}
+
+// GetNomadNode returns the node identified by nodeID in the Nomad cluster
+// identified by clusterID.
+func (s *Storer) GetNomadNode(scope Scope, clusterID, nodeID string) (NomadNode, error) {
+	txn := s.txn(false)
+	_, err := nomadJobCluster(txn, scope, clusterID)
+	if err != nil {
+		return NomadNode{}, err
+	}
+	return nomadNode(txn, clusterID, nodeID)
 }
 
-func (s *Storer) DeleteAccessPolicy(id string) error {
-	txn := s.db.Txn(true)
+// RegisterNomadNode stores node in its Nomad cluster, with a new ID, as an
+// eligible node that isn't draining. Its datacenter defaults to the
+// cluster's, and its node pool must exist.
+func (s *Storer) RegisterNomadNode(node NomadNode) (NomadNode, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("accessPolicy", "id", id)
+	cluster, err := nomadJobCluster(txn, Scope{Organization: node.Organization, Project: node.Project}, node.ClusterID)
 	if err != nil {
-		return err
+		return NomadNode{}, err
 	}
-	if existing == nil {
-		return ErrAccessPolicyNotFound
+	_, err = nomadNodePool(txn, cluster.ID, node.NodePool)
+	if err != nil {
+		return NomadNode{}, err
 	}
-	err = txn.Delete("accessPolicy", existing)
+	node.ID, err = uuid.GenerateUUID()
 	if err != nil {
-		return err
+		return NomadNode{}, err
+	}
+	node.ClusterID = cluster.ID
+	if node.Datacenter == "" {
+		node.Datacenter = cluster.Datacenter
+	}
+	node.Drain = false
+	node.SchedulingEligibility = nomadNodeEligible
+	node.CreatedAt = time.Now()
+	node.ModifiedAt = node.CreatedAt
+	err = txn.Insert("nomadNode", &node)
+	if err != nil {
+		return NomadNode{}, err
 	}
 	txn.Commit()
-	return nil
+	return node, nil
 }
 
-func (s *Storer) GetConsulCluster(id string) (ConsulCluster, error) {
-	txn := s.db.Txn(false)
-	cluster, err := txn.First("consulCluster", "id", id)
+// DeregisterNomadNode removes the node identified by nodeID from the Nomad
+// cluster identified by clusterID, returning it. Its allocations that
+// haven't finished are lost, and the scheduler replaces them.
+func (s *Storer) DeregisterNomadNode(scope Scope, clusterID, nodeID string) (NomadNode, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	cluster, err := nomadJobCluster(txn, scope, clusterID)
 	if err != nil {
-		return ConsulCluster{}, err
+		return NomadNode{}, err
 	}
-	if cluster == nil {
-		return ConsulCluster{}, ErrConsulClusterNotFound
+	node, err := nomadNode(txn, cluster.ID, nodeID)
+	if err != nil {
+		return NomadNode{}, err
 	}
-	return *cluster.(*ConsulCluster), nil
+	allocs, err := nomadNodeAllocations(txn, cluster.ID, node.ID)
+	if err != nil {
+		return NomadNode{}, err
+	}
+	for pos := range allocs {
+		allocs[pos].DesiredStatus = nomadAllocDesiredStop
+		allocs[pos].DesiredDescription = nomadAllocNodeGone
+		allocs[pos].setClientStatus(nomadAllocLost)
+		err = txn.Insert("nomadAllocation", &allocs[pos])
+		if err != nil {
+			return NomadNode{}, err
+		}
+	}
+	err = txn.Delete("nomadNode", &node)
+	if err != nil {
+		return NomadNode{}, err
+	}
+	txn.Commit()
+	return node, nil
 }
 
-func (s *Storer) CreateConsulCluster(cluster ConsulCluster) error {
-	txn := s.db.Txn(true)
+// DrainNomadNode starts or cancels draining the node identified by nodeID
+// in the Nomad cluster identified by clusterID. Starting a drain makes the
+// node ineligible and marks its allocations to be migrated, which the
+// scheduler does by stopping them and placing replacements on other nodes.
+// Canceling a drain makes the node eligible again; allocations already
+// being migrated still are.
+func (s *Storer) DrainNomadNode(scope Scope, clusterID, nodeID string, enable bool) (NomadNode, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	exists, err := txn.First("consulCluster", "id", cluster.ID)
+	cluster, err := nomadJobCluster(txn, scope, clusterID)
 	if err != nil {
-		return err
+		return NomadNode{}, err
 	}
-	if exists != nil {
-		return ErrConsulClusterAlreadyExists
+	node, err := nomadNode(txn, cluster.ID, nodeID)
+	if err != nil {
+		return NomadNode{}, err
 	}
-	err = txn.Insert("consulCluster", &cluster)
+	if node.Drain == enable {
+		return node, nil
+	}
+	node.Drain = enable
+	node.SchedulingEligibility = nomadNodeEligible
+	if enable {
+		node.SchedulingEligibility = nomadNodeIneligible
+		allocs, err := nomadNodeAllocations(txn, cluster.ID, node.ID)
+		if err != nil {
+			return NomadNode{}, err
+		}
+		for pos := range allocs {
+			if allocs[pos].DesiredStatus == nomadAllocDesiredStop {
+				continue
+			}
+			allocs[pos].DesiredStatus = nomadAllocDesiredStop
+			allocs[pos].DesiredDescription = nomadAllocMigrating
+			allocs[pos].ModifiedAt = time.Now()
+			err = txn.Insert("nomadAllocation", &allocs[pos])
+			if err != nil {
+				return NomadNode{}, err
+			}
+		}
+	}
+	node.ModifiedAt = time.Now()
+	err = txn.Insert("nomadNode", &node)
 	if err != nil {
-		return err
+		return NomadNode{}, err
 	}
 	txn.Commit()
-	return nil
+	return node, nil
 }
 
-func (s *Storer) UpdateConsulCluster(cluster ConsulCluster) error {
-	txn := s.db.Txn(true)
+// SetNomadNodeEligibility sets whether the node identified by nodeID in the
+// Nomad cluster identified by clusterID is eligible for new allocations.
+// Making a node ineligible leaves its allocations where they are, and a
+// draining node can't be made eligible.
+func (s *Storer) SetNomadNodeEligibility(scope Scope, clusterID, nodeID string, eligible bool) (NomadNode, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("consulCluster", "id", cluster.ID)
+	cluster, err := nomadJobCluster(txn, scope, clusterID)
 	if err != nil {
-		return err
+		return NomadNode{}, err
 	}
-	if existing == nil {
-		return ErrConsulClusterNotFound
+	node, err := nomadNode(txn, cluster.ID, nodeID)
+	if err != nil {
+		return NomadNode{}, err
 	}
-	err = txn.Insert("consulCluster", &cluster)
+	if eligible && node.Drain {
+		return NomadNode{}, ErrNomadNodeDraining
+	}
+	node.SchedulingEligibility = nomadNodeIneligible
+	if eligible {
+		node.SchedulingEligibility = nomadNodeEligible
+	}
+	node.ModifiedAt = time.Now()
+	err = txn.Insert("nomadNode", &node)
 	if err != nil {
-		return err
+		return NomadNode{}, err
 	}
 	txn.Commit()
-	return nil
+	return node, nil
 }
 
-func (s *Storer) DeleteConsulCluster(id string) error {
-	txn := s.db.Txn(true)
-	defer txn.Abort()
-	existing, err := txn.First("consulCluster", "id", id)
+// resolveNomadJoinAddress returns the IDs of the other clusters visible to
+// cluster that address reaches.
+func resolveNomadJoinAddress(txn *memdb.Txn, cluster NomadCluster, address string) ([]string, error) {
+	iter, err := txn.Get("nomadCluster", "project", cluster.Organization, cluster.Project)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for other := iter.Next(); other != nil; other = iter.Next() {
+		candidate := *other.(*NomadCluster)
+		if candidate.DeletedAt != nil || strings.EqualFold(candidate.ID, cluster.ID) {
+			continue
+		}
+		if candidate.joinedBy(address) {
+			ids = append(ids, candidate.ID)
+		}
+	}
+	return ids, nil
+}
+
+// joinNomadClusters resolves addresses and adds the clusters they reach to
+// join's peers, returning whether any were reached.
+func joinNomadClusters(txn *memdb.Txn, cluster NomadCluster, join *nomadJoin, addresses []string) (bool, error) {
+	var joined bool
+	for _, address := range addresses {
+		ids, err := resolveNomadJoinAddress(txn, cluster, address)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range ids {
+			joined = true
+			var known bool
+			for _, peer := range join.Peers {
+				if strings.EqualFold(peer, id) {
+					known = true
+				}
+			}
+			if !known {
+				join.Peers = append(join.Peers, id)
+			}
+		}
+	}
+	return joined, nil
+}
+
+// startNomadJoin starts cluster joining other clusters, as its agent would
+// when it starts with new ServerJoin settings. The StartJoin addresses are
+// tried once, straight away, and the RetryJoin addresses are tried by
+// StepNomadJoins. Clusters the cluster has already joined stay joined.
+func startNomadJoin(txn *memdb.Txn, cluster NomadCluster, now time.Time) error {
+	join := nomadJoin{ClusterID: cluster.ID}
+	existing, err := txn.First("nomadJoin", "id", cluster.ID)
 	if err != nil {
 		return err
 	}
-	if existing == nil {
-		return ErrConsulClusterNotFound
+	if existing != nil {
+		join.Peers = append(join.Peers, existing.(*nomadJoin).Peers...)
 	}
-	err = txn.Delete("consulCluster", existing)
+	_, err = joinNomadClusters(txn, cluster, &join, cluster.Server.ServerJoin.StartJoin)
 	if err != nil {
 		return err
 	}
+	if len(cluster.Server.ServerJoin.RetryJoin) > 0 {
+		join.NextRetryAt = &now
+	}
+	return txn.Insert("nomadJoin", &join)
+}
+
+// StepNomadJoins simulates Nomad clusters retrying their RetryJoin
+// addresses. Every cluster with a retry due at now tries all of its
+// addresses, and stops retrying once it reaches at least one other cluster.
+// Otherwise it tries again after its RetryInterval, unless it has made
+// RetryMax attempts; a RetryMax of 0 means it never gives up. It returns the
+// number of clusters that joined.
+func (s *Storer) StepNomadJoins(now time.Time) (int, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("nomadJoin", "id")
+	if err != nil {
+		return 0, err
+	}
+	var due []nomadJoin
+	for join := iter.Next(); join != nil; join = iter.Next() {
+		next := join.(*nomadJoin).NextRetryAt
+		if next != nil && !next.After(now) {
+			copied := *join.(*nomadJoin)
+			copied.Peers = append([]string{}, copied.Peers...)
+			due = append(due, copied)
+		}
+	}
+	var joined int
+	for pos := range due {
+		join := &due[pos]
+		cluster, err := txn.First("nomadCluster", "id", join.ClusterID)
+		if err != nil {
+			return 0, err
+		}
+		if cluster == nil || cluster.(*NomadCluster).DeletedAt != nil {
+			continue
+		}
+		serverJoin := cluster.(*NomadCluster).Server.ServerJoin
+		ok, err := joinNomadClusters(txn, *cluster.(*NomadCluster), join, serverJoin.RetryJoin)
+		if err != nil {
+			return 0, err
+		}
+		join.NextRetryAt = nil
+		if ok {
+			joined++
+		} else {
+			join.RetryAttempts++
+			interval, err := time.ParseDuration(serverJoin.RetryInterval)
+			if err == nil && interval > 0 && (serverJoin.RetryMax == 0 || join.RetryAttempts < serverJoin.RetryMax) {
+				next := now.Add(interval)
+				join.NextRetryAt = &next
+			}
+		}
+		err = txn.Insert("nomadJoin", join)
+		if err != nil {
+			return 0, err
+		}
+	}
 	txn.Commit()
-	return nil
+	return joined, nil
 }
 
-func (s *Storer) GetVaultCluster(id string) (VaultCluster, error) {
-	txn := s.db.Txn(false)
-	cluster, err := txn.First("vaultCluster", "id", id)
+// ListNomadMembers returns the members of the gossip pool the Nomad cluster
+// identified by id belongs to, sorted by name. The pool is every cluster
+// that's connected to it through clusters joining each other, in either
+// direction, leaving out deleted clusters.
+func (s *Storer) ListNomadMembers(scope Scope, id string) ([]NomadMember, error) {
+	txn := s.txn(false)
+	existing, err := txn.First("nomadCluster", "id", id)
 	if err != nil {
-		return VaultCluster{}, err
+		return nil, err
 	}
-	if cluster == nil {
-		return VaultCluster{}, ErrVaultClusterNotFound
+	if !recordVisible(scope, existing) {
+		return nil, ErrNomadClusterNotFound
 	}
-	return *cluster.(*VaultCluster), nil
+	cluster := *existing.(*NomadCluster)
+	iter, err := txn.Get("nomadCluster", "project", cluster.Organization, cluster.Project)
+	if err != nil {
+		return nil, err
+	}
+	clusters := map[string]NomadCluster{}
+	for other := iter.Next(); other != nil; other = iter.Next() {
+		if other.(*NomadCluster).DeletedAt == nil {
+			clusters[strings.ToLower(other.(*NomadCluster).ID)] = *other.(*NomadCluster)
+		}
+	}
+	links := map[string][]string{}
+	for key := range clusters {
+		join, err := txn.First("nomadJoin", "id", key)
+		if err != nil {
+			return nil, err
+		}
+		if join == nil {
+			continue
+		}
+		for _, peer := range join.(*nomadJoin).Peers {
+			peer = strings.ToLower(peer)
+			links[key] = append(links[key], peer)
+			links[peer] = append(links[peer], key)
+		}
+	}
+	start := strings.ToLower(cluster.ID)
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	var members []NomadMember
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		members = append(members, clusters[key].member())
+		for _, peer := range links[key] {
+			if _, ok := clusters[peer]; !ok || seen[peer] {
+				continue
+			}
+			seen[peer] = true
+			queue = append(queue, peer)
+		}
+	}
+	sortNomadMembers(members)
+	return members, nil
+}
+
+func (s *Storer) ListIPPools(scope Scope) ([]IPPool, error) {
+	txn := s.txn(false)
+	iter, err := txn.Get("ipPool", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return nil, err
+	}
+	var results []IPPool
+	for pool := iter.Next(); pool != nil; pool = iter.Next() {
+		results = append(results, *pool.(*IPPool))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+func (s *Storer) GetIPPool(scope Scope, id string) (IPPool, error) {
+	txn := s.txn(false)
+	pool, err := txn.First("ipPool", "id", id)
+	if err != nil {
+		return IPPool{}, err
+	}
+	if !recordVisible(scope, pool) {
+		return IPPool{}, ErrIPPoolNotFound
+	}
+	return *pool.(*IPPool), nil
+}
+
+// ipPoolOverlaps reports whether any pool other than pool in the same
+// project and region shares addresses with it.
+func ipPoolOverlaps(txn *memdb.Txn, pool IPPool) (bool, error) {
+	network := pool.network()
+	iter, err := txn.Get("ipPool", "project", pool.Organization, pool.Project)
+	if err != nil {
+		return false, err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		other := record.(*IPPool)
+		if strings.EqualFold(other.ID, pool.ID) || other.Region != pool.Region {
+			continue
+		}
+		otherNetwork := other.network()
+		if otherNetwork == nil {
+			continue
+		}
+		if network.Contains(otherNetwork.IP) || otherNetwork.Contains(network.IP) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (s *Storer) CreateVaultCluster(cluster VaultCluster) error {
-	txn := s.db.Txn(true)
+func (s *Storer) CreateIPPool(pool IPPool) error {
+	txn := s.txn(true)
 	defer txn.Abort()
-	exists, err := txn.First("vaultCluster", "id", cluster.ID)
+	exists, err := txn.First("ipPool", "id", pool.ID)
 	if err != nil {
 		return err
 	}
 	if exists != nil {
-		return ErrVaultClusterAlreadyExists
+		return ErrIPPoolAlreadyExists
 	}
-	err = txn.Insert("vaultCluster", &cluster)
+	taken, err := nameTaken(txn, "ipPool", recordScope(&pool), pool.ID, pool.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrIPPoolNameConflict
+	}
+	overlaps, err := ipPoolOverlaps(txn, pool)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return ErrIPPoolOverlap
+	}
+	err = txn.Insert("ipPool", &pool)
 	if err != nil {
 		return err
 	}
@@ -248,170 +6250,356 @@ func (s *Storer) CreateVaultCluster(cluster VaultCluster) error {
 	return nil
 }
 
-func (s *Storer) UpdateVaultCluster(cluster VaultCluster) error {
-	txn := s.db.Txn(true)
+// UpdateIPPool replaces an IP pool and returns it. A pool's region and block
+// can only change if every address allocated from it would still be usable.
+func (s *Storer) UpdateIPPool(pool IPPool) (IPPool, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("vaultCluster", "id", cluster.ID)
+	existing, err := txn.First("ipPool", "id", pool.ID)
 	if err != nil {
-		return err
+		return IPPool{}, err
 	}
-	if existing == nil {
-		return ErrVaultClusterNotFound
+	if !recordVisible(recordScope(&pool), existing) {
+		return IPPool{}, ErrIPPoolNotFound
 	}
-	err = txn.Insert("vaultCluster", &cluster)
+	pool.CreatedAt = existing.(*IPPool).CreatedAt
+	taken, err := nameTaken(txn, "ipPool", recordScope(&pool), pool.ID, pool.Name)
 	if err != nil {
-		return err
+		return IPPool{}, err
+	}
+	if taken {
+		return IPPool{}, ErrIPPoolNameConflict
+	}
+	overlaps, err := ipPoolOverlaps(txn, pool)
+	if err != nil {
+		return IPPool{}, err
+	}
+	if overlaps {
+		return IPPool{}, ErrIPPoolOverlap
+	}
+	iter, err := txn.Get("ipAddress", "pool", pool.ID)
+	if err != nil {
+		return IPPool{}, err
+	}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		address := record.(*IPAddress)
+		if address.Region != pool.Region || !pool.usable(canonicalIP(address.Address)) {
+			return IPPool{}, ErrIPPoolInUse
+		}
+	}
+	err = txn.Insert("ipPool", &pool)
+	if err != nil {
+		return IPPool{}, err
 	}
 	txn.Commit()
-	return nil
+	return pool, nil
 }
 
-func (s *Storer) DeleteVaultCluster(id string) error {
-	txn := s.db.Txn(true)
+// DeleteIPPool removes an IP pool and returns it. Pools with addresses still
+// allocated from them can't be removed.
+func (s *Storer) DeleteIPPool(scope Scope, id string) (IPPool, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("vaultCluster", "id", id)
+	existing, err := txn.First("ipPool", "id", id)
 	if err != nil {
-		return err
+		return IPPool{}, err
 	}
-	if existing == nil {
-		return ErrVaultClusterNotFound
+	if !recordVisible(scope, existing) {
+		return IPPool{}, ErrIPPoolNotFound
 	}
-	err = txn.Delete("vaultCluster", existing)
+	address, err := txn.First("ipAddress", "pool", id)
 	if err != nil {
-		return err
+		return IPPool{}, err
+	}
+	if address != nil {
+		return IPPool{}, ErrIPPoolInUse
+	}
+	err = txn.Delete("ipPool", existing)
+	if err != nil {
+		return IPPool{}, err
 	}
 	txn.Commit()
-	return nil
+	return *existing.(*IPPool), nil
 }
 
-func (s *Storer) GetNomadCluster(id string) (NomadCluster, error) {
-	txn := s.db.Txn(false)
-	cluster, err := txn.First("nomadCluster", "id", id)
+// ListIPAddresses returns the addresses allocated in the project identified
+// by scope, ordered by region and address. If poolID is set, only addresses
+// allocated from that pool are returned.
+func (s *Storer) ListIPAddresses(scope Scope, poolID string) ([]IPAddress, error) {
+	txn := s.txn(false)
+	var iter memdb.ResultIterator
+	var err error
+	if poolID != "" {
+		iter, err = txn.Get("ipAddress", "pool", poolID)
+	} else {
+		iter, err = txn.Get("ipAddress", "project", scope.Organization, scope.Project)
+	}
 	if err != nil {
-		return NomadCluster{}, err
+		return nil, err
 	}
-	if cluster == nil {
-		return NomadCluster{}, ErrNomadClusterNotFound
+	var results []IPAddress
+	for address := iter.Next(); address != nil; address = iter.Next() {
+		if !recordVisible(scope, address) {
+			continue
+		}
+		results = append(results, *address.(*IPAddress))
 	}
-	return *cluster.(*NomadCluster), nil
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Region != results[j].Region {
+			return results[i].Region < results[j].Region
+		}
+		iIP, jIP := canonicalIP(results[i].Address), canonicalIP(results[j].Address)
+		if len(iIP) != len(jIP) {
+			return len(iIP) < len(jIP)
+		}
+		return bytes.Compare(iIP, jIP) < 0
+	})
+	return results, nil
 }
 
-func (s *Storer) CreateNomadCluster(cluster NomadCluster) error {
-	txn := s.db.Txn(true)
+func (s *Storer) GetIPAddress(scope Scope, id string) (IPAddress, error) {
+	txn := s.txn(false)
+	address, err := txn.First("ipAddress", "id", id)
+	if err != nil {
+		return IPAddress{}, err
+	}
+	if !recordVisible(scope, address) {
+		return IPAddress{}, ErrIPAddressNotFound
+	}
+	return *address.(*IPAddress), nil
+}
+
+// AllocateIPAddress stores address and returns it. If address.Address is set
+// that address is reserved, as long as it's usable in one of the region's
+// pools and not already allocated. Otherwise address is given the lowest free
+// address in address.PoolID, or in the first of the region's pools, by name,
+// that has one.
+func (s *Storer) AllocateIPAddress(address IPAddress) (IPAddress, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	exists, err := txn.First("nomadCluster", "id", cluster.ID)
+	exists, err := txn.First("ipAddress", "id", address.ID)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
 	if exists != nil {
-		return ErrNomadClusterAlreadyExists
+		return IPAddress{}, ErrIPAddressAlreadyExists
 	}
-	err = txn.Insert("nomadCluster", &cluster)
+	scope := recordScope(&address)
+	iter, err := txn.Get("ipPool", "project", scope.Organization, scope.Project)
 	if err != nil {
-		return err
+		return IPAddress{}, err
+	}
+	var pools []IPPool
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		pool := record.(*IPPool)
+		if pool.Region != address.Region {
+			continue
+		}
+		if address.PoolID != "" && !strings.EqualFold(pool.ID, address.PoolID) {
+			continue
+		}
+		pools = append(pools, *pool)
+	}
+	if address.PoolID != "" && len(pools) < 1 {
+		return IPAddress{}, ErrIPAddressPoolInvalid
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	iter, err = txn.Get("ipAddress", "project", scope.Organization, scope.Project)
+	if err != nil {
+		return IPAddress{}, err
+	}
+	used := map[string]bool{}
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		if record.(*IPAddress).Region == address.Region {
+			used[record.(*IPAddress).Address] = true
+		}
+	}
+	if address.Address != "" {
+		ip := canonicalIP(address.Address)
+		address.PoolID = ""
+		for _, pool := range pools {
+			if pool.usable(ip) {
+				address.PoolID = pool.ID
+				break
+			}
+		}
+		if address.PoolID == "" {
+			return IPAddress{}, ErrIPAddressOutOfRange
+		}
+		if used[ip.String()] {
+			return IPAddress{}, ErrIPAddressInUse
+		}
+		address.Address = ip.String()
+	} else {
+		for _, pool := range pools {
+			ip := freeIP(pool, used)
+			if ip != nil {
+				address.PoolID = pool.ID
+				address.Address = ip.String()
+				break
+			}
+		}
+		if address.Address == "" {
+			return IPAddress{}, ErrIPPoolExhausted
+		}
+	}
+	err = txn.Insert("ipAddress", &address)
+	if err != nil {
+		return IPAddress{}, err
 	}
 	txn.Commit()
-	return nil
+	return address, nil
 }
 
-func (s *Storer) UpdateNomadCluster(cluster NomadCluster) error {
-	txn := s.db.Txn(true)
+// freeIP returns the lowest usable address in pool that isn't in used, or
+// nil if the pool is full.
+func freeIP(pool IPPool, used map[string]bool) net.IP {
+	network := pool.network()
+	if network == nil {
+		return nil
+	}
+	last := lastIP(network)
+	for ip := network.IP; ; ip = nextIP(ip) {
+		if pool.usable(ip) && !used[ip.String()] {
+			return ip
+		}
+		if ip.Equal(last) {
+			return nil
+		}
+	}
+}
+
+func (s *Storer) UpdateIPAddress(scope Scope, id string, update IPAddressUpdate) (IPAddress, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("nomadCluster", "id", cluster.ID)
+	existing, err := txn.First("ipAddress", "id", id)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
-	if existing == nil {
-		return ErrNomadClusterNotFound
+	if !recordVisible(scope, existing) {
+		return IPAddress{}, ErrIPAddressNotFound
 	}
-	err = txn.Insert("nomadCluster", &cluster)
+	address := *existing.(*IPAddress)
+	address.Description = update.Description
+	err = txn.Insert("ipAddress", &address)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
 	txn.Commit()
-	return nil
+	return address, nil
 }
 
-func (s *Storer) DeleteNomadCluster(id string) error {
-	txn := s.db.Txn(true)
+// ReleaseIPAddress removes an address, so it can be allocated again, and
+// returns it, detached from its cluster.
+func (s *Storer) ReleaseIPAddress(scope Scope, id string) (IPAddress, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("nomadCluster", "id", id)
+	existing, err := txn.First("ipAddress", "id", id)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
-	if existing == nil {
-		return ErrNomadClusterNotFound
+	if !recordVisible(scope, existing) {
+		return IPAddress{}, ErrIPAddressNotFound
 	}
-	err = txn.Delete("nomadCluster", existing)
+	err = txn.Delete("ipAddress", existing)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
 	txn.Commit()
-	return nil
+	address := *existing.(*IPAddress)
+	address.AttachedTo = nil
+	return address, nil
 }
 
-func (s *Storer) GetTerraformWorkspace(id string) (TerraformWorkspace, error) {
-	txn := s.db.Txn(false)
-	cluster, err := txn.First("terraformWorkspace", "id", id)
+// AttachIPAddress attaches an address to the cluster identified by
+// attachment, which must be in the address's project and, for Vault
+// clusters, its region. Attaching an address to the cluster it's already
+// attached to does nothing; it has to be detached before it can be attached
+// to a different one.
+func (s *Storer) AttachIPAddress(scope Scope, id string, attachment IPAttachment) (IPAddress, error) {
+	txn := s.txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("ipAddress", "id", id)
 	if err != nil {
-		return TerraformWorkspace{}, err
+		return IPAddress{}, err
 	}
-	if cluster == nil {
-		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	if !recordVisible(scope, existing) {
+		return IPAddress{}, ErrIPAddressNotFound
 	}
-	return *cluster.(*TerraformWorkspace), nil
-}
-
-func (s *Storer) CreateTerraformWorkspace(workspace TerraformWorkspace) error {
-	txn := s.db.Txn(true)
-	defer txn.Abort()
-	exists, err := txn.First("terraformWorkspace", "id", workspace.ID)
+	address := *existing.(*IPAddress)
+	cluster, err := txn.First(attachment.table(), "id", attachment.ClusterID)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
-	if exists != nil {
-		return ErrTerraformWorkspaceAlreadyExists
+	if !recordVisible(recordScope(&address), cluster) {
+		return IPAddress{}, ErrIPAttachmentClusterNotFound
 	}
-	err = txn.Insert("terraformWorkspace", &workspace)
+	if vault, ok := cluster.(*VaultCluster); ok && vault.Region != address.Region {
+		return IPAddress{}, ErrIPAttachmentRegionMismatch
+	}
+	attachment.ClusterID = recordID(cluster)
+	if address.AttachedTo != nil {
+		if *address.AttachedTo == attachment {
+			return address, nil
+		}
+		return IPAddress{}, ErrIPAddressAttached
+	}
+	address.AttachedTo = &attachment
+	err = txn.Insert("ipAddress", &address)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
 	txn.Commit()
-	return nil
+	return address, nil
 }
 
-func (s *Storer) UpdateTerraformWorkspace(workspace TerraformWorkspace) error {
-	txn := s.db.Txn(true)
+// DetachIPAddress detaches an address from its cluster. Addresses that
+// aren't attached are left alone.
+func (s *Storer) DetachIPAddress(scope Scope, id string) (IPAddress, error) {
+	txn := s.txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("terraformWorkspace", "id", workspace.ID)
+	existing, err := txn.First("ipAddress", "id", id)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
-	if existing == nil {
-		return ErrTerraformWorkspaceNotFound
+	if !recordVisible(scope, existing) {
+		return IPAddress{}, ErrIPAddressNotFound
 	}
-	err = txn.Insert("terraformWorkspace", &workspace)
+	address := *existing.(*IPAddress)
+	if address.AttachedTo == nil {
+		return address, nil
+	}
+	address.AttachedTo = nil
+	err = txn.Insert("ipAddress", &address)
 	if err != nil {
-		return err
+		return IPAddress{}, err
 	}
 	txn.Commit()
-	return nil
+	return address, nil
 }
 
-func (s *Storer) DeleteTerraformWorkspace(id string) error {
-	txn := s.db.Txn(true)
-	defer txn.Abort()
-	existing, err := txn.First("terraformWorkspace", "id", id)
+// detachIPAddresses detaches every address attached to the cluster
+// identified by clusterID, once the cluster is gone for good.
+func detachIPAddresses(txn *memdb.Txn, clusterID string) error {
+	iter, err := txn.Get("ipAddress", "id")
 	if err != nil {
 		return err
 	}
-	if existing == nil {
-		return ErrTerraformWorkspaceNotFound
+	var detached []IPAddress
+	for record := iter.Next(); record != nil; record = iter.Next() {
+		address := record.(*IPAddress)
+		if address.AttachedTo == nil || !strings.EqualFold(address.AttachedTo.ClusterID, clusterID) {
+			continue
+		}
+		updated := *address
+		updated.AttachedTo = nil
+		detached = append(detached, updated)
 	}
-	err = txn.Delete("terraformWorkspace", existing)
-	if err != nil {
-		return err
+	for pos := range detached {
+		err = txn.Insert("ipAddress", &detached[pos])
+		if err != nil {
+			return err
+		}
 	}
-	txn.Commit()
 	return nil
 }
@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -10,6 +12,8 @@ import (
 
 type TerraformWorkspace struct {
 	ID                  string                    `json:"id"`
+	Organization        string                    `json:"organization"`
+	Project             string                    `json:"project"`
 	Name                string                    `json:"name"`
 	AgentPoolID         string                    `json:"agentPoolID"`
 	AllowDestroyPlan    *bool                     `json:"allowDestroyPlan"`
@@ -25,6 +29,9 @@ type TerraformWorkspace struct {
 	TriggerPrefixes     []string                  `json:"triggerPrefixes"`
 	WorkingDirectory    string                    `json:"workingDirectory"`
 	VCSRepo             TerraformWorkspaceVCSRepo `json:"vcsRepo"`
+	Locked              bool                      `json:"locked"`
+	Lock                *TerraformWorkspaceLock   `json:"lock,omitempty"`
+	DeletedAt           *time.Time                `json:"deletedAt,omitempty"`
 }
 
 type TerraformWorkspaceVCSRepo struct {
@@ -40,7 +47,7 @@ func (workspace *TerraformWorkspace) FillDefaults() {
 		workspace.AllowDestroyPlan = &adp
 	}
 	if workspace.ExecutionMode == "" {
-		workspace.ExecutionMode = "remote"
+		workspace.ExecutionMode = TerraformExecutionModeRemote
 	}
 	if workspace.FileTriggersEnabled == nil {
 		fte := true
@@ -50,6 +57,8 @@ func (workspace *TerraformWorkspace) FillDefaults() {
 		se := true
 		workspace.SpeculativeEnabled = &se
 	}
+	// workspace handlers default to the newest version in the catalog;
+	// this is only used while the catalog is empty
 	if workspace.TerraformVersion == "" {
 		workspace.TerraformVersion = "0.13.5"
 	}
@@ -62,7 +71,7 @@ func (workspace *TerraformWorkspace) FillDefaults() {
 }
 
 func (a API) handleGetTerraformWorkspace(w http.ResponseWriter, r *http.Request) {
-	workspace, err := a.Storer.GetTerraformWorkspace(trout.RequestVars(r).Get("id"))
+	workspace, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrTerraformWorkspaceNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -81,6 +90,10 @@ func (a API) handlePostTerraformWorkspace(w http.ResponseWriter, r *http.Request
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	workspace.DeletedAt = nil
+	workspace.setLock(nil)
+	workspace.Organization = trout.RequestVars(r).Get("org")
+	workspace.Project = trout.RequestVars(r).Get("project")
 	if workspace.ID == "" {
 		workspace.ID, err = uuid.GenerateUUID()
 		if err != nil {
@@ -92,13 +105,52 @@ func (a API) handlePostTerraformWorkspace(w http.ResponseWriter, r *http.Request
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
 		return
 	}
+	if workspace.TerraformVersion == "" {
+		workspace.TerraformVersion, err = a.Storer.DefaultTerraformVersion()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
 	workspace.FillDefaults()
+	if errs := validateTerraformExecution(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	if errs := validateTerraformWorkspaceVersion(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	if errs := validateTerraformVCSRepo(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	err = a.Storer.CreateTerraformWorkspace(workspace)
 	if err != nil {
 		if err == ErrTerraformWorkspaceAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if err == ErrTerraformWorkspaceNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/agentPoolID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformOAuthTokenNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/oauthTokenID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceVCSIdentifier {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/identifier", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+		if err == ErrTerraformVersionNotAllowed {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/terraformVersion", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -112,6 +164,9 @@ func (a API) handlePutTerraformWorkspace(w http.ResponseWriter, r *http.Request)
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	workspace.DeletedAt = nil
+	workspace.Organization = trout.RequestVars(r).Get("org")
+	workspace.Project = trout.RequestVars(r).Get("project")
 	if workspace.ID != "" && workspace.ID != trout.RequestVars(r).Get("id") {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 		return
@@ -120,13 +175,56 @@ func (a API) handlePutTerraformWorkspace(w http.ResponseWriter, r *http.Request)
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
 		return
 	}
+	if workspace.TerraformVersion == "" {
+		workspace.TerraformVersion, err = a.Storer.DefaultTerraformVersion()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
 	workspace.FillDefaults()
-	err = a.Storer.UpdateTerraformWorkspace(workspace)
+	if errs := validateTerraformExecution(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	if errs := validateTerraformWorkspaceVersion(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	if errs := validateTerraformVCSRepo(workspace); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	workspace, err = a.Storer.UpdateTerraformWorkspace(workspace, r.Header.Get(TerraformLockHolderHeader))
 	if err != nil {
 		if err == ErrTerraformWorkspaceNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: lockedError})
+			return
+		}
+		if err == ErrTerraformWorkspaceNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/agentPoolID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformOAuthTokenNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/oauthTokenID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceVCSIdentifier {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/identifier", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+		if err == ErrTerraformVersionNotAllowed {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/terraformVersion", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -134,7 +232,16 @@ func (a API) handlePutTerraformWorkspace(w http.ResponseWriter, r *http.Request)
 }
 
 func (a API) handleDeleteTerraformWorkspace(w http.ResponseWriter, r *http.Request) {
-	workspace, err := a.Storer.GetTerraformWorkspace(trout.RequestVars(r).Get("id"))
+	workspace, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.Storer.DeleteTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrTerraformWorkspaceNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -143,7 +250,12 @@ func (a API) handleDeleteTerraformWorkspace(w http.ResponseWriter, r *http.Reque
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteTerraformWorkspace(trout.RequestVars(r).Get("id"))
+	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: []TerraformWorkspace{workspace}})
+}
+
+func (a API) handleGetTerraformWorkspaceVersions(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	_, err := a.Storer.GetTerraformWorkspace(requestScope(r), id)
 	if err != nil {
 		if err == ErrTerraformWorkspaceNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -152,5 +264,100 @@ func (a API) handleDeleteTerraformWorkspace(w http.ResponseWriter, r *http.Reque
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	versions, err := a.Storer.ListVersions("terraformWorkspace", id)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = fillVersionChanges(versions)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Versions: versions})
+}
+
+func (a API) handlePostTerraformWorkspaceVersionRestore(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	workspace, err := a.Storer.RestoreTerraformWorkspaceVersion(requestScope(r), trout.RequestVars(r).Get("id"), version, r.Header.Get(TerraformLockHolderHeader))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: lockedError})
+			return
+		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/agentPoolID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformOAuthTokenNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/oauthTokenID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceVCSIdentifier {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/vcsRepo/identifier", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+		if err == ErrTerraformVersionNotAllowed {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/terraformVersion", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: []TerraformWorkspace{workspace}})
+}
+
+func (a API) handleListTerraformWorkspaces(w http.ResponseWriter, r *http.Request) {
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deleted", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	results, err := a.Storer.ListTerraformWorkspaces(requestScope(r), includeDeleted)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: results})
+}
+
+func (a API) handlePostTerraformWorkspaceUndelete(w http.ResponseWriter, r *http.Request) {
+	workspace, err := a.Storer.UndeleteTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
 	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: []TerraformWorkspace{workspace}})
 }
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformExecutionModeRemote = "remote"
+	TerraformExecutionModeLocal  = "local"
+	TerraformExecutionModeAgent  = "agent"
+)
+
+// TerraformAgentPool is a group of agents that workspaces with the agent
+// execution mode run on.
+type TerraformAgentPool struct {
+	ID           string `json:"id"`
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	Name         string `json:"name"`
+}
+
+// TerraformAgentToken is a credential agents use to join an agent pool.
+// Token is only ever returned when the token is created.
+type TerraformAgentToken struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Project      string    `json:"project"`
+	AgentPoolID  string    `json:"agentPoolID"`
+	Description  string    `json:"description"`
+	Token        string    `json:"token,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (token TerraformAgentToken) redacted() TerraformAgentToken {
+	token.Token = ""
+	return token
+}
+
+func (token TerraformAgentToken) in(scope Scope, agentPoolID string) bool {
+	return scope.Contains(Scope{Organization: token.Organization, Project: token.Project}) && strings.EqualFold(token.AgentPoolID, agentPoolID)
+}
+
+// validateTerraformExecution checks the execution mode and agent pool of
+// workspace, which must already have had its defaults filled. Whether the
+// agent pool exists is checked when the workspace is stored.
+func validateTerraformExecution(workspace TerraformWorkspace) []api.RequestError {
+	switch workspace.ExecutionMode {
+	case TerraformExecutionModeRemote, TerraformExecutionModeLocal:
+		if workspace.AgentPoolID != "" {
+			return []api.RequestError{{Field: "/agentPoolID", Slug: api.RequestErrConflict}}
+		}
+	case TerraformExecutionModeAgent:
+		if workspace.AgentPoolID == "" {
+			return []api.RequestError{{Field: "/agentPoolID", Slug: api.RequestErrMissing}}
+		}
+	default:
+		return []api.RequestError{{Field: "/executionMode", Slug: api.RequestErrInvalidValue}}
+	}
+	return nil
+}
+
+func (a API) handleListTerraformAgentPools(w http.ResponseWriter, r *http.Request) {
+	pools, err := a.Storer.ListTerraformAgentPools(requestScope(r))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentPools: pools})
+}
+
+func (a API) handlePostTerraformAgentPool(w http.ResponseWriter, r *http.Request) {
+	var pool TerraformAgentPool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	if pool.ID == "" {
+		pool.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	if pool.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.CreateTerraformAgentPool(pool)
+	if err != nil {
+		if err == ErrTerraformAgentPoolAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformAgentPools: []TerraformAgentPool{pool}})
+}
+
+func (a API) handleGetTerraformAgentPool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.GetTerraformAgentPool(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentPools: []TerraformAgentPool{pool}})
+}
+
+func (a API) handlePutTerraformAgentPool(w http.ResponseWriter, r *http.Request) {
+	var pool TerraformAgentPool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	pool.Organization = trout.RequestVars(r).Get("org")
+	pool.Project = trout.RequestVars(r).Get("project")
+	if pool.ID != "" && pool.ID != trout.RequestVars(r).Get("id") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	pool.ID = trout.RequestVars(r).Get("id")
+	if pool.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	err = a.Storer.UpdateTerraformAgentPool(pool)
+	if err != nil {
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentPools: []TerraformAgentPool{pool}})
+}
+
+func (a API) handleDeleteTerraformAgentPool(w http.ResponseWriter, r *http.Request) {
+	pool, err := a.Storer.DeleteTerraformAgentPool(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformAgentPoolInUse {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentPools: []TerraformAgentPool{pool}})
+}
+
+func (a API) handleListTerraformAgentTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := a.Storer.ListTerraformAgentTokens(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := make([]TerraformAgentToken, 0, len(tokens))
+	for _, token := range tokens {
+		results = append(results, token.redacted())
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentTokens: results})
+}
+
+func (a API) handlePostTerraformAgentToken(w http.ResponseWriter, r *http.Request) {
+	var token TerraformAgentToken
+	err := api.Decode(r, &token)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	token.Organization = trout.RequestVars(r).Get("org")
+	token.Project = trout.RequestVars(r).Get("project")
+	token.AgentPoolID = trout.RequestVars(r).Get("id")
+	token.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	token.Token, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	token.CreatedAt = time.Now()
+	err = a.Storer.CreateTerraformAgentToken(token)
+	if err != nil {
+		if err == ErrTerraformAgentPoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	// this is the only time the token is returned, so it had better be
+	// written down
+	api.Encode(w, r, http.StatusCreated, Response{TerraformAgentTokens: []TerraformAgentToken{token}})
+}
+
+func (a API) handleGetTerraformAgentToken(w http.ResponseWriter, r *http.Request) {
+	token, err := a.Storer.GetTerraformAgentToken(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("token"))
+	if err != nil {
+		if err == ErrTerraformAgentTokenNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "token", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentTokens: []TerraformAgentToken{token.redacted()}})
+}
+
+func (a API) handleDeleteTerraformAgentToken(w http.ResponseWriter, r *http.Request) {
+	token, err := a.Storer.DeleteTerraformAgentToken(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("token"))
+	if err != nil {
+		if err == ErrTerraformAgentTokenNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "token", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformAgentTokens: []TerraformAgentToken{token.redacted()}})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// TerraformLockHolderHeader identifies who is making a request to a locked
+// workspace. Only the lock's holder can update the workspace or queue runs
+// in it.
+const TerraformLockHolderHeader = "X-Dadcorp-Lock-Holder"
+
+// TerraformWorkspaceLock records who locked a workspace, and why.
+type TerraformWorkspaceLock struct {
+	Holder   string    `json:"holder"`
+	Reason   string    `json:"reason"`
+	LockedAt time.Time `json:"lockedAt"`
+}
+
+// lockedAgainst reports whether the workspace is locked by anyone other
+// than holder.
+func (workspace TerraformWorkspace) lockedAgainst(holder string) bool {
+	return workspace.Lock != nil && workspace.Lock.Holder != holder
+}
+
+// setLock locks the workspace with lock, or unlocks it if lock is nil.
+func (workspace *TerraformWorkspace) setLock(lock *TerraformWorkspaceLock) {
+	workspace.Lock = lock
+	workspace.Locked = lock != nil
+}
+
+// lockedError is the error returned when a request is refused because
+// someone else holds the workspace's lock.
+var lockedError = []api.RequestError{{Header: TerraformLockHolderHeader, Slug: api.RequestErrConflict}}
+
+func (a API) handlePostTerraformWorkspaceLock(w http.ResponseWriter, r *http.Request) {
+	var lock TerraformWorkspaceLock
+	err := api.Decode(r, &lock)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if lock.Holder == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/holder", Slug: api.RequestErrMissing}}})
+		return
+	}
+	lock.LockedAt = time.Now()
+	workspace, err := a.Storer.LockTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"), lock)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: []TerraformWorkspace{workspace}})
+}
+
+func (a API) handlePostTerraformWorkspaceUnlock(w http.ResponseWriter, r *http.Request) {
+	var lock TerraformWorkspaceLock
+	err := api.Decode(r, &lock)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if lock.Holder == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/holder", Slug: api.RequestErrMissing}}})
+		return
+	}
+	a.unlockTerraformWorkspace(w, r, lock.Holder, false)
+}
+
+func (a API) handlePostTerraformWorkspaceForceUnlock(w http.ResponseWriter, r *http.Request) {
+	a.unlockTerraformWorkspace(w, r, "", true)
+}
+
+func (a API) unlockTerraformWorkspace(w http.ResponseWriter, r *http.Request, holder string, force bool) {
+	workspace, err := a.Storer.UnlockTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"), holder, force)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNotLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/holder", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformWorkspaces: []TerraformWorkspace{workspace}})
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformVCSProviderGitHub             = "github"
+	TerraformVCSProviderGitHubEnterprise   = "github_enterprise"
+	TerraformVCSProviderGitLabHosted       = "gitlab_hosted"
+	TerraformVCSProviderGitLabEnterprise   = "gitlab_enterprise"
+	TerraformVCSProviderBitbucketHosted    = "bitbucket_hosted"
+	TerraformVCSProviderBitbucketServer    = "bitbucket_server"
+	TerraformVCSProviderAzureDevOpsService = "azure_devops_services"
+)
+
+var (
+	// terraformVCSHostedURLs holds the HTTP and API URLs of the VCS
+	// providers that are only ever hosted in one place.
+	terraformVCSHostedURLs = map[string][2]string{
+		TerraformVCSProviderGitHub:             {"https://github.com", "https://api.github.com"},
+		TerraformVCSProviderGitLabHosted:       {"https://gitlab.com", "https://gitlab.com/api/v4"},
+		TerraformVCSProviderBitbucketHosted:    {"https://bitbucket.org", "https://api.bitbucket.org"},
+		TerraformVCSProviderAzureDevOpsService: {"https://dev.azure.com", "https://dev.azure.com"},
+	}
+
+	terraformVCSIdentifier            = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	terraformVCSAzureDevOpsIdentifier = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_. -]+/_git/[A-Za-z0-9_.-]+$`)
+)
+
+// TerraformOAuthClient is a connection to a VCS provider that workspaces can
+// pull their configuration from. OAuthToken is write-only: it's used to
+// create or rotate the client's OAuth token, and is never returned.
+type TerraformOAuthClient struct {
+	ID              string `json:"id"`
+	Organization    string `json:"organization"`
+	Project         string `json:"project"`
+	Name            string `json:"name"`
+	ServiceProvider string `json:"serviceProvider"`
+	HTTPURL         string `json:"httpURL"`
+	APIURL          string `json:"apiURL"`
+	OAuthToken      string `json:"oauthToken,omitempty"`
+	OAuthTokenID    string `json:"oauthTokenID"`
+}
+
+func (client *TerraformOAuthClient) FillDefaults() {
+	urls, ok := terraformVCSHostedURLs[client.ServiceProvider]
+	if !ok {
+		return
+	}
+	if client.HTTPURL == "" {
+		client.HTTPURL = urls[0]
+	}
+	if client.APIURL == "" {
+		client.APIURL = urls[1]
+	}
+}
+
+// TerraformOAuthToken is the credential an OAuth client uses to talk to its
+// VCS provider. Workspaces reference it in their VCS repo settings. Token is
+// write-only, and is never returned.
+type TerraformOAuthToken struct {
+	ID            string    `json:"id"`
+	Organization  string    `json:"organization"`
+	Project       string    `json:"project"`
+	OAuthClientID string    `json:"oauthClientID"`
+	Token         string    `json:"token,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (token TerraformOAuthToken) redacted() TerraformOAuthToken {
+	token.Token = ""
+	return token
+}
+
+func (token TerraformOAuthToken) in(scope Scope, oauthClientID string) bool {
+	return scope.Contains(Scope{Organization: token.Organization, Project: token.Project}) && strings.EqualFold(token.OAuthClientID, oauthClientID)
+}
+
+// validVCSIdentifier reports whether identifier is a repository identifier
+// that the VCS provider serviceProvider would understand.
+func validVCSIdentifier(serviceProvider, identifier string) bool {
+	if serviceProvider == TerraformVCSProviderAzureDevOpsService {
+		return terraformVCSAzureDevOpsIdentifier.MatchString(identifier)
+	}
+	return terraformVCSIdentifier.MatchString(identifier)
+}
+
+func validVCSURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validateTerraformOAuthClient checks client, which must already have had
+// its defaults filled. create should be true if the client is being
+// created, when an OAuth token is required.
+func validateTerraformOAuthClient(client TerraformOAuthClient, create bool) []api.RequestError {
+	var errs []api.RequestError
+	if client.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	switch client.ServiceProvider {
+	case "":
+		errs = append(errs, api.RequestError{Field: "/serviceProvider", Slug: api.RequestErrMissing})
+	case TerraformVCSProviderGitHub, TerraformVCSProviderGitHubEnterprise, TerraformVCSProviderGitLabHosted,
+		TerraformVCSProviderGitLabEnterprise, TerraformVCSProviderBitbucketHosted, TerraformVCSProviderBitbucketServer,
+		TerraformVCSProviderAzureDevOpsService:
+	default:
+		errs = append(errs, api.RequestError{Field: "/serviceProvider", Slug: api.RequestErrInvalidValue})
+	}
+	if client.HTTPURL == "" {
+		errs = append(errs, api.RequestError{Field: "/httpURL", Slug: api.RequestErrMissing})
+	} else if !validVCSURL(client.HTTPURL) {
+		errs = append(errs, api.RequestError{Field: "/httpURL", Slug: api.RequestErrInvalidFormat})
+	}
+	if client.APIURL == "" {
+		errs = append(errs, api.RequestError{Field: "/apiURL", Slug: api.RequestErrMissing})
+	} else if !validVCSURL(client.APIURL) {
+		errs = append(errs, api.RequestError{Field: "/apiURL", Slug: api.RequestErrInvalidFormat})
+	}
+	if create && client.OAuthToken == "" {
+		errs = append(errs, api.RequestError{Field: "/oauthToken", Slug: api.RequestErrMissing})
+	}
+	return errs
+}
+
+// validateTerraformVCSRepo checks that the VCS repo settings of workspace
+// are complete. Whether the OAuth token exists, and whether the identifier
+// makes sense for its VCS provider, is checked when the workspace is
+// stored.
+func validateTerraformVCSRepo(workspace TerraformWorkspace) []api.RequestError {
+	if workspace.VCSRepo.OAuthTokenID == "" && workspace.VCSRepo.Identifier != "" {
+		return []api.RequestError{{Field: "/vcsRepo/oauthTokenID", Slug: api.RequestErrMissing}}
+	}
+	if workspace.VCSRepo.OAuthTokenID != "" && workspace.VCSRepo.Identifier == "" {
+		return []api.RequestError{{Field: "/vcsRepo/identifier", Slug: api.RequestErrMissing}}
+	}
+	return nil
+}
+
+func (a API) handleListTerraformOAuthClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := a.Storer.ListTerraformOAuthClients(requestScope(r))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthClients: clients})
+}
+
+func (a API) handlePostTerraformOAuthClient(w http.ResponseWriter, r *http.Request) {
+	var client TerraformOAuthClient
+	err := api.Decode(r, &client)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	client.Organization = trout.RequestVars(r).Get("org")
+	client.Project = trout.RequestVars(r).Get("project")
+	if client.ID == "" {
+		client.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	client.FillDefaults()
+	if errs := validateTerraformOAuthClient(client, true); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	token := TerraformOAuthToken{
+		Organization:  client.Organization,
+		Project:       client.Project,
+		OAuthClientID: client.ID,
+		Token:         client.OAuthToken,
+		CreatedAt:     time.Now(),
+	}
+	token.UpdatedAt = token.CreatedAt
+	token.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	client.OAuthToken = ""
+	client.OAuthTokenID = token.ID
+	err = a.Storer.CreateTerraformOAuthClient(client, token)
+	if err != nil {
+		if err == ErrTerraformOAuthClientAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformOAuthClientNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformOAuthClients: []TerraformOAuthClient{client}})
+}
+
+func (a API) handleGetTerraformOAuthClient(w http.ResponseWriter, r *http.Request) {
+	client, err := a.Storer.GetTerraformOAuthClient(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformOAuthClientNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthClients: []TerraformOAuthClient{client}})
+}
+
+func (a API) handlePutTerraformOAuthClient(w http.ResponseWriter, r *http.Request) {
+	var client TerraformOAuthClient
+	err := api.Decode(r, &client)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	client.Organization = trout.RequestVars(r).Get("org")
+	client.Project = trout.RequestVars(r).Get("project")
+	if client.ID != "" && client.ID != trout.RequestVars(r).Get("id") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	client.ID = trout.RequestVars(r).Get("id")
+	client.FillDefaults()
+	if errs := validateTerraformOAuthClient(client, false); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	client, err = a.Storer.UpdateTerraformOAuthClient(client)
+	if err != nil {
+		if err == ErrTerraformOAuthClientNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformOAuthClientNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformOAuthClientProviderInUse {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/serviceProvider", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthClients: []TerraformOAuthClient{client}})
+}
+
+func (a API) handleDeleteTerraformOAuthClient(w http.ResponseWriter, r *http.Request) {
+	client, err := a.Storer.DeleteTerraformOAuthClient(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformOAuthClientNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformOAuthTokenInUse {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthClients: []TerraformOAuthClient{client}})
+}
+
+func (a API) handleListTerraformOAuthTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := a.Storer.ListTerraformOAuthTokens(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformOAuthClientNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := make([]TerraformOAuthToken, 0, len(tokens))
+	for _, token := range tokens {
+		results = append(results, token.redacted())
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthTokens: results})
+}
+
+func (a API) handleGetTerraformOAuthToken(w http.ResponseWriter, r *http.Request) {
+	token, err := a.Storer.GetTerraformOAuthToken(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("token"))
+	if err != nil {
+		if err == ErrTerraformOAuthTokenNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "token", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformOAuthTokens: []TerraformOAuthToken{token.redacted()}})
+}
@@ -0,0 +1,362 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformPolicyAdvisory      = "advisory"
+	TerraformPolicySoftMandatory = "soft-mandatory"
+	TerraformPolicyHardMandatory = "hard-mandatory"
+)
+
+// TerraformPolicySet is a group of policies that are checked against the
+// plan of every run in the workspaces it's attached to.
+type TerraformPolicySet struct {
+	ID           string                `json:"id"`
+	Organization string                `json:"organization"`
+	Project      string                `json:"project"`
+	Name         string                `json:"name"`
+	Description  string                `json:"description"`
+	WorkspaceIDs []string              `json:"workspaceIDs"`
+	Policies     []TerraformPolicyRule `json:"policies"`
+}
+
+// TerraformPolicyRule is a single policy in a policy set. A plan fails the
+// policy if it creates or updates a resource of one of the forbidden types,
+// or creates or updates a taggable resource without all of the required
+// tags.
+//
+// What happens when a plan fails depends on the enforcement level: advisory
+// policies just record the failure, soft-mandatory policies stop the run
+// until someone allowed to override policies lets it continue, and
+// hard-mandatory policies error the run.
+type TerraformPolicyRule struct {
+	Name                   string   `json:"name"`
+	EnforcementLevel       string   `json:"enforcementLevel"`
+	ForbiddenResourceTypes []string `json:"forbiddenResourceTypes"`
+	RequiredTags           []string `json:"requiredTags"`
+}
+
+// TerraformPolicyCheck is the result of checking a run's plan against one
+// policy.
+type TerraformPolicyCheck struct {
+	PolicySetID      string   `json:"policySetID"`
+	Policy           string   `json:"policy"`
+	EnforcementLevel string   `json:"enforcementLevel"`
+	Passed           bool     `json:"passed"`
+	Violations       []string `json:"violations,omitempty"`
+}
+
+// terraformPlan is the part of a JSON plan, as output by
+// `terraform show -json`, that policies are checked against.
+type terraformPlan struct {
+	ResourceChanges []terraformPlanResourceChange `json:"resource_changes"`
+}
+
+type terraformPlanResourceChange struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions []string               `json:"actions"`
+		After   map[string]interface{} `json:"after"`
+	} `json:"change"`
+}
+
+func parseTerraformPlan(raw json.RawMessage) (terraformPlan, error) {
+	var plan terraformPlan
+	if len(raw) == 0 {
+		return plan, nil
+	}
+	err := json.Unmarshal(raw, &plan)
+	return plan, err
+}
+
+// createsOrUpdates reports whether the change leaves the resource in place
+// after the run; deleted resources and resources that aren't changing can't
+// violate a policy.
+func (change terraformPlanResourceChange) createsOrUpdates() bool {
+	for _, action := range change.Change.Actions {
+		if action == "create" || action == "update" {
+			return true
+		}
+	}
+	return false
+}
+
+func (set *TerraformPolicySet) FillDefaults() {
+	if set.WorkspaceIDs == nil {
+		set.WorkspaceIDs = []string{}
+	}
+	if set.Policies == nil {
+		set.Policies = []TerraformPolicyRule{}
+	}
+	for pos := range set.Policies {
+		if set.Policies[pos].EnforcementLevel == "" {
+			set.Policies[pos].EnforcementLevel = TerraformPolicyAdvisory
+		}
+		if set.Policies[pos].ForbiddenResourceTypes == nil {
+			set.Policies[pos].ForbiddenResourceTypes = []string{}
+		}
+		if set.Policies[pos].RequiredTags == nil {
+			set.Policies[pos].RequiredTags = []string{}
+		}
+	}
+}
+
+func validateTerraformPolicySet(set TerraformPolicySet) []api.RequestError {
+	var errs []api.RequestError
+	if set.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	names := map[string]struct{}{}
+	for pos, policy := range set.Policies {
+		field := "/policies/" + strconv.Itoa(pos)
+		if policy.Name == "" {
+			errs = append(errs, api.RequestError{Field: field + "/name", Slug: api.RequestErrMissing})
+		} else if _, ok := names[strings.ToLower(policy.Name)]; ok {
+			errs = append(errs, api.RequestError{Field: field + "/name", Slug: api.RequestErrConflict})
+		}
+		names[strings.ToLower(policy.Name)] = struct{}{}
+		switch policy.EnforcementLevel {
+		case TerraformPolicyAdvisory, TerraformPolicySoftMandatory, TerraformPolicyHardMandatory:
+		default:
+			errs = append(errs, api.RequestError{Field: field + "/enforcementLevel", Slug: api.RequestErrInvalidValue})
+		}
+		if len(policy.ForbiddenResourceTypes) < 1 && len(policy.RequiredTags) < 1 {
+			errs = append(errs, api.RequestError{Field: field, Slug: api.RequestErrMissing})
+		}
+		for typePos, resourceType := range policy.ForbiddenResourceTypes {
+			if resourceType == "" {
+				errs = append(errs, api.RequestError{Field: field + "/forbiddenResourceTypes/" + strconv.Itoa(typePos), Slug: api.RequestErrMissing})
+			}
+		}
+		for tagPos, tag := range policy.RequiredTags {
+			if tag == "" {
+				errs = append(errs, api.RequestError{Field: field + "/requiredTags/" + strconv.Itoa(tagPos), Slug: api.RequestErrMissing})
+			}
+		}
+	}
+	return errs
+}
+
+// checkTerraformPolicies checks plan against every policy in sets, in the
+// order the sets are passed in.
+func checkTerraformPolicies(sets []TerraformPolicySet, plan terraformPlan) []TerraformPolicyCheck {
+	checks := []TerraformPolicyCheck{}
+	for _, set := range sets {
+		for _, policy := range set.Policies {
+			check := TerraformPolicyCheck{
+				PolicySetID:      set.ID,
+				Policy:           policy.Name,
+				EnforcementLevel: policy.EnforcementLevel,
+			}
+			for _, change := range plan.ResourceChanges {
+				if !change.createsOrUpdates() {
+					continue
+				}
+				for _, forbidden := range policy.ForbiddenResourceTypes {
+					if change.Type == forbidden {
+						check.Violations = append(check.Violations, change.Address+" is a "+forbidden+", which is forbidden")
+					}
+				}
+				// resources without a tags attribute can't be tagged,
+				// so they don't need any tags
+				tagsVal, taggable := change.Change.After["tags"]
+				if !taggable {
+					continue
+				}
+				tags, _ := tagsVal.(map[string]interface{})
+				for _, tag := range policy.RequiredTags {
+					if v, ok := tags[tag]; !ok || v == nil || v == "" {
+						check.Violations = append(check.Violations, change.Address+" is missing required tag "+strconv.Quote(tag))
+					}
+				}
+			}
+			check.Passed = len(check.Violations) < 1
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// terraformPolicyOutcome decides what status a run that just finished
+// planning should move to, given its policy checks, and why.
+func terraformPolicyOutcome(run TerraformRun, checks []TerraformPolicyCheck) (string, string) {
+	var advisory, soft []string
+	for _, check := range checks {
+		if check.Passed {
+			continue
+		}
+		switch check.EnforcementLevel {
+		case TerraformPolicyHardMandatory:
+			return TerraformRunErrored, "hard-mandatory policy " + strconv.Quote(check.Policy) + " failed"
+		case TerraformPolicySoftMandatory:
+			soft = append(soft, strconv.Quote(check.Policy))
+		default:
+			advisory = append(advisory, strconv.Quote(check.Policy))
+		}
+	}
+	// speculative runs can never be applied, so there's nothing for a
+	// soft-mandatory policy to stop
+	if len(soft) > 0 && !run.Speculative {
+		return TerraformRunPolicyOverride, "soft-mandatory policies " + strings.Join(soft, ", ") + " failed and must be overridden"
+	}
+	if len(soft) > 0 || len(advisory) > 0 {
+		return TerraformRunPlanned, "policies " + strings.Join(append(soft, advisory...), ", ") + " failed"
+	}
+	return TerraformRunPlanned, ""
+}
+
+func sortTerraformPolicySets(sets []TerraformPolicySet) {
+	sort.Slice(sets, func(i, j int) bool {
+		if !strings.EqualFold(sets[i].Name, sets[j].Name) {
+			return strings.ToLower(sets[i].Name) < strings.ToLower(sets[j].Name)
+		}
+		return sets[i].ID < sets[j].ID
+	})
+}
+
+func (a API) handleListTerraformPolicySets(w http.ResponseWriter, r *http.Request) {
+	sets, err := a.Storer.ListTerraformPolicySets(requestScope(r))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformPolicySets: sets})
+}
+
+func (a API) handlePostTerraformPolicySet(w http.ResponseWriter, r *http.Request) {
+	var set TerraformPolicySet
+	err := api.Decode(r, &set)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	set.Organization = trout.RequestVars(r).Get("org")
+	set.Project = trout.RequestVars(r).Get("project")
+	if set.ID == "" {
+		set.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	set.FillDefaults()
+	if errs := validateTerraformPolicySet(set); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	err = a.Storer.CreateTerraformPolicySet(set)
+	if err != nil {
+		if err == ErrTerraformPolicySetAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformPolicySetNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/workspaceIDs", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformPolicySets: []TerraformPolicySet{set}})
+}
+
+func (a API) handleGetTerraformPolicySet(w http.ResponseWriter, r *http.Request) {
+	set, err := a.Storer.GetTerraformPolicySet(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformPolicySetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformPolicySets: []TerraformPolicySet{set}})
+}
+
+func (a API) handlePutTerraformPolicySet(w http.ResponseWriter, r *http.Request) {
+	var set TerraformPolicySet
+	err := api.Decode(r, &set)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	set.Organization = trout.RequestVars(r).Get("org")
+	set.Project = trout.RequestVars(r).Get("project")
+	if set.ID != "" && set.ID != trout.RequestVars(r).Get("id") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	set.ID = trout.RequestVars(r).Get("id")
+	set.FillDefaults()
+	if errs := validateTerraformPolicySet(set); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	err = a.Storer.UpdateTerraformPolicySet(set)
+	if err != nil {
+		if err == ErrTerraformPolicySetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformPolicySetNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/workspaceIDs", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformPolicySets: []TerraformPolicySet{set}})
+}
+
+func (a API) handleDeleteTerraformPolicySet(w http.ResponseWriter, r *http.Request) {
+	set, err := a.Storer.DeleteTerraformPolicySet(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformPolicySetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformPolicySets: []TerraformPolicySet{set}})
+}
+
+// handlePostTerraformRunOverride lets a run that failed soft-mandatory
+// policies continue. The request has to be made under an access policy
+// that allows overriding policies in the run's workspace.
+func (a API) handlePostTerraformRunOverride(w http.ResponseWriter, r *http.Request) {
+	ap, err := a.requestAccessPolicy(r)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	tf, ok := ap.terraformPolicy(trout.RequestVars(r).Get("id"))
+	if !ok || !tf.OverridePolicies {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return
+	}
+	a.transitionTerraformRun(w, r, a.Storer.OverrideTerraformRun)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformRunTriggerInbound  = "inbound"
+	TerraformRunTriggerOutbound = "outbound"
+)
+
+// TerraformRunTrigger queues a run in the workspace identified by
+// WorkspaceID whenever a run is applied in the workspace identified by
+// SourceWorkspaceID. Triggers are inbound for their WorkspaceID and
+// outbound for their SourceWorkspaceID.
+type TerraformRunTrigger struct {
+	ID                string    `json:"id"`
+	Organization      string    `json:"organization"`
+	Project           string    `json:"project"`
+	WorkspaceID       string    `json:"workspaceID"`
+	SourceWorkspaceID string    `json:"sourceWorkspaceID"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+func (trigger TerraformRunTrigger) in(scope Scope, workspaceID string) bool {
+	if !scope.Contains(Scope{Organization: trigger.Organization, Project: trigger.Project}) {
+		return false
+	}
+	return strings.EqualFold(trigger.WorkspaceID, workspaceID) || strings.EqualFold(trigger.SourceWorkspaceID, workspaceID)
+}
+
+func (a API) handleListTerraformRunTriggers(w http.ResponseWriter, r *http.Request) {
+	triggerType := r.URL.Query().Get("type")
+	switch triggerType {
+	case "", TerraformRunTriggerInbound, TerraformRunTriggerOutbound:
+	default:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "type", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	_, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	triggers, err := a.Storer.ListTerraformRunTriggers(requestScope(r), trout.RequestVars(r).Get("id"), triggerType)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRunTriggers: triggers})
+}
+
+// handlePostTerraformRunTrigger creates an inbound run trigger for the
+// workspace in the URL.
+func (a API) handlePostTerraformRunTrigger(w http.ResponseWriter, r *http.Request) {
+	var trigger TerraformRunTrigger
+	err := api.Decode(r, &trigger)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if trigger.SourceWorkspaceID == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/sourceWorkspaceID", Slug: api.RequestErrMissing}}})
+		return
+	}
+	trigger.Organization = trout.RequestVars(r).Get("org")
+	trigger.Project = trout.RequestVars(r).Get("project")
+	trigger.WorkspaceID = trout.RequestVars(r).Get("id")
+	trigger.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	trigger.CreatedAt = time.Now()
+	err = a.Storer.CreateTerraformRunTrigger(trigger)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformRunTriggerSourceNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/sourceWorkspaceID", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformRunTriggerAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/sourceWorkspaceID", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformRunTriggerCycle {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/sourceWorkspaceID", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformRunTriggers: []TerraformRunTrigger{trigger}})
+}
+
+func (a API) handleGetTerraformRunTrigger(w http.ResponseWriter, r *http.Request) {
+	trigger, err := a.Storer.GetTerraformRunTrigger(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformRunTriggerNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRunTriggers: []TerraformRunTrigger{trigger}})
+}
+
+func (a API) handleDeleteTerraformRunTrigger(w http.ResponseWriter, r *http.Request) {
+	trigger, err := a.Storer.DeleteTerraformRunTrigger(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformRunTriggerNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRunTriggers: []TerraformRunTrigger{trigger}})
+}
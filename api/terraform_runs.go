@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformRunPending   = "pending"
+	TerraformRunPlanning  = "planning"
+	TerraformRunPlanned   = "planned"
+	TerraformRunApplying  = "applying"
+	TerraformRunApplied   = "applied"
+	TerraformRunErrored   = "errored"
+	TerraformRunDiscarded = "discarded"
+	TerraformRunCanceled  = "canceled"
+)
+
+// TerraformRunPolicyOverride is the status of runs that failed a
+// soft-mandatory policy, and are waiting for someone to override it.
+const TerraformRunPolicyOverride = "policy_override"
+
+type TerraformRun struct {
+	ID              string    `json:"id"`
+	Organization    string    `json:"organization"`
+	Project         string    `json:"project"`
+	WorkspaceID     string    `json:"workspaceID"`
+	Message         string    `json:"message"`
+	IsDestroy       bool      `json:"isDestroy"`
+	Speculative     bool      `json:"speculative"`
+	AutoApply       bool      `json:"autoApply"`
+	Status          string    `json:"status"`
+	StatusReason    string    `json:"statusReason,omitempty"`
+	StatusChangedAt time.Time `json:"statusChangedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+
+	// Plan is the run's plan, as output by `terraform show -json`. The
+	// policy sets attached to the workspace are checked against it once the
+	// run is planned, and the results are recorded in PolicyChecks.
+	Plan         json.RawMessage        `json:"plan,omitempty"`
+	PolicyChecks []TerraformPolicyCheck `json:"policyChecks,omitempty"`
+}
+
+// Finished reports whether the run has reached a status it can't leave. A
+// speculative run is finished once it's planned, because it can never be
+// applied.
+func (run TerraformRun) Finished() bool {
+	switch run.Status {
+	case TerraformRunApplied, TerraformRunErrored, TerraformRunDiscarded, TerraformRunCanceled:
+		return true
+	case TerraformRunPlanned:
+		return run.Speculative
+	}
+	return false
+}
+
+func (run *TerraformRun) setStatus(status, reason string) {
+	run.Status = status
+	run.StatusReason = reason
+	run.StatusChangedAt = time.Now()
+}
+
+func (run TerraformRun) in(scope Scope, workspaceID string) bool {
+	return scope.Contains(Scope{Organization: run.Organization, Project: run.Project}) && strings.EqualFold(run.WorkspaceID, workspaceID)
+}
+
+func (a API) handlePostTerraformRun(w http.ResponseWriter, r *http.Request) {
+	var run TerraformRun
+	err := api.Decode(r, &run)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	workspace, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if run.Speculative && workspace.SpeculativeEnabled != nil && !*workspace.SpeculativeEnabled {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/speculative", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if run.IsDestroy && workspace.AllowDestroyPlan != nil && !*workspace.AllowDestroyPlan {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/isDestroy", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if _, err := parseTerraformPlan(run.Plan); err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/plan", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	run.PolicyChecks = nil
+	run.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	run.Organization = workspace.Organization
+	run.Project = workspace.Project
+	run.WorkspaceID = workspace.ID
+	run.AutoApply = workspace.AutoApply
+	run.CreatedAt = time.Now()
+	run.setStatus(TerraformRunPending, "")
+	err = a.Storer.CreateTerraformRun(run, r.Header.Get(TerraformLockHolderHeader))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: lockedError})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformRuns: []TerraformRun{run}})
+}
+
+func (a API) handleListTerraformRuns(w http.ResponseWriter, r *http.Request) {
+	_, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	runs, err := a.Storer.ListTerraformRuns(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRuns: runs})
+}
+
+func (a API) handleGetTerraformRun(w http.ResponseWriter, r *http.Request) {
+	run, err := a.Storer.GetTerraformRun(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("run"))
+	if err != nil {
+		if err == ErrTerraformRunNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "run", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRuns: []TerraformRun{run}})
+}
+
+func (a API) handlePostTerraformRunApply(w http.ResponseWriter, r *http.Request) {
+	a.transitionTerraformRun(w, r, a.Storer.ApplyTerraformRun)
+}
+
+func (a API) handlePostTerraformRunDiscard(w http.ResponseWriter, r *http.Request) {
+	a.transitionTerraformRun(w, r, a.Storer.DiscardTerraformRun)
+}
+
+func (a API) handlePostTerraformRunCancel(w http.ResponseWriter, r *http.Request) {
+	a.transitionTerraformRun(w, r, a.Storer.CancelTerraformRun)
+}
+
+func (a API) transitionTerraformRun(w http.ResponseWriter, r *http.Request, transition func(scope Scope, workspaceID, id string) (TerraformRun, error)) {
+	run, err := transition(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("run"))
+	if err != nil {
+		if err == ErrTerraformRunNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "run", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformRunInvalidTransition {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "run", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformRuns: []TerraformRun{run}})
+}
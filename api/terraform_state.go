@@ -0,0 +1,243 @@
+package api
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+var terraformStateMD5 = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+// TerraformStateVersion is a snapshot of a workspace's Terraform state.
+// State holds the raw state file, exactly as it was uploaded; it's left out
+// when listing state versions to keep responses small.
+type TerraformStateVersion struct {
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	WorkspaceID  string                 `json:"workspaceID"`
+	Serial       *int64                 `json:"serial"`
+	Lineage      string                 `json:"lineage"`
+	MD5          string                 `json:"md5"`
+	State        []byte                 `json:"state,omitempty"`
+	Outputs      []TerraformStateOutput `json:"outputs,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// TerraformStateOutput is a root module output, as recorded in a state
+// version. Value and Type are kept in the JSON encoding Terraform wrote them
+// in.
+type TerraformStateOutput struct {
+	Name      string          `json:"name"`
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// terraformStateFile is the part of a Terraform state file we care about.
+type terraformStateFile struct {
+	Serial  *int64 `json:"serial"`
+	Lineage string `json:"lineage"`
+	Outputs map[string]struct {
+		Value     json.RawMessage `json:"value"`
+		Type      json.RawMessage `json:"type"`
+		Sensitive bool            `json:"sensitive"`
+	} `json:"outputs"`
+}
+
+func (version TerraformStateVersion) in(scope Scope, workspaceID string) bool {
+	return scope.Contains(Scope{Organization: version.Organization, Project: version.Project}) && strings.EqualFold(version.WorkspaceID, workspaceID)
+}
+
+func (version TerraformStateVersion) summary() TerraformStateVersion {
+	version.State = nil
+	return version
+}
+
+// parseTerraformStateVersion checks an uploaded state version against the
+// state file it carries, and fills in its outputs from that state file.
+func parseTerraformStateVersion(version *TerraformStateVersion) []api.RequestError {
+	var errs []api.RequestError
+	if version.Serial == nil {
+		errs = append(errs, api.RequestError{Field: "/serial", Slug: api.RequestErrMissing})
+	} else if *version.Serial < 0 {
+		errs = append(errs, api.RequestError{Field: "/serial", Slug: api.RequestErrInvalidValue})
+	}
+	if version.MD5 == "" {
+		errs = append(errs, api.RequestError{Field: "/md5", Slug: api.RequestErrMissing})
+	} else if !terraformStateMD5.MatchString(version.MD5) {
+		errs = append(errs, api.RequestError{Field: "/md5", Slug: api.RequestErrInvalidFormat})
+	}
+	if len(version.State) < 1 {
+		errs = append(errs, api.RequestError{Field: "/state", Slug: api.RequestErrMissing})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	sum := md5.Sum(version.State)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), version.MD5) {
+		return []api.RequestError{{Field: "/md5", Slug: api.RequestErrInvalidValue}}
+	}
+	version.MD5 = strings.ToLower(version.MD5)
+	var state terraformStateFile
+	err := json.Unmarshal(version.State, &state)
+	if err != nil {
+		return []api.RequestError{{Field: "/state", Slug: api.RequestErrInvalidFormat}}
+	}
+	if state.Serial != nil && *state.Serial != *version.Serial {
+		errs = append(errs, api.RequestError{Field: "/serial", Slug: api.RequestErrInvalidValue})
+	}
+	if version.Lineage == "" {
+		version.Lineage = state.Lineage
+	} else if state.Lineage != "" && state.Lineage != version.Lineage {
+		errs = append(errs, api.RequestError{Field: "/lineage", Slug: api.RequestErrInvalidValue})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	version.Outputs = nil
+	for name, output := range state.Outputs {
+		version.Outputs = append(version.Outputs, TerraformStateOutput{
+			Name:      name,
+			Value:     output.Value,
+			Type:      output.Type,
+			Sensitive: output.Sensitive,
+		})
+	}
+	sort.Slice(version.Outputs, func(i, j int) bool {
+		return version.Outputs[i].Name < version.Outputs[j].Name
+	})
+	return nil
+}
+
+func (a API) handlePostTerraformStateVersion(w http.ResponseWriter, r *http.Request) {
+	var version TerraformStateVersion
+	err := api.Decode(r, &version)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	workspace, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if errs := parseTerraformStateVersion(&version); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	version.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	version.Organization = workspace.Organization
+	version.Project = workspace.Project
+	version.WorkspaceID = workspace.ID
+	version.CreatedAt = time.Now()
+	err = a.Storer.CreateTerraformStateVersion(version)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformStateVersionSerialConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/serial", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformStateVersionLineageConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/lineage", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformStateVersions: []TerraformStateVersion{version.summary()}})
+}
+
+func (a API) handleListTerraformStateVersions(w http.ResponseWriter, r *http.Request) {
+	_, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	versions, err := a.Storer.ListTerraformStateVersions(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	for pos := range versions {
+		versions[pos] = versions[pos].summary()
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformStateVersions: versions})
+}
+
+func (a API) handleGetTerraformStateVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := a.Storer.GetTerraformStateVersion(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("stateVersion"))
+	if err != nil {
+		if err == ErrTerraformStateVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "stateVersion", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformStateVersions: []TerraformStateVersion{version}})
+}
+
+func (a API) handleGetTerraformCurrentStateVersion(w http.ResponseWriter, r *http.Request) {
+	version, ok := a.currentTerraformStateVersion(w, r)
+	if !ok {
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformStateVersions: []TerraformStateVersion{version}})
+}
+
+func (a API) handleGetTerraformCurrentStateVersionOutputs(w http.ResponseWriter, r *http.Request) {
+	version, ok := a.currentTerraformStateVersion(w, r)
+	if !ok {
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformStateOutputs: version.Outputs})
+}
+
+// currentTerraformStateVersion writes the error response and returns false
+// if the workspace in the request doesn't exist or has no state yet.
+func (a API) currentTerraformStateVersion(w http.ResponseWriter, r *http.Request) (TerraformStateVersion, bool) {
+	_, err := a.Storer.GetTerraformWorkspace(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return TerraformStateVersion{}, false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return TerraformStateVersion{}, false
+	}
+	version, err := a.Storer.GetCurrentTerraformStateVersion(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformStateVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "stateVersion", Slug: api.RequestErrNotFound}}})
+			return TerraformStateVersion{}, false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return TerraformStateVersion{}, false
+	}
+	return version, true
+}
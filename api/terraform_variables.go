@@ -0,0 +1,369 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	TerraformVariableCategoryTerraform = "terraform"
+	TerraformVariableCategoryEnv       = "env"
+)
+
+// TerraformVariable is a variable that's set on every run in a workspace. A
+// variable belongs either to a single workspace, identified by WorkspaceID,
+// or to a variable set, identified by VariableSetID, which can be attached
+// to any number of workspaces.
+type TerraformVariable struct {
+	ID            string `json:"id"`
+	Organization  string `json:"organization"`
+	Project       string `json:"project"`
+	WorkspaceID   string `json:"workspaceID,omitempty"`
+	VariableSetID string `json:"variableSetID,omitempty"`
+	Key           string `json:"key"`
+	Value         string `json:"value"`
+	Description   string `json:"description"`
+	Category      string `json:"category"`
+	HCL           bool   `json:"hcl"`
+	Sensitive     bool   `json:"sensitive"`
+}
+
+type TerraformVariableSet struct {
+	ID           string   `json:"id"`
+	Organization string   `json:"organization"`
+	Project      string   `json:"project"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	WorkspaceIDs []string `json:"workspaceIDs"`
+}
+
+func (variable *TerraformVariable) FillDefaults() {
+	if variable.Category == "" {
+		variable.Category = TerraformVariableCategoryTerraform
+	}
+}
+
+// redacted returns the variable as it should be shown to users. The values
+// of sensitive variables can be written, but never read back.
+func (variable TerraformVariable) redacted() TerraformVariable {
+	if variable.Sensitive {
+		variable.Value = ""
+	}
+	return variable
+}
+
+func (variable TerraformVariable) in(scope Scope, workspaceID, variableSetID string) bool {
+	if !scope.Contains(Scope{Organization: variable.Organization, Project: variable.Project}) {
+		return false
+	}
+	return strings.EqualFold(variable.WorkspaceID, workspaceID) && strings.EqualFold(variable.VariableSetID, variableSetID)
+}
+
+func redactTerraformVariables(variables []TerraformVariable) []TerraformVariable {
+	results := make([]TerraformVariable, 0, len(variables))
+	for _, variable := range variables {
+		results = append(results, variable.redacted())
+	}
+	return results
+}
+
+// variableParent returns the workspace ID and variable set ID that the
+// variables in the request belong to. Only one of them will be set,
+// depending on whether the request was routed under a variable set.
+func variableParent(r *http.Request, inSet bool) (string, string) {
+	if inSet {
+		return "", trout.RequestVars(r).Get("id")
+	}
+	return trout.RequestVars(r).Get("id"), ""
+}
+
+func (a API) handleListTerraformWorkspaceVariables(w http.ResponseWriter, r *http.Request) {
+	a.listTerraformVariables(w, r, false)
+}
+
+func (a API) handlePostTerraformWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	a.postTerraformVariable(w, r, false)
+}
+
+func (a API) handleGetTerraformWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	a.getTerraformVariable(w, r, false)
+}
+
+func (a API) handlePutTerraformWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	a.putTerraformVariable(w, r, false)
+}
+
+func (a API) handleDeleteTerraformWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	a.deleteTerraformVariable(w, r, false)
+}
+
+func (a API) handleListTerraformVariableSetVariables(w http.ResponseWriter, r *http.Request) {
+	a.listTerraformVariables(w, r, true)
+}
+
+func (a API) handlePostTerraformVariableSetVariable(w http.ResponseWriter, r *http.Request) {
+	a.postTerraformVariable(w, r, true)
+}
+
+func (a API) handleGetTerraformVariableSetVariable(w http.ResponseWriter, r *http.Request) {
+	a.getTerraformVariable(w, r, true)
+}
+
+func (a API) handlePutTerraformVariableSetVariable(w http.ResponseWriter, r *http.Request) {
+	a.putTerraformVariable(w, r, true)
+}
+
+func (a API) handleDeleteTerraformVariableSetVariable(w http.ResponseWriter, r *http.Request) {
+	a.deleteTerraformVariable(w, r, true)
+}
+
+func validateTerraformVariable(variable TerraformVariable) []api.RequestError {
+	var errs []api.RequestError
+	if variable.Key == "" {
+		errs = append(errs, api.RequestError{Field: "/key", Slug: api.RequestErrMissing})
+	}
+	if variable.Category != TerraformVariableCategoryTerraform && variable.Category != TerraformVariableCategoryEnv {
+		errs = append(errs, api.RequestError{Field: "/category", Slug: api.RequestErrInvalidValue})
+	}
+	if variable.Category == TerraformVariableCategoryEnv && variable.HCL {
+		errs = append(errs, api.RequestError{Field: "/hcl", Slug: api.RequestErrConflict})
+	}
+	return errs
+}
+
+func (a API) listTerraformVariables(w http.ResponseWriter, r *http.Request, inSet bool) {
+	workspaceID, variableSetID := variableParent(r, inSet)
+	variables, err := a.Storer.ListTerraformVariables(requestScope(r), workspaceID, variableSetID)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound || err == ErrTerraformVariableSetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariables: redactTerraformVariables(variables)})
+}
+
+func (a API) postTerraformVariable(w http.ResponseWriter, r *http.Request, inSet bool) {
+	var variable TerraformVariable
+	err := api.Decode(r, &variable)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	variable.Organization = trout.RequestVars(r).Get("org")
+	variable.Project = trout.RequestVars(r).Get("project")
+	variable.WorkspaceID, variable.VariableSetID = variableParent(r, inSet)
+	variable.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	variable.FillDefaults()
+	if errs := validateTerraformVariable(variable); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	err = a.Storer.CreateTerraformVariable(variable)
+	if err != nil {
+		if err == ErrTerraformWorkspaceNotFound || err == ErrTerraformVariableSetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformVariableKeyConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/key", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformVariables: []TerraformVariable{variable.redacted()}})
+}
+
+func (a API) getTerraformVariable(w http.ResponseWriter, r *http.Request, inSet bool) {
+	workspaceID, variableSetID := variableParent(r, inSet)
+	variable, err := a.Storer.GetTerraformVariable(requestScope(r), workspaceID, variableSetID, trout.RequestVars(r).Get("var"))
+	if err != nil {
+		if err == ErrTerraformVariableNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "var", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariables: []TerraformVariable{variable.redacted()}})
+}
+
+func (a API) putTerraformVariable(w http.ResponseWriter, r *http.Request, inSet bool) {
+	var variable TerraformVariable
+	err := api.Decode(r, &variable)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if variable.ID != "" && variable.ID != trout.RequestVars(r).Get("var") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	variable.ID = trout.RequestVars(r).Get("var")
+	variable.Organization = trout.RequestVars(r).Get("org")
+	variable.Project = trout.RequestVars(r).Get("project")
+	variable.WorkspaceID, variable.VariableSetID = variableParent(r, inSet)
+	variable.FillDefaults()
+	if errs := validateTerraformVariable(variable); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	err = a.Storer.UpdateTerraformVariable(variable)
+	if err != nil {
+		if err == ErrTerraformVariableNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "var", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformVariableKeyConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/key", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariables: []TerraformVariable{variable.redacted()}})
+}
+
+func (a API) deleteTerraformVariable(w http.ResponseWriter, r *http.Request, inSet bool) {
+	workspaceID, variableSetID := variableParent(r, inSet)
+	variable, err := a.Storer.DeleteTerraformVariable(requestScope(r), workspaceID, variableSetID, trout.RequestVars(r).Get("var"))
+	if err != nil {
+		if err == ErrTerraformVariableNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "var", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariables: []TerraformVariable{variable.redacted()}})
+}
+
+func (a API) handleListTerraformVariableSets(w http.ResponseWriter, r *http.Request) {
+	sets, err := a.Storer.ListTerraformVariableSets(requestScope(r))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariableSets: sets})
+}
+
+func (a API) handlePostTerraformVariableSet(w http.ResponseWriter, r *http.Request) {
+	var set TerraformVariableSet
+	err := api.Decode(r, &set)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	set.Organization = trout.RequestVars(r).Get("org")
+	set.Project = trout.RequestVars(r).Get("project")
+	if set.ID == "" {
+		set.ID, err = uuid.GenerateUUID()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+	}
+	if set.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	if set.WorkspaceIDs == nil {
+		set.WorkspaceIDs = []string{}
+	}
+	err = a.Storer.CreateTerraformVariableSet(set)
+	if err != nil {
+		if err == ErrTerraformVariableSetAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformVariableSetNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/workspaceIDs", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformVariableSets: []TerraformVariableSet{set}})
+}
+
+func (a API) handleGetTerraformVariableSet(w http.ResponseWriter, r *http.Request) {
+	set, err := a.Storer.GetTerraformVariableSet(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformVariableSetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariableSets: []TerraformVariableSet{set}})
+}
+
+func (a API) handlePutTerraformVariableSet(w http.ResponseWriter, r *http.Request) {
+	var set TerraformVariableSet
+	err := api.Decode(r, &set)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	set.Organization = trout.RequestVars(r).Get("org")
+	set.Project = trout.RequestVars(r).Get("project")
+	if set.ID != "" && set.ID != trout.RequestVars(r).Get("id") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	set.ID = trout.RequestVars(r).Get("id")
+	if set.Name == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrMissing}}})
+		return
+	}
+	if set.WorkspaceIDs == nil {
+		set.WorkspaceIDs = []string{}
+	}
+	err = a.Storer.UpdateTerraformVariableSet(set)
+	if err != nil {
+		if err == ErrTerraformVariableSetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformVariableSetNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrTerraformWorkspaceNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/workspaceIDs", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariableSets: []TerraformVariableSet{set}})
+}
+
+func (a API) handleDeleteTerraformVariableSet(w http.ResponseWriter, r *http.Request) {
+	set, err := a.Storer.DeleteTerraformVariableSet(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformVariableSetNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVariableSets: []TerraformVariableSet{set}})
+}
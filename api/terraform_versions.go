@@ -0,0 +1,375 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// TerraformVersion is an entry in the catalog of Terraform versions that
+// workspaces can use. The catalog is managed by admins.
+//
+// Workspaces can pin a version in the catalog or use a constraint like
+// "~> 1.5". Constraints only ever select versions that are neither beta nor
+// deprecated; beta versions have to be pinned exactly. Deprecated versions
+// can't be newly selected, but workspaces already using them keep working
+// until they change their terraformVersion.
+//
+// While the catalog is empty, workspaces can use any well-formed version or
+// constraint.
+type TerraformVersion struct {
+	Version    string    `json:"version"`
+	Beta       bool      `json:"beta"`
+	Deprecated bool      `json:"deprecated"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+var (
+	errTerraformVersionFormat    = errors.New("invalid Terraform version")
+	errTerraformConstraintFormat = errors.New("invalid Terraform version constraint")
+)
+
+var (
+	semverRegexp                = regexp.MustCompile(`^v?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+	terraformConstraintOpRegexp = regexp.MustCompile(`^(~>|>=|<=|!=|=|>|<)?\s*(.*)$`)
+)
+
+// semver is a parsed semantic version. segments is how many of major,
+// minor, and patch were actually specified, which matters for the "~>"
+// operator.
+type semver struct {
+	major, minor, patch int64
+	prerelease          string
+	segments            int
+}
+
+func parseSemver(s string) (semver, error) {
+	matches := semverRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return semver{}, errTerraformVersionFormat
+	}
+	var v semver
+	for pos, dst := range []*int64{&v.major, &v.minor, &v.patch} {
+		if matches[pos+1] == "" {
+			break
+		}
+		n, err := strconv.ParseInt(matches[pos+1], 10, 64)
+		if err != nil {
+			return semver{}, errTerraformVersionFormat
+		}
+		*dst = n
+		v.segments++
+	}
+	v.prerelease = matches[4]
+	if v.prerelease != "" && v.segments < 3 {
+		return semver{}, errTerraformVersionFormat
+	}
+	return v, nil
+}
+
+// compare returns -1, 0, or 1 depending on whether v sorts before, the same
+// as, or after other, following the semantic versioning precedence rules.
+func (v semver) compare(other semver) int {
+	for _, pair := range [][2]int64{{v.major, other.major}, {v.minor, other.minor}, {v.patch, other.patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	if v.prerelease == other.prerelease {
+		return 0
+	}
+	// a version without a prerelease sorts after any prerelease of it
+	if v.prerelease == "" {
+		return 1
+	}
+	if other.prerelease == "" {
+		return -1
+	}
+	vParts, otherParts := strings.Split(v.prerelease, "."), strings.Split(other.prerelease, ".")
+	for pos := 0; pos < len(vParts) && pos < len(otherParts); pos++ {
+		if c := comparePrereleasePart(vParts[pos], otherParts[pos]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(vParts) < len(otherParts):
+		return -1
+	case len(vParts) > len(otherParts):
+		return 1
+	}
+	return 0
+}
+
+// comparePrereleasePart compares one dot-separated identifier of a
+// prerelease. Numeric identifiers compare numerically and sort before
+// alphanumeric ones.
+func comparePrereleasePart(a, b string) int {
+	aNum, aErr := strconv.ParseInt(a, 10, 64)
+	bNum, bErr := strconv.ParseInt(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case aNum < bNum:
+			return -1
+		case aNum > bNum:
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// terraformVersionConstraint is one comma-separated part of a constraint
+// string like ">= 1.3, < 1.6".
+type terraformVersionConstraint struct {
+	op      string
+	version semver
+}
+
+// parseTerraformVersionConstraints parses a workspace's terraformVersion. A
+// plain version is a constraint that only matches that version.
+func parseTerraformVersionConstraints(s string) ([]terraformVersionConstraint, error) {
+	var constraints []terraformVersionConstraint
+	for _, part := range strings.Split(s, ",") {
+		matches := terraformConstraintOpRegexp.FindStringSubmatch(strings.TrimSpace(part))
+		if matches == nil || matches[2] == "" {
+			return nil, errTerraformConstraintFormat
+		}
+		version, err := parseSemver(matches[2])
+		if err != nil {
+			return nil, errTerraformConstraintFormat
+		}
+		op := matches[1]
+		if op == "" {
+			op = "="
+		}
+		constraints = append(constraints, terraformVersionConstraint{op: op, version: version})
+	}
+	return constraints, nil
+}
+
+// exactTerraformVersion reports whether constraints pin a single, fully
+// specified version.
+func exactTerraformVersion(constraints []terraformVersionConstraint) bool {
+	return len(constraints) == 1 && constraints[0].op == "=" && constraints[0].version.segments == 3
+}
+
+// matches reports whether v satisfies the constraint. Like Terraform, a
+// prerelease only satisfies constraints that name that exact prerelease, so
+// "~> 1.5" doesn't match 2.0.0-beta1 and ">= 1.3" doesn't match
+// 1.6.0-beta1, even though they sort inside those ranges.
+func (c terraformVersionConstraint) matches(v semver) bool {
+	if v.prerelease != "" && (c.version.major != v.major || c.version.minor != v.minor || c.version.patch != v.patch || c.version.prerelease != v.prerelease) {
+		return false
+	}
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		// "~> 1.5" allows anything from 1.5 up to, but not including, 2.0;
+		// "~> 1.5.2" allows anything from 1.5.2 up to, but not including,
+		// 1.6.0.
+		if cmp < 0 {
+			return false
+		}
+		upper := semver{major: c.version.major + 1}
+		if c.version.segments == 3 {
+			upper = semver{major: c.version.major, minor: c.version.minor + 1}
+		}
+		return v.compare(upper) < 0
+	}
+	return false
+}
+
+func terraformVersionMatches(constraints []terraformVersionConstraint, v semver) bool {
+	for _, c := range constraints {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateTerraformVersion(version TerraformVersion) []api.RequestError {
+	if version.Version == "" {
+		return []api.RequestError{{Field: "/version", Slug: api.RequestErrMissing}}
+	}
+	v, err := parseSemver(version.Version)
+	if err != nil || v.segments < 3 || strings.HasPrefix(version.Version, "v") {
+		return []api.RequestError{{Field: "/version", Slug: api.RequestErrInvalidFormat}}
+	}
+	return nil
+}
+
+func validateTerraformWorkspaceVersion(workspace TerraformWorkspace) []api.RequestError {
+	_, err := parseTerraformVersionConstraints(workspace.TerraformVersion)
+	if err != nil {
+		return []api.RequestError{{Field: "/terraformVersion", Slug: api.RequestErrInvalidFormat}}
+	}
+	return nil
+}
+
+func (a API) handleListTerraformVersions(w http.ResponseWriter, r *http.Request) {
+	var includeBeta, includeDeprecated bool
+	if v := r.URL.Query().Get("include_beta"); v != "" {
+		var err error
+		includeBeta, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_beta", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	if v := r.URL.Query().Get("include_deprecated"); v != "" {
+		var err error
+		includeDeprecated, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deprecated", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	var constraints []terraformVersionConstraint
+	if v := r.URL.Query().Get("constraint"); v != "" {
+		var err error
+		constraints, err = parseTerraformVersionConstraints(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "constraint", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	versions, err := a.Storer.ListTerraformVersions()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := make([]TerraformVersion, 0, len(versions))
+	for _, version := range versions {
+		if version.Beta && !includeBeta {
+			continue
+		}
+		if version.Deprecated && !includeDeprecated {
+			continue
+		}
+		if constraints != nil {
+			v, err := parseSemver(version.Version)
+			if err != nil || !terraformVersionMatches(constraints, v) {
+				continue
+			}
+		}
+		results = append(results, version)
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVersions: results})
+}
+
+func (a API) handleGetTerraformVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := a.Storer.GetTerraformVersion(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		if err == ErrTerraformVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVersions: []TerraformVersion{version}})
+}
+
+func (a API) handlePostTerraformVersion(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var version TerraformVersion
+	err := api.Decode(r, &version)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := validateTerraformVersion(version); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	version.CreatedAt = time.Now()
+	err = a.Storer.CreateTerraformVersion(version)
+	if err != nil {
+		if err == ErrTerraformVersionAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/version", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformVersions: []TerraformVersion{version}})
+}
+
+func (a API) handlePutTerraformVersion(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	var version TerraformVersion
+	err := api.Decode(r, &version)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if version.Version != "" && version.Version != trout.RequestVars(r).Get("version") {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/version", Slug: api.RequestErrConflict}}})
+		return
+	}
+	version.Version = trout.RequestVars(r).Get("version")
+	version, err = a.Storer.UpdateTerraformVersion(version)
+	if err != nil {
+		if err == ErrTerraformVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVersions: []TerraformVersion{version}})
+}
+
+func (a API) handleDeleteTerraformVersion(w http.ResponseWriter, r *http.Request) {
+	if !isAuthenticated(r) {
+		api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+		return
+	}
+	version, err := a.Storer.DeleteTerraformVersion(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		if err == ErrTerraformVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrTerraformVersionInUse {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVersions: []TerraformVersion{version}})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+// TerraformVCSEvent is a push to a VCS repository, as delivered to the VCS
+// webhook, along with what it meant for each workspace connected to that
+// repository.
+type TerraformVCSEvent struct {
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	Repository   string                 `json:"repository"`
+	Branch       string                 `json:"branch"`
+	CommitSHA    string                 `json:"commitSHA"`
+	Message      string                 `json:"message"`
+	ChangedFiles []string               `json:"changedFiles"`
+	Decisions    []TerraformVCSDecision `json:"decisions"`
+	ReceivedAt   time.Time              `json:"receivedAt"`
+}
+
+// TerraformVCSDecision records whether a push queued a run in a workspace,
+// and why.
+type TerraformVCSDecision struct {
+	WorkspaceID string `json:"workspaceID"`
+	Triggered   bool   `json:"triggered"`
+	Reason      string `json:"reason"`
+	RunID       string `json:"runID,omitempty"`
+}
+
+func validateTerraformVCSEvent(event TerraformVCSEvent) []api.RequestError {
+	var errs []api.RequestError
+	if event.Repository == "" {
+		errs = append(errs, api.RequestError{Field: "/repository", Slug: api.RequestErrMissing})
+	}
+	if event.Branch == "" {
+		errs = append(errs, api.RequestError{Field: "/branch", Slug: api.RequestErrMissing})
+	}
+	for pos, file := range event.ChangedFiles {
+		if file == "" {
+			errs = append(errs, api.RequestError{Field: "/changedFiles/" + strconv.Itoa(pos), Slug: api.RequestErrMissing})
+		}
+	}
+	return errs
+}
+
+// terraformVCSTrigger decides whether event should queue a run in
+// workspace, which must be connected to the event's repository, and
+// explains the decision.
+//
+// The push has to be to the branch the workspace tracks. With file triggers
+// disabled, any push to that branch queues a run. Otherwise, at least one
+// changed file has to be inside the workspace's working directory or start
+// with one of its trigger prefixes; a workspace without a working directory
+// treats the whole repository as its working directory.
+func terraformVCSTrigger(workspace TerraformWorkspace, event TerraformVCSEvent) (bool, string) {
+	if event.Branch != workspace.VCSRepo.Branch {
+		return false, "push was to branch " + strconv.Quote(event.Branch) + ", but the workspace tracks branch " + strconv.Quote(workspace.VCSRepo.Branch)
+	}
+	if workspace.FileTriggersEnabled != nil && !*workspace.FileTriggersEnabled {
+		return true, "file triggers are disabled, so every push to branch " + strconv.Quote(event.Branch) + " queues a run"
+	}
+	workingDirectory := cleanVCSPath(workspace.WorkingDirectory)
+	for _, file := range event.ChangedFiles {
+		file = cleanVCSPath(file)
+		if workingDirectory == "" {
+			return true, "changed file " + strconv.Quote(file) + " is in the repository, which is the workspace's working directory"
+		}
+		if file == workingDirectory || strings.HasPrefix(file, workingDirectory+"/") {
+			return true, "changed file " + strconv.Quote(file) + " is in working directory " + strconv.Quote(workingDirectory)
+		}
+		for _, prefix := range workspace.TriggerPrefixes {
+			if strings.HasPrefix(file, strings.TrimPrefix(prefix, "/")) {
+				return true, "changed file " + strconv.Quote(file) + " matches trigger prefix " + strconv.Quote(prefix)
+			}
+		}
+	}
+	if len(event.ChangedFiles) < 1 {
+		return false, "no files changed"
+	}
+	if len(workspace.TriggerPrefixes) < 1 {
+		return false, "no changed files are in working directory " + strconv.Quote(workingDirectory)
+	}
+	return false, "no changed files are in working directory " + strconv.Quote(workingDirectory) + " or match a trigger prefix"
+}
+
+// cleanVCSPath normalises a repository path, so "./modules/" and "modules"
+// compare equal. The repository root is "".
+func cleanVCSPath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
+func (a API) handlePostTerraformVCSWebhook(w http.ResponseWriter, r *http.Request) {
+	var event TerraformVCSEvent
+	err := api.Decode(r, &event)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := validateTerraformVCSEvent(event); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	scope := requestScope(r)
+	event.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	event.Organization = scope.Organization
+	event.Project = scope.Project
+	event.ReceivedAt = time.Now()
+	if event.ChangedFiles == nil {
+		event.ChangedFiles = []string{}
+	}
+	event.Decisions = []TerraformVCSDecision{}
+	workspaces, err := a.Storer.ListTerraformWorkspaces(scope, false)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	var runs []TerraformRun
+	for _, workspace := range workspaces {
+		if !strings.EqualFold(workspace.VCSRepo.Identifier, event.Repository) {
+			continue
+		}
+		triggered, reason := terraformVCSTrigger(workspace, event)
+		if triggered && workspace.Lock != nil {
+			triggered, reason = false, reason+", but the workspace is locked by "+strconv.Quote(workspace.Lock.Holder)
+		}
+		decision := TerraformVCSDecision{
+			WorkspaceID: workspace.ID,
+			Triggered:   triggered,
+			Reason:      reason,
+		}
+		if triggered {
+			run := TerraformRun{
+				Organization: workspace.Organization,
+				Project:      workspace.Project,
+				WorkspaceID:  workspace.ID,
+				Message:      event.Message,
+				AutoApply:    workspace.AutoApply,
+				CreatedAt:    event.ReceivedAt,
+			}
+			if run.Message == "" {
+				run.Message = "Queued by push to branch " + event.Branch
+			}
+			run.ID, err = uuid.GenerateUUID()
+			if err != nil {
+				api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+				return
+			}
+			run.setStatus(TerraformRunPending, "")
+			runs = append(runs, run)
+			decision.RunID = run.ID
+		}
+		event.Decisions = append(event.Decisions, decision)
+	}
+	err = a.Storer.CreateTerraformVCSEvent(event, runs)
+	if err != nil {
+		if err == ErrTerraformWorkspaceLocked {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: lockedError})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{TerraformVCSEvents: []TerraformVCSEvent{event}})
+}
+
+func (a API) handleListTerraformVCSEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := a.Storer.ListTerraformVCSEvents(requestScope(r))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVCSEvents: events})
+}
+
+func (a API) handleGetTerraformVCSEvent(w http.ResponseWriter, r *http.Request) {
+	event, err := a.Storer.GetTerraformVCSEvent(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrTerraformVCSEventNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{TerraformVCSEvents: []TerraformVCSEvent{event}})
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -10,11 +12,15 @@ import (
 
 type VaultCluster struct {
 	ID              string                  `json:"id"`
+	Organization    string                  `json:"organization"`
+	Project         string                  `json:"project"`
 	Name            string                  `json:"name"`
 	Region          string                  `json:"region"`
 	DefaultLeaseTTL string                  `json:"defaultLeaseTTL"`
 	MaxLeaseTTL     string                  `json:"maxLeaseTTL"`
 	TCPListener     VaultClusterTCPListener `json:"tcpListener"`
+	SealStatus      VaultClusterSealStatus  `json:"sealStatus"`
+	DeletedAt       *time.Time              `json:"deletedAt,omitempty"`
 }
 
 type VaultClusterTCPListener struct {
@@ -37,8 +43,44 @@ func (cluster *VaultCluster) FillDefaults() {
 	}
 }
 
+// leaseTTLs parses the cluster's DefaultLeaseTTL and MaxLeaseTTL.
+func (cluster VaultCluster) leaseTTLs() (defaultTTL, maxTTL time.Duration, err error) {
+	defaultTTL, err = time.ParseDuration(cluster.DefaultLeaseTTL)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxTTL, err = time.ParseDuration(cluster.MaxLeaseTTL)
+	if err != nil {
+		return 0, 0, err
+	}
+	return defaultTTL, maxTTL, nil
+}
+
+// leaseTTLErrors returns the problems with the cluster's lease TTLs. Both
+// need to be positive Go durations, and the default can't be longer than the
+// max.
+func (cluster VaultCluster) leaseTTLErrors() []api.RequestError {
+	var errs []api.RequestError
+	defaultTTL, err := time.ParseDuration(cluster.DefaultLeaseTTL)
+	if err != nil {
+		errs = append(errs, api.RequestError{Field: "/defaultLeaseTTL", Slug: api.RequestErrInvalidFormat})
+	} else if defaultTTL <= 0 {
+		errs = append(errs, api.RequestError{Field: "/defaultLeaseTTL", Slug: api.RequestErrInvalidValue})
+	}
+	maxTTL, err := time.ParseDuration(cluster.MaxLeaseTTL)
+	if err != nil {
+		errs = append(errs, api.RequestError{Field: "/maxLeaseTTL", Slug: api.RequestErrInvalidFormat})
+	} else if maxTTL <= 0 {
+		errs = append(errs, api.RequestError{Field: "/maxLeaseTTL", Slug: api.RequestErrInvalidValue})
+	}
+	if len(errs) < 1 && defaultTTL > maxTTL {
+		errs = append(errs, api.RequestError{Field: "/defaultLeaseTTL", Slug: api.RequestErrConflict})
+	}
+	return errs
+}
+
 func (a API) handleGetVaultCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetVaultCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrVaultClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -57,6 +99,10 @@ func (a API) handlePostVaultCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.SealStatus = VaultClusterSealStatus{}
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	regions := getRegions(isAuthenticated(r))
 	var validRegion bool
 	for _, region := range regions {
@@ -85,12 +131,24 @@ func (a API) handlePostVaultCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
-	err = a.Storer.CreateVaultCluster(cluster)
+	if errs := append(cluster.leaseTTLErrors(), cluster.tcpListenerErrors()...); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	cluster, err = a.Storer.CreateVaultCluster(cluster)
 	if err != nil {
 		if err == ErrVaultClusterAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if err == ErrVaultClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if errs := vaultListenerError(err); errs != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -104,6 +162,9 @@ func (a API) handlePutVaultCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	cluster.DeletedAt = nil
+	cluster.Organization = trout.RequestVars(r).Get("org")
+	cluster.Project = trout.RequestVars(r).Get("project")
 	if cluster.ID != "" && cluster.ID != trout.RequestVars(r).Get("id") {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 		return
@@ -113,12 +174,24 @@ func (a API) handlePutVaultCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cluster.FillDefaults()
-	err = a.Storer.UpdateVaultCluster(cluster)
+	if errs := append(cluster.leaseTTLErrors(), cluster.tcpListenerErrors()...); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	cluster, err = a.Storer.UpdateVaultCluster(cluster)
 	if err != nil {
 		if err == ErrVaultClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrVaultClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if errs := vaultListenerError(err); errs != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -126,21 +199,137 @@ func (a API) handlePutVaultCluster(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a API) handleDeleteVaultCluster(w http.ResponseWriter, r *http.Request) {
-	cluster, err := a.Storer.GetVaultCluster(trout.RequestVars(r).Get("id"))
+	cluster, err := a.Storer.GetVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrVaultClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.Storer.DeleteVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrVaultClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: []VaultCluster{cluster}})
+}
+
+func (a API) handleGetVaultClusterVersions(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	_, err := a.Storer.GetVaultCluster(requestScope(r), id)
+	if err != nil {
+		if err == ErrVaultClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	versions, err := a.Storer.ListVersions("vaultCluster", id)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = fillVersionChanges(versions)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Versions: versions})
+}
+
+func (a API) handlePostVaultClusterVersionRestore(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(trout.RequestVars(r).Get("version"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	// restoring a version is an update like any other, so the version has
+	// to pass the same checks
+	cluster, err := a.Storer.GetVaultClusterVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
 	if err != nil {
 		if err == ErrVaultClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteVaultCluster(trout.RequestVars(r).Get("id"))
+	if errs := append(cluster.leaseTTLErrors(), cluster.tcpListenerErrors()...); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	cluster, err = a.Storer.RestoreVaultClusterVersion(requestScope(r), trout.RequestVars(r).Get("id"), version)
 	if err != nil {
 		if err == ErrVaultClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrVersionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVaultClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if errs := vaultListenerError(err); errs != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: []VaultCluster{cluster}})
+}
+
+func (a API) handleListVaultClusters(w http.ResponseWriter, r *http.Request) {
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(v)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "include_deleted", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	results, err := a.Storer.ListVaultClusters(requestScope(r), includeDeleted)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: results})
+}
+
+func (a API) handlePostVaultClusterUndelete(w http.ResponseWriter, r *http.Request) {
+	cluster, err := a.Storer.UndeleteVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrVaultClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNotDeleted {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrVaultClusterNameConflict {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if errs := vaultListenerError(err); errs != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
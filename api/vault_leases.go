@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+// VaultLease is a set of dynamic credentials issued by a Vault cluster. The
+// credentials are only returned when the lease is issued. Leases expire at
+// ExpiresAt unless they're renewed, and can never be renewed past the
+// cluster's MaxLeaseTTL after they were issued.
+type VaultLease struct {
+	ID           string            `json:"id"`
+	Organization string            `json:"organization"`
+	Project      string            `json:"project"`
+	ClusterID    string            `json:"clusterID"`
+	Role         string            `json:"role"`
+	TTL          string            `json:"ttl"`
+	Renewable    bool              `json:"renewable"`
+	Credentials  map[string]string `json:"credentials,omitempty"`
+	IssuedAt     time.Time         `json:"issuedAt"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+}
+
+// VaultLeaseRenewal is the body of a request to renew a lease. Increment is
+// how long from now the lease should last, defaulting to the cluster's
+// DefaultLeaseTTL.
+type VaultLeaseRenewal struct {
+	Increment string `json:"increment"`
+}
+
+// expired reports whether the lease had expired at now.
+func (lease VaultLease) expired(now time.Time) bool {
+	return !lease.ExpiresAt.After(now)
+}
+
+// withoutCredentials returns a copy of the lease without its credentials.
+func (lease VaultLease) withoutCredentials() VaultLease {
+	lease.Credentials = nil
+	return lease
+}
+
+// clampVaultLease sets the lease to expire ttl after now, or at the latest
+// expiry maxTTL allows. A ttl of 0 uses defaultTTL.
+func clampVaultLease(lease *VaultLease, now time.Time, ttl, defaultTTL, maxTTL time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	latest := lease.IssuedAt.Add(maxTTL)
+	lease.ExpiresAt = now.Add(ttl)
+	if lease.ExpiresAt.After(latest) {
+		lease.ExpiresAt = latest
+	}
+	lease.TTL = lease.ExpiresAt.Sub(now).String()
+	lease.Renewable = lease.ExpiresAt.Before(latest)
+}
+
+// parseLeaseTTL parses a TTL requested for a lease. An empty TTL is 0, for
+// the cluster's default.
+func parseLeaseTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (a API) handleListVaultLeases(w http.ResponseWriter, r *http.Request) {
+	leases, err := a.Storer.ListVaultLeases(requestScope(r), trout.RequestVars(r).Get("id"))
+	if err != nil {
+		a.encodeVaultLeaseError(w, r, err)
+		return
+	}
+	results := []VaultLease{}
+	for _, lease := range leases {
+		results = append(results, lease.withoutCredentials())
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultLeases: results})
+}
+
+// handlePostVaultLease issues new dynamic credentials for a role. The lease
+// lasts for the requested TTL, clamped to the cluster's MaxLeaseTTL, or the
+// cluster's DefaultLeaseTTL if no TTL is requested.
+func (a API) handlePostVaultLease(w http.ResponseWriter, r *http.Request) {
+	var lease VaultLease
+	err := api.Decode(r, &lease)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if lease.Role == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/role", Slug: api.RequestErrMissing}}})
+		return
+	}
+	ttl, err := parseLeaseTTL(lease.TTL)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/ttl", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if ttl < 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/ttl", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	lease.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	password, err := uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	lease.Organization = trout.RequestVars(r).Get("org")
+	lease.Project = trout.RequestVars(r).Get("project")
+	lease.ClusterID = trout.RequestVars(r).Get("id")
+	lease.Credentials = map[string]string{
+		"username": "v-" + lease.Role + "-" + lease.ID[:8],
+		"password": password,
+	}
+	lease, err = a.Storer.IssueVaultLease(lease, ttl)
+	if err != nil {
+		a.encodeVaultLeaseError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{VaultLeases: []VaultLease{lease}})
+}
+
+func (a API) handleGetVaultLease(w http.ResponseWriter, r *http.Request) {
+	lease, err := a.Storer.GetVaultLease(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("lease"))
+	if err != nil {
+		a.encodeVaultLeaseError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultLeases: []VaultLease{lease.withoutCredentials()}})
+}
+
+// handlePostVaultLeaseRenew extends a lease by the requested increment,
+// without letting it outlive the cluster's MaxLeaseTTL.
+func (a API) handlePostVaultLeaseRenew(w http.ResponseWriter, r *http.Request) {
+	var renewal VaultLeaseRenewal
+	err := api.Decode(r, &renewal)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	increment, err := parseLeaseTTL(renewal.Increment)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/increment", Slug: api.RequestErrInvalidFormat}}})
+		return
+	}
+	if increment < 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/increment", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	lease, err := a.Storer.RenewVaultLease(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("lease"), increment)
+	if err != nil {
+		a.encodeVaultLeaseError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultLeases: []VaultLease{lease.withoutCredentials()}})
+}
+
+// handleDeleteVaultLease revokes a lease before it expires.
+func (a API) handleDeleteVaultLease(w http.ResponseWriter, r *http.Request) {
+	lease, err := a.Storer.RevokeVaultLease(requestScope(r), trout.RequestVars(r).Get("id"), trout.RequestVars(r).Get("lease"))
+	if err != nil {
+		a.encodeVaultLeaseError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultLeases: []VaultLease{lease.withoutCredentials()}})
+}
+
+func (a API) encodeVaultLeaseError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrVaultClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrVaultClusterSealed:
+		api.Encode(w, r, http.StatusServiceUnavailable, Response{Errors: sealedError})
+	case ErrVaultLeaseNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "lease", Slug: api.RequestErrNotFound}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+// sortVaultLeases sorts leases by when they expire, soonest first.
+func sortVaultLeases(leases []VaultLease) {
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].ExpiresAt.Equal(leases[j].ExpiresAt) {
+			return leases[i].ID < leases[j].ID
+		}
+		return leases[i].ExpiresAt.Before(leases[j].ExpiresAt)
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"darlinggo.co/api"
+)
+
+const (
+	// defaultVaultAddressPort and defaultVaultClusterAddressPort are the
+	// first ports tried when a listener address asks for a port to be
+	// assigned by using port 0.
+	defaultVaultAddressPort        = 8200
+	defaultVaultClusterAddressPort = 8201
+	maxPort                        = 65535
+)
+
+// vaultListenerAddress is a parsed TCP listener address.
+type vaultListenerAddress struct {
+	Host string
+	Port int
+}
+
+// parseVaultListenerAddress parses a host:port listener address. Port 0
+// means a free port should be assigned.
+func parseVaultListenerAddress(address string) (vaultListenerAddress, bool) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || strings.ContainsAny(host, " \t") {
+		return vaultListenerAddress{}, false
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > maxPort {
+		return vaultListenerAddress{}, false
+	}
+	return vaultListenerAddress{Host: host, Port: p}, true
+}
+
+func (addr vaultListenerAddress) String() string {
+	return net.JoinHostPort(addr.Host, strconv.Itoa(addr.Port))
+}
+
+// conflicts reports whether addr and other can't both be listened on, which
+// is the case if they use the same port on the same host, or the same port
+// and either of them listens on every interface.
+func (addr vaultListenerAddress) conflicts(other vaultListenerAddress) bool {
+	if addr.Port != other.Port {
+		return false
+	}
+	if strings.EqualFold(addr.Host, other.Host) {
+		return true
+	}
+	return addr.unspecified() || other.unspecified()
+}
+
+// unspecified reports whether addr listens on every interface.
+func (addr vaultListenerAddress) unspecified() bool {
+	ip := net.ParseIP(addr.Host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// tcpListenerErrors returns the problems with the cluster's TCP listener
+// addresses, which need to be host:port addresses that don't conflict with
+// each other.
+func (cluster VaultCluster) tcpListenerErrors() []api.RequestError {
+	var errs []api.RequestError
+	address, ok := parseVaultListenerAddress(cluster.TCPListener.Address)
+	if !ok {
+		errs = append(errs, api.RequestError{Field: "/tcpListener/address", Slug: api.RequestErrInvalidFormat})
+	}
+	clusterAddress, clusterOK := parseVaultListenerAddress(cluster.TCPListener.ClusterAddress)
+	if !clusterOK {
+		errs = append(errs, api.RequestError{Field: "/tcpListener/clusterAddress", Slug: api.RequestErrInvalidFormat})
+	}
+	if ok && clusterOK && address.Port != 0 && address.conflicts(clusterAddress) {
+		errs = append(errs, api.RequestError{Field: "/tcpListener/clusterAddress", Slug: api.RequestErrConflict})
+	}
+	return errs
+}
+
+// vaultListenerError returns the errors to respond with when the Storer
+// refuses a cluster's listener addresses, or nil if err isn't about them.
+func vaultListenerError(err error) []api.RequestError {
+	switch err {
+	case ErrVaultClusterAddressInvalid:
+		return []api.RequestError{{Field: "/tcpListener/address", Slug: api.RequestErrInvalidFormat}}
+	case ErrVaultClusterAddressConflict:
+		return []api.RequestError{{Field: "/tcpListener/address", Slug: api.RequestErrConflict}}
+	case ErrVaultClusterClusterAddressInvalid:
+		return []api.RequestError{{Field: "/tcpListener/clusterAddress", Slug: api.RequestErrInvalidFormat}}
+	case ErrVaultClusterClusterAddressConflict:
+		return []api.RequestError{{Field: "/tcpListener/clusterAddress", Slug: api.RequestErrConflict}}
+	}
+	return nil
+}
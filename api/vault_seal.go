@@ -0,0 +1,205 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// VaultClusterSealStatus describes whether a Vault cluster has been
+// initialized and whether it's sealed. New clusters aren't initialized, and
+// aren't sealed either; once they're initialized, they start out sealed and
+// need SecretThreshold of their unseal keys before they can be used. While a
+// cluster is sealed, its KV store and leases can't be used.
+type VaultClusterSealStatus struct {
+	Initialized     bool `json:"initialized"`
+	Sealed          bool `json:"sealed"`
+	SecretShares    int  `json:"secretShares,omitempty"`
+	SecretThreshold int  `json:"secretThreshold,omitempty"`
+	UnsealProgress  int  `json:"unsealProgress"`
+}
+
+// VaultClusterInit is the body of a request to initialize a Vault cluster.
+// The cluster's root key is split into SecretShares unseal keys, of which
+// SecretThreshold are needed to unseal it.
+type VaultClusterInit struct {
+	SecretShares    int `json:"secretShares"`
+	SecretThreshold int `json:"secretThreshold"`
+}
+
+// VaultClusterKeys are the unseal keys and root token generated when a Vault
+// cluster is initialized. They're only ever returned once.
+type VaultClusterKeys struct {
+	ClusterID string   `json:"clusterID"`
+	Keys      []string `json:"keys"`
+	RootToken string   `json:"rootToken"`
+}
+
+// VaultClusterUnseal is the body of a request to unseal a Vault cluster,
+// providing one of its unseal keys. If Reset is set, any keys provided so
+// far are discarded instead.
+type VaultClusterUnseal struct {
+	Key   string `json:"key"`
+	Reset bool   `json:"reset"`
+}
+
+// VaultClusterSeal is the body of a request to seal a Vault cluster, which
+// needs the cluster's root token.
+type VaultClusterSeal struct {
+	Token string `json:"token"`
+}
+
+// vaultSeal holds hashes of a Vault cluster's unseal keys and root token, and
+// of the unseal keys provided since it was last sealed.
+type vaultSeal struct {
+	ClusterID     string
+	KeyHashes     []string
+	RootTokenHash string
+	Provided      []string
+}
+
+const (
+	defaultVaultSecretShares    = 5
+	defaultVaultSecretThreshold = 3
+	maxVaultSecretShares        = 255
+)
+
+// sealedError is the error returned when a request needs a Vault cluster to
+// be unsealed, but it's sealed.
+var sealedError = []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}
+
+// hashVaultKey returns the hash of an unseal key or root token, which is all
+// that's stored of them.
+func hashVaultKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateVaultKey returns a new random unseal key or root token.
+func generateVaultKey() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func (a API) handlePostVaultClusterInit(w http.ResponseWriter, r *http.Request) {
+	var init VaultClusterInit
+	err := api.Decode(r, &init)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if init.SecretShares == 0 && init.SecretThreshold == 0 {
+		init.SecretShares = defaultVaultSecretShares
+		init.SecretThreshold = defaultVaultSecretThreshold
+	}
+	if init.SecretShares < 1 || init.SecretShares > maxVaultSecretShares {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/secretShares", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if init.SecretThreshold < 1 || init.SecretThreshold > init.SecretShares {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/secretThreshold", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	keys := VaultClusterKeys{ClusterID: trout.RequestVars(r).Get("id")}
+	seal := vaultSeal{ClusterID: keys.ClusterID}
+	for i := 0; i < init.SecretShares; i++ {
+		key, err := generateVaultKey()
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		keys.Keys = append(keys.Keys, key)
+		seal.KeyHashes = append(seal.KeyHashes, hashVaultKey(key))
+	}
+	keys.RootToken, err = generateVaultKey()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	seal.RootTokenHash = hashVaultKey(keys.RootToken)
+	cluster, err := a.Storer.InitVaultCluster(requestScope(r), seal, init.SecretThreshold)
+	if err != nil {
+		if err == ErrVaultClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrVaultClusterAlreadyInitialized {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: []VaultCluster{cluster}, VaultClusterKeys: []VaultClusterKeys{keys}})
+}
+
+// handlePostVaultClusterUnseal takes one of a sealed cluster's unseal keys.
+// The cluster is unsealed once it has been given SecretThreshold different
+// keys.
+func (a API) handlePostVaultClusterUnseal(w http.ResponseWriter, r *http.Request) {
+	var unseal VaultClusterUnseal
+	err := api.Decode(r, &unseal)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if unseal.Key == "" && !unseal.Reset {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/key", Slug: api.RequestErrMissing}}})
+		return
+	}
+	var keyHash string
+	if !unseal.Reset {
+		keyHash = hashVaultKey(unseal.Key)
+	}
+	cluster, err := a.Storer.UnsealVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"), keyHash)
+	if err != nil {
+		a.encodeVaultSealError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: []VaultCluster{cluster}})
+}
+
+// handlePostVaultClusterSeal seals a cluster in an emergency. Sealing needs
+// the cluster's root token.
+func (a API) handlePostVaultClusterSeal(w http.ResponseWriter, r *http.Request) {
+	var seal VaultClusterSeal
+	err := api.Decode(r, &seal)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if seal.Token == "" {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/token", Slug: api.RequestErrMissing}}})
+		return
+	}
+	cluster, err := a.Storer.SealVaultCluster(requestScope(r), trout.RequestVars(r).Get("id"), hashVaultKey(seal.Token))
+	if err != nil {
+		a.encodeVaultSealError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultClusters: []VaultCluster{cluster}})
+}
+
+func (a API) encodeVaultSealError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrVaultClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrVaultClusterNotInitialized:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrInvalidValue}}})
+	case ErrVaultUnsealKeyInvalid:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/key", Slug: api.RequestErrInvalidValue}}})
+	case ErrVaultRootTokenInvalid:
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: []api.RequestError{{Field: "/token", Slug: api.RequestErrAccessDenied}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
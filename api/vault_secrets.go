@@ -0,0 +1,378 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// VaultSecret is a secret in a Vault cluster's KV store. Every write to the
+// secret adds a new version; old versions are kept until they're destroyed
+// or the secret's metadata is deleted.
+type VaultSecret struct {
+	ClusterID      string               `json:"clusterID"`
+	Organization   string               `json:"organization"`
+	Project        string               `json:"project"`
+	Path           string               `json:"path"`
+	CurrentVersion int                  `json:"currentVersion"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+	Versions       []VaultSecretVersion `json:"versions"`
+}
+
+// VaultSecretVersion is a single version of a VaultSecret. Deleted versions
+// can be undeleted; destroyed versions have had their data removed for good.
+type VaultSecretVersion struct {
+	Version   int               `json:"version"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	DeletedAt *time.Time        `json:"deletedAt,omitempty"`
+	Destroyed bool              `json:"destroyed"`
+}
+
+// VaultSecretWrite is the body of a request to write a new version of a
+// secret. If CAS is set, the write only succeeds if it matches the secret's
+// current version, with 0 meaning the secret must not exist yet.
+type VaultSecretWrite struct {
+	Data map[string]string `json:"data"`
+	CAS  *int              `json:"cas,omitempty"`
+}
+
+// VaultSecretVersions is the body of a request to undelete or destroy
+// versions of a secret.
+type VaultSecretVersions struct {
+	Versions []int `json:"versions"`
+}
+
+// metadata returns a copy of the secret without any of its versions' data.
+func (secret VaultSecret) metadata() VaultSecret {
+	versions := make([]VaultSecretVersion, 0, len(secret.Versions))
+	for _, version := range secret.Versions {
+		version.Data = nil
+		versions = append(versions, version)
+	}
+	secret.Versions = versions
+	return secret
+}
+
+// version returns the version of the secret numbered v.
+func (secret VaultSecret) version(v int) (VaultSecretVersion, bool) {
+	for _, version := range secret.Versions {
+		if version.Version == v {
+			return version, true
+		}
+	}
+	return VaultSecretVersion{}, false
+}
+
+// vaultSecretPath returns the path of the secret a request to one of the KV
+// routes is for. Paths can contain slashes, so they can't be a route
+// variable; the path is everything after the segment the route's prefix
+// ends in.
+func vaultSecretPath(r *http.Request, segment string) string {
+	marker := "/vault/clusters/" + trout.RequestVars(r).Get("id") + "/" + segment + "/"
+	i := strings.Index(r.URL.Path, marker)
+	if i < 0 {
+		return ""
+	}
+	return r.URL.Path[i+len(marker):]
+}
+
+// validVaultSecretPath reports whether path can name a secret: it needs at
+// least one segment, and none of its segments can be empty, "." or "..".
+func validVaultSecretPath(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// vaultSecretAccess checks that the request's access policy is a Vault
+// policy for the cluster that covers path and grants the permission checked
+// by allowed. If it doesn't, an error response is written and false is
+// returned.
+func (a API) vaultSecretAccess(w http.ResponseWriter, r *http.Request, path string, allowed func(VaultPolicy) bool) bool {
+	ap, err := a.requestAccessPolicy(r)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+			return false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return false
+	}
+	vault, ok := ap.vaultPolicy(trout.RequestVars(r).Get("id"))
+	if !ok || !vault.covers(path) || !allowed(vault) {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return false
+	}
+	return true
+}
+
+func canReadVaultSecrets(vault VaultPolicy) bool   { return vault.Read }
+func canWriteVaultSecrets(vault VaultPolicy) bool  { return vault.Write }
+func canDeleteVaultSecrets(vault VaultPolicy) bool { return vault.Delete }
+
+// encodeVaultSecretError writes the response for an error returned by one of
+// the Storer's Vault secret methods.
+func encodeVaultSecretError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrVaultClusterNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+	case ErrVaultClusterSealed:
+		api.Encode(w, r, http.StatusServiceUnavailable, Response{Errors: sealedError})
+	case ErrVaultSecretNotFound:
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrNotFound}}})
+	case ErrVaultSecretVersionNotFound:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/versions", Slug: api.RequestErrInvalidValue}}})
+	case ErrVaultSecretCASMismatch:
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/cas", Slug: api.RequestErrConflict}}})
+	default:
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+	}
+}
+
+// parseVaultSecretVersions parses a comma-separated list of version numbers.
+func parseVaultSecretVersions(s string) ([]int, error) {
+	var versions []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// handleGetVaultSecret returns the data of a version of a secret, the
+// current version unless the version query param says otherwise.
+func (a API) handleGetVaultSecret(w http.ResponseWriter, r *http.Request) {
+	path := vaultSecretPath(r, "kv")
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var v int
+	if s := r.URL.Query().Get("version"); s != "" {
+		var err error
+		v, err = strconv.Atoi(s)
+		if err != nil || v < 1 {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	if !a.vaultSecretAccess(w, r, path, canReadVaultSecrets) {
+		return
+	}
+	secret, err := a.Storer.GetVaultSecret(requestScope(r), trout.RequestVars(r).Get("id"), path)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	if v == 0 {
+		v = secret.CurrentVersion
+	}
+	version, ok := secret.version(v)
+	if !ok {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	if version.DeletedAt != nil || version.Destroyed {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "version", Slug: api.RequestErrConflict}}})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecretVersions: []VaultSecretVersion{version}})
+}
+
+// handlePutVaultSecret writes a new version of a secret, creating the secret
+// if it doesn't exist yet.
+func (a API) handlePutVaultSecret(w http.ResponseWriter, r *http.Request) {
+	path := vaultSecretPath(r, "kv")
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var write VaultSecretWrite
+	err := api.Decode(r, &write)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if write.Data == nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/data", Slug: api.RequestErrMissing}}})
+		return
+	}
+	if write.CAS != nil && *write.CAS < 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/cas", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if !a.vaultSecretAccess(w, r, path, canWriteVaultSecrets) {
+		return
+	}
+	secret, err := a.Storer.WriteVaultSecret(requestScope(r), trout.RequestVars(r).Get("id"), path, write)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: []VaultSecret{secret.metadata()}})
+}
+
+// handleDeleteVaultSecretVersions soft-deletes versions of a secret, the
+// current version unless the versions query param lists others. Deleted
+// versions can be undeleted.
+func (a API) handleDeleteVaultSecretVersions(w http.ResponseWriter, r *http.Request) {
+	path := vaultSecretPath(r, "kv")
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var versions []int
+	if s := r.URL.Query().Get("versions"); s != "" {
+		var err error
+		versions, err = parseVaultSecretVersions(s)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "versions", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	if !a.vaultSecretAccess(w, r, path, canDeleteVaultSecrets) {
+		return
+	}
+	secret, err := a.Storer.DeleteVaultSecretVersions(requestScope(r), trout.RequestVars(r).Get("id"), path, versions)
+	if err != nil {
+		if err == ErrVaultSecretVersionNotFound {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "versions", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: []VaultSecret{secret.metadata()}})
+}
+
+// handlePostVaultSecretUndelete restores soft-deleted versions of a secret.
+func (a API) handlePostVaultSecretUndelete(w http.ResponseWriter, r *http.Request) {
+	a.changeVaultSecretVersions(w, r, "kvUndelete", canWriteVaultSecrets, a.Storer.UndeleteVaultSecretVersions)
+}
+
+// handlePostVaultSecretDestroy permanently removes the data of versions of a
+// secret.
+func (a API) handlePostVaultSecretDestroy(w http.ResponseWriter, r *http.Request) {
+	a.changeVaultSecretVersions(w, r, "kvDestroy", canDeleteVaultSecrets, a.Storer.DestroyVaultSecretVersions)
+}
+
+// changeVaultSecretVersions handles requests that list the versions of a
+// secret to change in their body.
+func (a API) changeVaultSecretVersions(w http.ResponseWriter, r *http.Request, segment string, allowed func(VaultPolicy) bool, change func(Scope, string, string, []int) (VaultSecret, error)) {
+	path := vaultSecretPath(r, segment)
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	var body VaultSecretVersions
+	err := api.Decode(r, &body)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if len(body.Versions) < 1 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/versions", Slug: api.RequestErrMissing}}})
+		return
+	}
+	if !a.vaultSecretAccess(w, r, path, allowed) {
+		return
+	}
+	secret, err := change(requestScope(r), trout.RequestVars(r).Get("id"), path, body.Versions)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: []VaultSecret{secret.metadata()}})
+}
+
+// handleGetVaultSecretMetadata returns a secret's metadata, without any of
+// its data. If the path is empty or ends in a slash, it lists the metadata of
+// every secret under it that the request's access policy can read instead.
+func (a API) handleGetVaultSecretMetadata(w http.ResponseWriter, r *http.Request) {
+	path := vaultSecretPath(r, "kvMetadata")
+	if path == "" || strings.HasSuffix(path, "/") {
+		a.listVaultSecrets(w, r, path)
+		return
+	}
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if !a.vaultSecretAccess(w, r, path, canReadVaultSecrets) {
+		return
+	}
+	secret, err := a.Storer.GetVaultSecret(requestScope(r), trout.RequestVars(r).Get("id"), path)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: []VaultSecret{secret.metadata()}})
+}
+
+func (a API) listVaultSecrets(w http.ResponseWriter, r *http.Request, prefix string) {
+	if prefix != "" && !validVaultSecretPath(strings.TrimSuffix(prefix, "/")) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	ap, err := a.requestAccessPolicy(r)
+	if err != nil {
+		if err == ErrAccessPolicyNotFound {
+			api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	vault, ok := ap.vaultPolicy(trout.RequestVars(r).Get("id"))
+	if !ok || !vault.Read {
+		api.Encode(w, r, http.StatusForbidden, Response{Errors: accessDeniedError})
+		return
+	}
+	secrets, err := a.Storer.ListVaultSecrets(requestScope(r), trout.RequestVars(r).Get("id"), prefix)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	results := []VaultSecret{}
+	for _, secret := range secrets {
+		if !vault.covers(secret.Path) {
+			continue
+		}
+		results = append(results, secret.metadata())
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: results})
+}
+
+// handleDeleteVaultSecretMetadata removes a secret and all its versions for
+// good.
+func (a API) handleDeleteVaultSecretMetadata(w http.ResponseWriter, r *http.Request) {
+	path := vaultSecretPath(r, "kvMetadata")
+	if !validVaultSecretPath(path) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "path", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	if !a.vaultSecretAccess(w, r, path, canDeleteVaultSecrets) {
+		return
+	}
+	secret, err := a.Storer.DeleteVaultSecret(requestScope(r), trout.RequestVars(r).Get("id"), path)
+	if err != nil {
+		encodeVaultSecretError(w, r, err)
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{VaultSecrets: []VaultSecret{secret.metadata()}})
+}
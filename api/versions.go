@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Version struct {
+	ResourceType string          `json:"resourceType"`
+	ResourceID   string          `json:"resourceID"`
+	Version      int             `json:"version"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	Data         json.RawMessage `json:"data"`
+	Changes      []VersionChange `json:"changes,omitempty"`
+}
+
+type VersionChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// fillVersionChanges sets the Changes of each version to the difference
+// between it and the version before it. The first version has no changes.
+func fillVersionChanges(versions []Version) error {
+	for pos := 1; pos < len(versions); pos++ {
+		changes, err := diffVersionData(versions[pos-1].Data, versions[pos].Data)
+		if err != nil {
+			return err
+		}
+		versions[pos].Changes = changes
+	}
+	return nil
+}
+
+func diffVersionData(from, to json.RawMessage) ([]VersionChange, error) {
+	var fromVal, toVal interface{}
+	err := json.Unmarshal(from, &fromVal)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(to, &toVal)
+	if err != nil {
+		return nil, err
+	}
+	var changes []VersionChange
+	diffValues("", fromVal, toVal, &changes)
+	return changes, nil
+}
+
+// diffValues appends a VersionChange for every leaf that differs between from
+// and to. Paths are JSON pointers, to match the Field of a RequestError.
+func diffValues(path string, from, to interface{}, changes *[]VersionChange) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		keys := map[string]struct{}{}
+		for k := range fromMap {
+			keys[k] = struct{}{}
+		}
+		for k := range toMap {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValues(path+"/"+escapeJSONPointer(k), fromMap[k], toMap[k], changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	if path == "" {
+		path = "/"
+	}
+	*changes = append(*changes, VersionChange{
+		Path: path,
+		From: from,
+		To:   to,
+	})
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -8,12 +8,48 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"time"
 )
 
+// AccessPolicyHeader identifies the access policy a request is made under.
+// Actions that an access policy has to allow, like overriding failed
+// Terraform policy checks, are denied without it; use WithAccessPolicy to
+// set it.
+const AccessPolicyHeader = "X-Dadcorp-Access-Policy"
+
 var (
-	ErrAccessPolicyNotFound = errors.New("access policy not found")
+	ErrAccessPolicyNotFound   = errors.New("access policy not found")
+	ErrAccessPolicyNotDeleted = errors.New("access policy is not deleted")
+	ErrAccessPolicyDenied     = errors.New("the request's access policy doesn't allow it")
+
+	// ErrAccessPolicyUnauthenticated is returned when a client without a
+	// username and password tries to set up an access policy that can
+	// override policy sets.
+	ErrAccessPolicyUnauthenticated = errors.New("access policies that can override policy sets can only be set up by authenticated clients")
 )
 
+// accessDeniedError is returned by the API when the request's access policy
+// doesn't allow what it's trying to do.
+var accessDeniedError = RequestError{Slug: requestErrAccessDenied, Header: AccessPolicyHeader}
+
+// unauthenticatedError is returned by the API when a request without valid
+// credentials tries to do something only authenticated clients can.
+var unauthenticatedError = RequestError{Slug: requestErrAccessDenied, Header: "Authorization"}
+
+type accessPolicyKey struct{}
+
+// WithAccessPolicy returns a Context that makes requests under the access
+// policy identified by id.
+func WithAccessPolicy(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, accessPolicyKey{}, id)
+}
+
+func accessPolicyID(ctx context.Context) string {
+	id, _ := ctx.Value(accessPolicyKey{}).(string)
+	return id
+}
+
 type AccessPoliciesService struct {
 	basePath string
 	client   *Client
@@ -27,9 +63,12 @@ func newAccessPoliciesService(basePath string, client *Client) *AccessPoliciesSe
 }
 
 type AccessPolicy struct {
-	ID         string      `json:"id"`
-	Type       string      `json:"type"`
-	PolicyData interface{} `json:"policyData"`
+	ID           string      `json:"id"`
+	Organization string      `json:"organization"`
+	Project      string      `json:"project"`
+	Type         string      `json:"type"`
+	PolicyData   interface{} `json:"policyData"`
+	DeletedAt    *time.Time  `json:"deletedAt,omitempty"`
 }
 
 type TerraformPolicy struct {
@@ -63,7 +102,7 @@ type ConsulPolicy struct {
 }
 
 func (a AccessPoliciesService) buildURL(p string) string {
-	return path.Join(a.basePath, p)
+	return path.Join(a.client.projectPath(), a.basePath, p)
 }
 
 func (a AccessPoliciesService) Create(ctx context.Context, policy AccessPolicy) (AccessPolicy, error) {
@@ -88,6 +127,9 @@ func (a AccessPoliciesService) Create(ctx context.Context, policy AccessPolicy)
 	if resp.Errors.Contains(serverError) {
 		return AccessPolicy{}, errors.New("server error")
 	}
+	if resp.Errors.Contains(unauthenticatedError) {
+		return AccessPolicy{}, ErrAccessPolicyUnauthenticated
+	}
 	if resp.Errors.Contains(invalidFormatError) {
 		return AccessPolicy{}, errors.New("invalid format error returned")
 	}
@@ -184,6 +226,9 @@ func (a AccessPoliciesService) Update(ctx context.Context, policy AccessPolicy)
 	if resp.Errors.Contains(serverError) {
 		return AccessPolicy{}, errors.New("server error")
 	}
+	if resp.Errors.Contains(unauthenticatedError) {
+		return AccessPolicy{}, ErrAccessPolicyUnauthenticated
+	}
 	if resp.Errors.Contains(invalidFormatError) {
 		return AccessPolicy{}, errors.New("invalid format error returned")
 	}
@@ -224,7 +269,7 @@ func (a AccessPoliciesService) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id must be specified")
 	}
-	req, err := a.client.NewRequest(ctx, http.MethodGet, a.buildURL("/"+id), nil)
+	req, err := a.client.NewRequest(ctx, http.MethodDelete, a.buildURL("/"+id), nil)
 	if err != nil {
 		return fmt.Errorf("error constructing request: %w", err)
 	}
@@ -251,3 +296,150 @@ func (a AccessPoliciesService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (a AccessPoliciesService) ListVersions(ctx context.Context, id string) ([]Version, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := a.client.NewRequest(ctx, http.MethodGet, a.buildURL("/"+id+"/versions"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrAccessPolicyNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Versions, nil
+}
+
+func (a AccessPoliciesService) RestoreVersion(ctx context.Context, id string, version int) (AccessPolicy, error) {
+	if id == "" {
+		return AccessPolicy{}, errors.New("id must be specified")
+	}
+	req, err := a.client.NewRequest(ctx, http.MethodPost, a.buildURL("/"+id+"/versions/"+strconv.Itoa(version)+"/restore"), nil)
+	if err != nil {
+		return AccessPolicy{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return AccessPolicy{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return AccessPolicy{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(unauthenticatedError) {
+		return AccessPolicy{}, ErrAccessPolicyUnauthenticated
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return AccessPolicy{}, ErrVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return AccessPolicy{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.AccessPolicies) < 1 {
+		return AccessPolicy{}, errors.New("no Terraform policy returned in response")
+	}
+	return resp.AccessPolicies[0], nil
+}
+
+func (a AccessPoliciesService) List(ctx context.Context, includeDeleted bool) ([]AccessPolicy, error) {
+	u := a.buildURL("/")
+	if includeDeleted {
+		u += "?include_deleted=true"
+	}
+	req, err := a.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.AccessPolicies, nil
+}
+
+func (a AccessPoliciesService) Undelete(ctx context.Context, id string) (AccessPolicy, error) {
+	if id == "" {
+		return AccessPolicy{}, errors.New("id must be specified")
+	}
+	req, err := a.client.NewRequest(ctx, http.MethodPost, a.buildURL("/"+id+"/undelete"), nil)
+	if err != nil {
+		return AccessPolicy{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return AccessPolicy{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return AccessPolicy{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(unauthenticatedError) {
+		return AccessPolicy{}, ErrAccessPolicyUnauthenticated
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return AccessPolicy{}, ErrAccessPolicyNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return AccessPolicy{}, ErrAccessPolicyNotDeleted
+	}
+	if len(resp.Errors) > 0 {
+		return AccessPolicy{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.AccessPolicies) < 1 {
+		return AccessPolicy{}, errors.New("no access policy returned in response")
+	}
+	return resp.AccessPolicies[0], nil
+}
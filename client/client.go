@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 
 	"github.com/hashicorp/go-cleanhttp"
 )
@@ -16,15 +17,22 @@ type Client struct {
 
 	username, password string
 
+	// organization and project are the project that resources are
+	// created in and looked up from
+	organization, project string
+
 	Terraform      *TerraformService
 	Vault          *VaultService
 	Nomad          *NomadService
 	Consul         *ConsulService
+	IP             *IPService
 	AccessPolicies *AccessPoliciesService
 	Regions        *RegionsService
+	Organizations  *OrganizationsService
+	Projects       *ProjectsService
 }
 
-func NewClient(baseURL, username, password string) (*Client, error) {
+func NewClient(baseURL, username, password, organization, project string) (*Client, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, err
@@ -34,16 +42,28 @@ func NewClient(baseURL, username, password string) (*Client, error) {
 		baseURL:  base,
 		username: username,
 		password: password,
+
+		organization: organization,
+		project:      project,
 	}
 	c.Terraform = newTerraformService("terraform", c)
 	c.Vault = newVaultService("vault", c)
 	c.Nomad = newNomadService("nomad", c)
 	c.Consul = newConsulService("consul", c)
+	c.IP = newIPService("ip", c)
 	c.Regions = newRegionsService("regions", c)
 	c.AccessPolicies = newAccessPoliciesService("accessPolicies", c)
+	c.Organizations = newOrganizationsService("orgs", c)
+	c.Projects = newProjectsService("orgs", c)
 	return c, nil
 }
 
+// projectPath returns the path that the resources in the Client's project
+// live under.
+func (c Client) projectPath() string {
+	return path.Join("orgs", c.organization, "projects", c.project)
+}
+
 func (c Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
 	u, err := url.Parse(path)
 	if err != nil {
@@ -59,6 +79,12 @@ func (c Client) NewRequest(ctx context.Context, method, path string, body io.Rea
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if holder := lockHolder(ctx); holder != "" {
+		req.Header.Set(TerraformLockHolderHeader, holder)
+	}
+	if policy := accessPolicyID(ctx); policy != "" {
+		req.Header.Set(AccessPolicyHeader, policy)
+	}
 	return req, nil
 }
 
@@ -8,10 +8,24 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"regexp"
+	"strconv"
+	"time"
 )
 
 var (
-	ErrConsulClusterNotFound = errors.New("consul cluster not found")
+	ErrConsulClusterNotFound     = errors.New("consul cluster not found")
+	ErrConsulClusterNotDeleted   = errors.New("consul cluster is not deleted")
+	ErrConsulClusterNameConflict = errors.New("consul cluster name is already in use in the project")
+	ErrConsulClusterPortInvalid  = errors.New("consul cluster ports must be between 1 and 65535")
+	ErrConsulClusterPortConflict = errors.New("consul cluster ports must be different from each other and outside the sidecar and expose ranges")
+	ErrConsulClusterRangeInvalid = errors.New("consul cluster sidecar and expose ranges need a min and max between 1 and 65535, with the min no greater than the max")
+	ErrConsulClusterRangeOverlap = errors.New("consul cluster sidecar and expose ranges can't overlap")
+)
+
+var (
+	consulPortRangeField = regexp.MustCompile(`^/ports/(sidecar|expose)(Min|Max)Port$`)
+	consulPortField      = regexp.MustCompile(`^/ports/[a-zA-Z]+$`)
 )
 
 type ConsulService struct {
@@ -32,21 +46,31 @@ func newConsulService(basePath string, client *Client) *ConsulService {
 type ConsulClustersService struct {
 	consulService *ConsulService
 	basePath      string
+	KV            *ConsulKVService
+	Sessions      *ConsulSessionsService
+	Services      *ConsulServicesService
 }
 
 func newConsulClustersService(basePath string, consul *ConsulService) *ConsulClustersService {
-	return &ConsulClustersService{
+	s := &ConsulClustersService{
 		basePath:      basePath,
 		consulService: consul,
 	}
+	s.KV = newConsulKVService(s)
+	s.Sessions = newConsulSessionsService(s)
+	s.Services = newConsulServicesService(s)
+	return s
 }
 
 type ConsulCluster struct {
-	ID        string                 `json:"id"`
-	Name      string                 `json:"name"`
-	BindAddr  string                 `json:"bindAddr"`
-	Addresses ConsulClusterAddresses `json:"addresses"`
-	Ports     ConsulClusterPorts     `json:"ports"`
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	Name         string                 `json:"name"`
+	BindAddr     string                 `json:"bindAddr"`
+	Addresses    ConsulClusterAddresses `json:"addresses"`
+	Ports        ConsulClusterPorts     `json:"ports"`
+	DeletedAt    *time.Time             `json:"deletedAt,omitempty"`
 }
 
 type ConsulClusterAddresses struct {
@@ -71,7 +95,25 @@ type ConsulClusterPorts struct {
 }
 
 func (c ConsulClustersService) buildURL(p string) string {
-	return path.Join(c.consulService.basePath, c.basePath, p)
+	return path.Join(c.consulService.client.projectPath(), c.consulService.basePath, c.basePath, p)
+}
+
+// portError returns the error for an invalid or conflicting port or port
+// range in errs, or nil if there isn't one.
+func portError(errs RequestErrors) error {
+	if errs.FieldMatches(requestErrMissing, consulPortRangeField) != nil || errs.FieldMatches(requestErrInvalidValue, consulPortRangeField) != nil {
+		return ErrConsulClusterRangeInvalid
+	}
+	if errs.FieldMatches(requestErrConflict, consulPortRangeField) != nil {
+		return ErrConsulClusterRangeOverlap
+	}
+	if errs.FieldMatches(requestErrInvalidValue, consulPortField) != nil {
+		return ErrConsulClusterPortInvalid
+	}
+	if errs.FieldMatches(requestErrConflict, consulPortField) != nil {
+		return ErrConsulClusterPortConflict
+	}
+	return nil
 }
 
 func (c ConsulClustersService) Create(ctx context.Context, cluster ConsulCluster) (ConsulCluster, error) {
@@ -111,6 +153,15 @@ func (c ConsulClustersService) Create(ctx context.Context, cluster ConsulCluster
 	}) {
 		return ConsulCluster{}, errors.New("cluster already exists")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	if err := portError(resp.Errors); err != nil {
+		return ConsulCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return ConsulCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -195,6 +246,15 @@ func (c ConsulClustersService) Update(ctx context.Context, cluster ConsulCluster
 	}) {
 		return ConsulCluster{}, errors.New("cluster must have a name")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	if err := portError(resp.Errors); err != nil {
+		return ConsulCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return ConsulCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -208,7 +268,7 @@ func (c ConsulClustersService) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id must be specified")
 	}
-	req, err := c.consulService.client.NewRequest(ctx, http.MethodGet, c.buildURL("/"+id), nil)
+	req, err := c.consulService.client.NewRequest(ctx, http.MethodDelete, c.buildURL("/"+id), nil)
 	if err != nil {
 		return fmt.Errorf("error constructing request: %w", err)
 	}
@@ -235,3 +295,156 @@ func (c ConsulClustersService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (c ConsulClustersService) ListVersions(ctx context.Context, id string) ([]Version, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := c.consulService.client.NewRequest(ctx, http.MethodGet, c.buildURL("/"+id+"/versions"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.consulService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrConsulClusterNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Versions, nil
+}
+
+func (c ConsulClustersService) RestoreVersion(ctx context.Context, id string, version int) (ConsulCluster, error) {
+	if id == "" {
+		return ConsulCluster{}, errors.New("id must be specified")
+	}
+	req, err := c.consulService.client.NewRequest(ctx, http.MethodPost, c.buildURL("/"+id+"/versions/"+strconv.Itoa(version)+"/restore"), nil)
+	if err != nil {
+		return ConsulCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.consulService.client.Do(req)
+	if err != nil {
+		return ConsulCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return ConsulCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return ConsulCluster{}, ErrVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return ConsulCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.ConsulClusters) < 1 {
+		return ConsulCluster{}, errors.New("no Consul cluster returned in response")
+	}
+	return resp.ConsulClusters[0], nil
+}
+
+func (c ConsulClustersService) List(ctx context.Context, includeDeleted bool) ([]ConsulCluster, error) {
+	u := c.buildURL("/")
+	if includeDeleted {
+		u += "?include_deleted=true"
+	}
+	req, err := c.consulService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.consulService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.ConsulClusters, nil
+}
+
+func (c ConsulClustersService) Undelete(ctx context.Context, id string) (ConsulCluster, error) {
+	if id == "" {
+		return ConsulCluster{}, errors.New("id must be specified")
+	}
+	req, err := c.consulService.client.NewRequest(ctx, http.MethodPost, c.buildURL("/"+id+"/undelete"), nil)
+	if err != nil {
+		return ConsulCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.consulService.client.Do(req)
+	if err != nil {
+		return ConsulCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return ConsulCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return ConsulCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNotDeleted
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return ConsulCluster{}, ErrConsulClusterNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return ConsulCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.ConsulClusters) < 1 {
+		return ConsulCluster{}, errors.New("no Consul cluster returned in response")
+	}
+	return resp.ConsulClusters[0], nil
+}
@@ -0,0 +1,348 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+)
+
+var (
+	ErrConsulServiceNotFound     = errors.New("consul service not found in the catalog")
+	ErrConsulHealthCheckNotFound = errors.New("consul health check not found")
+	ErrConsulServiceNameInvalid  = errors.New("consul service name must be a DNS label")
+	ErrConsulServiceIDInvalid    = errors.New("consul service ID can't contain a slash")
+	ErrConsulServiceTagInvalid   = errors.New("consul service tags can't be empty")
+	ErrConsulServiceAddrInvalid  = errors.New("consul service address must be an IP address or hostname")
+	ErrConsulServicePortInvalid  = errors.New("consul service port must be between 0 and 65535")
+	ErrConsulHealthCheckInvalid  = errors.New("consul health checks must have a name and a status of passing, warning, or critical")
+	ErrConsulHealthCheckConflict = errors.New("consul health check IDs must be unique within a service")
+	ErrConsulDNSNameInvalid      = errors.New("consul DNS name must be in the form [TAG.]SERVICE.service.consul")
+	ErrConsulDNSNameNotFound     = errors.New("consul DNS name has no instances")
+)
+
+var (
+	consulServiceTagField    = regexp.MustCompile(`^/tags/[0-9]+$`)
+	consulHealthCheckField   = regexp.MustCompile(`^/checks/[0-9]+/(name|status)$`)
+	consulHealthCheckIDField = regexp.MustCompile(`^/checks/[0-9]+/id$`)
+)
+
+const (
+	ConsulHealthPassing  = "passing"
+	ConsulHealthWarning  = "warning"
+	ConsulHealthCritical = "critical"
+)
+
+// ConsulServicesService manages the service catalogs of Consul clusters,
+// and looks up services in them.
+type ConsulServicesService struct {
+	clustersService *ConsulClustersService
+}
+
+func newConsulServicesService(clusters *ConsulClustersService) *ConsulServicesService {
+	return &ConsulServicesService{
+		clustersService: clusters,
+	}
+}
+
+// ConsulCatalogService is an instance of a service registered in a Consul
+// cluster's catalog. ID defaults to Name, and Address to the cluster's
+// BindAddr. Status is the worst status of the instance's checks, or
+// ConsulHealthPassing if it has none.
+type ConsulCatalogService struct {
+	ID           string              `json:"id"`
+	Organization string              `json:"organization"`
+	Project      string              `json:"project"`
+	ClusterID    string              `json:"clusterID"`
+	Name         string              `json:"name"`
+	Tags         []string            `json:"tags,omitempty"`
+	Address      string              `json:"address"`
+	Port         int                 `json:"port,omitempty"`
+	Checks       []ConsulHealthCheck `json:"checks,omitempty"`
+	Status       string              `json:"status"`
+	CreateIndex  uint64              `json:"createIndex"`
+	ModifyIndex  uint64              `json:"modifyIndex"`
+}
+
+// ConsulHealthCheck is a health check of a service instance. ID defaults to
+// Name, and Status to ConsulHealthCritical.
+type ConsulHealthCheck struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Notes  string `json:"notes,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+type consulHealthCheckUpdate struct {
+	Status string `json:"status"`
+	Output string `json:"output"`
+}
+
+// ConsulDNSAnswer is the answer to a DNS lookup of a service. Server is the
+// address of the cluster's DNS interface that would give it.
+type ConsulDNSAnswer struct {
+	Server   string            `json:"server"`
+	Question string            `json:"question"`
+	Type     string            `json:"type"`
+	Records  []ConsulDNSRecord `json:"records"`
+}
+
+// ConsulDNSRecord is a single record in a ConsulDNSAnswer. Port is only set
+// on SRV records.
+type ConsulDNSRecord struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
+
+func (c ConsulServicesService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{c.clustersService.buildURL(clusterID)}, p...)...)
+}
+
+// do makes a request to the catalog routes and returns the response.
+func (c ConsulServicesService) do(ctx context.Context, method, u string, body interface{}) (Response, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := c.clustersService.consulService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.clustersService.consulService.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrConsulClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "service",
+	}) {
+		return Response{}, ErrConsulServiceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "check",
+	}) {
+		return Response{}, ErrConsulHealthCheckNotFound
+	}
+	return resp, nil
+}
+
+// one returns the only service instance in resp.
+func (c ConsulServicesService) one(resp Response, err error) (ConsulCatalogService, error) {
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return ConsulCatalogService{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.ConsulCatalogServices) < 1 {
+		return ConsulCatalogService{}, errors.New("no Consul service returned in response")
+	}
+	return resp.ConsulCatalogServices[0], nil
+}
+
+// serviceErr returns the error describing why a service instance was
+// rejected, or nil if it wasn't.
+func (c ConsulServicesService) serviceErr(errs RequestErrors) error {
+	if errs.Contains(RequestError{Slug: requestErrMissing, Field: "/name"}) || errs.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/name"}) {
+		return ErrConsulServiceNameInvalid
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/id"}) {
+		return ErrConsulServiceIDInvalid
+	}
+	if errs.FieldMatches(requestErrInvalidValue, consulServiceTagField) != nil {
+		return ErrConsulServiceTagInvalid
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/address"}) {
+		return ErrConsulServiceAddrInvalid
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/port"}) {
+		return ErrConsulServicePortInvalid
+	}
+	if errs.FieldMatches(requestErrMissing, consulHealthCheckField) != nil || errs.FieldMatches(requestErrInvalidValue, consulHealthCheckField) != nil {
+		return ErrConsulHealthCheckInvalid
+	}
+	if errs.FieldMatches(requestErrConflict, consulHealthCheckIDField) != nil {
+		return ErrConsulHealthCheckConflict
+	}
+	return nil
+}
+
+// Register adds a service instance to the catalog of the Consul cluster
+// identified by clusterID, replacing any instance with the same ID.
+func (c ConsulServicesService) Register(ctx context.Context, clusterID string, service ConsulCatalogService) (ConsulCatalogService, error) {
+	if clusterID == "" {
+		return ConsulCatalogService{}, errors.New("cluster ID must be specified")
+	}
+	resp, err := c.do(ctx, http.MethodPost, c.buildURL(clusterID, "services"), service)
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	if err := c.serviceErr(resp.Errors); err != nil {
+		return ConsulCatalogService{}, err
+	}
+	return c.one(resp, nil)
+}
+
+// Get returns the service instance identified by serviceID.
+func (c ConsulServicesService) Get(ctx context.Context, clusterID, serviceID string) (ConsulCatalogService, error) {
+	if clusterID == "" {
+		return ConsulCatalogService{}, errors.New("cluster ID must be specified")
+	}
+	if serviceID == "" {
+		return ConsulCatalogService{}, errors.New("service ID must be specified")
+	}
+	return c.one(c.do(ctx, http.MethodGet, c.buildURL(clusterID, "services", serviceID), nil))
+}
+
+// List returns every service instance in the catalog of the Consul cluster
+// identified by clusterID, sorted by name then ID.
+func (c ConsulServicesService) List(ctx context.Context, clusterID string) ([]ConsulCatalogService, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.buildURL(clusterID, "services"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.ConsulCatalogServices, nil
+}
+
+// Deregister removes the service instance identified by serviceID from the
+// catalog.
+func (c ConsulServicesService) Deregister(ctx context.Context, clusterID, serviceID string) error {
+	if clusterID == "" {
+		return errors.New("cluster ID must be specified")
+	}
+	if serviceID == "" {
+		return errors.New("service ID must be specified")
+	}
+	_, err := c.one(c.do(ctx, http.MethodDelete, c.buildURL(clusterID, "services", serviceID), nil))
+	return err
+}
+
+// UpdateCheck sets the status and output of the health check identified by
+// checkID on the service instance identified by serviceID, returning the
+// instance with its new health.
+func (c ConsulServicesService) UpdateCheck(ctx context.Context, clusterID, serviceID, checkID, status, output string) (ConsulCatalogService, error) {
+	if clusterID == "" {
+		return ConsulCatalogService{}, errors.New("cluster ID must be specified")
+	}
+	if serviceID == "" {
+		return ConsulCatalogService{}, errors.New("service ID must be specified")
+	}
+	if checkID == "" {
+		return ConsulCatalogService{}, errors.New("check ID must be specified")
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.buildURL(clusterID, "services", serviceID, "checks", checkID), consulHealthCheckUpdate{
+		Status: status,
+		Output: output,
+	})
+	if err != nil {
+		return ConsulCatalogService{}, err
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/status",
+	}) {
+		return ConsulCatalogService{}, ErrConsulHealthCheckInvalid
+	}
+	return c.one(resp, nil)
+}
+
+// Health returns every instance of the service called name, with its
+// health. If passingOnly is true, only passing instances are returned.
+func (c ConsulServicesService) Health(ctx context.Context, clusterID, name string, passingOnly bool) ([]ConsulCatalogService, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	if name == "" {
+		return nil, errors.New("service name must be specified")
+	}
+	u := c.buildURL(clusterID, "health", name)
+	if passingOnly {
+		u += "?" + url.Values{"passing": []string{"true"}}.Encode()
+	}
+	resp, err := c.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.ConsulCatalogServices, nil
+}
+
+// LookupDNS looks up name, in the form [TAG.]SERVICE.service.consul, the way
+// the cluster's DNS interface would, leaving out critical instances.
+// recordType is A, AAAA, or SRV, defaulting to A.
+func (c ConsulServicesService) LookupDNS(ctx context.Context, clusterID, name, recordType string) (ConsulDNSAnswer, error) {
+	if clusterID == "" {
+		return ConsulDNSAnswer{}, errors.New("cluster ID must be specified")
+	}
+	q := url.Values{"name": []string{name}}
+	if recordType != "" {
+		q.Set("type", recordType)
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.buildURL(clusterID, "dns")+"?"+q.Encode(), nil)
+	if err != nil {
+		return ConsulDNSAnswer{}, err
+	}
+	for _, slug := range []string{requestErrMissing, requestErrInvalidFormat} {
+		if resp.Errors.Contains(RequestError{Slug: slug, Param: "name"}) {
+			return ConsulDNSAnswer{}, ErrConsulDNSNameInvalid
+		}
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "type",
+	}) {
+		return ConsulDNSAnswer{}, errors.New("DNS record type must be A, AAAA, or SRV")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "name",
+	}) {
+		return ConsulDNSAnswer{}, ErrConsulDNSNameNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return ConsulDNSAnswer{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.ConsulDNSAnswers) < 1 {
+		return ConsulDNSAnswer{}, errors.New("no Consul DNS answer returned in response")
+	}
+	return resp.ConsulDNSAnswers[0], nil
+}
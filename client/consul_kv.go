@@ -0,0 +1,404 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrConsulKeyNotFound     = errors.New("consul key not found")
+	ErrConsulKeyInvalid      = errors.New("consul key can't be empty or start with a slash")
+	ErrConsulKeyCASMismatch  = errors.New("consul key check-and-set index doesn't match the key's modify index")
+	ErrConsulKeyLockHeld     = errors.New("consul key is locked by another session")
+	ErrConsulKeyLockNotHeld  = errors.New("consul key is not locked by the session")
+	ErrConsulSessionNotFound = errors.New("consul session not found")
+	ErrConsulSessionInvalid  = errors.New("consul session TTL must be a positive duration and behavior must be release or delete")
+)
+
+// ConsulKVService manages the keys in Consul clusters' KV stores. Every
+// request it makes needs an access policy for the cluster whose key covers
+// the keys involved; see WithAccessPolicy.
+type ConsulKVService struct {
+	clustersService *ConsulClustersService
+}
+
+func newConsulKVService(clusters *ConsulClustersService) *ConsulKVService {
+	return &ConsulKVService{
+		clustersService: clusters,
+	}
+}
+
+// ConsulKVPair is a key and its value in a Consul cluster's KV store.
+// ModifyIndex changes every time the key is written, and is what
+// check-and-set writes compare against. Session is the session holding the
+// key's lock, if any.
+type ConsulKVPair struct {
+	ClusterID    string `json:"clusterID"`
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	Flags        uint64 `json:"flags"`
+	CreateIndex  uint64 `json:"createIndex"`
+	ModifyIndex  uint64 `json:"modifyIndex"`
+	LockIndex    uint64 `json:"lockIndex"`
+	Session      string `json:"session,omitempty"`
+}
+
+// ConsulKVWrite is a new value for a key. If CAS is set, the write only
+// succeeds if it matches the key's ModifyIndex, with 0 meaning the key must
+// not exist yet. Acquire takes the key's lock for the session it identifies
+// while writing, and Release gives it up.
+type ConsulKVWrite struct {
+	Value   string  `json:"value"`
+	Flags   uint64  `json:"flags"`
+	CAS     *uint64 `json:"cas,omitempty"`
+	Acquire string  `json:"acquire,omitempty"`
+	Release string  `json:"release,omitempty"`
+}
+
+// buildURL returns the URL of key. Keys ending in a slash keep it, so
+// folders can be stored.
+func (c ConsulKVService) buildURL(clusterID, key string) string {
+	u := path.Join(c.clustersService.buildURL(clusterID), "kv", key)
+	if strings.HasSuffix(key, "/") {
+		u += "/"
+	}
+	return u
+}
+
+// kvError returns the error for the errors every KV route can return, or
+// nil if there aren't any of them in errs.
+func (c ConsulKVService) kvError(errs RequestErrors) error {
+	if errs.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if errs.Contains(invalidFormatError) {
+		return errors.New("invalid format error returned")
+	}
+	if errs.Contains(accessDeniedError) {
+		return ErrAccessPolicyDenied
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "key",
+	}) {
+		return ErrConsulKeyInvalid
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrConsulClusterNotFound
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "key",
+	}) {
+		return ErrConsulKeyNotFound
+	}
+	for _, err := range []RequestError{{Slug: requestErrConflict, Field: "/cas"}, {Slug: requestErrConflict, Param: "cas"}} {
+		if errs.Contains(err) {
+			return ErrConsulKeyCASMismatch
+		}
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/acquire",
+	}) {
+		return ErrConsulKeyLockHeld
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/release",
+	}) {
+		return ErrConsulKeyLockNotHeld
+	}
+	for _, field := range []string{"/acquire", "/release"} {
+		if errs.Contains(RequestError{Slug: requestErrInvalidValue, Field: field}) {
+			return ErrConsulSessionNotFound
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", errs)
+	}
+	return nil
+}
+
+// do makes a request to the KV routes and returns the keys in the response.
+func (c ConsulKVService) do(ctx context.Context, method, u string, body interface{}) ([]ConsulKVPair, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := c.clustersService.consulService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.clustersService.consulService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.kvError(resp.Errors); err != nil {
+		return nil, err
+	}
+	return resp.ConsulKVPairs, nil
+}
+
+// Get returns key from the Consul cluster identified by clusterID.
+func (c ConsulKVService) Get(ctx context.Context, clusterID, key string) (ConsulKVPair, error) {
+	if clusterID == "" {
+		return ConsulKVPair{}, errors.New("cluster ID must be specified")
+	}
+	pairs, err := c.do(ctx, http.MethodGet, c.buildURL(clusterID, key), nil)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	if len(pairs) < 1 {
+		return ConsulKVPair{}, errors.New("no Consul key returned in response")
+	}
+	return pairs[0], nil
+}
+
+// List returns every key that starts with prefix in the Consul cluster
+// identified by clusterID, sorted by key. Keys the request's access policy
+// doesn't cover are left out.
+func (c ConsulKVService) List(ctx context.Context, clusterID, prefix string) ([]ConsulKVPair, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	u := c.buildURL(clusterID, prefix) + "?" + url.Values{"recurse": []string{"true"}}.Encode()
+	return c.do(ctx, http.MethodGet, u, nil)
+}
+
+// Put writes key in the Consul cluster identified by clusterID, creating it
+// if it doesn't exist yet.
+func (c ConsulKVService) Put(ctx context.Context, clusterID, key string, write ConsulKVWrite) (ConsulKVPair, error) {
+	if clusterID == "" {
+		return ConsulKVPair{}, errors.New("cluster ID must be specified")
+	}
+	pairs, err := c.do(ctx, http.MethodPut, c.buildURL(clusterID, key), write)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	if len(pairs) < 1 {
+		return ConsulKVPair{}, errors.New("no Consul key returned in response")
+	}
+	return pairs[0], nil
+}
+
+// Delete removes key from the Consul cluster identified by clusterID. If cas
+// is set, the key is only removed if cas matches its ModifyIndex.
+func (c ConsulKVService) Delete(ctx context.Context, clusterID, key string, cas *uint64) (ConsulKVPair, error) {
+	if clusterID == "" {
+		return ConsulKVPair{}, errors.New("cluster ID must be specified")
+	}
+	u := c.buildURL(clusterID, key)
+	if cas != nil {
+		u += "?" + url.Values{"cas": []string{strconv.FormatUint(*cas, 10)}}.Encode()
+	}
+	pairs, err := c.do(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return ConsulKVPair{}, err
+	}
+	if len(pairs) < 1 {
+		return ConsulKVPair{}, errors.New("no Consul key returned in response")
+	}
+	return pairs[0], nil
+}
+
+// DeleteTree removes every key that starts with prefix from the Consul
+// cluster identified by clusterID, returning the keys removed.
+func (c ConsulKVService) DeleteTree(ctx context.Context, clusterID, prefix string) ([]ConsulKVPair, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	u := c.buildURL(clusterID, prefix) + "?" + url.Values{"recurse": []string{"true"}}.Encode()
+	return c.do(ctx, http.MethodDelete, u, nil)
+}
+
+// ConsulSessionsService manages sessions in Consul clusters, which can hold
+// locks on keys. Every request it makes needs an access policy for the
+// cluster that can write keys; see WithAccessPolicy.
+type ConsulSessionsService struct {
+	clustersService *ConsulClustersService
+}
+
+func newConsulSessionsService(clusters *ConsulClustersService) *ConsulSessionsService {
+	return &ConsulSessionsService{
+		clustersService: clusters,
+	}
+}
+
+// ConsulSession is a session in a Consul cluster. Sessions with a TTL
+// expire unless they're renewed; when a session expires or is destroyed, the
+// keys it holds are released, or deleted if its Behavior is
+// ConsulSessionBehaviorDelete.
+type ConsulSession struct {
+	ID           string     `json:"id"`
+	Organization string     `json:"organization"`
+	Project      string     `json:"project"`
+	ClusterID    string     `json:"clusterID"`
+	Name         string     `json:"name"`
+	TTL          string     `json:"ttl,omitempty"`
+	Behavior     string     `json:"behavior"`
+	CreateIndex  uint64     `json:"createIndex"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+const (
+	ConsulSessionBehaviorRelease = "release"
+	ConsulSessionBehaviorDelete  = "delete"
+)
+
+func (c ConsulSessionsService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{c.clustersService.buildURL(clusterID), "sessions"}, p...)...)
+}
+
+// do makes a request to the session routes and returns the sessions in the
+// response.
+func (c ConsulSessionsService) do(ctx context.Context, method, u string, body interface{}) ([]ConsulSession, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := c.clustersService.consulService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.clustersService.consulService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return nil, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(accessDeniedError) {
+		return nil, ErrAccessPolicyDenied
+	}
+	for _, field := range []string{"/ttl", "/behavior"} {
+		for _, slug := range []string{requestErrInvalidFormat, requestErrInvalidValue} {
+			if resp.Errors.Contains(RequestError{Slug: slug, Field: field}) {
+				return nil, ErrConsulSessionInvalid
+			}
+		}
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrConsulClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "session",
+	}) {
+		return nil, ErrConsulSessionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.ConsulSessions, nil
+}
+
+// one returns the only session from a request to the session routes.
+func (c ConsulSessionsService) one(sessions []ConsulSession, err error) (ConsulSession, error) {
+	if err != nil {
+		return ConsulSession{}, err
+	}
+	if len(sessions) < 1 {
+		return ConsulSession{}, errors.New("no Consul session returned in response")
+	}
+	return sessions[0], nil
+}
+
+// Create starts a new session in the Consul cluster identified by clusterID.
+// A ttl of 0 creates a session that lasts until it's destroyed, and an empty
+// behavior means ConsulSessionBehaviorRelease.
+func (c ConsulSessionsService) Create(ctx context.Context, clusterID, name string, ttl time.Duration, behavior string) (ConsulSession, error) {
+	if clusterID == "" {
+		return ConsulSession{}, errors.New("cluster ID must be specified")
+	}
+	session := ConsulSession{Name: name, Behavior: behavior}
+	if ttl != 0 {
+		session.TTL = ttl.String()
+	}
+	return c.one(c.do(ctx, http.MethodPost, c.buildURL(clusterID), session))
+}
+
+// Get returns the session identified by sessionID. Expired sessions aren't
+// found.
+func (c ConsulSessionsService) Get(ctx context.Context, clusterID, sessionID string) (ConsulSession, error) {
+	if clusterID == "" {
+		return ConsulSession{}, errors.New("cluster ID must be specified")
+	}
+	if sessionID == "" {
+		return ConsulSession{}, errors.New("session ID must be specified")
+	}
+	return c.one(c.do(ctx, http.MethodGet, c.buildURL(clusterID, sessionID), nil))
+}
+
+// List returns the unexpired sessions in the Consul cluster identified by
+// clusterID, oldest first.
+func (c ConsulSessionsService) List(ctx context.Context, clusterID string) ([]ConsulSession, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	return c.do(ctx, http.MethodGet, c.buildURL(clusterID), nil)
+}
+
+// Renew resets the TTL of the session identified by sessionID.
+func (c ConsulSessionsService) Renew(ctx context.Context, clusterID, sessionID string) (ConsulSession, error) {
+	if clusterID == "" {
+		return ConsulSession{}, errors.New("cluster ID must be specified")
+	}
+	if sessionID == "" {
+		return ConsulSession{}, errors.New("session ID must be specified")
+	}
+	return c.one(c.do(ctx, http.MethodPost, c.buildURL(clusterID, sessionID, "renew"), nil))
+}
+
+// Destroy ends the session identified by sessionID, releasing or deleting
+// the keys it holds.
+func (c ConsulSessionsService) Destroy(ctx context.Context, clusterID, sessionID string) error {
+	if clusterID == "" {
+		return errors.New("cluster ID must be specified")
+	}
+	if sessionID == "" {
+		return errors.New("session ID must be specified")
+	}
+	_, err := c.do(ctx, http.MethodDelete, c.buildURL(clusterID, sessionID), nil)
+	return err
+}
@@ -0,0 +1,360 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+var (
+	ErrIPPoolNotFound              = errors.New("IP pool not found")
+	ErrIPPoolNameMissing           = errors.New("IP pool must have a name")
+	ErrIPPoolNameConflict          = errors.New("IP pool name is already in use in the project")
+	ErrIPPoolRegionMissing         = errors.New("IP pool must have a region")
+	ErrIPPoolRegionInvalid         = errors.New("IP pool region isn't a valid region")
+	ErrIPPoolCIDRMissing           = errors.New("IP pool must have a CIDR block")
+	ErrIPPoolCIDRInvalid           = errors.New("IP pool CIDR block must be in CIDR notation, like 10.0.0.0/24")
+	ErrIPPoolOverlap               = errors.New("IP pool CIDR block overlaps another pool in the region")
+	ErrIPPoolInUse                 = errors.New("IP pool still has addresses allocated from it")
+	ErrIPPoolExhausted             = errors.New("IP pool has no free addresses")
+	ErrIPAddressNotFound           = errors.New("IP address not found")
+	ErrIPAddressRegionMissing      = errors.New("IP address must have a region")
+	ErrIPAddressRegionInvalid      = errors.New("IP address region isn't a valid region")
+	ErrIPAddressPoolInvalid        = errors.New("IP address pool isn't a pool in the address's region")
+	ErrIPAddressInvalid            = errors.New("IP address isn't a valid IPv4 or IPv6 address")
+	ErrIPAddressOutOfRange         = errors.New("IP address isn't a usable address in any of the region's pools")
+	ErrIPAddressInUse              = errors.New("IP address is already allocated")
+	ErrIPAddressAttached           = errors.New("IP address is already attached to another cluster")
+	ErrIPAttachmentTypeInvalid     = errors.New("IP attachment type must be vault, consul, or nomad")
+	ErrIPAttachmentClusterMissing  = errors.New("IP attachment must have a cluster ID")
+	ErrIPAttachmentClusterNotFound = errors.New("IP attachment cluster not found")
+	ErrIPAttachmentRegionMismatch  = errors.New("IP attachment cluster is in a different region to the address")
+)
+
+const (
+	IPAttachmentVault  = "vault"
+	IPAttachmentConsul = "consul"
+	IPAttachmentNomad  = "nomad"
+)
+
+// IPService manages IP address pools and the addresses allocated from them.
+type IPService struct {
+	basePath  string
+	client    *Client
+	Pools     *IPPoolsService
+	Addresses *IPAddressesService
+}
+
+func newIPService(basePath string, client *Client) *IPService {
+	s := &IPService{
+		basePath: basePath,
+		client:   client,
+	}
+	s.Pools = &IPPoolsService{ipService: s}
+	s.Addresses = &IPAddressesService{ipService: s}
+	return s
+}
+
+func (i IPService) buildURL(p ...string) string {
+	return path.Join(append([]string{i.client.projectPath(), i.basePath}, p...)...)
+}
+
+// do makes a request to the IP routes and returns the response.
+func (i IPService) do(ctx context.Context, method, u string, body interface{}) (Response, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := i.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := i.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	return resp, nil
+}
+
+// IPPool is a block of addresses in a region that IP addresses are
+// allocated from. CIDR is normalised to the network it describes, so
+// 10.0.0.7/24 is stored as 10.0.0.0/24. Pools in the same project and
+// region can't overlap.
+type IPPool struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Project      string    `json:"project"`
+	Region       string    `json:"region"`
+	Name         string    `json:"name"`
+	CIDR         string    `json:"cidr"`
+	Description  string    `json:"description,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// IPPoolsService manages the pools IP addresses are allocated from.
+type IPPoolsService struct {
+	ipService *IPService
+}
+
+// one returns the only pool in resp, or the error describing why the pool
+// was rejected.
+func (i IPPoolsService) one(resp Response, err error) (IPPool, error) {
+	if err != nil {
+		return IPPool{}, err
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrNotFound, Param: "id"}) {
+		return IPPool{}, ErrIPPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Param: "id"}) {
+		return IPPool{}, ErrIPPoolInUse
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/name"}) {
+		return IPPool{}, ErrIPPoolNameMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/name"}) {
+		return IPPool{}, ErrIPPoolNameConflict
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/region"}) {
+		return IPPool{}, ErrIPPoolRegionMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/region"}) {
+		return IPPool{}, ErrIPPoolRegionInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/cidr"}) {
+		return IPPool{}, ErrIPPoolCIDRMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/cidr"}) {
+		return IPPool{}, ErrIPPoolCIDRInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/cidr"}) {
+		return IPPool{}, ErrIPPoolOverlap
+	}
+	if len(resp.Errors) > 0 {
+		return IPPool{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.IPPools) < 1 {
+		return IPPool{}, errors.New("no IP pool returned in response")
+	}
+	return resp.IPPools[0], nil
+}
+
+func (i IPPoolsService) Create(ctx context.Context, pool IPPool) (IPPool, error) {
+	return i.one(i.ipService.do(ctx, http.MethodPost, i.ipService.buildURL("pools"), pool))
+}
+
+func (i IPPoolsService) Get(ctx context.Context, id string) (IPPool, error) {
+	if id == "" {
+		return IPPool{}, errors.New("ID must be specified")
+	}
+	return i.one(i.ipService.do(ctx, http.MethodGet, i.ipService.buildURL("pools", id), nil))
+}
+
+// List returns the project's IP pools, sorted by name.
+func (i IPPoolsService) List(ctx context.Context) ([]IPPool, error) {
+	resp, err := i.ipService.do(ctx, http.MethodGet, i.ipService.buildURL("pools"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.IPPools, nil
+}
+
+// Update replaces the IP pool identified by pool.ID. The region and CIDR
+// block of a pool can only change if every address allocated from it would
+// still be in the pool.
+func (i IPPoolsService) Update(ctx context.Context, pool IPPool) (IPPool, error) {
+	if pool.ID == "" {
+		return IPPool{}, errors.New("ID must be specified")
+	}
+	return i.one(i.ipService.do(ctx, http.MethodPut, i.ipService.buildURL("pools", pool.ID), pool))
+}
+
+// Delete removes the IP pool identified by id. Pools that still have
+// addresses allocated from them can't be deleted.
+func (i IPPoolsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("ID must be specified")
+	}
+	_, err := i.one(i.ipService.do(ctx, http.MethodDelete, i.ipService.buildURL("pools", id), nil))
+	return err
+}
+
+// IPAddress is an address allocated from an IPPool. When allocating, Region
+// is required; setting Address reserves that address, and setting PoolID
+// allocates from that pool. Otherwise the lowest free address in the
+// region's pools, checked in name order, is allocated. AttachedTo is set
+// using IPAddressesService.Attach and IPAddressesService.Detach.
+type IPAddress struct {
+	ID           string        `json:"id"`
+	Organization string        `json:"organization"`
+	Project      string        `json:"project"`
+	Region       string        `json:"region"`
+	PoolID       string        `json:"poolID,omitempty"`
+	Address      string        `json:"address,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Reserved     bool          `json:"reserved"`
+	AttachedTo   *IPAttachment `json:"attachedTo,omitempty"`
+	AllocatedAt  time.Time     `json:"allocatedAt"`
+}
+
+// IPAttachment identifies the cluster an IP address is attached to. Type is
+// one of IPAttachmentVault, IPAttachmentConsul, or IPAttachmentNomad.
+type IPAttachment struct {
+	Type      string `json:"type"`
+	ClusterID string `json:"clusterID"`
+}
+
+// IPAddressesService allocates, releases, and attaches IP addresses.
+type IPAddressesService struct {
+	ipService *IPService
+}
+
+// one returns the only address in resp, or the error describing why the
+// address or the change to it was rejected.
+func (i IPAddressesService) one(resp Response, err error) (IPAddress, error) {
+	if err != nil {
+		return IPAddress{}, err
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrNotFound, Param: "id"}) {
+		return IPAddress{}, ErrIPAddressNotFound
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Param: "id"}) {
+		return IPAddress{}, ErrIPAddressAttached
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/region"}) {
+		return IPAddress{}, ErrIPAddressRegionMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/region"}) {
+		return IPAddress{}, ErrIPAddressRegionInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/poolID"}) {
+		return IPAddress{}, ErrIPAddressPoolInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/poolID"}) {
+		return IPAddress{}, ErrIPPoolExhausted
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/address"}) {
+		return IPAddress{}, ErrIPAddressInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/address"}) {
+		return IPAddress{}, ErrIPAddressOutOfRange
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/address"}) {
+		return IPAddress{}, ErrIPAddressInUse
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/type"}) || resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/type"}) {
+		return IPAddress{}, ErrIPAttachmentTypeInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/clusterID"}) {
+		return IPAddress{}, ErrIPAttachmentClusterMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/clusterID"}) {
+		return IPAddress{}, ErrIPAttachmentClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/clusterID"}) {
+		return IPAddress{}, ErrIPAttachmentRegionMismatch
+	}
+	if len(resp.Errors) > 0 {
+		return IPAddress{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.IPAddresses) < 1 {
+		return IPAddress{}, errors.New("no IP address returned in response")
+	}
+	return resp.IPAddresses[0], nil
+}
+
+// Allocate allocates an address in address.Region. See IPAddress for how
+// the address is chosen.
+func (i IPAddressesService) Allocate(ctx context.Context, address IPAddress) (IPAddress, error) {
+	return i.one(i.ipService.do(ctx, http.MethodPost, i.ipService.buildURL("addresses"), address))
+}
+
+func (i IPAddressesService) Get(ctx context.Context, id string) (IPAddress, error) {
+	if id == "" {
+		return IPAddress{}, errors.New("ID must be specified")
+	}
+	return i.one(i.ipService.do(ctx, http.MethodGet, i.ipService.buildURL("addresses", id), nil))
+}
+
+// List returns the project's allocated addresses, sorted by region and
+// address. If poolID is set, only addresses allocated from that pool are
+// returned.
+func (i IPAddressesService) List(ctx context.Context, poolID string) ([]IPAddress, error) {
+	u := i.ipService.buildURL("addresses")
+	if poolID != "" {
+		u += "?" + url.Values{"pool": []string{poolID}}.Encode()
+	}
+	resp, err := i.ipService.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.IPAddresses, nil
+}
+
+// SetDescription changes the description of the address identified by id.
+// Nothing else about an address can change once it's allocated.
+func (i IPAddressesService) SetDescription(ctx context.Context, id, description string) (IPAddress, error) {
+	if id == "" {
+		return IPAddress{}, errors.New("ID must be specified")
+	}
+	body := struct {
+		Description string `json:"description"`
+	}{Description: description}
+	return i.one(i.ipService.do(ctx, http.MethodPut, i.ipService.buildURL("addresses", id), body))
+}
+
+// Release returns the address identified by id to its pool, detaching it
+// from its cluster if it's attached.
+func (i IPAddressesService) Release(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("ID must be specified")
+	}
+	_, err := i.one(i.ipService.do(ctx, http.MethodDelete, i.ipService.buildURL("addresses", id), nil))
+	return err
+}
+
+// Attach attaches the address identified by id to a cluster in the
+// project. Vault clusters must be in the address's region. Addresses that
+// are attached to a different cluster must be detached first.
+func (i IPAddressesService) Attach(ctx context.Context, id string, attachment IPAttachment) (IPAddress, error) {
+	if id == "" {
+		return IPAddress{}, errors.New("ID must be specified")
+	}
+	return i.one(i.ipService.do(ctx, http.MethodPost, i.ipService.buildURL("addresses", id, "attach"), attachment))
+}
+
+// Detach detaches the address identified by id from its cluster, if it's
+// attached to one.
+func (i IPAddressesService) Detach(ctx context.Context, id string) (IPAddress, error) {
+	if id == "" {
+		return IPAddress{}, errors.New("ID must be specified")
+	}
+	return i.one(i.ipService.do(ctx, http.MethodPost, i.ipService.buildURL("addresses", id, "detach"), nil))
+}
@@ -8,12 +8,21 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"regexp"
+	"strconv"
+	"time"
 )
 
 var (
-	ErrNomadClusterNotFound = errors.New("nomad cluster not found")
+	ErrNomadClusterNotFound     = errors.New("nomad cluster not found")
+	ErrNomadClusterNotDeleted   = errors.New("nomad cluster is not deleted")
+	ErrNomadClusterNameConflict = errors.New("nomad cluster name is already in use in the project")
+	ErrNomadClusterJoinInvalid  = errors.New("nomad cluster retry_join and start_join addresses must be in the form HOST or HOST:PORT")
+	ErrNomadClusterRetryInvalid = errors.New("nomad cluster retry_interval must be a positive duration and retry_max can't be negative")
 )
 
+var nomadClusterJoinField = regexp.MustCompile(`^/server/serverJoin/(retryJoin|startJoin)/[0-9]+$`)
+
 type NomadService struct {
 	basePath string
 	client   *Client
@@ -32,23 +41,33 @@ func newNomadService(basePath string, client *Client) *NomadService {
 type NomadClustersService struct {
 	nomadService *NomadService
 	basePath     string
+	Jobs         *NomadJobsService
+	Nodes        *NomadNodesService
+	NodePools    *NomadNodePoolsService
 }
 
 func newNomadClustersService(basePath string, nomad *NomadService) *NomadClustersService {
-	return &NomadClustersService{
+	s := &NomadClustersService{
 		basePath:     basePath,
 		nomadService: nomad,
 	}
+	s.Jobs = newNomadJobsService(s)
+	s.Nodes = newNomadNodesService(s)
+	s.NodePools = newNomadNodePoolsService(s)
+	return s
 }
 
 type NomadCluster struct {
-	ID         string                `json:"id"`
-	Name       string                `json:"name"`
-	Datacenter string                `json:"datacenter"`
-	BindAddr   string                `json:"bindAddr"`
-	Advertise  NomadClusterAdvertise `json:"advertise"`
-	Ports      NomadClusterPorts     `json:"ports"`
-	Server     NomadClusterServer    `json:"server"`
+	ID           string                `json:"id"`
+	Organization string                `json:"organization"`
+	Project      string                `json:"project"`
+	Name         string                `json:"name"`
+	Datacenter   string                `json:"datacenter"`
+	BindAddr     string                `json:"bindAddr"`
+	Advertise    NomadClusterAdvertise `json:"advertise"`
+	Ports        NomadClusterPorts     `json:"ports"`
+	Server       NomadClusterServer    `json:"server"`
+	DeletedAt    *time.Time            `json:"deletedAt,omitempty"`
 }
 
 type NomadClusterAdvertise struct {
@@ -74,8 +93,39 @@ type NomadClusterServerServerJoin struct {
 	StartJoin     []string `json:"startJoin"`
 }
 
+// NomadMember is a server in the gossip pool a Nomad cluster belongs to.
+// Clusters join the pools of the clusters their StartJoin and RetryJoin
+// addresses reach, matched against each cluster's advertised serf address
+// (or bind address) and serf port.
+type NomadMember struct {
+	ClusterID  string `json:"clusterID"`
+	Name       string `json:"name"`
+	Datacenter string `json:"datacenter"`
+	Address    string `json:"address"`
+	Port       int    `json:"port"`
+	Status     string `json:"status"`
+}
+
 func (n NomadClustersService) buildURL(p string) string {
-	return path.Join(n.nomadService.basePath, n.basePath, p)
+	return path.Join(n.nomadService.client.projectPath(), n.nomadService.basePath, n.basePath, p)
+}
+
+// serverJoinError returns the error for invalid ServerJoin settings in errs,
+// or nil if there isn't one.
+func serverJoinError(errs RequestErrors) error {
+	if errs.FieldMatches(requestErrInvalidValue, nomadClusterJoinField) != nil {
+		return ErrNomadClusterJoinInvalid
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/server/serverJoin/retryInterval",
+	}) || errs.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/server/serverJoin/retryMax",
+	}) {
+		return ErrNomadClusterRetryInvalid
+	}
+	return nil
 }
 
 func (n NomadClustersService) Create(ctx context.Context, cluster NomadCluster) (NomadCluster, error) {
@@ -115,6 +165,15 @@ func (n NomadClustersService) Create(ctx context.Context, cluster NomadCluster)
 	}) {
 		return NomadCluster{}, errors.New("cluster must have a datacenter name")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	if err := serverJoinError(resp.Errors); err != nil {
+		return NomadCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return NomadCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -205,6 +264,15 @@ func (n NomadClustersService) Update(ctx context.Context, cluster NomadCluster)
 	}) {
 		return NomadCluster{}, errors.New("cluster must have a datacenter name")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	if err := serverJoinError(resp.Errors); err != nil {
+		return NomadCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return NomadCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -218,7 +286,7 @@ func (n NomadClustersService) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id must be specified")
 	}
-	req, err := n.nomadService.client.NewRequest(ctx, http.MethodGet, n.buildURL("/"+id), nil)
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodDelete, n.buildURL("/"+id), nil)
 	if err != nil {
 		return fmt.Errorf("error constructing request: %w", err)
 	}
@@ -245,3 +313,190 @@ func (n NomadClustersService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (n NomadClustersService) ListVersions(ctx context.Context, id string) ([]Version, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodGet, n.buildURL("/"+id+"/versions"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.nomadService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrNomadClusterNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Versions, nil
+}
+
+func (n NomadClustersService) RestoreVersion(ctx context.Context, id string, version int) (NomadCluster, error) {
+	if id == "" {
+		return NomadCluster{}, errors.New("id must be specified")
+	}
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodPost, n.buildURL("/"+id+"/versions/"+strconv.Itoa(version)+"/restore"), nil)
+	if err != nil {
+		return NomadCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.nomadService.client.Do(req)
+	if err != nil {
+		return NomadCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return NomadCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return NomadCluster{}, ErrVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return NomadCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.NomadClusters) < 1 {
+		return NomadCluster{}, errors.New("no Nomad cluster returned in response")
+	}
+	return resp.NomadClusters[0], nil
+}
+
+func (n NomadClustersService) List(ctx context.Context, includeDeleted bool) ([]NomadCluster, error) {
+	u := n.buildURL("/")
+	if includeDeleted {
+		u += "?include_deleted=true"
+	}
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.nomadService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadClusters, nil
+}
+
+func (n NomadClustersService) Undelete(ctx context.Context, id string) (NomadCluster, error) {
+	if id == "" {
+		return NomadCluster{}, errors.New("id must be specified")
+	}
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodPost, n.buildURL("/"+id+"/undelete"), nil)
+	if err != nil {
+		return NomadCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.nomadService.client.Do(req)
+	if err != nil {
+		return NomadCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return NomadCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return NomadCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNotDeleted
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return NomadCluster{}, ErrNomadClusterNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return NomadCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.NomadClusters) < 1 {
+		return NomadCluster{}, errors.New("no Nomad cluster returned in response")
+	}
+	return resp.NomadClusters[0], nil
+}
+
+// Members returns the members of the gossip pool the cluster identified by
+// id belongs to, including the cluster itself, sorted by name.
+func (n NomadClustersService) Members(ctx context.Context, id string) ([]NomadMember, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := n.nomadService.client.NewRequest(ctx, http.MethodGet, n.buildURL("/"+id+"/members"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.nomadService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrNomadClusterNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadMembers, nil
+}
@@ -0,0 +1,320 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrNomadJobNotFound          = errors.New("nomad job not found")
+	ErrNomadJobNameMissing       = errors.New("nomad job must have a name")
+	ErrNomadJobIDInvalid         = errors.New("nomad job ID can't contain a slash")
+	ErrNomadJobTypeInvalid       = errors.New("nomad job type must be service or batch")
+	ErrNomadJobDatacenterInvalid = errors.New("nomad job datacenters must include the cluster's datacenter")
+	ErrNomadJobConstraintInvalid = errors.New("nomad job constraints must have an attribute in the form ${attribute}, a valid operator, and a valid regexp value for the regexp operator")
+	ErrNomadJobTaskGroupInvalid  = errors.New("nomad job must have at least one task group, each with a name, a count of at least 1, and at least one task")
+	ErrNomadJobTaskGroupConflict = errors.New("nomad job task group names must be unique within the job")
+	ErrNomadJobTaskInvalid       = errors.New("nomad job tasks must have a name and a driver")
+	ErrNomadJobTaskConflict      = errors.New("nomad job task names must be unique within their task group")
+	ErrNomadJobNodePoolInvalid   = errors.New("nomad job node pool must exist in the cluster")
+	ErrNomadJobResourcesInvalid  = errors.New("nomad job task resources can't be negative")
+)
+
+var (
+	nomadJobDatacenterField    = regexp.MustCompile(`^/datacenters(/[0-9]+)?$`)
+	nomadJobConstraintField    = regexp.MustCompile(`^(/taskGroups/[0-9]+)?/constraints/[0-9]+/(attribute|operator|value)$`)
+	nomadJobTaskGroupField     = regexp.MustCompile(`^/taskGroups(/[0-9]+/(name|count|tasks))?$`)
+	nomadJobTaskGroupNameField = regexp.MustCompile(`^/taskGroups/[0-9]+/name$`)
+	nomadJobTaskField          = regexp.MustCompile(`^/taskGroups/[0-9]+/tasks/[0-9]+/(name|driver)$`)
+	nomadJobTaskNameField      = regexp.MustCompile(`^/taskGroups/[0-9]+/tasks/[0-9]+/name$`)
+	nomadJobTaskResourcesField = regexp.MustCompile(`^/taskGroups/[0-9]+/tasks/[0-9]+/resources/(cpu|memoryMB)$`)
+)
+
+const (
+	NomadJobPending = "pending"
+	NomadJobRunning = "running"
+	NomadJobDead    = "dead"
+
+	NomadJobTypeService = "service"
+	NomadJobTypeBatch   = "batch"
+)
+
+// NomadJobsService submits jobs to Nomad clusters, tracks their status, and
+// cancels them. Every request it makes needs a Nomad access policy for the
+// cluster that grants the permission involved; see WithAccessPolicy.
+type NomadJobsService struct {
+	clustersService *NomadClustersService
+}
+
+func newNomadJobsService(clusters *NomadClustersService) *NomadJobsService {
+	return &NomadJobsService{
+		clustersService: clusters,
+	}
+}
+
+// NomadJob is a job in a Nomad cluster. ID defaults to Name, Type to
+// NomadJobTypeService, Datacenters to the cluster's datacenter, and
+// NodePool to NomadNodePoolDefault.
+// Submitting a job with the ID of one already in the cluster replaces it and
+// bumps its Version. Status is NomadJobPending until the job's allocations
+// are placed, NomadJobRunning while they are, and NomadJobDead once they've
+// all stopped.
+type NomadJob struct {
+	ID                string            `json:"id"`
+	Organization      string            `json:"organization"`
+	Project           string            `json:"project"`
+	ClusterID         string            `json:"clusterID"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Datacenters       []string          `json:"datacenters"`
+	NodePool          string            `json:"nodePool,omitempty"`
+	Constraints       []NomadConstraint `json:"constraints,omitempty"`
+	TaskGroups        []NomadTaskGroup  `json:"taskGroups"`
+	Version           uint64            `json:"version"`
+	Stop              bool              `json:"stop"`
+	Status            string            `json:"status"`
+	StatusDescription string            `json:"statusDescription,omitempty"`
+	StatusChangedAt   time.Time         `json:"statusChangedAt"`
+	SubmittedAt       time.Time         `json:"submittedAt"`
+}
+
+// NomadTaskGroup is a set of tasks that are placed together. Count defaults
+// to 1.
+type NomadTaskGroup struct {
+	Name        string            `json:"name"`
+	Count       int               `json:"count"`
+	Constraints []NomadConstraint `json:"constraints,omitempty"`
+	Tasks       []NomadTask       `json:"tasks"`
+}
+
+// NomadTask is a task in a task group. Resources default to 100 MHz of CPU
+// and 300 MB of memory, and are reserved on the node the task is placed on.
+type NomadTask struct {
+	Name      string            `json:"name"`
+	Driver    string            `json:"driver"`
+	Config    map[string]string `json:"config,omitempty"`
+	Resources NomadResources    `json:"resources"`
+}
+
+// NomadConstraint limits the nodes a job or task group can be placed on.
+// Attribute is a node attribute in the form ${node.datacenter}, and Operator
+// defaults to "=".
+type NomadConstraint struct {
+	Attribute string `json:"attribute"`
+	Operator  string `json:"operator"`
+	Value     string `json:"value,omitempty"`
+}
+
+// NomadAllocation is one instance of a job's task group, placed by the
+// scheduler on a node. NodeID is empty if the cluster had no nodes
+// registered when the allocation was placed.
+type NomadAllocation struct {
+	ID                 string         `json:"id"`
+	Organization       string         `json:"organization"`
+	Project            string         `json:"project"`
+	ClusterID          string         `json:"clusterID"`
+	JobID              string         `json:"jobID"`
+	JobVersion         uint64         `json:"jobVersion"`
+	TaskGroup          string         `json:"taskGroup"`
+	Index              int            `json:"index"`
+	Name               string         `json:"name"`
+	NodeID             string         `json:"nodeID,omitempty"`
+	NodeName           string         `json:"nodeName,omitempty"`
+	Resources          NomadResources `json:"resources"`
+	DesiredStatus      string         `json:"desiredStatus"`
+	DesiredDescription string         `json:"desiredDescription,omitempty"`
+	ClientStatus       string         `json:"clientStatus"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	ModifiedAt         time.Time      `json:"modifiedAt"`
+}
+
+func (n NomadJobsService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{n.clustersService.buildURL(clusterID)}, p...)...)
+}
+
+// do makes a request to the job routes and returns the response.
+func (n NomadJobsService) do(ctx context.Context, method, u string, body interface{}) (Response, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := n.clustersService.nomadService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := n.clustersService.nomadService.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(accessDeniedError) {
+		return Response{}, ErrAccessPolicyDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrNomadClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "job",
+	}) {
+		return Response{}, ErrNomadJobNotFound
+	}
+	return resp, nil
+}
+
+// one returns the only job in resp.
+func (n NomadJobsService) one(resp Response, err error) (NomadJob, error) {
+	if err != nil {
+		return NomadJob{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return NomadJob{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.NomadJobs) < 1 {
+		return NomadJob{}, errors.New("no Nomad job returned in response")
+	}
+	return resp.NomadJobs[0], nil
+}
+
+// jobErr returns the error describing why a job was rejected, or nil if it
+// wasn't.
+func (n NomadJobsService) jobErr(errs RequestErrors) error {
+	if errs.Contains(RequestError{Slug: requestErrMissing, Field: "/name"}) {
+		return ErrNomadJobNameMissing
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/id"}) {
+		return ErrNomadJobIDInvalid
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/type"}) {
+		return ErrNomadJobTypeInvalid
+	}
+	if errs.FieldMatches(requestErrInvalidValue, nomadJobDatacenterField) != nil {
+		return ErrNomadJobDatacenterInvalid
+	}
+	if errs.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/nodePool"}) {
+		return ErrNomadJobNodePoolInvalid
+	}
+	for _, slug := range []string{requestErrMissing, requestErrInvalidFormat, requestErrInvalidValue} {
+		if errs.FieldMatches(slug, nomadJobConstraintField) != nil {
+			return ErrNomadJobConstraintInvalid
+		}
+	}
+	if errs.FieldMatches(requestErrConflict, nomadJobTaskGroupNameField) != nil {
+		return ErrNomadJobTaskGroupConflict
+	}
+	if errs.FieldMatches(requestErrMissing, nomadJobTaskGroupField) != nil || errs.FieldMatches(requestErrInvalidValue, nomadJobTaskGroupField) != nil {
+		return ErrNomadJobTaskGroupInvalid
+	}
+	if errs.FieldMatches(requestErrConflict, nomadJobTaskNameField) != nil {
+		return ErrNomadJobTaskConflict
+	}
+	if errs.FieldMatches(requestErrMissing, nomadJobTaskField) != nil {
+		return ErrNomadJobTaskInvalid
+	}
+	if errs.FieldMatches(requestErrInvalidValue, nomadJobTaskResourcesField) != nil {
+		return ErrNomadJobResourcesInvalid
+	}
+	return nil
+}
+
+// Submit adds job to the Nomad cluster identified by clusterID, replacing
+// any job with the same ID. The access policy must grant SubmitJobs.
+func (n NomadJobsService) Submit(ctx context.Context, clusterID string, job NomadJob) (NomadJob, error) {
+	if clusterID == "" {
+		return NomadJob{}, errors.New("cluster ID must be specified")
+	}
+	resp, err := n.do(ctx, http.MethodPost, n.buildURL(clusterID, "jobs"), job)
+	if err != nil {
+		return NomadJob{}, err
+	}
+	if err := n.jobErr(resp.Errors); err != nil {
+		return NomadJob{}, err
+	}
+	return n.one(resp, nil)
+}
+
+// Get returns the job identified by jobID, with its status. The access
+// policy must grant ReadJobStatus.
+func (n NomadJobsService) Get(ctx context.Context, clusterID, jobID string) (NomadJob, error) {
+	if clusterID == "" {
+		return NomadJob{}, errors.New("cluster ID must be specified")
+	}
+	if jobID == "" {
+		return NomadJob{}, errors.New("job ID must be specified")
+	}
+	return n.one(n.do(ctx, http.MethodGet, n.buildURL(clusterID, "jobs", jobID), nil))
+}
+
+// List returns every job in the Nomad cluster identified by clusterID,
+// sorted by ID. The access policy must grant ReadJobStatus.
+func (n NomadJobsService) List(ctx context.Context, clusterID string) ([]NomadJob, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	resp, err := n.do(ctx, http.MethodGet, n.buildURL(clusterID, "jobs"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadJobs, nil
+}
+
+// Cancel stops the job identified by jobID. Its allocations are stopped by
+// the scheduler, after which the job is NomadJobDead. The access policy
+// must grant CancelJobs.
+func (n NomadJobsService) Cancel(ctx context.Context, clusterID, jobID string) (NomadJob, error) {
+	if clusterID == "" {
+		return NomadJob{}, errors.New("cluster ID must be specified")
+	}
+	if jobID == "" {
+		return NomadJob{}, errors.New("job ID must be specified")
+	}
+	return n.one(n.do(ctx, http.MethodDelete, n.buildURL(clusterID, "jobs", jobID), nil))
+}
+
+// Allocations returns the allocations the scheduler has placed for the job
+// identified by jobID, across all its versions. The access policy must
+// grant ReadJobStatus.
+func (n NomadJobsService) Allocations(ctx context.Context, clusterID, jobID string) ([]NomadAllocation, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	if jobID == "" {
+		return nil, errors.New("job ID must be specified")
+	}
+	resp, err := n.do(ctx, http.MethodGet, n.buildURL(clusterID, "jobs", jobID, "allocations"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadAllocations, nil
+}
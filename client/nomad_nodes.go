@@ -0,0 +1,362 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+)
+
+var (
+	ErrNomadNodeNotFound             = errors.New("nomad node not found")
+	ErrNomadNodeNameMissing          = errors.New("nomad node must have a name")
+	ErrNomadNodePoolInvalid          = errors.New("nomad node pool must exist in the cluster and can't be the all pool")
+	ErrNomadNodeResourcesInvalid     = errors.New("nomad node resources can't be negative")
+	ErrNomadNodeDraining             = errors.New("nomad node is draining, so it can't be made eligible")
+	ErrNomadNodePoolNotFound         = errors.New("nomad node pool not found")
+	ErrNomadNodePoolNameMissing      = errors.New("nomad node pool must have a name")
+	ErrNomadNodePoolNameInvalid      = errors.New("nomad node pool names can only contain letters, numbers, dashes, and underscores, and are at most 128 characters")
+	ErrNomadNodePoolNameConflict     = errors.New("nomad node pool name is already in use in the cluster")
+	ErrNomadNodePoolSchedulerInvalid = errors.New("nomad node pool scheduler algorithm must be binpack or spread")
+	ErrNomadNodePoolInUse            = errors.New("nomad node pool still has nodes or jobs in it")
+)
+
+const (
+	NomadNodePoolDefault = "default"
+	NomadNodePoolAll     = "all"
+
+	NomadSchedulerBinpack = "binpack"
+	NomadSchedulerSpread  = "spread"
+
+	NomadNodeEligible   = "eligible"
+	NomadNodeIneligible = "ineligible"
+)
+
+// NomadNodePool is a named group of client nodes in a Nomad cluster. Jobs
+// are only placed on nodes in their pool. SchedulerAlgorithm defaults to
+// NomadSchedulerBinpack. The built-in NomadNodePoolDefault and
+// NomadNodePoolAll pools can't be created, updated, or deleted.
+type NomadNodePool struct {
+	Organization       string            `json:"organization"`
+	Project            string            `json:"project"`
+	ClusterID          string            `json:"clusterID"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	Meta               map[string]string `json:"meta,omitempty"`
+	SchedulerAlgorithm string            `json:"schedulerAlgorithm"`
+	CreatedAt          time.Time         `json:"createdAt"`
+	ModifiedAt         time.Time         `json:"modifiedAt"`
+}
+
+// NomadNode is a client node registered with a Nomad cluster. Datacenter
+// defaults to the cluster's, NodePool to NomadNodePoolDefault, and
+// Resources to 4000 MHz of CPU and 8192 MB of memory. Drain and
+// SchedulingEligibility are set using NomadNodesService.Drain and
+// NomadNodesService.SetEligibility.
+type NomadNode struct {
+	ID                    string         `json:"id"`
+	Organization          string         `json:"organization"`
+	Project               string         `json:"project"`
+	ClusterID             string         `json:"clusterID"`
+	Name                  string         `json:"name"`
+	Datacenter            string         `json:"datacenter"`
+	NodeClass             string         `json:"nodeClass,omitempty"`
+	NodePool              string         `json:"nodePool,omitempty"`
+	Resources             NomadResources `json:"resources"`
+	Drain                 bool           `json:"drain"`
+	SchedulingEligibility string         `json:"schedulingEligibility"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	ModifiedAt            time.Time      `json:"modifiedAt"`
+}
+
+// NomadResources is an amount of CPU, in MHz, and memory, in MB.
+type NomadResources struct {
+	CPU      int `json:"cpu,omitempty"`
+	MemoryMB int `json:"memoryMB,omitempty"`
+}
+
+// nomadNodesDo makes a request to the node or node pool routes of a Nomad
+// cluster and returns the response.
+func nomadNodesDo(ctx context.Context, clusters *NomadClustersService, method, u string, body interface{}) (Response, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := clusters.nomadService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := clusters.nomadService.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrNomadClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "node",
+	}) {
+		return Response{}, ErrNomadNodeNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "pool",
+	}) {
+		return Response{}, ErrNomadNodePoolNotFound
+	}
+	return resp, nil
+}
+
+// NomadNodePoolsService manages the node pools of Nomad clusters.
+type NomadNodePoolsService struct {
+	clustersService *NomadClustersService
+}
+
+func newNomadNodePoolsService(clusters *NomadClustersService) *NomadNodePoolsService {
+	return &NomadNodePoolsService{
+		clustersService: clusters,
+	}
+}
+
+func (n NomadNodePoolsService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{n.clustersService.buildURL(clusterID), "nodePools"}, p...)...)
+}
+
+// one returns the only node pool in resp, or the error describing why the
+// pool was rejected.
+func (n NomadNodePoolsService) one(resp Response, err error) (NomadNodePool, error) {
+	if err != nil {
+		return NomadNodePool{}, err
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/name"}) {
+		return NomadNodePool{}, ErrNomadNodePoolNameMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidFormat, Field: "/name"}) {
+		return NomadNodePool{}, ErrNomadNodePoolNameInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/name"}) {
+		return NomadNodePool{}, ErrNomadNodePoolNameConflict
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/schedulerAlgorithm"}) {
+		return NomadNodePool{}, ErrNomadNodePoolSchedulerInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Param: "pool"}) {
+		return NomadNodePool{}, ErrNomadNodePoolInUse
+	}
+	if len(resp.Errors) > 0 {
+		return NomadNodePool{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.NomadNodePools) < 1 {
+		return NomadNodePool{}, errors.New("no Nomad node pool returned in response")
+	}
+	return resp.NomadNodePools[0], nil
+}
+
+// Create adds pool to the Nomad cluster identified by clusterID.
+func (n NomadNodePoolsService) Create(ctx context.Context, clusterID string, pool NomadNodePool) (NomadNodePool, error) {
+	if clusterID == "" {
+		return NomadNodePool{}, errors.New("cluster ID must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodPost, n.buildURL(clusterID), pool))
+}
+
+// Get returns the node pool called name in the Nomad cluster identified by
+// clusterID.
+func (n NomadNodePoolsService) Get(ctx context.Context, clusterID, name string) (NomadNodePool, error) {
+	if clusterID == "" {
+		return NomadNodePool{}, errors.New("cluster ID must be specified")
+	}
+	if name == "" {
+		return NomadNodePool{}, errors.New("name must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodGet, n.buildURL(clusterID, name), nil))
+}
+
+// List returns the node pools in the Nomad cluster identified by clusterID,
+// sorted by name, leaving out the built-in pools.
+func (n NomadNodePoolsService) List(ctx context.Context, clusterID string) ([]NomadNodePool, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	resp, err := nomadNodesDo(ctx, n.clustersService, http.MethodGet, n.buildURL(clusterID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadNodePools, nil
+}
+
+// Update replaces the description, meta, and scheduler algorithm of the
+// node pool called pool.Name in the Nomad cluster identified by clusterID.
+func (n NomadNodePoolsService) Update(ctx context.Context, clusterID string, pool NomadNodePool) (NomadNodePool, error) {
+	if clusterID == "" {
+		return NomadNodePool{}, errors.New("cluster ID must be specified")
+	}
+	if pool.Name == "" {
+		return NomadNodePool{}, errors.New("name must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodPut, n.buildURL(clusterID, pool.Name), pool))
+}
+
+// Delete removes the node pool called name from the Nomad cluster
+// identified by clusterID. Pools with nodes in them, or jobs that aren't
+// dead using them, can't be deleted.
+func (n NomadNodePoolsService) Delete(ctx context.Context, clusterID, name string) error {
+	if clusterID == "" {
+		return errors.New("cluster ID must be specified")
+	}
+	if name == "" {
+		return errors.New("name must be specified")
+	}
+	_, err := n.one(nomadNodesDo(ctx, n.clustersService, http.MethodDelete, n.buildURL(clusterID, name), nil))
+	return err
+}
+
+// NomadNodesService registers client nodes with Nomad clusters, and drains
+// them or changes their eligibility for new allocations.
+type NomadNodesService struct {
+	clustersService *NomadClustersService
+}
+
+func newNomadNodesService(clusters *NomadClustersService) *NomadNodesService {
+	return &NomadNodesService{
+		clustersService: clusters,
+	}
+}
+
+func (n NomadNodesService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{n.clustersService.buildURL(clusterID), "nodes"}, p...)...)
+}
+
+// one returns the only node in resp, or the error describing why the node
+// or the change to it was rejected.
+func (n NomadNodesService) one(resp Response, err error) (NomadNode, error) {
+	if err != nil {
+		return NomadNode{}, err
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrMissing, Field: "/name"}) {
+		return NomadNode{}, ErrNomadNodeNameMissing
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/nodePool"}) {
+		return NomadNode{}, ErrNomadNodePoolInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/resources/cpu"}) ||
+		resp.Errors.Contains(RequestError{Slug: requestErrInvalidValue, Field: "/resources/memoryMB"}) {
+		return NomadNode{}, ErrNomadNodeResourcesInvalid
+	}
+	if resp.Errors.Contains(RequestError{Slug: requestErrConflict, Field: "/eligible"}) {
+		return NomadNode{}, ErrNomadNodeDraining
+	}
+	if len(resp.Errors) > 0 {
+		return NomadNode{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.NomadNodes) < 1 {
+		return NomadNode{}, errors.New("no Nomad node returned in response")
+	}
+	return resp.NomadNodes[0], nil
+}
+
+// Register adds node to the Nomad cluster identified by clusterID, with a
+// new ID, as an eligible node that isn't draining.
+func (n NomadNodesService) Register(ctx context.Context, clusterID string, node NomadNode) (NomadNode, error) {
+	if clusterID == "" {
+		return NomadNode{}, errors.New("cluster ID must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodPost, n.buildURL(clusterID), node))
+}
+
+// Get returns the node identified by nodeID in the Nomad cluster
+// identified by clusterID.
+func (n NomadNodesService) Get(ctx context.Context, clusterID, nodeID string) (NomadNode, error) {
+	if clusterID == "" {
+		return NomadNode{}, errors.New("cluster ID must be specified")
+	}
+	if nodeID == "" {
+		return NomadNode{}, errors.New("node ID must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodGet, n.buildURL(clusterID, nodeID), nil))
+}
+
+// List returns the nodes registered with the Nomad cluster identified by
+// clusterID, sorted by name.
+func (n NomadNodesService) List(ctx context.Context, clusterID string) ([]NomadNode, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	resp, err := nomadNodesDo(ctx, n.clustersService, http.MethodGet, n.buildURL(clusterID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.NomadNodes, nil
+}
+
+// Deregister removes the node identified by nodeID from the Nomad cluster
+// identified by clusterID. Its allocations are lost, and the scheduler
+// replaces them on other nodes.
+func (n NomadNodesService) Deregister(ctx context.Context, clusterID, nodeID string) (NomadNode, error) {
+	if clusterID == "" {
+		return NomadNode{}, errors.New("cluster ID must be specified")
+	}
+	if nodeID == "" {
+		return NomadNode{}, errors.New("node ID must be specified")
+	}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodDelete, n.buildURL(clusterID, nodeID), nil))
+}
+
+// Drain starts draining the node identified by nodeID if enable is true,
+// and cancels draining it if enable is false. A draining node is
+// ineligible, and its allocations are migrated to other nodes; once they
+// have been, the drain is done and the node is left ineligible. Canceling a
+// drain makes the node eligible again.
+func (n NomadNodesService) Drain(ctx context.Context, clusterID, nodeID string, enable bool) (NomadNode, error) {
+	if clusterID == "" {
+		return NomadNode{}, errors.New("cluster ID must be specified")
+	}
+	if nodeID == "" {
+		return NomadNode{}, errors.New("node ID must be specified")
+	}
+	body := map[string]bool{"enable": enable}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodPost, n.buildURL(clusterID, nodeID, "drain"), body))
+}
+
+// SetEligibility sets whether the node identified by nodeID can have new
+// allocations placed on it. Draining nodes can't be made eligible.
+func (n NomadNodesService) SetEligibility(ctx context.Context, clusterID, nodeID string, eligible bool) (NomadNode, error) {
+	if clusterID == "" {
+		return NomadNode{}, errors.New("cluster ID must be specified")
+	}
+	if nodeID == "" {
+		return NomadNode{}, errors.New("node ID must be specified")
+	}
+	body := map[string]bool{"eligible": eligible}
+	return n.one(nomadNodesDo(ctx, n.clustersService, http.MethodPost, n.buildURL(clusterID, nodeID, "eligibility"), body))
+}
@@ -0,0 +1,503 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+var (
+	ErrOrganizationNotFound      = errors.New("organization not found")
+	ErrOrganizationAlreadyExists = errors.New("organization already exists")
+	ErrOrganizationNotEmpty      = errors.New("organization still has projects")
+	ErrProjectNotFound           = errors.New("project not found")
+	ErrProjectAlreadyExists      = errors.New("project already exists")
+	ErrProjectNotEmpty           = errors.New("project still has resources")
+	ErrOrganizationAccessDenied  = errors.New("only admins can manage organizations and projects")
+)
+
+type OrganizationsService struct {
+	basePath string
+	client   *Client
+}
+
+func newOrganizationsService(basePath string, client *Client) *OrganizationsService {
+	return &OrganizationsService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+type ProjectsService struct {
+	basePath string
+	client   *Client
+}
+
+func newProjectsService(basePath string, client *Client) *ProjectsService {
+	return &ProjectsService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Project struct {
+	ID           string `json:"id"`
+	Organization string `json:"organization"`
+	Name         string `json:"name"`
+}
+
+func (o OrganizationsService) buildURL(p string) string {
+	return path.Join(o.basePath, p)
+}
+
+func (p ProjectsService) buildURL(org, id string) string {
+	return path.Join(p.basePath, org, "projects", id)
+}
+
+func (o OrganizationsService) Create(ctx context.Context, org Organization) (Organization, error) {
+	b, err := json.Marshal(org)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error serialising org: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := o.client.NewRequest(ctx, http.MethodPost, o.buildURL("/"), buf)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Organization{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Organization{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Organization{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Organization{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/id",
+	}) {
+		return Organization{}, ErrOrganizationAlreadyExists
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return Organization{}, errors.New("name must be set")
+	}
+	if len(resp.Errors) > 0 {
+		return Organization{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Organizations) < 1 {
+		return Organization{}, errors.New("no organization returned in response")
+	}
+	return resp.Organizations[0], nil
+}
+
+func (o OrganizationsService) Get(ctx context.Context, id string) (Organization, error) {
+	if id == "" {
+		return Organization{}, errors.New("id must be specified")
+	}
+	req, err := o.client.NewRequest(ctx, http.MethodGet, o.buildURL("/"+id), nil)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Organization{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Organization{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Organization{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "org",
+	}) {
+		return Organization{}, ErrOrganizationNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return Organization{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Organizations) < 1 {
+		return Organization{}, errors.New("no organization returned in response")
+	}
+	return resp.Organizations[0], nil
+}
+
+func (o OrganizationsService) Update(ctx context.Context, org Organization) (Organization, error) {
+	if org.ID == "" {
+		return Organization{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(org)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error serialising org: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := o.client.NewRequest(ctx, http.MethodPut, o.buildURL("/"+org.ID), buf)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return Organization{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Organization{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Organization{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Organization{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Organization{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "org",
+	}) {
+		return Organization{}, ErrOrganizationNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return Organization{}, errors.New("name must be set")
+	}
+	if len(resp.Errors) > 0 {
+		return Organization{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Organizations) < 1 {
+		return Organization{}, errors.New("no organization returned in response")
+	}
+	return resp.Organizations[0], nil
+}
+
+func (o OrganizationsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := o.client.NewRequest(ctx, http.MethodDelete, o.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "org",
+	}) {
+		return ErrOrganizationNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "org",
+	}) {
+		return ErrOrganizationNotEmpty
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (o OrganizationsService) List(ctx context.Context) ([]Organization, error) {
+	req, err := o.client.NewRequest(ctx, http.MethodGet, o.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return nil, ErrOrganizationAccessDenied
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Organizations, nil
+}
+
+func (p ProjectsService) Create(ctx context.Context, project Project) (Project, error) {
+	if project.Organization == "" {
+		return Project{}, errors.New("organization must be specified")
+	}
+	b, err := json.Marshal(project)
+	if err != nil {
+		return Project{}, fmt.Errorf("error serialising project: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := p.client.NewRequest(ctx, http.MethodPost, p.buildURL(project.Organization, "/"), buf)
+	if err != nil {
+		return Project{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return Project{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Project{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Project{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Project{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Project{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "org",
+	}) {
+		return Project{}, ErrOrganizationNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/id",
+	}) {
+		return Project{}, ErrProjectAlreadyExists
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return Project{}, errors.New("name must be set")
+	}
+	if len(resp.Errors) > 0 {
+		return Project{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Projects) < 1 {
+		return Project{}, errors.New("no project returned in response")
+	}
+	return resp.Projects[0], nil
+}
+
+func (p ProjectsService) Get(ctx context.Context, org, id string) (Project, error) {
+	if org == "" {
+		return Project{}, errors.New("organization must be specified")
+	}
+	if id == "" {
+		return Project{}, errors.New("id must be specified")
+	}
+	req, err := p.client.NewRequest(ctx, http.MethodGet, p.buildURL(org, id), nil)
+	if err != nil {
+		return Project{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return Project{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Project{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Project{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Project{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "project",
+	}) {
+		return Project{}, ErrProjectNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return Project{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Projects) < 1 {
+		return Project{}, errors.New("no project returned in response")
+	}
+	return resp.Projects[0], nil
+}
+
+func (p ProjectsService) Update(ctx context.Context, project Project) (Project, error) {
+	if project.Organization == "" {
+		return Project{}, errors.New("organization must be specified")
+	}
+	if project.ID == "" {
+		return Project{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(project)
+	if err != nil {
+		return Project{}, fmt.Errorf("error serialising project: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := p.client.NewRequest(ctx, http.MethodPut, p.buildURL(project.Organization, project.ID), buf)
+	if err != nil {
+		return Project{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return Project{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Project{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Project{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return Project{}, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Project{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "project",
+	}) {
+		return Project{}, ErrProjectNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return Project{}, errors.New("name must be set")
+	}
+	if len(resp.Errors) > 0 {
+		return Project{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Projects) < 1 {
+		return Project{}, errors.New("no project returned in response")
+	}
+	return resp.Projects[0], nil
+}
+
+func (p ProjectsService) Delete(ctx context.Context, org, id string) error {
+	if org == "" {
+		return errors.New("organization must be specified")
+	}
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := p.client.NewRequest(ctx, http.MethodDelete, p.buildURL(org, id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "project",
+	}) {
+		return ErrProjectNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "project",
+	}) {
+		return ErrProjectNotEmpty
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (p ProjectsService) List(ctx context.Context, org string) ([]Project, error) {
+	if org == "" {
+		return nil, errors.New("organization must be specified")
+	}
+	req, err := p.client.NewRequest(ctx, http.MethodGet, p.buildURL(org, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return nil, ErrOrganizationAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "org",
+	}) {
+		return nil, ErrOrganizationNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Projects, nil
+}
@@ -26,14 +26,45 @@ var (
 )
 
 type Response struct {
-	Regions             []Region             `json:"regions,omitempty"`
-	VaultClusters       []VaultCluster       `json:"vaultClusters,omitempty"`
-	TerraformWorkspaces []TerraformWorkspace `json:"terraformWorkspaces,omitempty"`
-	ConsulClusters      []ConsulCluster      `json:"consulClusters,omitempty"`
-	NomadClusters       []NomadCluster       `json:"nomadClusters,omitempty"`
-	AccessPolicies      []AccessPolicy       `json:"accessPolicies,omitempty"`
-	Errors              RequestErrors        `json:"errors,omitempty"`
-	Status              int                  `json:"-"`
+	Regions                []Region                `json:"regions,omitempty"`
+	VaultClusters          []VaultCluster          `json:"vaultClusters,omitempty"`
+	VaultClusterKeys       []VaultClusterKeys      `json:"vaultClusterKeys,omitempty"`
+	VaultSecrets           []VaultSecret           `json:"vaultSecrets,omitempty"`
+	VaultSecretVersions    []VaultSecretVersion    `json:"vaultSecretVersions,omitempty"`
+	VaultLeases            []VaultLease            `json:"vaultLeases,omitempty"`
+	TerraformWorkspaces    []TerraformWorkspace    `json:"terraformWorkspaces,omitempty"`
+	TerraformRuns          []TerraformRun          `json:"terraformRuns,omitempty"`
+	TerraformRunTriggers   []TerraformRunTrigger   `json:"terraformRunTriggers,omitempty"`
+	TerraformStateVersions []TerraformStateVersion `json:"terraformStateVersions,omitempty"`
+	TerraformStateOutputs  []TerraformStateOutput  `json:"terraformStateOutputs,omitempty"`
+	TerraformVCSEvents     []TerraformVCSEvent     `json:"terraformVCSEvents,omitempty"`
+	TerraformVariables     []TerraformVariable     `json:"terraformVariables,omitempty"`
+	TerraformVariableSets  []TerraformVariableSet  `json:"terraformVariableSets,omitempty"`
+	TerraformPolicySets    []TerraformPolicySet    `json:"terraformPolicySets,omitempty"`
+	TerraformAgentPools    []TerraformAgentPool    `json:"terraformAgentPools,omitempty"`
+	TerraformAgentTokens   []TerraformAgentToken   `json:"terraformAgentTokens,omitempty"`
+	TerraformOAuthClients  []TerraformOAuthClient  `json:"terraformOAuthClients,omitempty"`
+	TerraformOAuthTokens   []TerraformOAuthToken   `json:"terraformOAuthTokens,omitempty"`
+	TerraformVersions      []TerraformVersion      `json:"terraformVersions,omitempty"`
+	ConsulClusters         []ConsulCluster         `json:"consulClusters,omitempty"`
+	ConsulKVPairs          []ConsulKVPair          `json:"consulKVPairs,omitempty"`
+	ConsulSessions         []ConsulSession         `json:"consulSessions,omitempty"`
+	ConsulCatalogServices  []ConsulCatalogService  `json:"consulCatalogServices,omitempty"`
+	ConsulDNSAnswers       []ConsulDNSAnswer       `json:"consulDNSAnswers,omitempty"`
+	NomadClusters          []NomadCluster          `json:"nomadClusters,omitempty"`
+	NomadMembers           []NomadMember           `json:"nomadMembers,omitempty"`
+	NomadNodePools         []NomadNodePool         `json:"nomadNodePools,omitempty"`
+	NomadNodes             []NomadNode             `json:"nomadNodes,omitempty"`
+	NomadJobs              []NomadJob              `json:"nomadJobs,omitempty"`
+	NomadAllocations       []NomadAllocation       `json:"nomadAllocations,omitempty"`
+	IPPools                []IPPool                `json:"ipPools,omitempty"`
+	IPAddresses            []IPAddress             `json:"ipAddresses,omitempty"`
+	AccessPolicies         []AccessPolicy          `json:"accessPolicies,omitempty"`
+	Organizations          []Organization          `json:"organizations,omitempty"`
+	Projects               []Project               `json:"projects,omitempty"`
+	Versions               []Version               `json:"versions,omitempty"`
+	Errors                 RequestErrors           `json:"errors,omitempty"`
+	Status                 int                     `json:"-"`
 }
 
 func responseFromBody(resp *http.Response) (Response, error) {
@@ -8,16 +8,38 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"time"
 )
 
 var (
-	ErrTerraformWorkspaceNotFound = errors.New("terraform workspace not found")
+	ErrTerraformWorkspaceNotFound             = errors.New("terraform workspace not found")
+	ErrTerraformWorkspaceAlreadyExists        = errors.New("terraform workspace already exists")
+	ErrTerraformWorkspaceNotDeleted           = errors.New("terraform workspace is not deleted")
+	ErrTerraformWorkspaceNameConflict         = errors.New("terraform workspace name is already in use in the project")
+	ErrTerraformWorkspaceInvalidExecutionMode = errors.New("terraform workspace execution mode must be remote, local, or agent")
+	ErrTerraformWorkspaceAgentPoolRequired    = errors.New("terraform workspaces with the agent execution mode must have an agent pool")
+	ErrTerraformWorkspaceAgentPoolNotAllowed  = errors.New("only terraform workspaces with the agent execution mode can have an agent pool")
+	ErrTerraformWorkspaceVCSRepoIncomplete    = errors.New("terraform workspace VCS repos need both an OAuth token ID and an identifier")
+	ErrTerraformWorkspaceVCSIdentifier        = errors.New("terraform workspace VCS identifier isn't valid for its VCS provider")
+	ErrTerraformWorkspaceVersionInvalid       = errors.New("terraform workspace terraform version must be a version or a version constraint")
+	ErrTerraformWorkspaceVersionNotAllowed    = errors.New("terraform workspace terraform version isn't allowed by the Terraform version catalog")
 )
 
 type TerraformService struct {
-	basePath   string
-	client     *Client
-	Workspaces *TerraformWorkspacesService
+	basePath      string
+	client        *Client
+	Workspaces    *TerraformWorkspacesService
+	Runs          *TerraformRunsService
+	RunTriggers   *TerraformRunTriggersService
+	StateVersions *TerraformStateVersionsService
+	Variables     *TerraformVariablesService
+	VariableSets  *TerraformVariableSetsService
+	PolicySets    *TerraformPolicySetsService
+	AgentPools    *TerraformAgentPoolsService
+	OAuthClients  *TerraformOAuthClientsService
+	Webhooks      *TerraformWebhooksService
+	Versions      *TerraformVersionsService
 }
 
 func newTerraformService(basePath string, client *Client) *TerraformService {
@@ -26,6 +48,16 @@ func newTerraformService(basePath string, client *Client) *TerraformService {
 		client:   client,
 	}
 	s.Workspaces = newTerraformWorkspacesService("workspaces", s)
+	s.Runs = newTerraformRunsService("runs", s)
+	s.RunTriggers = newTerraformRunTriggersService("runTriggers", s)
+	s.StateVersions = newTerraformStateVersionsService("stateVersions", s)
+	s.Variables = newTerraformVariablesService("vars", "workspaces", ErrTerraformWorkspaceNotFound, s)
+	s.VariableSets = newTerraformVariableSetsService("varsets", s)
+	s.PolicySets = newTerraformPolicySetsService("policySets", s)
+	s.AgentPools = newTerraformAgentPoolsService("agentPools", s)
+	s.OAuthClients = newTerraformOAuthClientsService("oauthClients", s)
+	s.Webhooks = newTerraformWebhooksService("webhooks", s)
+	s.Versions = newTerraformVersionsService("versions", s)
 	return s
 }
 
@@ -43,6 +75,8 @@ func newTerraformWorkspacesService(basePath string, terraform *TerraformService)
 
 type TerraformWorkspace struct {
 	ID                  string                    `json:"id"`
+	Organization        string                    `json:"organization"`
+	Project             string                    `json:"project"`
 	Name                string                    `json:"name"`
 	AgentPoolID         string                    `json:"agentPoolID"`
 	AllowDestroyPlan    *bool                     `json:"allowDestroyPlan"`
@@ -58,6 +92,9 @@ type TerraformWorkspace struct {
 	TriggerPrefixes     []string                  `json:"triggerPrefixes"`
 	WorkingDirectory    string                    `json:"workingDirectory"`
 	VCSRepo             TerraformWorkspaceVCSRepo `json:"vcsRepo"`
+	Locked              bool                      `json:"locked"`
+	Lock                *TerraformWorkspaceLock   `json:"lock,omitempty"`
+	DeletedAt           *time.Time                `json:"deletedAt,omitempty"`
 }
 
 type TerraformWorkspaceVCSRepo struct {
@@ -68,7 +105,7 @@ type TerraformWorkspaceVCSRepo struct {
 }
 
 func (t TerraformWorkspacesService) buildURL(p string) string {
-	return path.Join(t.terraformService.basePath, t.basePath, p)
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
 }
 
 func (t TerraformWorkspacesService) Create(ctx context.Context, workspace TerraformWorkspace) (TerraformWorkspace, error) {
@@ -106,7 +143,70 @@ func (t TerraformWorkspacesService) Create(ctx context.Context, workspace Terraf
 		Slug:  requestErrConflict,
 		Field: "/id",
 	}) {
-		return TerraformWorkspace{}, errors.New("workspace already exists")
+		return TerraformWorkspace{}, ErrTerraformWorkspaceAlreadyExists
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/executionMode",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceInvalidExecutionMode
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceAgentPoolRequired
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceAgentPoolNotAllowed
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/vcsRepo/oauthTokenID",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/vcsRepo/identifier",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVCSRepoIncomplete
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformAgentPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/vcsRepo/oauthTokenID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformOAuthTokenNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/vcsRepo/identifier",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVCSIdentifier
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/terraformVersion",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVersionInvalid
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/terraformVersion",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVersionNotAllowed
 	}
 	if len(resp.Errors) > 0 {
 		return TerraformWorkspace{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
@@ -186,12 +286,78 @@ func (t TerraformWorkspacesService) Update(ctx context.Context, workspace Terraf
 	}) {
 		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
 	}
+	if resp.Errors.Contains(lockedError) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
 	if resp.Errors.Contains(RequestError{
 		Slug:  requestErrMissing,
 		Field: "/name",
 	}) {
 		return TerraformWorkspace{}, errors.New("workspace must have a name")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/executionMode",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceInvalidExecutionMode
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceAgentPoolRequired
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceAgentPoolNotAllowed
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/vcsRepo/oauthTokenID",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/vcsRepo/identifier",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVCSRepoIncomplete
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformAgentPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/vcsRepo/oauthTokenID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformOAuthTokenNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/vcsRepo/identifier",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVCSIdentifier
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/terraformVersion",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVersionInvalid
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/terraformVersion",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVersionNotAllowed
+	}
 	if len(resp.Errors) > 0 {
 		return TerraformWorkspace{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -205,7 +371,7 @@ func (t TerraformWorkspacesService) Delete(ctx context.Context, id string) error
 	if id == "" {
 		return errors.New("id must be specified")
 	}
-	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
 	if err != nil {
 		return fmt.Errorf("error constructing request: %w", err)
 	}
@@ -232,3 +398,183 @@ func (t TerraformWorkspacesService) Delete(ctx context.Context, id string) error
 	}
 	return nil
 }
+
+func (t TerraformWorkspacesService) ListVersions(ctx context.Context, id string) ([]Version, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id+"/versions"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Versions, nil
+}
+
+func (t TerraformWorkspacesService) RestoreVersion(ctx context.Context, id string, version int) (TerraformWorkspace, error) {
+	if id == "" {
+		return TerraformWorkspace{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"+id+"/versions/"+strconv.Itoa(version)+"/restore"), nil)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformWorkspace{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(lockedError) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return TerraformWorkspace{}, ErrVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/agentPoolID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformAgentPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/vcsRepo/oauthTokenID",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformOAuthTokenNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/vcsRepo/identifier",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVCSIdentifier
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/terraformVersion",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceVersionNotAllowed
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformWorkspace{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformWorkspaces) < 1 {
+		return TerraformWorkspace{}, errors.New("no Terraform workspace returned in response")
+	}
+	return resp.TerraformWorkspaces[0], nil
+}
+
+func (t TerraformWorkspacesService) List(ctx context.Context, includeDeleted bool) ([]TerraformWorkspace, error) {
+	u := t.buildURL("/")
+	if includeDeleted {
+		u += "?include_deleted=true"
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformWorkspaces, nil
+}
+
+func (t TerraformWorkspacesService) Undelete(ctx context.Context, id string) (TerraformWorkspace, error) {
+	if id == "" {
+		return TerraformWorkspace{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"+id+"/undelete"), nil)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformWorkspace{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotDeleted
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformWorkspace{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformWorkspaces) < 1 {
+		return TerraformWorkspace{}, errors.New("no Terraform workspace returned in response")
+	}
+	return resp.TerraformWorkspaces[0], nil
+}
@@ -0,0 +1,419 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+const (
+	TerraformExecutionModeRemote = "remote"
+	TerraformExecutionModeLocal  = "local"
+	TerraformExecutionModeAgent  = "agent"
+)
+
+var (
+	ErrTerraformAgentPoolNotFound     = errors.New("terraform agent pool not found")
+	ErrTerraformAgentPoolNameConflict = errors.New("terraform agent pool name is already in use in the project")
+	ErrTerraformAgentPoolInUse        = errors.New("terraform agent pool is still used by workspaces")
+	ErrTerraformAgentTokenNotFound    = errors.New("terraform agent token not found")
+)
+
+type TerraformAgentPoolsService struct {
+	terraformService *TerraformService
+	basePath         string
+	Tokens           *TerraformAgentTokensService
+}
+
+func newTerraformAgentPoolsService(basePath string, terraform *TerraformService) *TerraformAgentPoolsService {
+	return &TerraformAgentPoolsService{
+		basePath:         basePath,
+		terraformService: terraform,
+		Tokens:           newTerraformAgentTokensService("tokens", basePath, terraform),
+	}
+}
+
+// TerraformAgentPool is a group of agents that workspaces with the agent
+// execution mode run on.
+type TerraformAgentPool struct {
+	ID           string `json:"id"`
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	Name         string `json:"name"`
+}
+
+func (t TerraformAgentPoolsService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
+}
+
+func (t TerraformAgentPoolsService) Create(ctx context.Context, pool TerraformAgentPool) (TerraformAgentPool, error) {
+	b, err := json.Marshal(pool)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error serialising agent pool: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"), buf)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformAgentPool{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformAgentPool{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformAgentPool{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformAgentPools) < 1 {
+		return TerraformAgentPool{}, errors.New("no Terraform agent pool returned in response")
+	}
+	return resp.TerraformAgentPools[0], nil
+}
+
+func (t TerraformAgentPoolsService) Get(ctx context.Context, id string) (TerraformAgentPool, error) {
+	if id == "" {
+		return TerraformAgentPool{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformAgentPool{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformAgentPool{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformAgentPools) < 1 {
+		return TerraformAgentPool{}, errors.New("no Terraform agent pool returned in response")
+	}
+	return resp.TerraformAgentPools[0], nil
+}
+
+func (t TerraformAgentPoolsService) Update(ctx context.Context, pool TerraformAgentPool) (TerraformAgentPool, error) {
+	if pool.ID == "" {
+		return TerraformAgentPool{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(pool)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error serialising agent pool: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildURL("/"+pool.ID), buf)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformAgentPool{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformAgentPool{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformAgentPool{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformAgentPool{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformAgentPool{}, ErrTerraformAgentPoolNameConflict
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformAgentPool{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformAgentPools) < 1 {
+		return TerraformAgentPool{}, errors.New("no Terraform agent pool returned in response")
+	}
+	return resp.TerraformAgentPools[0], nil
+}
+
+// Delete removes an agent pool, along with all its tokens. Agent pools that
+// are still used by a workspace can't be deleted.
+func (t TerraformAgentPoolsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrTerraformAgentPoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return ErrTerraformAgentPoolInUse
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformAgentPoolsService) List(ctx context.Context) ([]TerraformAgentPool, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformAgentPools, nil
+}
+
+type TerraformAgentTokensService struct {
+	terraformService *TerraformService
+	basePath         string
+	parentPath       string
+}
+
+func newTerraformAgentTokensService(basePath, parentPath string, terraform *TerraformService) *TerraformAgentTokensService {
+	return &TerraformAgentTokensService{
+		basePath:         basePath,
+		parentPath:       parentPath,
+		terraformService: terraform,
+	}
+}
+
+// TerraformAgentToken is a credential agents use to join an agent pool.
+// Token is only set on the TerraformAgentToken returned by Create; the
+// server doesn't return it again after that.
+type TerraformAgentToken struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Project      string    `json:"project"`
+	AgentPoolID  string    `json:"agentPoolID"`
+	Description  string    `json:"description"`
+	Token        string    `json:"token,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (t TerraformAgentTokensService) buildURL(agentPoolID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.parentPath, agentPoolID, t.basePath, p)
+}
+
+func (t TerraformAgentTokensService) Create(ctx context.Context, token TerraformAgentToken) (TerraformAgentToken, error) {
+	if token.AgentPoolID == "" {
+		return TerraformAgentToken{}, errors.New("agent pool ID must be specified")
+	}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return TerraformAgentToken{}, fmt.Errorf("error serialising agent token: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(token.AgentPoolID, "/"), buf)
+	if err != nil {
+		return TerraformAgentToken{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformAgentToken{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformAgentToken{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformAgentToken{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformAgentToken{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformAgentToken{}, ErrTerraformAgentPoolNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformAgentToken{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformAgentTokens) < 1 {
+		return TerraformAgentToken{}, errors.New("no Terraform agent token returned in response")
+	}
+	return resp.TerraformAgentTokens[0], nil
+}
+
+func (t TerraformAgentTokensService) Get(ctx context.Context, agentPoolID, id string) (TerraformAgentToken, error) {
+	if agentPoolID == "" {
+		return TerraformAgentToken{}, errors.New("agent pool ID must be specified")
+	}
+	if id == "" {
+		return TerraformAgentToken{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(agentPoolID, "/"+id), nil)
+	if err != nil {
+		return TerraformAgentToken{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformAgentToken{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformAgentToken{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformAgentToken{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "token",
+	}) {
+		return TerraformAgentToken{}, ErrTerraformAgentTokenNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformAgentToken{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformAgentTokens) < 1 {
+		return TerraformAgentToken{}, errors.New("no Terraform agent token returned in response")
+	}
+	return resp.TerraformAgentTokens[0], nil
+}
+
+// Delete revokes an agent token.
+func (t TerraformAgentTokensService) Delete(ctx context.Context, agentPoolID, id string) error {
+	if agentPoolID == "" {
+		return errors.New("agent pool ID must be specified")
+	}
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL(agentPoolID, "/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "token",
+	}) {
+		return ErrTerraformAgentTokenNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformAgentTokensService) List(ctx context.Context, agentPoolID string) ([]TerraformAgentToken, error) {
+	if agentPoolID == "" {
+		return nil, errors.New("agent pool ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(agentPoolID, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformAgentPoolNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformAgentTokens, nil
+}
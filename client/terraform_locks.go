@@ -0,0 +1,130 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TerraformLockHolderHeader identifies who is making a request to a locked
+// workspace. Only the lock's holder can update the workspace or queue runs
+// in it; use WithLockHolder to set it.
+const TerraformLockHolderHeader = "X-Dadcorp-Lock-Holder"
+
+var (
+	ErrTerraformWorkspaceLocked            = errors.New("terraform workspace is locked by someone else")
+	ErrTerraformWorkspaceNotLocked         = errors.New("terraform workspace is not locked")
+	ErrTerraformWorkspaceLockHolderMissing = errors.New("terraform workspace locks must have a holder")
+)
+
+// lockedError is returned by the API when someone else holds the lock on
+// the workspace being changed.
+var lockedError = RequestError{Slug: requestErrConflict, Header: TerraformLockHolderHeader}
+
+type TerraformWorkspaceLock struct {
+	Holder   string    `json:"holder"`
+	Reason   string    `json:"reason"`
+	LockedAt time.Time `json:"lockedAt"`
+}
+
+type lockHolderKey struct{}
+
+// WithLockHolder returns a Context that makes requests on behalf of holder,
+// so they can change workspaces that holder has locked.
+func WithLockHolder(ctx context.Context, holder string) context.Context {
+	return context.WithValue(ctx, lockHolderKey{}, holder)
+}
+
+func lockHolder(ctx context.Context) string {
+	holder, _ := ctx.Value(lockHolderKey{}).(string)
+	return holder
+}
+
+// Lock locks the workspace, so only holder can update it or queue runs in
+// it until it's unlocked.
+func (t TerraformWorkspacesService) Lock(ctx context.Context, id, holder, reason string) (TerraformWorkspace, error) {
+	if id == "" {
+		return TerraformWorkspace{}, errors.New("id must be specified")
+	}
+	return t.lock(ctx, id, "/lock", TerraformWorkspaceLock{Holder: holder, Reason: reason}, ErrTerraformWorkspaceLocked)
+}
+
+// Unlock releases holder's lock on the workspace.
+func (t TerraformWorkspacesService) Unlock(ctx context.Context, id, holder string) (TerraformWorkspace, error) {
+	if id == "" {
+		return TerraformWorkspace{}, errors.New("id must be specified")
+	}
+	return t.lock(ctx, id, "/unlock", TerraformWorkspaceLock{Holder: holder}, ErrTerraformWorkspaceNotLocked)
+}
+
+// ForceUnlock releases the lock on the workspace, no matter who holds it.
+func (t TerraformWorkspacesService) ForceUnlock(ctx context.Context, id string) (TerraformWorkspace, error) {
+	if id == "" {
+		return TerraformWorkspace{}, errors.New("id must be specified")
+	}
+	return t.lock(ctx, id, "/forceUnlock", TerraformWorkspaceLock{}, ErrTerraformWorkspaceNotLocked)
+}
+
+// lock makes a request to one of the workspace lock endpoints. conflict is
+// what the endpoint means when it says the workspace is in the wrong state.
+func (t TerraformWorkspacesService) lock(ctx context.Context, id, action string, lock TerraformWorkspaceLock, conflict error) (TerraformWorkspace, error) {
+	b, err := json.Marshal(lock)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error serialising lock: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"+id+action), buf)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformWorkspace{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformWorkspace{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformWorkspace{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformWorkspace{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/holder",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLockHolderMissing
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/holder",
+	}) {
+		return TerraformWorkspace{}, ErrTerraformWorkspaceLocked
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return TerraformWorkspace{}, conflict
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformWorkspace{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformWorkspaces) < 1 {
+		return TerraformWorkspace{}, errors.New("no Terraform workspace returned in response")
+	}
+	return resp.TerraformWorkspaces[0], nil
+}
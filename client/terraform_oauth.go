@@ -0,0 +1,392 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"time"
+)
+
+const (
+	TerraformVCSProviderGitHub             = "github"
+	TerraformVCSProviderGitHubEnterprise   = "github_enterprise"
+	TerraformVCSProviderGitLabHosted       = "gitlab_hosted"
+	TerraformVCSProviderGitLabEnterprise   = "gitlab_enterprise"
+	TerraformVCSProviderBitbucketHosted    = "bitbucket_hosted"
+	TerraformVCSProviderBitbucketServer    = "bitbucket_server"
+	TerraformVCSProviderAzureDevOpsService = "azure_devops_services"
+)
+
+var (
+	ErrTerraformOAuthClientNotFound               = errors.New("terraform OAuth client not found")
+	ErrTerraformOAuthClientNameConflict           = errors.New("terraform OAuth client name is already in use in the project")
+	ErrTerraformOAuthClientInvalidServiceProvider = errors.New("terraform OAuth client service provider isn't supported")
+	ErrTerraformOAuthClientInvalidURL             = errors.New("terraform OAuth client HTTP and API URLs must be absolute http or https URLs")
+	ErrTerraformOAuthClientTokenRequired          = errors.New("terraform OAuth clients must be created with an OAuth token")
+	ErrTerraformOAuthClientProviderInUse          = errors.New("terraform OAuth client service provider can't change while workspaces use it")
+	ErrTerraformOAuthTokenNotFound                = errors.New("terraform OAuth token not found")
+	ErrTerraformOAuthTokenInUse                   = errors.New("terraform OAuth token is still used by workspaces")
+
+	terraformOAuthClientURLField = regexp.MustCompile(`^/(httpURL|apiURL)$`)
+)
+
+type TerraformOAuthClientsService struct {
+	terraformService *TerraformService
+	basePath         string
+	Tokens           *TerraformOAuthTokensService
+}
+
+func newTerraformOAuthClientsService(basePath string, terraform *TerraformService) *TerraformOAuthClientsService {
+	return &TerraformOAuthClientsService{
+		basePath:         basePath,
+		terraformService: terraform,
+		Tokens:           newTerraformOAuthTokensService("tokens", basePath, terraform),
+	}
+}
+
+// TerraformOAuthClient is a connection to a VCS provider that workspaces can
+// pull their configuration from. OAuthToken is write-only: set it to create
+// or rotate the client's OAuth token. The server never returns it.
+// Workspaces use OAuthTokenID in their VCS repo settings.
+type TerraformOAuthClient struct {
+	ID              string `json:"id"`
+	Organization    string `json:"organization"`
+	Project         string `json:"project"`
+	Name            string `json:"name"`
+	ServiceProvider string `json:"serviceProvider"`
+	HTTPURL         string `json:"httpURL"`
+	APIURL          string `json:"apiURL"`
+	OAuthToken      string `json:"oauthToken,omitempty"`
+	OAuthTokenID    string `json:"oauthTokenID"`
+}
+
+func (t TerraformOAuthClientsService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
+}
+
+func (t TerraformOAuthClientsService) Create(ctx context.Context, pool TerraformOAuthClient) (TerraformOAuthClient, error) {
+	b, err := json.Marshal(pool)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error serialising OAuth client: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"), buf)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformOAuthClient{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformOAuthClient{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/serviceProvider",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/serviceProvider",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientInvalidServiceProvider
+	}
+	if len(resp.Errors.FieldMatches(requestErrMissing, terraformOAuthClientURLField)) > 0 || len(resp.Errors.FieldMatches(requestErrInvalidFormat, terraformOAuthClientURLField)) > 0 {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientInvalidURL
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/oauthToken",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientTokenRequired
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformOAuthClient{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformOAuthClients) < 1 {
+		return TerraformOAuthClient{}, errors.New("no Terraform OAuth client returned in response")
+	}
+	return resp.TerraformOAuthClients[0], nil
+}
+
+func (t TerraformOAuthClientsService) Get(ctx context.Context, id string) (TerraformOAuthClient, error) {
+	if id == "" {
+		return TerraformOAuthClient{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformOAuthClient{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformOAuthClient{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformOAuthClients) < 1 {
+		return TerraformOAuthClient{}, errors.New("no Terraform OAuth client returned in response")
+	}
+	return resp.TerraformOAuthClients[0], nil
+}
+
+func (t TerraformOAuthClientsService) Update(ctx context.Context, pool TerraformOAuthClient) (TerraformOAuthClient, error) {
+	if pool.ID == "" {
+		return TerraformOAuthClient{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(pool)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error serialising OAuth client: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildURL("/"+pool.ID), buf)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformOAuthClient{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformOAuthClient{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformOAuthClient{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformOAuthClient{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/serviceProvider",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/serviceProvider",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientInvalidServiceProvider
+	}
+	if len(resp.Errors.FieldMatches(requestErrMissing, terraformOAuthClientURLField)) > 0 || len(resp.Errors.FieldMatches(requestErrInvalidFormat, terraformOAuthClientURLField)) > 0 {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientInvalidURL
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/serviceProvider",
+	}) {
+		return TerraformOAuthClient{}, ErrTerraformOAuthClientProviderInUse
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformOAuthClient{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformOAuthClients) < 1 {
+		return TerraformOAuthClient{}, errors.New("no Terraform OAuth client returned in response")
+	}
+	return resp.TerraformOAuthClients[0], nil
+}
+
+// Delete removes an OAuth client, along with all its tokens. OAuth clients
+// with tokens that are still used by a workspace can't be deleted.
+func (t TerraformOAuthClientsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrTerraformOAuthClientNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return ErrTerraformOAuthTokenInUse
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformOAuthClientsService) List(ctx context.Context) ([]TerraformOAuthClient, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformOAuthClients, nil
+}
+
+type TerraformOAuthTokensService struct {
+	terraformService *TerraformService
+	basePath         string
+	parentPath       string
+}
+
+func newTerraformOAuthTokensService(basePath, parentPath string, terraform *TerraformService) *TerraformOAuthTokensService {
+	return &TerraformOAuthTokensService{
+		basePath:         basePath,
+		parentPath:       parentPath,
+		terraformService: terraform,
+	}
+}
+
+// TerraformOAuthToken is the credential an OAuth client uses to talk to its
+// VCS provider. The token itself is never returned by the server.
+type TerraformOAuthToken struct {
+	ID            string    `json:"id"`
+	Organization  string    `json:"organization"`
+	Project       string    `json:"project"`
+	OAuthClientID string    `json:"oauthClientID"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (t TerraformOAuthTokensService) buildURL(oauthClientID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.parentPath, oauthClientID, t.basePath, p)
+}
+
+func (t TerraformOAuthTokensService) Get(ctx context.Context, oauthClientID, id string) (TerraformOAuthToken, error) {
+	if oauthClientID == "" {
+		return TerraformOAuthToken{}, errors.New("OAuth client ID must be specified")
+	}
+	if id == "" {
+		return TerraformOAuthToken{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(oauthClientID, "/"+id), nil)
+	if err != nil {
+		return TerraformOAuthToken{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformOAuthToken{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformOAuthToken{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformOAuthToken{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "token",
+	}) {
+		return TerraformOAuthToken{}, ErrTerraformOAuthTokenNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformOAuthToken{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformOAuthTokens) < 1 {
+		return TerraformOAuthToken{}, errors.New("no Terraform OAuth token returned in response")
+	}
+	return resp.TerraformOAuthTokens[0], nil
+}
+
+func (t TerraformOAuthTokensService) List(ctx context.Context, oauthClientID string) ([]TerraformOAuthToken, error) {
+	if oauthClientID == "" {
+		return nil, errors.New("OAuth client ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(oauthClientID, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformOAuthClientNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformOAuthTokens, nil
+}
@@ -0,0 +1,312 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+)
+
+const (
+	TerraformPolicyAdvisory      = "advisory"
+	TerraformPolicySoftMandatory = "soft-mandatory"
+	TerraformPolicyHardMandatory = "hard-mandatory"
+)
+
+var (
+	ErrTerraformPolicySetNotFound             = errors.New("terraform policy set not found")
+	ErrTerraformPolicySetNameConflict         = errors.New("terraform policy set name is already in use in the project")
+	ErrTerraformPolicyNameMissing             = errors.New("terraform policies must have a name")
+	ErrTerraformPolicyNameConflict            = errors.New("terraform policy names must be unique within a policy set")
+	ErrTerraformPolicyEnforcementLevelInvalid = errors.New("terraform policy enforcement level must be advisory, soft-mandatory, or hard-mandatory")
+	ErrTerraformPolicyEmpty                   = errors.New("terraform policies must forbid resource types or require tags")
+
+	terraformPolicyField      = regexp.MustCompile(`^/policies/[0-9]+$`)
+	terraformPolicyRuleField  = regexp.MustCompile(`^/policies/[0-9]+/(forbiddenResourceTypes|requiredTags)/[0-9]+$`)
+	terraformPolicyNameField  = regexp.MustCompile(`^/policies/[0-9]+/name$`)
+	terraformPolicyLevelField = regexp.MustCompile(`^/policies/[0-9]+/enforcementLevel$`)
+)
+
+type TerraformPolicySetsService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformPolicySetsService(basePath string, terraform *TerraformService) *TerraformPolicySetsService {
+	return &TerraformPolicySetsService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+// TerraformPolicySet is a group of policies that are checked against the
+// plan of every run in the workspaces it's attached to.
+type TerraformPolicySet struct {
+	ID           string                `json:"id"`
+	Organization string                `json:"organization"`
+	Project      string                `json:"project"`
+	Name         string                `json:"name"`
+	Description  string                `json:"description"`
+	WorkspaceIDs []string              `json:"workspaceIDs"`
+	Policies     []TerraformPolicyRule `json:"policies"`
+}
+
+// TerraformPolicyRule is a single policy in a policy set. Plans fail it by
+// creating or updating resources of a forbidden type, or taggable resources
+// that are missing a required tag.
+type TerraformPolicyRule struct {
+	Name                   string   `json:"name"`
+	EnforcementLevel       string   `json:"enforcementLevel"`
+	ForbiddenResourceTypes []string `json:"forbiddenResourceTypes"`
+	RequiredTags           []string `json:"requiredTags"`
+}
+
+// TerraformPolicyCheck is the result of checking a run's plan against one
+// policy.
+type TerraformPolicyCheck struct {
+	PolicySetID      string   `json:"policySetID"`
+	Policy           string   `json:"policy"`
+	EnforcementLevel string   `json:"enforcementLevel"`
+	Passed           bool     `json:"passed"`
+	Violations       []string `json:"violations,omitempty"`
+}
+
+func (t TerraformPolicySetsService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
+}
+
+// policyErr returns the error describing why the policies in a policy set
+// were rejected, or nil if they weren't.
+func (t TerraformPolicySetsService) policyErr(errs RequestErrors) error {
+	if errs.FieldMatches(requestErrMissing, terraformPolicyNameField) != nil {
+		return ErrTerraformPolicyNameMissing
+	}
+	if errs.FieldMatches(requestErrConflict, terraformPolicyNameField) != nil {
+		return ErrTerraformPolicyNameConflict
+	}
+	if errs.FieldMatches(requestErrInvalidValue, terraformPolicyLevelField) != nil {
+		return ErrTerraformPolicyEnforcementLevelInvalid
+	}
+	if errs.FieldMatches(requestErrMissing, terraformPolicyField) != nil {
+		return ErrTerraformPolicyEmpty
+	}
+	if errs.FieldMatches(requestErrMissing, terraformPolicyRuleField) != nil {
+		return errors.New("forbidden resource types and required tags can't be empty")
+	}
+	return nil
+}
+
+func (t TerraformPolicySetsService) Create(ctx context.Context, set TerraformPolicySet) (TerraformPolicySet, error) {
+	b, err := json.Marshal(set)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error serialising policy set: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"), buf)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformPolicySet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformPolicySet{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return TerraformPolicySet{}, errors.New("name must be specified")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/workspaceIDs",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformWorkspaceNotFound
+	}
+	if err := t.policyErr(resp.Errors); err != nil {
+		return TerraformPolicySet{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformPolicySet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformPolicySets) < 1 {
+		return TerraformPolicySet{}, errors.New("no Terraform policy set returned in response")
+	}
+	return resp.TerraformPolicySets[0], nil
+}
+
+func (t TerraformPolicySetsService) Get(ctx context.Context, id string) (TerraformPolicySet, error) {
+	if id == "" {
+		return TerraformPolicySet{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformPolicySet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformPolicySet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformPolicySets) < 1 {
+		return TerraformPolicySet{}, errors.New("no Terraform policy set returned in response")
+	}
+	return resp.TerraformPolicySets[0], nil
+}
+
+func (t TerraformPolicySetsService) Update(ctx context.Context, set TerraformPolicySet) (TerraformPolicySet, error) {
+	if set.ID == "" {
+		return TerraformPolicySet{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error serialising policy set: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildURL("/"+set.ID), buf)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformPolicySet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformPolicySet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformPolicySet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformPolicySet{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return TerraformPolicySet{}, errors.New("name must be specified")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformPolicySetNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/workspaceIDs",
+	}) {
+		return TerraformPolicySet{}, ErrTerraformWorkspaceNotFound
+	}
+	if err := t.policyErr(resp.Errors); err != nil {
+		return TerraformPolicySet{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformPolicySet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformPolicySets) < 1 {
+		return TerraformPolicySet{}, errors.New("no Terraform policy set returned in response")
+	}
+	return resp.TerraformPolicySets[0], nil
+}
+
+// Delete removes a policy set. Runs that were already checked against it
+// keep their results.
+func (t TerraformPolicySetsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrTerraformPolicySetNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformPolicySetsService) List(ctx context.Context) ([]TerraformPolicySet, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformPolicySets, nil
+}
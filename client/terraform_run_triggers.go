@@ -0,0 +1,241 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+const (
+	TerraformRunTriggerInbound  = "inbound"
+	TerraformRunTriggerOutbound = "outbound"
+)
+
+var (
+	ErrTerraformRunTriggerNotFound       = errors.New("terraform run trigger not found")
+	ErrTerraformRunTriggerAlreadyExists  = errors.New("terraform run trigger already exists between those workspaces")
+	ErrTerraformRunTriggerSourceNotFound = errors.New("terraform run trigger source workspace not found")
+	ErrTerraformRunTriggerCycle          = errors.New("terraform run trigger would create a cycle")
+)
+
+// TerraformRunTriggersService manages run triggers, which queue a run in one
+// workspace whenever a run is applied in another.
+type TerraformRunTriggersService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformRunTriggersService(basePath string, terraform *TerraformService) *TerraformRunTriggersService {
+	return &TerraformRunTriggersService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+// TerraformRunTrigger queues a run in the workspace identified by
+// WorkspaceID whenever a run is applied in the workspace identified by
+// SourceWorkspaceID.
+type TerraformRunTrigger struct {
+	ID                string    `json:"id"`
+	Organization      string    `json:"organization"`
+	Project           string    `json:"project"`
+	WorkspaceID       string    `json:"workspaceID"`
+	SourceWorkspaceID string    `json:"sourceWorkspaceID"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+func (t TerraformRunTriggersService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
+}
+
+func (t TerraformRunTriggersService) buildWorkspaceURL(workspaceID string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, "workspaces", workspaceID, t.basePath)
+}
+
+// Create adds a run trigger that queues a run in trigger.WorkspaceID
+// whenever a run is applied in trigger.SourceWorkspaceID.
+func (t TerraformRunTriggersService) Create(ctx context.Context, trigger TerraformRunTrigger) (TerraformRunTrigger, error) {
+	if trigger.WorkspaceID == "" {
+		return TerraformRunTrigger{}, errors.New("workspace ID must be specified")
+	}
+	b, err := json.Marshal(trigger)
+	if err != nil {
+		return TerraformRunTrigger{}, fmt.Errorf("error serialising run trigger: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildWorkspaceURL(trigger.WorkspaceID), buf)
+	if err != nil {
+		return TerraformRunTrigger{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRunTrigger{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRunTrigger{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRunTrigger{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformRunTrigger{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformRunTrigger{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/sourceWorkspaceID",
+	}) {
+		return TerraformRunTrigger{}, errors.New("source workspace ID must be specified")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Field: "/sourceWorkspaceID",
+	}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerSourceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/sourceWorkspaceID",
+	}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerAlreadyExists
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/sourceWorkspaceID",
+	}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerCycle
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRunTrigger{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRunTriggers) < 1 {
+		return TerraformRunTrigger{}, errors.New("no Terraform run trigger returned in response")
+	}
+	return resp.TerraformRunTriggers[0], nil
+}
+
+func (t TerraformRunTriggersService) Get(ctx context.Context, id string) (TerraformRunTrigger, error) {
+	if id == "" {
+		return TerraformRunTrigger{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformRunTrigger{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRunTrigger{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRunTrigger{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRunTrigger{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformRunTrigger{}, ErrTerraformRunTriggerNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRunTrigger{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRunTriggers) < 1 {
+		return TerraformRunTrigger{}, errors.New("no Terraform run trigger returned in response")
+	}
+	return resp.TerraformRunTriggers[0], nil
+}
+
+func (t TerraformRunTriggersService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrTerraformRunTriggerNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+// List returns the run triggers of a workspace, oldest first. triggerType
+// can be TerraformRunTriggerInbound, for the triggers that queue runs in
+// the workspace, or TerraformRunTriggerOutbound, for the triggers that
+// its applies fire. If it's empty, both are returned.
+func (t TerraformRunTriggersService) List(ctx context.Context, workspaceID, triggerType string) ([]TerraformRunTrigger, error) {
+	if workspaceID == "" {
+		return nil, errors.New("workspace ID must be specified")
+	}
+	u := t.buildWorkspaceURL(workspaceID)
+	if triggerType != "" {
+		u += "?" + url.Values{"type": []string{triggerType}}.Encode()
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "type",
+	}) {
+		return nil, errors.New("trigger type must be inbound or outbound")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformRunTriggers, nil
+}
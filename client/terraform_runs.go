@@ -0,0 +1,401 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+const (
+	TerraformRunPending   = "pending"
+	TerraformRunPlanning  = "planning"
+	TerraformRunPlanned   = "planned"
+	TerraformRunApplying  = "applying"
+	TerraformRunApplied   = "applied"
+	TerraformRunErrored   = "errored"
+	TerraformRunDiscarded = "discarded"
+	TerraformRunCanceled  = "canceled"
+)
+
+// TerraformRunPolicyOverride is the status of a run whose plan failed
+// soft-mandatory policies. It can't be applied until it's overridden.
+const TerraformRunPolicyOverride = "policy_override"
+
+var (
+	ErrTerraformRunNotFound            = errors.New("terraform run not found")
+	ErrTerraformRunInvalidTransition   = errors.New("terraform run can't make that transition from its current status")
+	ErrTerraformRunSpeculativeDisabled = errors.New("speculative runs are disabled for the workspace")
+	ErrTerraformRunDestroyDisabled     = errors.New("destroy plans are disabled for the workspace")
+	ErrTerraformRunPlanInvalid         = errors.New("terraform run plan must be a JSON plan object")
+)
+
+type TerraformRunsService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformRunsService(basePath string, terraform *TerraformService) *TerraformRunsService {
+	return &TerraformRunsService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+type TerraformRun struct {
+	ID              string    `json:"id"`
+	Organization    string    `json:"organization"`
+	Project         string    `json:"project"`
+	WorkspaceID     string    `json:"workspaceID"`
+	Message         string    `json:"message"`
+	IsDestroy       bool      `json:"isDestroy"`
+	Speculative     bool      `json:"speculative"`
+	AutoApply       bool      `json:"autoApply"`
+	Status          string    `json:"status"`
+	StatusReason    string    `json:"statusReason,omitempty"`
+	StatusChangedAt time.Time `json:"statusChangedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+
+	// Plan is the JSON plan, as output by `terraform show -json`, that the
+	// run's policy checks are made against.
+	Plan         json.RawMessage        `json:"plan,omitempty"`
+	PolicyChecks []TerraformPolicyCheck `json:"policyChecks,omitempty"`
+}
+
+// Finished reports whether the run has reached a status it can't leave.
+func (run TerraformRun) Finished() bool {
+	switch run.Status {
+	case TerraformRunApplied, TerraformRunErrored, TerraformRunDiscarded, TerraformRunCanceled:
+		return true
+	case TerraformRunPlanned:
+		return run.Speculative
+	}
+	return false
+}
+
+func (t TerraformRunsService) buildURL(workspaceID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, "workspaces", workspaceID, t.basePath, p)
+}
+
+func (t TerraformRunsService) Create(ctx context.Context, run TerraformRun) (TerraformRun, error) {
+	if run.WorkspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	b, err := json.Marshal(run)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error serialising run: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(run.WorkspaceID, "/"), buf)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformRun{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformRun{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(lockedError) {
+		return TerraformRun{}, ErrTerraformWorkspaceLocked
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/speculative",
+	}) {
+		return TerraformRun{}, ErrTerraformRunSpeculativeDisabled
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/isDestroy",
+	}) {
+		return TerraformRun{}, ErrTerraformRunDestroyDisabled
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/plan",
+	}) {
+		return TerraformRun{}, ErrTerraformRunPlanInvalid
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
+
+func (t TerraformRunsService) Get(ctx context.Context, workspaceID, id string) (TerraformRun, error) {
+	if workspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformRun{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(workspaceID, "/"+id), nil)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
+
+func (t TerraformRunsService) List(ctx context.Context, workspaceID string) ([]TerraformRun, error) {
+	if workspaceID == "" {
+		return nil, errors.New("workspace ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(workspaceID, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformRuns, nil
+}
+
+// Apply confirms a planned run, so it will be applied.
+func (t TerraformRunsService) Apply(ctx context.Context, workspaceID, id string) (TerraformRun, error) {
+	if workspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformRun{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(workspaceID, "/"+id+"/apply"), nil)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunInvalidTransition
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
+
+// Discard throws away a run that hasn't started applying.
+func (t TerraformRunsService) Discard(ctx context.Context, workspaceID, id string) (TerraformRun, error) {
+	if workspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformRun{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(workspaceID, "/"+id+"/discard"), nil)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunInvalidTransition
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
+
+// Cancel stops a run that is planning or applying.
+func (t TerraformRunsService) Cancel(ctx context.Context, workspaceID, id string) (TerraformRun, error) {
+	if workspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformRun{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(workspaceID, "/"+id+"/cancel"), nil)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunInvalidTransition
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
+
+// Override lets a run that failed soft-mandatory policies continue as if
+// they had passed. It must be made under an access policy that allows
+// overriding policies in the workspace; see WithAccessPolicy.
+func (t TerraformRunsService) Override(ctx context.Context, workspaceID, id string) (TerraformRun, error) {
+	if workspaceID == "" {
+		return TerraformRun{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformRun{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(workspaceID, "/"+id+"/override"), nil)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformRun{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformRun{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformRun{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(accessDeniedError) {
+		return TerraformRun{}, ErrAccessPolicyDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "run",
+	}) {
+		return TerraformRun{}, ErrTerraformRunInvalidTransition
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformRun{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformRuns) < 1 {
+		return TerraformRun{}, errors.New("no Terraform run returned in response")
+	}
+	return resp.TerraformRuns[0], nil
+}
@@ -0,0 +1,288 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+var (
+	ErrTerraformStateVersionNotFound        = errors.New("terraform state version not found")
+	ErrTerraformStateVersionInvalidSerial   = errors.New("terraform state version serial is missing or doesn't match the state")
+	ErrTerraformStateVersionInvalidLineage  = errors.New("terraform state version lineage doesn't match the state")
+	ErrTerraformStateVersionMD5Mismatch     = errors.New("terraform state version MD5 doesn't match the state")
+	ErrTerraformStateVersionInvalidState    = errors.New("terraform state version state isn't a valid state file")
+	ErrTerraformStateVersionSerialConflict  = errors.New("terraform state version serial must be greater than the current state's")
+	ErrTerraformStateVersionLineageConflict = errors.New("terraform state version lineage doesn't match the current state's")
+)
+
+type TerraformStateVersionsService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformStateVersionsService(basePath string, terraform *TerraformService) *TerraformStateVersionsService {
+	return &TerraformStateVersionsService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+type TerraformStateVersion struct {
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	WorkspaceID  string                 `json:"workspaceID"`
+	Serial       *int64                 `json:"serial"`
+	Lineage      string                 `json:"lineage"`
+	MD5          string                 `json:"md5"`
+	State        []byte                 `json:"state,omitempty"`
+	Outputs      []TerraformStateOutput `json:"outputs,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// TerraformStateOutput is a root module output from a state version. Value
+// and Type are JSON, as Terraform wrote them in the state file.
+type TerraformStateOutput struct {
+	Name      string          `json:"name"`
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+func (t TerraformStateVersionsService) buildURL(workspaceID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, "workspaces", workspaceID, t.basePath, p)
+}
+
+func (t TerraformStateVersionsService) buildCurrentURL(workspaceID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, "workspaces", workspaceID, "current-state-version", p)
+}
+
+// Create uploads a new state version, which becomes the workspace's current
+// state. If version.MD5 is empty, it's calculated from version.State.
+func (t TerraformStateVersionsService) Create(ctx context.Context, version TerraformStateVersion) (TerraformStateVersion, error) {
+	if version.WorkspaceID == "" {
+		return TerraformStateVersion{}, errors.New("workspace ID must be specified")
+	}
+	if version.MD5 == "" {
+		sum := md5.Sum(version.State)
+		version.MD5 = hex.EncodeToString(sum[:])
+	}
+	b, err := json.Marshal(version)
+	if err != nil {
+		return TerraformStateVersion{}, fmt.Errorf("error serialising state version: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(version.WorkspaceID, "/"), buf)
+	if err != nil {
+		return TerraformStateVersion{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformStateVersion{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformStateVersion{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformStateVersion{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformStateVersion{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/serial",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/serial",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionInvalidSerial
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/lineage",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionInvalidLineage
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/md5",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionMD5Mismatch
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/state",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/state",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionInvalidState
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/serial",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionSerialConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/lineage",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionLineageConflict
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformStateVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformStateVersions) < 1 {
+		return TerraformStateVersion{}, errors.New("no Terraform state version returned in response")
+	}
+	return resp.TerraformStateVersions[0], nil
+}
+
+func (t TerraformStateVersionsService) Get(ctx context.Context, workspaceID, id string) (TerraformStateVersion, error) {
+	if workspaceID == "" {
+		return TerraformStateVersion{}, errors.New("workspace ID must be specified")
+	}
+	if id == "" {
+		return TerraformStateVersion{}, errors.New("id must be specified")
+	}
+	return t.get(ctx, t.buildURL(workspaceID, "/"+id))
+}
+
+// Current returns the workspace's current state version, including the
+// state itself.
+func (t TerraformStateVersionsService) Current(ctx context.Context, workspaceID string) (TerraformStateVersion, error) {
+	if workspaceID == "" {
+		return TerraformStateVersion{}, errors.New("workspace ID must be specified")
+	}
+	return t.get(ctx, t.buildCurrentURL(workspaceID, "/"))
+}
+
+func (t TerraformStateVersionsService) get(ctx context.Context, u string) (TerraformStateVersion, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return TerraformStateVersion{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformStateVersion{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformStateVersion{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformStateVersion{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "stateVersion",
+	}) {
+		return TerraformStateVersion{}, ErrTerraformStateVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformStateVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformStateVersions) < 1 {
+		return TerraformStateVersion{}, errors.New("no Terraform state version returned in response")
+	}
+	return resp.TerraformStateVersions[0], nil
+}
+
+// List returns the workspace's state versions, newest first. The state
+// itself isn't included; use Get to download it.
+func (t TerraformStateVersionsService) List(ctx context.Context, workspaceID string) ([]TerraformStateVersion, error) {
+	if workspaceID == "" {
+		return nil, errors.New("workspace ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(workspaceID, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformStateVersions, nil
+}
+
+// Outputs returns the root module outputs of the workspace's current state
+// version.
+func (t TerraformStateVersionsService) Outputs(ctx context.Context, workspaceID string) ([]TerraformStateOutput, error) {
+	if workspaceID == "" {
+		return nil, errors.New("workspace ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildCurrentURL(workspaceID, "/outputs"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrTerraformWorkspaceNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "stateVersion",
+	}) {
+		return nil, ErrTerraformStateVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformStateOutputs, nil
+}
@@ -0,0 +1,524 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+const (
+	TerraformVariableCategoryTerraform = "terraform"
+	TerraformVariableCategoryEnv       = "env"
+)
+
+var (
+	ErrTerraformVariableNotFound        = errors.New("terraform variable not found")
+	ErrTerraformVariableKeyConflict     = errors.New("terraform variable key is already in use")
+	ErrTerraformVariableInvalidCategory = errors.New("terraform variable category must be terraform or env")
+	ErrTerraformVariableHCLEnv          = errors.New("environment variables can't be HCL")
+	ErrTerraformVariableSetNotFound     = errors.New("terraform variable set not found")
+	ErrTerraformVariableSetNameConflict = errors.New("terraform variable set name is already in use in the project")
+)
+
+// TerraformVariablesService manages the variables that belong to either
+// workspaces or variable sets, depending on which service it was reached
+// through.
+type TerraformVariablesService struct {
+	terraformService *TerraformService
+	basePath         string
+	parentPath       string
+	parentNotFound   error
+}
+
+func newTerraformVariablesService(basePath, parentPath string, parentNotFound error, terraform *TerraformService) *TerraformVariablesService {
+	return &TerraformVariablesService{
+		basePath:         basePath,
+		parentPath:       parentPath,
+		parentNotFound:   parentNotFound,
+		terraformService: terraform,
+	}
+}
+
+// TerraformVariable is a variable set on a workspace, or on a variable set.
+// Values of sensitive variables are never returned by the API.
+type TerraformVariable struct {
+	ID            string `json:"id"`
+	Organization  string `json:"organization"`
+	Project       string `json:"project"`
+	WorkspaceID   string `json:"workspaceID,omitempty"`
+	VariableSetID string `json:"variableSetID,omitempty"`
+	Key           string `json:"key"`
+	Value         string `json:"value"`
+	Description   string `json:"description"`
+	Category      string `json:"category"`
+	HCL           bool   `json:"hcl"`
+	Sensitive     bool   `json:"sensitive"`
+}
+
+func (t TerraformVariablesService) buildURL(parentID, p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.parentPath, parentID, t.basePath, p)
+}
+
+func (t TerraformVariablesService) parentID(variable TerraformVariable) string {
+	if t.parentPath == "varsets" {
+		return variable.VariableSetID
+	}
+	return variable.WorkspaceID
+}
+
+func (t TerraformVariablesService) Create(ctx context.Context, variable TerraformVariable) (TerraformVariable, error) {
+	parentID := t.parentID(variable)
+	if parentID == "" {
+		return TerraformVariable{}, errors.New("workspace or variable set ID must be specified")
+	}
+	b, err := json.Marshal(variable)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error serialising variable: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL(parentID, "/"), buf)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariable{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVariable{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformVariable{}, t.parentNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/key",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableKeyConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/category",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableInvalidCategory
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/hcl",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableHCLEnv
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariable{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariables) < 1 {
+		return TerraformVariable{}, errors.New("no Terraform variable returned in response")
+	}
+	return resp.TerraformVariables[0], nil
+}
+
+func (t TerraformVariablesService) Get(ctx context.Context, parentID, id string) (TerraformVariable, error) {
+	if parentID == "" {
+		return TerraformVariable{}, errors.New("workspace or variable set ID must be specified")
+	}
+	if id == "" {
+		return TerraformVariable{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(parentID, "/"+id), nil)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariable{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "var",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariable{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariables) < 1 {
+		return TerraformVariable{}, errors.New("no Terraform variable returned in response")
+	}
+	return resp.TerraformVariables[0], nil
+}
+
+func (t TerraformVariablesService) Update(ctx context.Context, variable TerraformVariable) (TerraformVariable, error) {
+	parentID := t.parentID(variable)
+	if parentID == "" {
+		return TerraformVariable{}, errors.New("workspace or variable set ID must be specified")
+	}
+	if variable.ID == "" {
+		return TerraformVariable{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(variable)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error serialising variable: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildURL(parentID, "/"+variable.ID), buf)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariable{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariable{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariable{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVariable{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "var",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/key",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableKeyConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/category",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableInvalidCategory
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/hcl",
+	}) {
+		return TerraformVariable{}, ErrTerraformVariableHCLEnv
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariable{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariables) < 1 {
+		return TerraformVariable{}, errors.New("no Terraform variable returned in response")
+	}
+	return resp.TerraformVariables[0], nil
+}
+
+func (t TerraformVariablesService) Delete(ctx context.Context, parentID, id string) error {
+	if parentID == "" {
+		return errors.New("workspace or variable set ID must be specified")
+	}
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL(parentID, "/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "var",
+	}) {
+		return ErrTerraformVariableNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformVariablesService) List(ctx context.Context, parentID string) ([]TerraformVariable, error) {
+	if parentID == "" {
+		return nil, errors.New("workspace or variable set ID must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL(parentID, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, t.parentNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformVariables, nil
+}
+
+type TerraformVariableSetsService struct {
+	terraformService *TerraformService
+	basePath         string
+	Variables        *TerraformVariablesService
+}
+
+func newTerraformVariableSetsService(basePath string, terraform *TerraformService) *TerraformVariableSetsService {
+	return &TerraformVariableSetsService{
+		basePath:         basePath,
+		terraformService: terraform,
+		Variables:        newTerraformVariablesService("vars", basePath, ErrTerraformVariableSetNotFound, terraform),
+	}
+}
+
+// TerraformVariableSet is a group of variables that are set on every
+// workspace it's attached to.
+type TerraformVariableSet struct {
+	ID           string   `json:"id"`
+	Organization string   `json:"organization"`
+	Project      string   `json:"project"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	WorkspaceIDs []string `json:"workspaceIDs"`
+}
+
+func (t TerraformVariableSetsService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, p)
+}
+
+func (t TerraformVariableSetsService) Create(ctx context.Context, set TerraformVariableSet) (TerraformVariableSet, error) {
+	b, err := json.Marshal(set)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error serialising variable set: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"), buf)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariableSet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVariableSet{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/workspaceIDs",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariableSet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariableSets) < 1 {
+		return TerraformVariableSet{}, errors.New("no Terraform variable set returned in response")
+	}
+	return resp.TerraformVariableSets[0], nil
+}
+
+func (t TerraformVariableSetsService) Get(ctx context.Context, id string) (TerraformVariableSet, error) {
+	if id == "" {
+		return TerraformVariableSet{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariableSet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariableSet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariableSets) < 1 {
+		return TerraformVariableSet{}, errors.New("no Terraform variable set returned in response")
+	}
+	return resp.TerraformVariableSets[0], nil
+}
+
+func (t TerraformVariableSetsService) Update(ctx context.Context, set TerraformVariableSet) (TerraformVariableSet, error) {
+	if set.ID == "" {
+		return TerraformVariableSet{}, errors.New("id must be specified")
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error serialising variable set: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildURL("/"+set.ID), buf)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVariableSet{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVariableSet{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVariableSet{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVariableSet{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformVariableSetNameConflict
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/workspaceIDs",
+	}) {
+		return TerraformVariableSet{}, ErrTerraformWorkspaceNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVariableSet{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVariableSets) < 1 {
+		return TerraformVariableSet{}, errors.New("no Terraform variable set returned in response")
+	}
+	return resp.TerraformVariableSets[0], nil
+}
+
+// Delete removes a variable set, along with all the variables in it.
+func (t TerraformVariableSetsService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildURL("/"+id), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrTerraformVariableSetNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
+
+func (t TerraformVariableSetsService) List(ctx context.Context) ([]TerraformVariableSet, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformVariableSets, nil
+}
@@ -0,0 +1,292 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+var (
+	ErrTerraformVersionNotFound      = errors.New("terraform version not found in the catalog")
+	ErrTerraformVersionAlreadyExists = errors.New("terraform version already exists in the catalog")
+	ErrTerraformVersionInUse         = errors.New("terraform version is still pinned by workspaces")
+	ErrTerraformVersionInvalid       = errors.New("terraform version must be a full version like 1.5.7")
+	ErrTerraformVersionAccessDenied  = errors.New("only admins can change the Terraform version catalog")
+	ErrTerraformVersionConstraint    = errors.New("terraform version constraint isn't valid")
+)
+
+var adminAccessDeniedError = RequestError{Slug: requestErrAccessDenied, Header: "Authorization"}
+
+// TerraformVersionsService reads and manages the catalog of Terraform
+// versions that workspaces can use. The catalog is shared by every project;
+// changing it requires admin credentials.
+type TerraformVersionsService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformVersionsService(basePath string, terraform *TerraformService) *TerraformVersionsService {
+	return &TerraformVersionsService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+type TerraformVersion struct {
+	Version    string    `json:"version"`
+	Beta       bool      `json:"beta"`
+	Deprecated bool      `json:"deprecated"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TerraformVersionFilter limits which versions TerraformVersionsService.List
+// returns. The zero value returns every version that is neither beta nor
+// deprecated.
+type TerraformVersionFilter struct {
+	// Constraint is a version constraint like "~> 1.5" that versions must
+	// match.
+	Constraint        string
+	IncludeBeta       bool
+	IncludeDeprecated bool
+}
+
+func (t TerraformVersionsService) buildURL(p string) string {
+	return path.Join(t.terraformService.basePath, t.basePath, p)
+}
+
+func (t TerraformVersionsService) buildAdminURL(p string) string {
+	return path.Join("admin", t.terraformService.basePath, t.basePath, p)
+}
+
+// List returns the versions in the catalog that match filter, newest first.
+func (t TerraformVersionsService) List(ctx context.Context, filter TerraformVersionFilter) ([]TerraformVersion, error) {
+	v := url.Values{}
+	if filter.Constraint != "" {
+		v.Set("constraint", filter.Constraint)
+	}
+	if filter.IncludeBeta {
+		v.Set("include_beta", "true")
+	}
+	if filter.IncludeDeprecated {
+		v.Set("include_deprecated", "true")
+	}
+	u := t.buildURL("/")
+	if len(v) > 0 {
+		u += "?" + v.Encode()
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Param: "constraint",
+	}) {
+		return nil, ErrTerraformVersionConstraint
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformVersions, nil
+}
+
+func (t TerraformVersionsService) Get(ctx context.Context, version string) (TerraformVersion, error) {
+	if version == "" {
+		return TerraformVersion{}, errors.New("version must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+version), nil)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVersion{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return TerraformVersion{}, ErrTerraformVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVersions) < 1 {
+		return TerraformVersion{}, errors.New("no Terraform version returned in response")
+	}
+	return resp.TerraformVersions[0], nil
+}
+
+// Create adds a version to the catalog. It requires admin credentials.
+func (t TerraformVersionsService) Create(ctx context.Context, version TerraformVersion) (TerraformVersion, error) {
+	b, err := json.Marshal(version)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error serialising version: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildAdminURL("/"), buf)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVersion{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVersion{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return TerraformVersion{}, ErrTerraformVersionAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/version",
+	}) {
+		return TerraformVersion{}, errors.New("version must be specified")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Field: "/version",
+	}) {
+		return TerraformVersion{}, ErrTerraformVersionInvalid
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/version",
+	}) {
+		return TerraformVersion{}, ErrTerraformVersionAlreadyExists
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVersions) < 1 {
+		return TerraformVersion{}, errors.New("no Terraform version returned in response")
+	}
+	return resp.TerraformVersions[0], nil
+}
+
+// Update changes the beta and deprecated flags of a version in the catalog.
+// It requires admin credentials.
+func (t TerraformVersionsService) Update(ctx context.Context, version TerraformVersion) (TerraformVersion, error) {
+	if version.Version == "" {
+		return TerraformVersion{}, errors.New("version must be specified")
+	}
+	b, err := json.Marshal(version)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error serialising version: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPut, t.buildAdminURL("/"+version.Version), buf)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVersion{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVersion{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVersion{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVersion{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return TerraformVersion{}, ErrTerraformVersionAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return TerraformVersion{}, ErrTerraformVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVersions) < 1 {
+		return TerraformVersion{}, errors.New("no Terraform version returned in response")
+	}
+	return resp.TerraformVersions[0], nil
+}
+
+// Delete removes a version from the catalog. Versions that workspaces have
+// pinned can't be removed; deprecate them with Update instead. It requires
+// admin credentials.
+func (t TerraformVersionsService) Delete(ctx context.Context, version string) error {
+	if version == "" {
+		return errors.New("version must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodDelete, t.buildAdminURL("/"+version), nil)
+	if err != nil {
+		return fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if resp.Errors.Contains(adminAccessDeniedError) {
+		return ErrTerraformVersionAccessDenied
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return ErrTerraformVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "version",
+	}) {
+		return ErrTerraformVersionInUse
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrTerraformVCSEventNotFound          = errors.New("terraform VCS event not found")
+	ErrTerraformVCSEventRepositoryMissing = errors.New("terraform VCS event must specify a repository")
+	ErrTerraformVCSEventBranchMissing     = errors.New("terraform VCS event must specify a branch")
+	ErrTerraformVCSEventChangedFileEmpty  = errors.New("terraform VCS event changed files can't be empty")
+)
+
+var terraformVCSEventChangedFileField = regexp.MustCompile(`^/changedFiles/[0-9]+$`)
+
+type TerraformWebhooksService struct {
+	terraformService *TerraformService
+	basePath         string
+}
+
+func newTerraformWebhooksService(basePath string, terraform *TerraformService) *TerraformWebhooksService {
+	return &TerraformWebhooksService{
+		basePath:         basePath,
+		terraformService: terraform,
+	}
+}
+
+type TerraformVCSEvent struct {
+	ID           string                 `json:"id"`
+	Organization string                 `json:"organization"`
+	Project      string                 `json:"project"`
+	Repository   string                 `json:"repository"`
+	Branch       string                 `json:"branch"`
+	CommitSHA    string                 `json:"commitSHA"`
+	Message      string                 `json:"message"`
+	ChangedFiles []string               `json:"changedFiles"`
+	Decisions    []TerraformVCSDecision `json:"decisions"`
+	ReceivedAt   time.Time              `json:"receivedAt"`
+}
+
+// TerraformVCSDecision records whether a VCS event queued a run in a
+// workspace connected to its repository, and why.
+type TerraformVCSDecision struct {
+	WorkspaceID string `json:"workspaceID"`
+	Triggered   bool   `json:"triggered"`
+	Reason      string `json:"reason"`
+	RunID       string `json:"runID,omitempty"`
+}
+
+func (t TerraformWebhooksService) buildURL(p string) string {
+	return path.Join(t.terraformService.client.projectPath(), t.terraformService.basePath, t.basePath, "vcs", p)
+}
+
+// PushVCS delivers a push event to the VCS webhook, which queues runs in
+// the workspaces connected to the event's repository that the push should
+// trigger. The returned event records the decision made for each of those
+// workspaces.
+func (t TerraformWebhooksService) PushVCS(ctx context.Context, event TerraformVCSEvent) (TerraformVCSEvent, error) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return TerraformVCSEvent{}, fmt.Errorf("error serialising VCS event: %w", err)
+	}
+	buf := bytes.NewBuffer(b)
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodPost, t.buildURL("/"), buf)
+	if err != nil {
+		return TerraformVCSEvent{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVCSEvent{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVCSEvent{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVCSEvent{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return TerraformVCSEvent{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/repository",
+	}) {
+		return TerraformVCSEvent{}, ErrTerraformVCSEventRepositoryMissing
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/branch",
+	}) {
+		return TerraformVCSEvent{}, ErrTerraformVCSEventBranchMissing
+	}
+	if resp.Errors.Contains(lockedError) {
+		return TerraformVCSEvent{}, ErrTerraformWorkspaceLocked
+	}
+	if resp.Errors.FieldMatches(requestErrMissing, terraformVCSEventChangedFileField) != nil {
+		return TerraformVCSEvent{}, ErrTerraformVCSEventChangedFileEmpty
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVCSEvent{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVCSEvents) < 1 {
+		return TerraformVCSEvent{}, errors.New("no Terraform VCS event returned in response")
+	}
+	return resp.TerraformVCSEvents[0], nil
+}
+
+func (t TerraformWebhooksService) GetVCSEvent(ctx context.Context, id string) (TerraformVCSEvent, error) {
+	if id == "" {
+		return TerraformVCSEvent{}, errors.New("id must be specified")
+	}
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"+id), nil)
+	if err != nil {
+		return TerraformVCSEvent{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return TerraformVCSEvent{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return TerraformVCSEvent{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return TerraformVCSEvent{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return TerraformVCSEvent{}, ErrTerraformVCSEventNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return TerraformVCSEvent{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.TerraformVCSEvents) < 1 {
+		return TerraformVCSEvent{}, errors.New("no Terraform VCS event returned in response")
+	}
+	return resp.TerraformVCSEvents[0], nil
+}
+
+// ListVCSEvents returns the VCS events the project has received, oldest
+// first.
+func (t TerraformWebhooksService) ListVCSEvents(ctx context.Context) ([]TerraformVCSEvent, error) {
+	req, err := t.terraformService.client.NewRequest(ctx, http.MethodGet, t.buildURL("/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := t.terraformService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.TerraformVCSEvents, nil
+}
@@ -8,18 +8,28 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"time"
 )
 
 var (
 	ErrVaultClusterNotFound           = errors.New("vault cluster not found")
+	ErrVaultClusterNotDeleted         = errors.New("vault cluster is not deleted")
+	ErrVaultClusterNameConflict       = errors.New("vault cluster name is already in use in the project")
 	ErrVaultClusterRegionNotFound     = errors.New("vault cluster region not found")
 	ErrVaultClusterRegionAccessDenied = errors.New("authenticated user doesn't have the ability to provision Vault clusters in that region")
+	ErrVaultClusterLeaseTTLInvalid    = errors.New("vault cluster lease TTLs must be positive durations")
+	ErrVaultClusterLeaseTTLConflict   = errors.New("vault cluster default lease TTL must not be longer than its max lease TTL")
+	ErrVaultClusterAddressInvalid     = errors.New("vault cluster listener addresses must be host:port addresses")
+	ErrVaultClusterAddressConflict    = errors.New("vault cluster listener address is already in use in the region")
 )
 
 type VaultService struct {
 	basePath string
 	client   *Client
 	Clusters *VaultClustersService
+	Secrets  *VaultSecretsService
+	Leases   *VaultLeasesService
 }
 
 func newVaultService(basePath string, client *Client) *VaultService {
@@ -28,6 +38,8 @@ func newVaultService(basePath string, client *Client) *VaultService {
 		client:   client,
 	}
 	s.Clusters = newVaultClustersService("clusters", s)
+	s.Secrets = newVaultSecretsService("clusters", s)
+	s.Leases = newVaultLeasesService("clusters", s)
 	return s
 }
 
@@ -45,11 +57,15 @@ func newVaultClustersService(basePath string, vault *VaultService) *VaultCluster
 
 type VaultCluster struct {
 	ID              string                  `json:"id"`
+	Organization    string                  `json:"organization"`
+	Project         string                  `json:"project"`
 	Name            string                  `json:"name"`
 	Region          string                  `json:"region"`
 	DefaultLeaseTTL string                  `json:"defaultLeaseTTL"`
 	MaxLeaseTTL     string                  `json:"maxLeaseTTL"`
 	TCPListener     VaultClusterTCPListener `json:"tcpListener"`
+	SealStatus      VaultClusterSealStatus  `json:"sealStatus"`
+	DeletedAt       *time.Time              `json:"deletedAt,omitempty"`
 }
 
 type VaultClusterTCPListener struct {
@@ -58,7 +74,7 @@ type VaultClusterTCPListener struct {
 }
 
 func (v VaultClustersService) buildURL(p string) string {
-	return path.Join(v.vaultService.basePath, v.basePath, p)
+	return path.Join(v.vaultService.client.projectPath(), v.vaultService.basePath, v.basePath, p)
 }
 
 func (v VaultClustersService) Create(ctx context.Context, cluster VaultCluster) (VaultCluster, error) {
@@ -104,6 +120,18 @@ func (v VaultClustersService) Create(ctx context.Context, cluster VaultCluster)
 	}) {
 		return VaultCluster{}, ErrVaultClusterRegionNotFound
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	if err := listenerError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
+	if err := leaseTTLError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return VaultCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -188,6 +216,18 @@ func (v VaultClustersService) Update(ctx context.Context, cluster VaultCluster)
 	}) {
 		return VaultCluster{}, errors.New("cluster must have a name")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	if err := listenerError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
+	if err := leaseTTLError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return VaultCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -201,7 +241,7 @@ func (v VaultClustersService) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id must be specified")
 	}
-	req, err := v.vaultService.client.NewRequest(ctx, http.MethodGet, v.buildURL("/"+id), nil)
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodDelete, v.buildURL("/"+id), nil)
 	if err != nil {
 		return fmt.Errorf("error constructing request: %w", err)
 	}
@@ -228,3 +268,198 @@ func (v VaultClustersService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (v VaultClustersService) ListVersions(ctx context.Context, id string) ([]Version, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodGet, v.buildURL("/"+id+"/versions"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, ErrVaultClusterNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Versions, nil
+}
+
+func (v VaultClustersService) RestoreVersion(ctx context.Context, id string, version int) (VaultCluster, error) {
+	if id == "" {
+		return VaultCluster{}, errors.New("id must be specified")
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodPost, v.buildURL("/"+id+"/versions/"+strconv.Itoa(version)+"/restore"), nil)
+	if err != nil {
+		return VaultCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return VaultCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return VaultCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return VaultCluster{}, ErrVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	if err := listenerError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
+	if err := leaseTTLError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return VaultCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultClusters) < 1 {
+		return VaultCluster{}, errors.New("no Vault cluster returned in response")
+	}
+	return resp.VaultClusters[0], nil
+}
+
+func (v VaultClustersService) List(ctx context.Context, includeDeleted bool) ([]VaultCluster, error) {
+	u := v.buildURL("/")
+	if includeDeleted {
+		u += "?include_deleted=true"
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.VaultClusters, nil
+}
+
+func (v VaultClustersService) Undelete(ctx context.Context, id string) (VaultCluster, error) {
+	if id == "" {
+		return VaultCluster{}, errors.New("id must be specified")
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodPost, v.buildURL("/"+id+"/undelete"), nil)
+	if err != nil {
+		return VaultCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return VaultCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return VaultCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return VaultCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNotDeleted
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return VaultCluster{}, ErrVaultClusterNameConflict
+	}
+	if err := listenerError(resp.Errors); err != nil {
+		return VaultCluster{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return VaultCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultClusters) < 1 {
+		return VaultCluster{}, errors.New("no Vault cluster returned in response")
+	}
+	return resp.VaultClusters[0], nil
+}
+
+// leaseTTLError returns the error for an invalid DefaultLeaseTTL or
+// MaxLeaseTTL in errs, or nil if there isn't one.
+func leaseTTLError(errs RequestErrors) error {
+	for _, field := range []string{"/defaultLeaseTTL", "/maxLeaseTTL"} {
+		for _, slug := range []string{requestErrInvalidFormat, requestErrInvalidValue} {
+			if errs.Contains(RequestError{Slug: slug, Field: field}) {
+				return ErrVaultClusterLeaseTTLInvalid
+			}
+		}
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/defaultLeaseTTL",
+	}) {
+		return ErrVaultClusterLeaseTTLConflict
+	}
+	return nil
+}
+
+// listenerError returns the error for an invalid or conflicting TCP listener
+// address in errs, or nil if there isn't one.
+func listenerError(errs RequestErrors) error {
+	for _, field := range []string{"/tcpListener/address", "/tcpListener/clusterAddress"} {
+		if errs.Contains(RequestError{Slug: requestErrInvalidFormat, Field: field}) {
+			return ErrVaultClusterAddressInvalid
+		}
+		if errs.Contains(RequestError{Slug: requestErrConflict, Field: field}) {
+			return ErrVaultClusterAddressConflict
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+)
+
+var (
+	ErrVaultLeaseNotFound   = errors.New("vault lease not found")
+	ErrVaultLeaseTTLInvalid = errors.New("vault lease TTL must be a positive duration")
+)
+
+// VaultLeasesService issues and manages dynamic credentials from Vault
+// clusters.
+type VaultLeasesService struct {
+	vaultService *VaultService
+	basePath     string
+}
+
+func newVaultLeasesService(basePath string, vault *VaultService) *VaultLeasesService {
+	return &VaultLeasesService{
+		basePath:     basePath,
+		vaultService: vault,
+	}
+}
+
+// VaultLease is a set of dynamic credentials issued by a Vault cluster.
+// Credentials are only set on the lease returned by Issue. A lease can't be
+// renewed past its cluster's MaxLeaseTTL after it was issued; Renewable is
+// false once it reaches that limit.
+type VaultLease struct {
+	ID           string            `json:"id"`
+	Organization string            `json:"organization"`
+	Project      string            `json:"project"`
+	ClusterID    string            `json:"clusterID"`
+	Role         string            `json:"role"`
+	TTL          string            `json:"ttl"`
+	Renewable    bool              `json:"renewable"`
+	Credentials  map[string]string `json:"credentials,omitempty"`
+	IssuedAt     time.Time         `json:"issuedAt"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+}
+
+type vaultLeaseRenewal struct {
+	Increment string `json:"increment"`
+}
+
+func (v VaultLeasesService) buildURL(clusterID string, p ...string) string {
+	return path.Join(append([]string{v.vaultService.client.projectPath(), v.vaultService.basePath, v.basePath, clusterID, "leases"}, p...)...)
+}
+
+// leaseError returns the error for the errors every lease route can return,
+// or nil if there aren't any of them in errs.
+func (v VaultLeasesService) leaseError(errs RequestErrors) error {
+	if errs.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if errs.Contains(invalidFormatError) {
+		return errors.New("invalid format error returned")
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrVaultClusterNotFound
+	}
+	if errs.Contains(sealedError) {
+		return ErrVaultClusterSealed
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "lease",
+	}) {
+		return ErrVaultLeaseNotFound
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", errs)
+	}
+	return nil
+}
+
+// do makes a request to one of the lease routes and returns the lease in
+// the response.
+func (v VaultLeasesService) do(ctx context.Context, method, u string, body interface{}) (VaultLease, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return VaultLease{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return VaultLease{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return VaultLease{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return VaultLease{}, err
+	}
+
+	for _, field := range []string{"/ttl", "/increment"} {
+		for _, slug := range []string{requestErrInvalidFormat, requestErrInvalidValue} {
+			if resp.Errors.Contains(RequestError{Slug: slug, Field: field}) {
+				return VaultLease{}, ErrVaultLeaseTTLInvalid
+			}
+		}
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/role",
+	}) {
+		return VaultLease{}, errors.New("lease must have a role")
+	}
+	if err := v.leaseError(resp.Errors); err != nil {
+		return VaultLease{}, err
+	}
+	if len(resp.VaultLeases) < 1 {
+		return VaultLease{}, errors.New("no Vault lease returned in response")
+	}
+	return resp.VaultLeases[0], nil
+}
+
+// Issue creates new credentials for role in the Vault cluster identified by
+// clusterID. The lease lasts for ttl, or the cluster's DefaultLeaseTTL if
+// ttl is 0, but never longer than the cluster's MaxLeaseTTL.
+func (v VaultLeasesService) Issue(ctx context.Context, clusterID, role string, ttl time.Duration) (VaultLease, error) {
+	if clusterID == "" {
+		return VaultLease{}, errors.New("cluster ID must be specified")
+	}
+	lease := VaultLease{Role: role}
+	if ttl != 0 {
+		lease.TTL = ttl.String()
+	}
+	return v.do(ctx, http.MethodPost, v.buildURL(clusterID), lease)
+}
+
+// Get returns the lease identified by leaseID, without its credentials.
+// Expired leases aren't found.
+func (v VaultLeasesService) Get(ctx context.Context, clusterID, leaseID string) (VaultLease, error) {
+	if clusterID == "" {
+		return VaultLease{}, errors.New("cluster ID must be specified")
+	}
+	if leaseID == "" {
+		return VaultLease{}, errors.New("lease ID must be specified")
+	}
+	return v.do(ctx, http.MethodGet, v.buildURL(clusterID, leaseID), nil)
+}
+
+// List returns the unexpired leases in the Vault cluster identified by
+// clusterID, without their credentials, soonest to expire first.
+func (v VaultLeasesService) List(ctx context.Context, clusterID string) ([]VaultLease, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodGet, v.buildURL(clusterID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.leaseError(resp.Errors); err != nil {
+		return nil, err
+	}
+	return resp.VaultLeases, nil
+}
+
+// Renew extends the lease identified by leaseID so it expires increment from
+// now, or the cluster's DefaultLeaseTTL from now if increment is 0, but never
+// more than the cluster's MaxLeaseTTL after it was issued.
+func (v VaultLeasesService) Renew(ctx context.Context, clusterID, leaseID string, increment time.Duration) (VaultLease, error) {
+	if clusterID == "" {
+		return VaultLease{}, errors.New("cluster ID must be specified")
+	}
+	if leaseID == "" {
+		return VaultLease{}, errors.New("lease ID must be specified")
+	}
+	var renewal vaultLeaseRenewal
+	if increment != 0 {
+		renewal.Increment = increment.String()
+	}
+	return v.do(ctx, http.MethodPost, v.buildURL(clusterID, leaseID, "renew"), renewal)
+}
+
+// Revoke ends the lease identified by leaseID before it expires.
+func (v VaultLeasesService) Revoke(ctx context.Context, clusterID, leaseID string) error {
+	if clusterID == "" {
+		return errors.New("cluster ID must be specified")
+	}
+	if leaseID == "" {
+		return errors.New("lease ID must be specified")
+	}
+	_, err := v.do(ctx, http.MethodDelete, v.buildURL(clusterID, leaseID), nil)
+	return err
+}
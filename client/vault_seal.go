@@ -0,0 +1,176 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrVaultClusterAlreadyInitialized = errors.New("vault cluster is already initialized")
+	ErrVaultClusterNotInitialized     = errors.New("vault cluster is not initialized")
+	ErrVaultClusterSealed             = errors.New("vault cluster is sealed")
+	ErrVaultUnsealKeyInvalid          = errors.New("vault unseal key is not one of the cluster's unseal keys")
+	ErrVaultRootTokenInvalid          = errors.New("vault root token is not the cluster's root token")
+)
+
+// sealedError is returned by the KV and lease routes when the cluster is
+// sealed.
+var sealedError = RequestError{Slug: requestErrConflict, Param: "id"}
+
+// VaultClusterSealStatus describes whether a Vault cluster has been
+// initialized and whether it's sealed. New clusters aren't initialized or
+// sealed; initialized clusters start out sealed, and need SecretThreshold of
+// their unseal keys to be unsealed.
+type VaultClusterSealStatus struct {
+	Initialized     bool `json:"initialized"`
+	Sealed          bool `json:"sealed"`
+	SecretShares    int  `json:"secretShares,omitempty"`
+	SecretThreshold int  `json:"secretThreshold,omitempty"`
+	UnsealProgress  int  `json:"unsealProgress"`
+}
+
+// VaultClusterKeys are the unseal keys and root token generated when a Vault
+// cluster is initialized. The API never returns them again.
+type VaultClusterKeys struct {
+	ClusterID string   `json:"clusterID"`
+	Keys      []string `json:"keys"`
+	RootToken string   `json:"rootToken"`
+}
+
+type vaultClusterInit struct {
+	SecretShares    int `json:"secretShares"`
+	SecretThreshold int `json:"secretThreshold"`
+}
+
+type vaultClusterUnseal struct {
+	Key   string `json:"key,omitempty"`
+	Reset bool   `json:"reset,omitempty"`
+}
+
+type vaultClusterSeal struct {
+	Token string `json:"token"`
+}
+
+// sealRequest makes a request to one of the seal routes for the cluster
+// identified by id, and returns the response.
+func (v VaultClustersService) sealRequest(ctx context.Context, id, op string, body interface{}) (Response, error) {
+	if id == "" {
+		return Response{}, errors.New("ID must be specified")
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return Response{}, fmt.Errorf("error serialising request: %w", err)
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, http.MethodPost, v.buildURL("/"+id+"/"+op), bytes.NewBuffer(b))
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrVaultClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "id",
+	}) {
+		return Response{}, ErrVaultClusterNotInitialized
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return Response{}, ErrVaultClusterAlreadyInitialized
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/key",
+	}) {
+		return Response{}, ErrVaultUnsealKeyInvalid
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrAccessDenied,
+		Field: "/token",
+	}) {
+		return Response{}, ErrVaultRootTokenInvalid
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultClusters) < 1 {
+		return Response{}, errors.New("no Vault cluster returned in response")
+	}
+	return resp, nil
+}
+
+// Init initializes the cluster identified by id, splitting its root key into
+// shares unseal keys, threshold of which are needed to unseal it. If shares
+// and threshold are both 0, the API's defaults are used. The cluster is
+// sealed once it's initialized.
+func (v VaultClustersService) Init(ctx context.Context, id string, shares, threshold int) (VaultCluster, VaultClusterKeys, error) {
+	resp, err := v.sealRequest(ctx, id, "init", vaultClusterInit{SecretShares: shares, SecretThreshold: threshold})
+	if err != nil {
+		return VaultCluster{}, VaultClusterKeys{}, err
+	}
+	if len(resp.VaultClusterKeys) < 1 {
+		return VaultCluster{}, VaultClusterKeys{}, errors.New("no Vault cluster keys returned in response")
+	}
+	return resp.VaultClusters[0], resp.VaultClusterKeys[0], nil
+}
+
+// Unseal provides one of the unseal keys of the cluster identified by id.
+// The returned cluster's SealStatus shows how many keys have been provided,
+// and whether the cluster is still sealed.
+func (v VaultClustersService) Unseal(ctx context.Context, id, key string) (VaultCluster, error) {
+	if key == "" {
+		return VaultCluster{}, errors.New("key must be specified")
+	}
+	resp, err := v.sealRequest(ctx, id, "unseal", vaultClusterUnseal{Key: key})
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	return resp.VaultClusters[0], nil
+}
+
+// ResetUnseal discards the unseal keys provided to the cluster identified by
+// id so far.
+func (v VaultClustersService) ResetUnseal(ctx context.Context, id string) (VaultCluster, error) {
+	resp, err := v.sealRequest(ctx, id, "unseal", vaultClusterUnseal{Reset: true})
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	return resp.VaultClusters[0], nil
+}
+
+// Seal seals the cluster identified by id, using the root token returned
+// when it was initialized.
+func (v VaultClustersService) Seal(ctx context.Context, id, rootToken string) (VaultCluster, error) {
+	if rootToken == "" {
+		return VaultCluster{}, errors.New("root token must be specified")
+	}
+	resp, err := v.sealRequest(ctx, id, "seal", vaultClusterSeal{Token: rootToken})
+	if err != nil {
+		return VaultCluster{}, err
+	}
+	return resp.VaultClusters[0], nil
+}
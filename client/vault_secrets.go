@@ -0,0 +1,364 @@
+package dadcorp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrVaultSecretNotFound        = errors.New("vault secret not found")
+	ErrVaultSecretVersionNotFound = errors.New("vault secret version not found")
+	ErrVaultSecretVersionDeleted  = errors.New("vault secret version has been deleted or destroyed")
+	ErrVaultSecretCASMismatch     = errors.New("vault secret check-and-set version doesn't match the current version")
+	ErrVaultSecretPathInvalid     = errors.New("vault secret path is invalid")
+)
+
+// VaultSecretsService manages the secrets in Vault clusters' KV stores.
+// Every request it makes needs an access policy for the cluster whose key
+// covers the secret's path; see WithAccessPolicy.
+type VaultSecretsService struct {
+	vaultService *VaultService
+	basePath     string
+}
+
+func newVaultSecretsService(basePath string, vault *VaultService) *VaultSecretsService {
+	return &VaultSecretsService{
+		basePath:     basePath,
+		vaultService: vault,
+	}
+}
+
+// VaultSecret is a secret in a Vault cluster's KV store. Secrets returned by
+// the API never include the data of their versions; use Get to read it.
+type VaultSecret struct {
+	ClusterID      string               `json:"clusterID"`
+	Organization   string               `json:"organization"`
+	Project        string               `json:"project"`
+	Path           string               `json:"path"`
+	CurrentVersion int                  `json:"currentVersion"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+	Versions       []VaultSecretVersion `json:"versions"`
+}
+
+// VaultSecretVersion is a single version of a VaultSecret. Deleted versions
+// can be undeleted; destroyed versions have had their data removed for good.
+type VaultSecretVersion struct {
+	Version   int               `json:"version"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	DeletedAt *time.Time        `json:"deletedAt,omitempty"`
+	Destroyed bool              `json:"destroyed"`
+}
+
+// VaultSecretWrite is a new version of a secret. If CAS is set, the write
+// only succeeds if it matches the secret's current version, with 0 meaning
+// the secret must not exist yet.
+type VaultSecretWrite struct {
+	Data map[string]string `json:"data"`
+	CAS  *int              `json:"cas,omitempty"`
+}
+
+type vaultSecretVersions struct {
+	Versions []int `json:"versions"`
+}
+
+// buildURL returns the URL of the secret at p under one of the KV routes,
+// which are named by segment.
+func (v VaultSecretsService) buildURL(clusterID, segment, p string) string {
+	return path.Join(v.vaultService.client.projectPath(), v.vaultService.basePath, v.basePath, clusterID, segment, p)
+}
+
+// secretError returns the error for the errors every KV route can return,
+// or nil if there aren't any of them in errs.
+func (v VaultSecretsService) secretError(errs RequestErrors) error {
+	if errs.Contains(serverError) {
+		return errors.New("server error")
+	}
+	if errs.Contains(accessDeniedError) {
+		return ErrAccessPolicyDenied
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "path",
+	}) {
+		return ErrVaultSecretPathInvalid
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return ErrVaultClusterNotFound
+	}
+	if errs.Contains(sealedError) {
+		return ErrVaultClusterSealed
+	}
+	if errs.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "path",
+	}) {
+		return ErrVaultSecretNotFound
+	}
+	return nil
+}
+
+// do makes a request to one of the KV routes and returns the response.
+func (v VaultSecretsService) do(ctx context.Context, method, u string, body interface{}) (Response, error) {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising request: %w", err)
+		}
+		buf = bytes.NewBuffer(b)
+	}
+	req, err := v.vaultService.client.NewRequest(ctx, method, u, buf)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := v.vaultService.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	return responseFromBody(res)
+}
+
+// Get returns a version of the secret at secretPath, including its data. If
+// version is 0, the current version is returned.
+func (v VaultSecretsService) Get(ctx context.Context, clusterID, secretPath string, version int) (VaultSecretVersion, error) {
+	if clusterID == "" {
+		return VaultSecretVersion{}, errors.New("cluster ID must be specified")
+	}
+	u := v.buildURL(clusterID, "kv", secretPath)
+	if version > 0 {
+		u += "?" + url.Values{"version": []string{strconv.Itoa(version)}}.Encode()
+	}
+	resp, err := v.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return VaultSecretVersion{}, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return VaultSecretVersion{}, err
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "version",
+	}) {
+		return VaultSecretVersion{}, ErrVaultSecretVersionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "version",
+	}) {
+		return VaultSecretVersion{}, ErrVaultSecretVersionDeleted
+	}
+	if len(resp.Errors) > 0 {
+		return VaultSecretVersion{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultSecretVersions) < 1 {
+		return VaultSecretVersion{}, errors.New("no Vault secret version returned in response")
+	}
+	return resp.VaultSecretVersions[0], nil
+}
+
+// Write adds a new version to the secret at secretPath, creating the secret
+// if it doesn't exist yet.
+func (v VaultSecretsService) Write(ctx context.Context, clusterID, secretPath string, write VaultSecretWrite) (VaultSecret, error) {
+	if clusterID == "" {
+		return VaultSecret{}, errors.New("cluster ID must be specified")
+	}
+	if write.Data == nil {
+		write.Data = map[string]string{}
+	}
+	resp, err := v.do(ctx, http.MethodPut, v.buildURL(clusterID, "kv", secretPath), write)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return VaultSecret{}, err
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return VaultSecret{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/cas",
+	}) {
+		return VaultSecret{}, ErrVaultSecretCASMismatch
+	}
+	if len(resp.Errors) > 0 {
+		return VaultSecret{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultSecrets) < 1 {
+		return VaultSecret{}, errors.New("no Vault secret returned in response")
+	}
+	return resp.VaultSecrets[0], nil
+}
+
+// Delete soft-deletes versions of the secret at secretPath, or its current
+// version if none are given. Deleted versions can be undeleted.
+func (v VaultSecretsService) Delete(ctx context.Context, clusterID, secretPath string, versions ...int) (VaultSecret, error) {
+	if clusterID == "" {
+		return VaultSecret{}, errors.New("cluster ID must be specified")
+	}
+	u := v.buildURL(clusterID, "kv", secretPath)
+	if len(versions) > 0 {
+		vs := make([]string, 0, len(versions))
+		for _, version := range versions {
+			vs = append(vs, strconv.Itoa(version))
+		}
+		u += "?" + url.Values{"versions": []string{strings.Join(vs, ",")}}.Encode()
+	}
+	resp, err := v.do(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return VaultSecret{}, err
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "versions",
+	}) {
+		return VaultSecret{}, ErrVaultSecretVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return VaultSecret{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultSecrets) < 1 {
+		return VaultSecret{}, errors.New("no Vault secret returned in response")
+	}
+	return resp.VaultSecrets[0], nil
+}
+
+// Undelete restores soft-deleted versions of the secret at secretPath.
+// Destroyed versions can't be restored.
+func (v VaultSecretsService) Undelete(ctx context.Context, clusterID, secretPath string, versions ...int) (VaultSecret, error) {
+	return v.changeVersions(ctx, clusterID, "kvUndelete", secretPath, versions)
+}
+
+// Destroy permanently removes the data of versions of the secret at
+// secretPath.
+func (v VaultSecretsService) Destroy(ctx context.Context, clusterID, secretPath string, versions ...int) (VaultSecret, error) {
+	return v.changeVersions(ctx, clusterID, "kvDestroy", secretPath, versions)
+}
+
+func (v VaultSecretsService) changeVersions(ctx context.Context, clusterID, segment, secretPath string, versions []int) (VaultSecret, error) {
+	if clusterID == "" {
+		return VaultSecret{}, errors.New("cluster ID must be specified")
+	}
+	if len(versions) < 1 {
+		return VaultSecret{}, errors.New("at least one version must be specified")
+	}
+	resp, err := v.do(ctx, http.MethodPost, v.buildURL(clusterID, segment, secretPath), vaultSecretVersions{Versions: versions})
+	if err != nil {
+		return VaultSecret{}, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return VaultSecret{}, err
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return VaultSecret{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/versions",
+	}) {
+		return VaultSecret{}, ErrVaultSecretVersionNotFound
+	}
+	if len(resp.Errors) > 0 {
+		return VaultSecret{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultSecrets) < 1 {
+		return VaultSecret{}, errors.New("no Vault secret returned in response")
+	}
+	return resp.VaultSecrets[0], nil
+}
+
+// GetMetadata returns the secret at secretPath and the metadata of all its
+// versions, without any of their data.
+func (v VaultSecretsService) GetMetadata(ctx context.Context, clusterID, secretPath string) (VaultSecret, error) {
+	if clusterID == "" {
+		return VaultSecret{}, errors.New("cluster ID must be specified")
+	}
+	if secretPath == "" || strings.HasSuffix(secretPath, "/") {
+		return VaultSecret{}, ErrVaultSecretPathInvalid
+	}
+	resp, err := v.do(ctx, http.MethodGet, v.buildURL(clusterID, "kvMetadata", secretPath), nil)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return VaultSecret{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return VaultSecret{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.VaultSecrets) < 1 {
+		return VaultSecret{}, errors.New("no Vault secret returned in response")
+	}
+	return resp.VaultSecrets[0], nil
+}
+
+// List returns the metadata of the secrets whose paths start with prefix,
+// sorted by path. prefix must be empty or end in a slash. Only the secrets
+// the access policy covers are returned.
+func (v VaultSecretsService) List(ctx context.Context, clusterID, prefix string) ([]VaultSecret, error) {
+	if clusterID == "" {
+		return nil, errors.New("cluster ID must be specified")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		return nil, ErrVaultSecretPathInvalid
+	}
+	// path.Join drops the trailing slash that marks this as a list
+	resp, err := v.do(ctx, http.MethodGet, v.buildURL(clusterID, "kvMetadata", prefix)+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.VaultSecrets, nil
+}
+
+// DeleteMetadata removes the secret at secretPath and all its versions for
+// good.
+func (v VaultSecretsService) DeleteMetadata(ctx context.Context, clusterID, secretPath string) error {
+	if clusterID == "" {
+		return errors.New("cluster ID must be specified")
+	}
+	if secretPath == "" || strings.HasSuffix(secretPath, "/") {
+		return ErrVaultSecretPathInvalid
+	}
+	resp, err := v.do(ctx, http.MethodDelete, v.buildURL(clusterID, "kvMetadata", secretPath), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := v.secretError(resp.Errors); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package dadcorp
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrVersionNotFound = errors.New("version not found")
+)
+
+type Version struct {
+	ResourceType string          `json:"resourceType"`
+	ResourceID   string          `json:"resourceID"`
+	Version      int             `json:"version"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	Data         json.RawMessage `json:"data"`
+	Changes      []VersionChange `json:"changes,omitempty"`
+}
+
+type VersionChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -22,3 +22,33 @@ resource "dadcorp_consul_cluster" "demo" {
     expose_max_port  = 31000
   }
 }
+
+resource "dadcorp_access_policy" "consul_demo" {
+  type = "consul"
+  policy_data = {
+    cluster_id = dadcorp_consul_cluster.demo.id
+    key        = "hashicorp-live/"
+    read       = true
+    write      = true
+    delete     = true
+  }
+}
+
+resource "dadcorp_consul_key" "demo" {
+  cluster_id       = dadcorp_consul_cluster.demo.id
+  access_policy_id = dadcorp_access_policy.consul_demo.id
+  key              = "hashicorp-live/config"
+  value            = "hunter2"
+}
+
+resource "dadcorp_consul_service" "demo" {
+  cluster_id = dadcorp_consul_cluster.demo.id
+  name       = "web"
+  tags       = ["primary"]
+  port       = 8080
+
+  check {
+    name   = "http"
+    status = "passing"
+  }
+}
@@ -1,9 +1,20 @@
-#resource "dadcorp_ip" "demo" {}
+resource "dadcorp_ip_pool" "demo" {
+  region      = "us-va-1"
+  name        = "nomad-demo"
+  cidr        = "10.48.0.0/24"
+  description = "Addresses for the demo Nomad clusters"
+}
+
+resource "dadcorp_ip" "demo" {
+  region      = dadcorp_ip_pool.demo.region
+  pool        = dadcorp_ip_pool.demo.id
+  description = "hashicorp-live bind address"
+}
 
 resource "dadcorp_nomad_cluster" "demo" {
   name       = "hashicorp-live"
   datacenter = "dc2"
-  #	bind_addr  = dadcorp_ip.demo.ip
+  bind_addr  = dadcorp_ip.demo.ip
 
   advertise {
     http = "0.1.0.1"
@@ -26,3 +37,76 @@ resource "dadcorp_nomad_cluster" "demo" {
     }
   }
 }
+
+resource "dadcorp_nomad_cluster" "demo_west" {
+  name       = "hashicorp-live-west"
+  datacenter = "dc3"
+  bind_addr  = "0.1.2.3"
+
+  server {
+    server_join {
+      retry_join = ["${dadcorp_nomad_cluster.demo.advertise[0].serf}:${dadcorp_nomad_cluster.demo.ports[0].serf}"]
+    }
+  }
+}
+
+resource "dadcorp_ip" "demo_west" {
+  region      = dadcorp_ip_pool.demo.region
+  pool        = dadcorp_ip_pool.demo.id
+  description = "hashicorp-live-west ingress"
+
+  attached_to {
+    type       = "nomad"
+    cluster_id = dadcorp_nomad_cluster.demo_west.id
+  }
+}
+
+output "nomad_members" {
+  value = dadcorp_nomad_cluster.demo_west.members[*].name
+}
+
+resource "dadcorp_access_policy" "nomad_jobs" {
+  type = "nomad"
+  policy_data = {
+    cluster_id      = dadcorp_nomad_cluster.demo.id
+    submit_jobs     = true
+    read_job_status = true
+    cancel_jobs     = true
+  }
+}
+
+resource "dadcorp_nomad_job" "demo" {
+  cluster_id       = dadcorp_nomad_cluster.demo.id
+  access_policy_id = dadcorp_access_policy.nomad_jobs.id
+  name             = "web"
+  datacenters      = ["dc2"]
+
+  constraint {
+    attribute = "$${attr.kernel.name}"
+    value     = "linux"
+  }
+
+  task_group {
+    name  = "app"
+    count = 3
+
+    task {
+      name   = "server"
+      driver = "docker"
+      config = {
+        image = "nginx:1.25"
+      }
+    }
+  }
+}
+
+resource "dadcorp_nomad_node_pool" "demo" {
+  cluster_id          = dadcorp_nomad_cluster.demo.id
+  name                = "batch"
+  description         = "Nodes for batch workloads"
+  scheduler_algorithm = "spread"
+
+  meta = {
+    team = "data"
+  }
+}
@@ -1,6 +1,10 @@
 provider "dadcorp" {
   username = "admin"
   password = "hunter2"
+
+  # dadcorpd creates this organization and project when it starts
+  organization = "dadcorp"
+  project      = "demo"
 }
 
 terraform {
@@ -1,12 +1,80 @@
+resource "dadcorp_terraform_agent_pool" "demo" {
+  name = "hashicorp-live-agents"
+}
+
+resource "dadcorp_terraform_agent_token" "demo" {
+  agent_pool_id = dadcorp_terraform_agent_pool.demo.id
+  description   = "hashicorp-live agent"
+}
+
+resource "dadcorp_terraform_oauth_client" "demo" {
+  name             = "hashicorp-live-github"
+  service_provider = "github"
+  oauth_token      = "ghp-hashicorp-live"
+}
+
 resource "dadcorp_terraform_workspace" "demo" {
   name             = "hashicorp-live"
-  agent_pool_id    = "hashicorp-live-agent-pool"
+  agent_pool_id    = dadcorp_terraform_agent_pool.demo.id
   execution_mode   = "agent"
   trigger_prefixes = ["config", "examples", "legacy"]
 
   vcs_repo {
-    oauth_token_id = "hashicorp-live"
+    oauth_token_id = dadcorp_terraform_oauth_client.demo.oauth_token_id
     branch         = "main"
     identifier     = "dadcorp/demo"
   }
 }
+
+resource "dadcorp_terraform_workspace" "downstream" {
+  name = "hashicorp-live-downstream"
+}
+
+resource "dadcorp_terraform_run_trigger" "demo" {
+  workspace_id        = dadcorp_terraform_workspace.downstream.id
+  source_workspace_id = dadcorp_terraform_workspace.demo.id
+}
+
+resource "dadcorp_terraform_variable" "demo_region" {
+  workspace_id = dadcorp_terraform_workspace.demo.id
+  key          = "region"
+  value        = "us-east-1"
+}
+
+resource "dadcorp_terraform_variable_set" "shared" {
+  name          = "hashicorp-live-shared"
+  workspace_ids = [dadcorp_terraform_workspace.demo.id]
+}
+
+resource "dadcorp_terraform_policy_set" "baseline" {
+  name          = "hashicorp-live-baseline"
+  workspace_ids = [dadcorp_terraform_workspace.demo.id]
+
+  policy {
+    name              = "require-owner-tag"
+    enforcement_level = "soft-mandatory"
+    required_tags     = ["owner"]
+  }
+
+  policy {
+    name                     = "no-iam-users"
+    enforcement_level        = "hard-mandatory"
+    forbidden_resource_types = ["aws_iam_user"]
+  }
+}
+
+resource "dadcorp_terraform_variable" "shared_token" {
+  variable_set_id = dadcorp_terraform_variable_set.shared.id
+  key             = "DADCORP_TOKEN"
+  value           = "hunter2"
+  category        = "env"
+  sensitive       = true
+}
+
+data "dadcorp_terraform_workspace_outputs" "demo" {
+  workspace_id = dadcorp_terraform_workspace.demo.id
+}
+
+data "dadcorp_terraform_versions" "stable" {
+  constraint = "~> 1.5"
+}
@@ -3,3 +3,25 @@ resource "dadcorp_vault_cluster" "demo" {
   region = "us-va-2"
   tcp_listener {}
 }
+
+resource "dadcorp_access_policy" "vault_demo" {
+  type = "vault"
+  policy_data = {
+    cluster_id = dadcorp_vault_cluster.demo.id
+    key        = "hashicorp-live/"
+    read       = true
+    write      = true
+    delete     = true
+  }
+}
+
+resource "dadcorp_vault_secret" "demo" {
+  cluster_id       = dadcorp_vault_cluster.demo.id
+  access_policy_id = dadcorp_access_policy.vault_demo.id
+  path             = "hashicorp-live/database"
+
+  data = {
+    username = "hashicorp"
+    password = "hunter2"
+  }
+}
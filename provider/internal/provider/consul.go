@@ -2,8 +2,10 @@ package provider
 
 import (
 	"context"
+	"fmt"
 
 	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -17,6 +19,13 @@ func resourceConsulCluster() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: func(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+			ports := diff.Get("ports").([]interface{})
+			if len(ports) < 1 || ports[0] == nil {
+				return nil
+			}
+			return validateConsulPorts(ports[0].(map[string]interface{}))
+		},
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -65,55 +74,66 @@ func resourceConsulCluster() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"dns": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"http": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"https": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"grpc": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"serf_lan": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"serf_wan": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"server": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:             schema.TypeInt,
+							Optional:         true,
+							Computed:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"sidecar_min_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:             schema.TypeInt,
+							Required:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"sidecar_max_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:             schema.TypeInt,
+							Required:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"expose_min_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:             schema.TypeInt,
+							Required:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 						"expose_max_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:             schema.TypeInt,
+							Required:         true,
+							ValidateDiagFunc: validateConsulPort,
 						},
 					},
 				},
@@ -122,6 +142,77 @@ func resourceConsulCluster() *schema.Resource {
 	}
 }
 
+// validateConsulPort checks that a port is between 1 and 65535, like the
+// API requires.
+func validateConsulPort(val interface{}, path cty.Path) diag.Diagnostics {
+	port, ok := val.(int)
+	if !ok {
+		return diag.Diagnostics{
+			{
+				Severity:      diag.Error,
+				Summary:       "Invalid type",
+				Detail:        "Value must be a number.",
+				AttributePath: path,
+			},
+		}
+	}
+	if port < 1 || port > 65535 {
+		return diag.Diagnostics{
+			{
+				Severity:      diag.Error,
+				Summary:       "Invalid port",
+				Detail:        "Value must be between 1 and 65535.",
+				AttributePath: path,
+			},
+		}
+	}
+	return nil
+}
+
+// validateConsulPorts checks that a cluster's ports are different from each
+// other, and that its sidecar and expose ranges have their min no greater
+// than their max and don't overlap each other or any of the other ports,
+// like the API requires. Ports that aren't known yet are 0, and skipped.
+func validateConsulPorts(ports map[string]interface{}) error {
+	ranges := [][2]string{
+		{"sidecar_min_port", "sidecar_max_port"},
+		{"expose_min_port", "expose_max_port"},
+	}
+	var valid [][2]string
+	for _, r := range ranges {
+		min, max := ports[r[0]].(int), ports[r[1]].(int)
+		if min == 0 || max == 0 {
+			continue
+		}
+		if min > max {
+			return fmt.Errorf("ports.0.%s can't be greater than ports.0.%s", r[0], r[1])
+		}
+		for _, other := range valid {
+			if min <= ports[other[1]].(int) && ports[other[0]].(int) <= max {
+				return fmt.Errorf("ports.0.%s to ports.0.%s overlaps ports.0.%s to ports.0.%s", r[0], r[1], other[0], other[1])
+			}
+		}
+		valid = append(valid, r)
+	}
+	seen := map[int]string{}
+	for _, attr := range []string{"dns", "http", "https", "grpc", "serf_lan", "serf_wan", "server"} {
+		port := ports[attr].(int)
+		if port == 0 {
+			continue
+		}
+		if other, ok := seen[port]; ok {
+			return fmt.Errorf("ports.0.%s is the same port as ports.0.%s", attr, other)
+		}
+		for _, r := range valid {
+			if ports[r[0]].(int) <= port && port <= ports[r[1]].(int) {
+				return fmt.Errorf("ports.0.%s is inside ports.0.%s to ports.0.%s", attr, r[0], r[1])
+			}
+		}
+		seen[port] = attr
+	}
+	return nil
+}
+
 func resourceConsulClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client, err := meta.(*clientFactory).NewClient()
 	if err != nil {
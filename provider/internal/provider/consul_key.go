@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceConsulKey() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceConsulKeyCreate,
+		ReadContext:   resourceConsulKeyRead,
+		UpdateContext: resourceConsulKeyUpdate,
+		DeleteContext: resourceConsulKeyDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceConsulKeyImport,
+		},
+		Schema: map[string]*schema.Schema{
+			"cluster_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"access_policy_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateDiagFunc: func(v interface{}, path cty.Path) diag.Diagnostics {
+					key := v.(string)
+					if key == "" || strings.HasPrefix(key, "/") {
+						return diag.Diagnostics{{
+							Severity:      diag.Error,
+							Summary:       "Invalid key",
+							Detail:        "Keys can't be empty or start with a slash.",
+							AttributePath: path,
+						}}
+					}
+					return nil
+				},
+			},
+			"value": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"flags": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"modify_index": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// setConsulKey sets the resource's attributes from pair.
+func setConsulKey(d *schema.ResourceData, pair dadcorp.ConsulKVPair) {
+	d.SetId(pair.ClusterID + "/" + pair.Key)
+	d.Set("cluster_id", pair.ClusterID)
+	d.Set("key", pair.Key)
+	d.Set("value", pair.Value)
+	d.Set("flags", int(pair.Flags))
+	d.Set("modify_index", int(pair.ModifyIndex))
+}
+
+func resourceConsulKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	resp, err := client.Consul.Clusters.KV.Put(ctx, d.Get("cluster_id").(string), d.Get("key").(string), dadcorp.ConsulKVWrite{
+		Value: d.Get("value").(string),
+		Flags: uint64(d.Get("flags").(int)),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setConsulKey(d, resp)
+	return nil
+}
+
+func resourceConsulKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	resp, err := client.Consul.Clusters.KV.Get(ctx, d.Get("cluster_id").(string), d.Get("key").(string))
+	if err != nil {
+		if err == dadcorp.ErrConsulKeyNotFound || err == dadcorp.ErrConsulClusterNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	setConsulKey(d, resp)
+	return nil
+}
+
+// resourceConsulKeyUpdate writes the key using the modify index from the
+// last refresh, so changes made outside of Terraform since then aren't
+// silently overwritten.
+func resourceConsulKeyUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	cas := uint64(d.Get("modify_index").(int))
+	resp, err := client.Consul.Clusters.KV.Put(ctx, d.Get("cluster_id").(string), d.Get("key").(string), dadcorp.ConsulKVWrite{
+		Value: d.Get("value").(string),
+		Flags: uint64(d.Get("flags").(int)),
+		CAS:   &cas,
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setConsulKey(d, resp)
+	return nil
+}
+
+func resourceConsulKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	_, err = client.Consul.Clusters.KV.Delete(ctx, d.Get("cluster_id").(string), d.Get("key").(string), nil)
+	if err != nil && err != dadcorp.ErrConsulKeyNotFound {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceConsulKeyImport imports keys using IDs in the format
+// ACCESS_POLICY_ID/CLUSTER_ID/KEY, as the access policy is needed to read
+// the key.
+func resourceConsulKeyImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("import IDs must be in the format ACCESS_POLICY_ID/CLUSTER_ID/KEY, got %q", d.Id())
+	}
+	d.Set("access_policy_id", parts[0])
+	d.Set("cluster_id", parts[1])
+	d.Set("key", parts[2])
+	d.SetId(parts[1] + "/" + parts[2])
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceConsulService() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceConsulServiceCreate,
+		ReadContext:   resourceConsulServiceRead,
+		UpdateContext: resourceConsulServiceUpdate,
+		DeleteContext: resourceConsulServiceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceConsulServiceImport,
+		},
+		Schema: map[string]*schema.Schema{
+			"cluster_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"service_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"tags": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"address": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"port": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"check": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"check_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+							ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+								switch val.(string) {
+								case dadcorp.ConsulHealthPassing, dadcorp.ConsulHealthWarning, dadcorp.ConsulHealthCritical:
+									return nil
+								}
+								return diag.Diagnostics{{
+									Severity:      diag.Error,
+									Summary:       "Invalid check status",
+									Detail:        `Value must be one of "passing", "warning", or "critical".`,
+									AttributePath: path,
+								}}
+							},
+						},
+						"notes": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// consulServiceFromResourceData returns the service instance described by
+// d.
+func consulServiceFromResourceData(d *schema.ResourceData) dadcorp.ConsulCatalogService {
+	service := dadcorp.ConsulCatalogService{
+		ID:      d.Get("service_id").(string),
+		Name:    d.Get("name").(string),
+		Address: d.Get("address").(string),
+		Port:    d.Get("port").(int),
+	}
+	for _, tag := range d.Get("tags").([]interface{}) {
+		s, _ := tag.(string)
+		service.Tags = append(service.Tags, s)
+	}
+	for _, check := range d.Get("check").([]interface{}) {
+		c := check.(map[string]interface{})
+		service.Checks = append(service.Checks, dadcorp.ConsulHealthCheck{
+			ID:     c["check_id"].(string),
+			Name:   c["name"].(string),
+			Status: c["status"].(string),
+			Notes:  c["notes"].(string),
+		})
+	}
+	return service
+}
+
+// setConsulService sets the resource's attributes from service.
+func setConsulService(d *schema.ResourceData, service dadcorp.ConsulCatalogService) {
+	d.SetId(service.ClusterID + "/" + service.ID)
+	d.Set("cluster_id", service.ClusterID)
+	d.Set("name", service.Name)
+	d.Set("service_id", service.ID)
+	d.Set("tags", service.Tags)
+	d.Set("address", service.Address)
+	d.Set("port", service.Port)
+	checks := make([]map[string]interface{}, 0, len(service.Checks))
+	for _, check := range service.Checks {
+		checks = append(checks, map[string]interface{}{
+			"check_id": check.ID,
+			"name":     check.Name,
+			"status":   check.Status,
+			"notes":    check.Notes,
+		})
+	}
+	d.Set("check", checks)
+	d.Set("status", service.Status)
+}
+
+func resourceConsulServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.Consul.Clusters.Services.Register(ctx, d.Get("cluster_id").(string), consulServiceFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setConsulService(d, resp)
+	return nil
+}
+
+func resourceConsulServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.Consul.Clusters.Services.Get(ctx, d.Get("cluster_id").(string), d.Get("service_id").(string))
+	if err != nil {
+		if err == dadcorp.ErrConsulServiceNotFound || err == dadcorp.ErrConsulClusterNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	setConsulService(d, resp)
+	return nil
+}
+
+// resourceConsulServiceUpdate registers the service instance again under
+// the same ID, which replaces it.
+func resourceConsulServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceConsulServiceCreate(ctx, d, meta)
+}
+
+func resourceConsulServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.Consul.Clusters.Services.Deregister(ctx, d.Get("cluster_id").(string), d.Get("service_id").(string))
+	if err != nil && err != dadcorp.ErrConsulServiceNotFound {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceConsulServiceImport imports service instances using IDs in the
+// format CLUSTER_ID/SERVICE_ID.
+func resourceConsulServiceImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("import IDs must be in the format CLUSTER_ID/SERVICE_ID, got %q", d.Id())
+	}
+	d.Set("cluster_id", parts[0])
+	d.Set("service_id", parts[1])
+	return []*schema.ResourceData{d}, nil
+}
@@ -1,9 +1,12 @@
 package provider
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccConsulCluster_basic(t *testing.T) {
@@ -33,6 +36,61 @@ func TestAccConsulCluster_basic(t *testing.T) {
 	})
 }
 
+func TestAccConsulCluster_ports(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccConfigConsulCluster_ports(70000, 18500, 20000, 21000, 30000, 31000),
+				ExpectError: regexp.MustCompile("Invalid port"),
+			},
+			{
+				Config:      testAccConfigConsulCluster_ports(18600, 18600, 20000, 21000, 30000, 31000),
+				ExpectError: regexp.MustCompile("ports.0.http is the same port as ports.0.dns"),
+			},
+			{
+				Config:      testAccConfigConsulCluster_ports(18600, 20500, 20000, 21000, 30000, 31000),
+				ExpectError: regexp.MustCompile("ports.0.http is inside ports.0.sidecar_min_port to ports.0.sidecar_max_port"),
+			},
+			{
+				Config:      testAccConfigConsulCluster_ports(18600, 18500, 21000, 20000, 30000, 31000),
+				ExpectError: regexp.MustCompile("ports.0.sidecar_min_port can't be greater than ports.0.sidecar_max_port"),
+			},
+			{
+				Config:      testAccConfigConsulCluster_ports(18600, 18500, 20000, 21000, 20500, 31000),
+				ExpectError: regexp.MustCompile("overlaps ports.0.sidecar_min_port to ports.0.sidecar_max_port"),
+			},
+			{
+				Config: testAccConfigConsulCluster_ports(18600, 18500, 20000, 21000, 30000, 31000),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_consul_cluster.test", "ports.0.dns", "18600"),
+					resource.TestCheckResourceAttr("dadcorp_consul_cluster.test", "ports.0.server", "8300"),
+				),
+			},
+		},
+	})
+}
+
+func testAccConfigConsulCluster_ports(dns, http, sidecarMin, sidecarMax, exposeMin, exposeMax int) string {
+	return fmt.Sprintf(`
+resource "dadcorp_consul_cluster" "test" {
+  name = "test ports cluster"
+
+  ports {
+    dns = %d
+    http = %d
+    sidecar_min_port = %d
+    sidecar_max_port = %d
+    expose_min_port = %d
+    expose_max_port = %d
+  }
+}
+`, dns, http, sidecarMin, sidecarMax, exposeMin, exposeMax)
+}
+
 func testAccConfigConsulCluster_basic() string {
 	return `
 resource "dadcorp_consul_cluster" "test" {
@@ -90,3 +148,138 @@ resource "dadcorp_consul_cluster" "test" {
 }
 `
 }
+
+func TestAccConsulKey_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigConsulKey("app/config", "v1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_consul_key.test", "value", "v1"),
+					resource.TestCheckResourceAttrSet("dadcorp_consul_key.test", "modify_index"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_consul_key.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: testAccConsulKeyImportID("dadcorp_consul_key.test"),
+			},
+			{
+				Config: testAccConfigConsulKey("app/config", "v2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_consul_key.test", "value", "v2"),
+				),
+			},
+			{
+				Config:      testAccConfigConsulKey("other/config", "v1"),
+				ExpectError: regexp.MustCompile("access policy doesn't allow it"),
+			},
+			{
+				Config:      testAccConfigConsulKey("/app/config", "v1"),
+				ExpectError: regexp.MustCompile("Invalid key"),
+			},
+		},
+	})
+}
+
+func testAccConsulKeyImportID(name string) resource.ImportStateIdFunc {
+	return func(state *sdkterraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", name)
+		}
+		return rs.Primary.Attributes["access_policy_id"] + "/" + rs.Primary.Attributes["cluster_id"] + "/" + rs.Primary.Attributes["key"], nil
+	}
+}
+
+func testAccConfigConsulKey(key, value string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_consul_cluster" "test" {
+  name = "test kv cluster"
+}
+
+resource "dadcorp_access_policy" "test" {
+  type = "consul"
+  policy_data = {
+    cluster_id = dadcorp_consul_cluster.test.id
+    key = "app/"
+    read = true
+    write = true
+    delete = true
+  }
+}
+
+resource "dadcorp_consul_key" "test" {
+  cluster_id = dadcorp_consul_cluster.test.id
+  access_policy_id = dadcorp_access_policy.test.id
+  key = %q
+  value = %q
+}
+`, key, value)
+}
+
+func TestAccConsulService_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigConsulService(8080, "critical"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "service_id", "web"),
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "address", "10.45.0.1"),
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "check.0.check_id", "http"),
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "status", "critical"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_consul_service.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigConsulService(8081, "passing"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "port", "8081"),
+					resource.TestCheckResourceAttr("dadcorp_consul_service.test", "status", "passing"),
+				),
+			},
+			{
+				Config:      testAccConfigConsulService(70000, "passing"),
+				ExpectError: regexp.MustCompile("port must be between 0 and 65535"),
+			},
+			{
+				Config:      testAccConfigConsulService(8081, "unknown"),
+				ExpectError: regexp.MustCompile("Invalid check status"),
+			},
+		},
+	})
+}
+
+func testAccConfigConsulService(port int, status string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_consul_cluster" "test" {
+  name = "test catalog cluster"
+  bind_addr = "10.45.0.1"
+}
+
+resource "dadcorp_consul_service" "test" {
+  cluster_id = dadcorp_consul_cluster.test.id
+  name = "web"
+  tags = ["primary"]
+  port = %d
+
+  check {
+    name = "http"
+    status = %q
+  }
+}
+`, port, status)
+}
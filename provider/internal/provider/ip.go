@@ -2,7 +2,10 @@ package provider
 
 import (
 	"context"
+	"net"
 
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -11,26 +14,183 @@ func resourceIP() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceIPCreate,
 		ReadContext:   resourceIPRead,
+		UpdateContext: resourceIPUpdate,
 		DeleteContext: resourceIPDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
 		Schema: map[string]*schema.Schema{
+			"region": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"pool": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
 			"ip": {
 				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					oldIP, newIP := net.ParseIP(old), net.ParseIP(new)
+					return oldIP != nil && oldIP.Equal(newIP)
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"reserved": {
+				Type:     schema.TypeBool,
 				Computed: true,
 			},
+			"attached_to": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+								switch val.(string) {
+								case dadcorp.IPAttachmentVault, dadcorp.IPAttachmentConsul, dadcorp.IPAttachmentNomad:
+									return nil
+								}
+								return diag.Diagnostics{{
+									Severity:      diag.Error,
+									Summary:       "Invalid attachment type",
+									Detail:        `Value must be one of "vault", "consul", or "nomad".`,
+									AttributePath: path,
+								}}
+							},
+						},
+						"cluster_id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+// ipAttachmentFromResourceData returns the cluster d says the address should
+// be attached to, or nil if it shouldn't be attached.
+func ipAttachmentFromResourceData(d *schema.ResourceData) *dadcorp.IPAttachment {
+	attachments := d.Get("attached_to").([]interface{})
+	if len(attachments) < 1 || attachments[0] == nil {
+		return nil
+	}
+	attachment := attachments[0].(map[string]interface{})
+	return &dadcorp.IPAttachment{
+		Type:      attachment["type"].(string),
+		ClusterID: attachment["cluster_id"].(string),
+	}
+}
+
+// setIP sets the resource's attributes from address.
+func setIP(d *schema.ResourceData, address dadcorp.IPAddress) {
+	d.SetId(address.ID)
+	d.Set("region", address.Region)
+	d.Set("pool", address.PoolID)
+	d.Set("ip", address.Address)
+	d.Set("description", address.Description)
+	d.Set("reserved", address.Reserved)
+	if address.AttachedTo == nil {
+		d.Set("attached_to", nil)
+		return
+	}
+	d.Set("attached_to", []interface{}{map[string]interface{}{
+		"type":       address.AttachedTo.Type,
+		"cluster_id": address.AttachedTo.ClusterID,
+	}})
+}
+
 func resourceIPCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	d.SetId("static-ip")
-	d.Set("ip", "1.2.3.4")
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Addresses.Allocate(ctx, dadcorp.IPAddress{
+		Region:      d.Get("region").(string),
+		PoolID:      d.Get("pool").(string),
+		Address:     d.Get("ip").(string),
+		Description: d.Get("description").(string),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(resp.ID)
+	if attachment := ipAttachmentFromResourceData(d); attachment != nil {
+		resp, err = client.IP.Addresses.Attach(ctx, resp.ID, *attachment)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	setIP(d, resp)
 	return nil
 }
 
 func resourceIPRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Addresses.Get(ctx, d.Id())
+	if err != nil {
+		if err == dadcorp.ErrIPAddressNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	setIP(d, resp)
+	return nil
+}
+
+func resourceIPUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Addresses.SetDescription(ctx, d.Id(), d.Get("description").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.HasChange("attached_to") {
+		if resp.AttachedTo != nil {
+			resp, err = client.IP.Addresses.Detach(ctx, d.Id())
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		if attachment := ipAttachmentFromResourceData(d); attachment != nil {
+			resp, err = client.IP.Addresses.Attach(ctx, d.Id(), *attachment)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+	setIP(d, resp)
 	return nil
 }
 
 func resourceIPDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.IP.Addresses.Release(ctx, d.Id())
+	if err != nil && err != dadcorp.ErrIPAddressNotFound {
+		return diag.FromErr(err)
+	}
 	return nil
 }
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"net"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceIPPool() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceIPPoolCreate,
+		ReadContext:   resourceIPPoolRead,
+		UpdateContext: resourceIPPoolUpdate,
+		DeleteContext: resourceIPPoolDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"region": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"cidr": {
+				Type:     schema.TypeString,
+				Required: true,
+				// the API stores the network the block describes, so
+				// 10.0.0.7/24 comes back as 10.0.0.0/24
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					_, oldNetwork, err := net.ParseCIDR(old)
+					if err != nil {
+						return false
+					}
+					_, newNetwork, err := net.ParseCIDR(new)
+					if err != nil {
+						return false
+					}
+					return oldNetwork.String() == newNetwork.String()
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+// ipPoolFromResourceData returns the IP pool described by d.
+func ipPoolFromResourceData(d *schema.ResourceData) dadcorp.IPPool {
+	return dadcorp.IPPool{
+		ID:          d.Id(),
+		Region:      d.Get("region").(string),
+		Name:        d.Get("name").(string),
+		CIDR:        d.Get("cidr").(string),
+		Description: d.Get("description").(string),
+	}
+}
+
+// setIPPool sets the resource's attributes from pool.
+func setIPPool(d *schema.ResourceData, pool dadcorp.IPPool) {
+	d.SetId(pool.ID)
+	d.Set("region", pool.Region)
+	d.Set("name", pool.Name)
+	d.Set("cidr", pool.CIDR)
+	d.Set("description", pool.Description)
+}
+
+func resourceIPPoolCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Pools.Create(ctx, ipPoolFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setIPPool(d, resp)
+	return nil
+}
+
+func resourceIPPoolRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Pools.Get(ctx, d.Id())
+	if err != nil {
+		if err == dadcorp.ErrIPPoolNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	setIPPool(d, resp)
+	return nil
+}
+
+func resourceIPPoolUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.IP.Pools.Update(ctx, ipPoolFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setIPPool(d, resp)
+	return nil
+}
+
+func resourceIPPoolDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.IP.Pools.Delete(ctx, d.Id())
+	if err != nil && err != dadcorp.ErrIPPoolNotFound {
+		return diag.FromErr(err)
+	}
+	return nil
+}
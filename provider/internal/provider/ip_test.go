@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccIP_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigIP("ingress", "consul"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_ip_pool.test", "cidr", "10.50.0.0/29"),
+					resource.TestCheckResourceAttrPair("dadcorp_ip.allocated", "pool", "dadcorp_ip_pool.test", "id"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "ip", "10.50.0.1"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "reserved", "false"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "description", "ingress"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "attached_to.0.type", "consul"),
+					resource.TestCheckResourceAttrPair("dadcorp_ip.allocated", "attached_to.0.cluster_id", "dadcorp_consul_cluster.test", "id"),
+					resource.TestCheckResourceAttr("dadcorp_ip.reserved", "ip", "10.50.0.6"),
+					resource.TestCheckResourceAttr("dadcorp_ip.reserved", "reserved", "true"),
+					resource.TestCheckResourceAttr("dadcorp_ip.reserved", "attached_to.#", "0"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_ip.allocated",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "dadcorp_ip_pool.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigIP("nomad ingress", "nomad"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "ip", "10.50.0.1"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "description", "nomad ingress"),
+					resource.TestCheckResourceAttr("dadcorp_ip.allocated", "attached_to.0.type", "nomad"),
+					resource.TestCheckResourceAttrPair("dadcorp_ip.allocated", "attached_to.0.cluster_id", "dadcorp_nomad_cluster.test", "id"),
+				),
+			},
+			{
+				Config:      testAccConfigIP("ingress", "terraform"),
+				ExpectError: regexp.MustCompile("Invalid attachment type"),
+			},
+			{
+				Config:      testAccConfigIPOutOfRange(),
+				ExpectError: regexp.MustCompile("isn't a usable address"),
+			},
+		},
+	})
+}
+
+func testAccConfigIPPool() string {
+	return `
+resource "dadcorp_ip_pool" "test" {
+  region = "us-va-1"
+  name = "ip test pool"
+  cidr = "10.50.0.3/29"
+  description = "addresses for the IP tests"
+}
+`
+}
+
+func testAccConfigIP(description, attachTo string) string {
+	return testAccConfigIPPool() + fmt.Sprintf(`
+resource "dadcorp_consul_cluster" "test" {
+  name = "ip test consul cluster"
+}
+
+resource "dadcorp_nomad_cluster" "test" {
+  name = "ip test nomad cluster"
+  datacenter = "dc1"
+}
+
+locals {
+  clusters = {
+    consul = dadcorp_consul_cluster.test.id
+    nomad = dadcorp_nomad_cluster.test.id
+    terraform = dadcorp_nomad_cluster.test.id
+  }
+}
+
+resource "dadcorp_ip" "allocated" {
+  region = dadcorp_ip_pool.test.region
+  pool = dadcorp_ip_pool.test.id
+  description = %q
+
+  attached_to {
+    type = %q
+    cluster_id = local.clusters[%q]
+  }
+}
+
+resource "dadcorp_ip" "reserved" {
+  region = dadcorp_ip_pool.test.region
+  ip = "10.50.0.6"
+  description = "reserved for DNS"
+
+  depends_on = [dadcorp_ip_pool.test]
+}
+`, description, attachTo, attachTo)
+}
+
+func testAccConfigIPOutOfRange() string {
+	return testAccConfigIPPool() + `
+resource "dadcorp_ip" "reserved" {
+  region = dadcorp_ip_pool.test.region
+  ip = "10.50.0.7"
+
+  depends_on = [dadcorp_ip_pool.test]
+}
+`
+}
@@ -157,10 +157,64 @@ func resourceNomadCluster() *schema.Resource {
 					},
 				},
 			},
+			"members": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cluster_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"datacenter": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"port": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+// setNomadMembers sets the members attribute to the members of the gossip
+// pool the cluster belongs to.
+func setNomadMembers(ctx context.Context, client *dadcorp.Client, d *schema.ResourceData) error {
+	members, err := client.Nomad.Clusters.Members(ctx, d.Id())
+	if err != nil {
+		return err
+	}
+	list := make([]map[string]interface{}, 0, len(members))
+	for _, member := range members {
+		list = append(list, map[string]interface{}{
+			"cluster_id": member.ClusterID,
+			"name":       member.Name,
+			"datacenter": member.Datacenter,
+			"address":    member.Address,
+			"port":       member.Port,
+			"status":     member.Status,
+		})
+	}
+	d.Set("members", list)
+	return nil
+}
+
 func resourceNomadClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client, err := meta.(*clientFactory).NewClient()
 	if err != nil {
@@ -243,6 +297,10 @@ func resourceNomadClusterCreate(ctx context.Context, d *schema.ResourceData, met
 			},
 		},
 	})
+	err = setNomadMembers(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
 
@@ -288,6 +346,10 @@ func resourceNomadClusterRead(ctx context.Context, d *schema.ResourceData, meta
 			},
 		},
 	})
+	err = setNomadMembers(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
 
@@ -373,6 +435,10 @@ func resourceNomadClusterUpdate(ctx context.Context, d *schema.ResourceData, met
 			},
 		},
 	})
+	err = setNomadMembers(ctx, client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
 
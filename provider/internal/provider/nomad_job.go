@@ -0,0 +1,375 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceNomadJob() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceNomadJobCreate,
+		ReadContext:   resourceNomadJobRead,
+		UpdateContext: resourceNomadJobUpdate,
+		DeleteContext: resourceNomadJobDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceNomadJobImport,
+		},
+		Schema: map[string]*schema.Schema{
+			"cluster_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"access_policy_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"job_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  dadcorp.NomadJobTypeService,
+				ForceNew: true,
+				ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+					switch val.(string) {
+					case dadcorp.NomadJobTypeService, dadcorp.NomadJobTypeBatch:
+						return nil
+					}
+					return diag.Diagnostics{{
+						Severity:      diag.Error,
+						Summary:       "Invalid job type",
+						Detail:        `Value must be one of "service" or "batch".`,
+						AttributePath: path,
+					}}
+				},
+			},
+			"datacenters": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"node_pool": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  dadcorp.NomadNodePoolDefault,
+			},
+			"constraint": nomadConstraintSchema(),
+			"task_group": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"count": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  1,
+						},
+						"constraint": nomadConstraintSchema(),
+						"task": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"driver": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"config": {
+										Type:     schema.TypeMap,
+										Optional: true,
+										Elem: &schema.Schema{
+											Type: schema.TypeString,
+										},
+									},
+									"resources": {
+										Type:     schema.TypeList,
+										Optional: true,
+										Computed: true,
+										MaxItems: 1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"cpu": {
+													Type:     schema.TypeInt,
+													Optional: true,
+													Default:  100,
+												},
+												"memory": {
+													Type:     schema.TypeInt,
+													Optional: true,
+													Default:  300,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"version": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status_description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// nomadConstraintSchema returns the schema of the constraint blocks jobs and
+// task groups can have.
+func nomadConstraintSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"attribute": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"operator": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Default:  "=",
+					ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+						switch val.(string) {
+						case "=", "!=", "<", "<=", ">", ">=", "regexp", "set_contains", "is_set", "is_not_set":
+							return nil
+						}
+						return diag.Diagnostics{{
+							Severity:      diag.Error,
+							Summary:       "Invalid constraint operator",
+							Detail:        `Value must be one of "=", "!=", "<", "<=", ">", ">=", "regexp", "set_contains", "is_set", or "is_not_set".`,
+							AttributePath: path,
+						}}
+					},
+				},
+				"value": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+		},
+	}
+}
+
+// nomadConstraintsFromList returns the constraints described by the
+// constraint blocks in list.
+func nomadConstraintsFromList(list []interface{}) []dadcorp.NomadConstraint {
+	var constraints []dadcorp.NomadConstraint
+	for _, item := range list {
+		c := item.(map[string]interface{})
+		constraints = append(constraints, dadcorp.NomadConstraint{
+			Attribute: c["attribute"].(string),
+			Operator:  c["operator"].(string),
+			Value:     c["value"].(string),
+		})
+	}
+	return constraints
+}
+
+// nomadConstraintsToList returns the constraint blocks describing
+// constraints.
+func nomadConstraintsToList(constraints []dadcorp.NomadConstraint) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(constraints))
+	for _, constraint := range constraints {
+		list = append(list, map[string]interface{}{
+			"attribute": constraint.Attribute,
+			"operator":  constraint.Operator,
+			"value":     constraint.Value,
+		})
+	}
+	return list
+}
+
+// nomadJobFromResourceData returns the job described by d.
+func nomadJobFromResourceData(d *schema.ResourceData) dadcorp.NomadJob {
+	job := dadcorp.NomadJob{
+		ID:          d.Get("job_id").(string),
+		Name:        d.Get("name").(string),
+		Type:        d.Get("type").(string),
+		NodePool:    d.Get("node_pool").(string),
+		Constraints: nomadConstraintsFromList(d.Get("constraint").([]interface{})),
+	}
+	for _, dc := range d.Get("datacenters").([]interface{}) {
+		s, _ := dc.(string)
+		job.Datacenters = append(job.Datacenters, s)
+	}
+	for _, item := range d.Get("task_group").([]interface{}) {
+		g := item.(map[string]interface{})
+		group := dadcorp.NomadTaskGroup{
+			Name:        g["name"].(string),
+			Count:       g["count"].(int),
+			Constraints: nomadConstraintsFromList(g["constraint"].([]interface{})),
+		}
+		for _, taskItem := range g["task"].([]interface{}) {
+			t := taskItem.(map[string]interface{})
+			task := dadcorp.NomadTask{
+				Name:   t["name"].(string),
+				Driver: t["driver"].(string),
+			}
+			for k, v := range t["config"].(map[string]interface{}) {
+				if task.Config == nil {
+					task.Config = map[string]string{}
+				}
+				task.Config[k], _ = v.(string)
+			}
+			if resources := t["resources"].([]interface{}); len(resources) > 0 && resources[0] != nil {
+				task.Resources = dadcorp.NomadResources{
+					CPU:      resources[0].(map[string]interface{})["cpu"].(int),
+					MemoryMB: resources[0].(map[string]interface{})["memory"].(int),
+				}
+			}
+			group.Tasks = append(group.Tasks, task)
+		}
+		job.TaskGroups = append(job.TaskGroups, group)
+	}
+	return job
+}
+
+// setNomadJob sets the resource's attributes from job.
+func setNomadJob(d *schema.ResourceData, job dadcorp.NomadJob) {
+	d.SetId(job.ClusterID + "/" + job.ID)
+	d.Set("cluster_id", job.ClusterID)
+	d.Set("name", job.Name)
+	d.Set("job_id", job.ID)
+	d.Set("type", job.Type)
+	d.Set("datacenters", job.Datacenters)
+	d.Set("node_pool", job.NodePool)
+	d.Set("constraint", nomadConstraintsToList(job.Constraints))
+	groups := make([]map[string]interface{}, 0, len(job.TaskGroups))
+	for _, group := range job.TaskGroups {
+		tasks := make([]map[string]interface{}, 0, len(group.Tasks))
+		for _, task := range group.Tasks {
+			tasks = append(tasks, map[string]interface{}{
+				"name":   task.Name,
+				"driver": task.Driver,
+				"config": task.Config,
+				"resources": []map[string]interface{}{
+					{
+						"cpu":    task.Resources.CPU,
+						"memory": task.Resources.MemoryMB,
+					},
+				},
+			})
+		}
+		groups = append(groups, map[string]interface{}{
+			"name":       group.Name,
+			"count":      group.Count,
+			"constraint": nomadConstraintsToList(group.Constraints),
+			"task":       tasks,
+		})
+	}
+	d.Set("task_group", groups)
+	d.Set("version", int(job.Version))
+	d.Set("status", job.Status)
+	d.Set("status_description", job.StatusDescription)
+}
+
+func resourceNomadJobCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	resp, err := client.Nomad.Clusters.Jobs.Submit(ctx, d.Get("cluster_id").(string), nomadJobFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setNomadJob(d, resp)
+	return nil
+}
+
+// resourceNomadJobRead removes jobs that have been canceled from the state,
+// so they're submitted again.
+func resourceNomadJobRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	resp, err := client.Nomad.Clusters.Jobs.Get(ctx, d.Get("cluster_id").(string), d.Get("job_id").(string))
+	if err != nil {
+		if err == dadcorp.ErrNomadJobNotFound || err == dadcorp.ErrNomadClusterNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	if resp.Stop {
+		d.SetId("")
+		return nil
+	}
+	setNomadJob(d, resp)
+	return nil
+}
+
+// resourceNomadJobUpdate submits the job again under the same ID, which
+// replaces it with a new version.
+func resourceNomadJobUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceNomadJobCreate(ctx, d, meta)
+}
+
+func resourceNomadJobDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ctx = dadcorp.WithAccessPolicy(ctx, d.Get("access_policy_id").(string))
+	_, err = client.Nomad.Clusters.Jobs.Cancel(ctx, d.Get("cluster_id").(string), d.Get("job_id").(string))
+	if err != nil && err != dadcorp.ErrNomadJobNotFound {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceNomadJobImport imports jobs using IDs in the format
+// ACCESS_POLICY_ID/CLUSTER_ID/JOB_ID, as the access policy is needed to read
+// the job.
+func resourceNomadJobImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("import IDs must be in the format ACCESS_POLICY_ID/CLUSTER_ID/JOB_ID, got %q", d.Id())
+	}
+	d.Set("access_policy_id", parts[0])
+	d.Set("cluster_id", parts[1])
+	d.Set("job_id", parts[2])
+	d.SetId(parts[1] + "/" + parts[2])
+	return []*schema.ResourceData{d}, nil
+}
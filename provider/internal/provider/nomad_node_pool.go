@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceNomadNodePool() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceNomadNodePoolCreate,
+		ReadContext:   resourceNomadNodePoolRead,
+		UpdateContext: resourceNomadNodePoolUpdate,
+		DeleteContext: resourceNomadNodePoolDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceNomadNodePoolImport,
+		},
+		Schema: map[string]*schema.Schema{
+			"cluster_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+					switch val.(string) {
+					case dadcorp.NomadNodePoolDefault, dadcorp.NomadNodePoolAll:
+						return diag.Diagnostics{{
+							Severity:      diag.Error,
+							Summary:       "Reserved node pool name",
+							Detail:        `The "default" and "all" node pools are built in to every cluster and can't be managed.`,
+							AttributePath: path,
+						}}
+					}
+					return nil
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"meta": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"scheduler_algorithm": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  dadcorp.NomadSchedulerBinpack,
+				ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
+					switch val.(string) {
+					case dadcorp.NomadSchedulerBinpack, dadcorp.NomadSchedulerSpread:
+						return nil
+					}
+					return diag.Diagnostics{{
+						Severity:      diag.Error,
+						Summary:       "Invalid scheduler algorithm",
+						Detail:        `Value must be one of "binpack" or "spread".`,
+						AttributePath: path,
+					}}
+				},
+			},
+		},
+	}
+}
+
+// nomadNodePoolFromResourceData returns the node pool described by d.
+func nomadNodePoolFromResourceData(d *schema.ResourceData) dadcorp.NomadNodePool {
+	pool := dadcorp.NomadNodePool{
+		Name:               d.Get("name").(string),
+		Description:        d.Get("description").(string),
+		SchedulerAlgorithm: d.Get("scheduler_algorithm").(string),
+	}
+	for k, v := range d.Get("meta").(map[string]interface{}) {
+		if pool.Meta == nil {
+			pool.Meta = map[string]string{}
+		}
+		pool.Meta[k], _ = v.(string)
+	}
+	return pool
+}
+
+// setNomadNodePool sets the resource's attributes from pool.
+func setNomadNodePool(d *schema.ResourceData, pool dadcorp.NomadNodePool) {
+	d.SetId(pool.ClusterID + "/" + pool.Name)
+	d.Set("cluster_id", pool.ClusterID)
+	d.Set("name", pool.Name)
+	d.Set("description", pool.Description)
+	d.Set("meta", pool.Meta)
+	d.Set("scheduler_algorithm", pool.SchedulerAlgorithm)
+}
+
+func resourceNomadNodePoolCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.Nomad.Clusters.NodePools.Create(ctx, d.Get("cluster_id").(string), nomadNodePoolFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setNomadNodePool(d, resp)
+	return nil
+}
+
+func resourceNomadNodePoolRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.Nomad.Clusters.NodePools.Get(ctx, d.Get("cluster_id").(string), d.Get("name").(string))
+	if err != nil {
+		if err == dadcorp.ErrNomadNodePoolNotFound || err == dadcorp.ErrNomadClusterNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	setNomadNodePool(d, resp)
+	return nil
+}
+
+func resourceNomadNodePoolUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	resp, err := client.Nomad.Clusters.NodePools.Update(ctx, d.Get("cluster_id").(string), nomadNodePoolFromResourceData(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setNomadNodePool(d, resp)
+	return nil
+}
+
+func resourceNomadNodePoolDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*clientFactory).NewClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.Nomad.Clusters.NodePools.Delete(ctx, d.Get("cluster_id").(string), d.Get("name").(string))
+	if err != nil && err != dadcorp.ErrNomadNodePoolNotFound {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceNomadNodePoolImport imports node pools using IDs in the format
+// CLUSTER_ID/NAME.
+func resourceNomadNodePoolImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("import IDs must be in the format CLUSTER_ID/NAME, got %q", d.Id())
+	}
+	d.Set("cluster_id", parts[0])
+	d.Set("name", parts[1])
+	return []*schema.ResourceData{d}, nil
+}
@@ -1,9 +1,12 @@
 package provider
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccNomadCluster_basic(t *testing.T) {
@@ -89,3 +92,264 @@ resource "dadcorp_nomad_cluster" "test" {
 }
 `
 }
+
+func TestAccNomadCluster_members(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigNomadCluster_members(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joining", "members.#", "2"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joining", "members.0.name", "members joined"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joining", "members.0.address", "10.48.0.1"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joining", "members.0.port", "4648"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joining", "members.0.status", "alive"),
+					resource.TestCheckResourceAttrPair("dadcorp_nomad_cluster.joining", "members.1.cluster_id", "dadcorp_nomad_cluster.joining", "id"),
+				),
+			},
+			{
+				// the joined cluster only sees the new member once it's
+				// refreshed
+				Config: testAccConfigNomadCluster_members(),
+				Check:  resource.TestCheckResourceAttr("dadcorp_nomad_cluster.joined", "members.#", "2"),
+			},
+			{
+				Config:      testAccConfigNomadCluster_invalidJoin(),
+				ExpectError: regexp.MustCompile("retry_join and start_join addresses"),
+			},
+		},
+	})
+}
+
+func testAccConfigNomadCluster_members() string {
+	return `
+resource "dadcorp_nomad_cluster" "joined" {
+  name = "members joined"
+  datacenter = "dc1"
+  bind_addr = "10.48.0.1"
+}
+
+resource "dadcorp_nomad_cluster" "joining" {
+  name = "members joining"
+  datacenter = "dc1"
+  bind_addr = "10.48.0.2"
+
+  server {
+    server_join {
+      start_join = ["${dadcorp_nomad_cluster.joined.bind_addr}:4648"]
+    }
+  }
+}
+`
+}
+
+func testAccConfigNomadCluster_invalidJoin() string {
+	return `
+resource "dadcorp_nomad_cluster" "joined" {
+  name = "members joined"
+  datacenter = "dc1"
+  bind_addr = "10.48.0.1"
+}
+
+resource "dadcorp_nomad_cluster" "joining" {
+  name = "members joining"
+  datacenter = "dc1"
+  bind_addr = "10.48.0.2"
+
+  server {
+    server_join {
+      start_join = ["10.48.0.1:99999"]
+    }
+  }
+}
+`
+}
+
+func TestAccNomadJob_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigNomadJob(true, "dc1", 2),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "job_id", "web"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "datacenters.0", "dc1"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "version", "0"),
+					resource.TestCheckResourceAttrSet("dadcorp_nomad_job.test", "status"),
+				),
+			},
+			{
+				ResourceName:            "dadcorp_nomad_job.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateIdFunc:       testAccNomadJobImportID("dadcorp_nomad_job.test"),
+				ImportStateVerifyIgnore: []string{"status", "status_description"},
+			},
+			{
+				Config: testAccConfigNomadJob(true, "dc1", 3),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "task_group.0.count", "3"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "version", "1"),
+				),
+			},
+			{
+				Config:      testAccConfigNomadJob(true, "dc2", 3),
+				ExpectError: regexp.MustCompile("datacenters must include the cluster's datacenter"),
+			},
+			{
+				Config:      testAccConfigNomadJob(false, "dc1", 4),
+				ExpectError: regexp.MustCompile("access policy doesn't allow it"),
+			},
+		},
+	})
+}
+
+func testAccNomadJobImportID(name string) resource.ImportStateIdFunc {
+	return func(state *sdkterraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", name)
+		}
+		return rs.Primary.Attributes["access_policy_id"] + "/" + rs.Primary.Attributes["cluster_id"] + "/" + rs.Primary.Attributes["job_id"], nil
+	}
+}
+
+func testAccConfigNomadJob(submit bool, datacenter string, count int) string {
+	return fmt.Sprintf(`
+resource "dadcorp_nomad_cluster" "test" {
+  name = "test jobs cluster"
+  datacenter = "dc1"
+}
+
+resource "dadcorp_access_policy" "test" {
+  type = "nomad"
+  policy_data = {
+    cluster_id = dadcorp_nomad_cluster.test.id
+    submit_jobs = %t
+    read_job_status = true
+    cancel_jobs = true
+  }
+}
+
+resource "dadcorp_nomad_job" "test" {
+  cluster_id = dadcorp_nomad_cluster.test.id
+  access_policy_id = dadcorp_access_policy.test.id
+  name = "web"
+  datacenters = [%q]
+
+  constraint {
+    attribute = "$${attr.kernel.name}"
+    value = "linux"
+  }
+
+  task_group {
+    name = "app"
+    count = %d
+
+    task {
+      name = "server"
+      driver = "docker"
+      config = {
+        image = "nginx:1.25"
+      }
+    }
+  }
+}
+`, submit, datacenter, count)
+}
+
+func TestAccNomadNodePool_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigNomadNodePool("GPU nodes", "binpack"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "name", "gpu"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "description", "GPU nodes"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "meta.team", "ml"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "scheduler_algorithm", "binpack"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "node_pool", "gpu"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "task_group.0.task.0.resources.0.cpu", "500"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_job.test", "task_group.0.task.0.resources.0.memory", "300"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_nomad_node_pool.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigNomadNodePool("Spread GPU nodes", "spread"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "description", "Spread GPU nodes"),
+					resource.TestCheckResourceAttr("dadcorp_nomad_node_pool.test", "scheduler_algorithm", "spread"),
+				),
+			},
+			{
+				Config:      testAccConfigNomadNodePool("GPU nodes", "random"),
+				ExpectError: regexp.MustCompile("Invalid scheduler algorithm"),
+			},
+		},
+	})
+}
+
+func testAccConfigNomadNodePool(description, algorithm string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_nomad_cluster" "test" {
+  name = "node pool cluster"
+  datacenter = "dc1"
+}
+
+resource "dadcorp_nomad_node_pool" "test" {
+  cluster_id = dadcorp_nomad_cluster.test.id
+  name = "gpu"
+  description = %q
+  scheduler_algorithm = %q
+
+  meta = {
+    team = "ml"
+  }
+}
+
+resource "dadcorp_access_policy" "test" {
+  type = "nomad"
+  policy_data = {
+    cluster_id = dadcorp_nomad_cluster.test.id
+    submit_jobs = true
+    read_job_status = true
+    cancel_jobs = true
+  }
+}
+
+resource "dadcorp_nomad_job" "test" {
+  cluster_id = dadcorp_nomad_cluster.test.id
+  access_policy_id = dadcorp_access_policy.test.id
+  name = "trainer"
+  node_pool = dadcorp_nomad_node_pool.test.name
+
+  task_group {
+    name = "train"
+
+    task {
+      name = "train"
+      driver = "docker"
+
+      resources {
+        cpu = 500
+      }
+    }
+  }
+}
+`, description, algorithm)
+}
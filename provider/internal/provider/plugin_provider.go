@@ -22,11 +22,21 @@ func (p *provider) GetProviderSchema(ctx context.Context, req *tfprotov5.GetProv
 		Provider: &tfprotov5.Schema{
 			Block: &tfprotov5.SchemaBlock{
 				Attributes: []*tfprotov5.SchemaAttribute{
+					{
+						Name:     "organization",
+						Type:     tftypes.String,
+						Optional: true,
+					},
 					{
 						Name:     "password",
 						Type:     tftypes.String,
 						Optional: true,
 					},
+					{
+						Name:     "project",
+						Type:     tftypes.String,
+						Optional: true,
+					},
 					{
 						Name:     "username",
 						Type:     tftypes.String,
@@ -36,11 +46,22 @@ func (p *provider) GetProviderSchema(ctx context.Context, req *tfprotov5.GetProv
 			},
 		},
 		ResourceSchemas: map[string]*tfprotov5.Schema{
-			"dadcorp_terraform_workspace": (&terraform{}).schema(),
-			"dadcorp_vault_cluster":       (&vault{}).schema(),
-			"dadcorp_access_policy":       (&accessPolicy{}).schema(),
+			"dadcorp_terraform_workspace":    (&terraform{}).schema(),
+			"dadcorp_terraform_variable":     (&terraformVariable{}).schema(),
+			"dadcorp_terraform_variable_set": (&terraformVariableSet{}).schema(),
+			"dadcorp_terraform_policy_set":   (&terraformPolicySet{}).schema(),
+			"dadcorp_terraform_run_trigger":  (&terraformRunTrigger{}).schema(),
+			"dadcorp_terraform_agent_pool":   (&terraformAgentPool{}).schema(),
+			"dadcorp_terraform_agent_token":  (&terraformAgentToken{}).schema(),
+			"dadcorp_terraform_oauth_client": (&terraformOAuthClient{}).schema(),
+			"dadcorp_vault_cluster":          (&vault{}).schema(),
+			"dadcorp_vault_secret":           (&vaultSecret{}).schema(),
+			"dadcorp_access_policy":          (&accessPolicy{}).schema(),
+		},
+		DataSourceSchemas: map[string]*tfprotov5.Schema{
+			"dadcorp_terraform_workspace_outputs": (&terraformWorkspaceOutputs{}).schema(),
+			"dadcorp_terraform_versions":          (&terraformVersions{}).schema(),
 		},
-		DataSourceSchemas: map[string]*tfprotov5.Schema{},
 	}, nil
 }
 
@@ -53,8 +74,10 @@ func (p *provider) PrepareProviderConfig(ctx context.Context, req *tfprotov5.Pre
 func (p *provider) ConfigureProvider(ctx context.Context, req *tfprotov5.ConfigureProviderRequest) (*tfprotov5.ConfigureProviderResponse, error) {
 	configType := tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
-			"username": tftypes.String,
-			"password": tftypes.String,
+			"username":     tftypes.String,
+			"password":     tftypes.String,
+			"organization": tftypes.String,
+			"project":      tftypes.String,
 		},
 	}
 	var client clientFactory
@@ -121,12 +144,67 @@ func (p *provider) ConfigureProvider(ctx context.Context, req *tfprotov5.Configu
 			}, err
 		}
 	}
+	if values["organization"].IsKnown() && !values["organization"].IsNull() {
+		err = values["organization"].As(&client.organization)
+		if err != nil {
+			return &tfprotov5.ConfigureProviderResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected provider configuration",
+						Detail:   "The provider got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError:" + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("organization"),
+							},
+						},
+					},
+				},
+			}, err
+		}
+	}
+	if values["project"].IsKnown() && !values["project"].IsNull() {
+		err = values["project"].As(&client.project)
+		if err != nil {
+			return &tfprotov5.ConfigureProviderResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected provider configuration",
+						Detail:   "The provider got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError:" + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("project"),
+							},
+						},
+					},
+				},
+			}, err
+		}
+	}
 	if os.Getenv("DADCORP_USERNAME") != "" {
 		client.username = os.Getenv("DADCORP_USERNAME")
 	}
 	if os.Getenv("DADCORP_PASSWORD") != "" {
 		client.password = os.Getenv("DADCORP_PASSWORD")
 	}
+	if os.Getenv("DADCORP_ORGANIZATION") != "" {
+		client.organization = os.Getenv("DADCORP_ORGANIZATION")
+	}
+	if os.Getenv("DADCORP_PROJECT") != "" {
+		client.project = os.Getenv("DADCORP_PROJECT")
+	}
+	if client.organization == "" || client.project == "" {
+		return &tfprotov5.ConfigureProviderResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Missing organization or project",
+					Detail:   "The organization and project to manage resources in must be set, either in the provider configuration or with the DADCORP_ORGANIZATION and DADCORP_PROJECT environment variables.",
+				},
+			},
+		}, nil
+	}
 	p.clientFactory = client
 	return &tfprotov5.ConfigureProviderResponse{}, nil
 }
@@ -143,11 +221,51 @@ func (p *provider) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov
 			clients: p.clientFactory,
 		}
 		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.ValidateResourceTypeConfig(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.ValidateResourceTypeConfig(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -172,11 +290,51 @@ func (p *provider) UpgradeResourceState(ctx context.Context, req *tfprotov5.Upgr
 			clients: p.clientFactory,
 		}
 		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.UpgradeResourceState(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.UpgradeResourceState(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -201,11 +359,51 @@ func (p *provider) ReadResource(ctx context.Context, req *tfprotov5.ReadResource
 			clients: p.clientFactory,
 		}
 		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.ReadResource(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.ReadResource(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -230,11 +428,51 @@ func (p *provider) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanRe
 			clients: p.clientFactory,
 		}
 		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.PlanResourceChange(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.PlanResourceChange(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -259,11 +497,51 @@ func (p *provider) ApplyResourceChange(ctx context.Context, req *tfprotov5.Apply
 			clients: p.clientFactory,
 		}
 		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.ApplyResourceChange(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.ApplyResourceChange(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -288,11 +566,51 @@ func (p *provider) ImportResourceState(ctx context.Context, req *tfprotov5.Impor
 			clients: p.clientFactory,
 		}
 		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_variable":
+		res := &terraformVariable{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_variable_set":
+		res := &terraformVariableSet{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_policy_set":
+		res := &terraformPolicySet{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_run_trigger":
+		res := &terraformRunTrigger{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_agent_pool":
+		res := &terraformAgentPool{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_agent_token":
+		res := &terraformAgentToken{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
+	case "dadcorp_terraform_oauth_client":
+		res := &terraformOAuthClient{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
 	case "dadcorp_vault_cluster":
 		res := &vault{
 			clients: p.clientFactory,
 		}
 		return res.ImportResourceState(ctx, req)
+	case "dadcorp_vault_secret":
+		res := &vaultSecret{
+			clients: p.clientFactory,
+		}
+		return res.ImportResourceState(ctx, req)
 	case "dadcorp_access_policy":
 		res := &accessPolicy{
 			clients: p.clientFactory,
@@ -313,6 +631,16 @@ func (p *provider) ImportResourceState(ctx context.Context, req *tfprotov5.Impor
 // data source methods
 func (p *provider) ValidateDataSourceConfig(ctx context.Context, req *tfprotov5.ValidateDataSourceConfigRequest) (*tfprotov5.ValidateDataSourceConfigResponse, error) {
 	switch req.TypeName {
+	case "dadcorp_terraform_workspace_outputs":
+		ds := &terraformWorkspaceOutputs{
+			clients: p.clientFactory,
+		}
+		return ds.ValidateDataSourceConfig(ctx, req)
+	case "dadcorp_terraform_versions":
+		ds := &terraformVersions{
+			clients: p.clientFactory,
+		}
+		return ds.ValidateDataSourceConfig(ctx, req)
 	}
 	return &tfprotov5.ValidateDataSourceConfigResponse{
 		Diagnostics: []*tfprotov5.Diagnostic{
@@ -327,6 +655,16 @@ func (p *provider) ValidateDataSourceConfig(ctx context.Context, req *tfprotov5.
 
 func (p *provider) ReadDataSource(ctx context.Context, req *tfprotov5.ReadDataSourceRequest) (*tfprotov5.ReadDataSourceResponse, error) {
 	switch req.TypeName {
+	case "dadcorp_terraform_workspace_outputs":
+		ds := &terraformWorkspaceOutputs{
+			clients: p.clientFactory,
+		}
+		return ds.ReadDataSource(ctx, req)
+	case "dadcorp_terraform_versions":
+		ds := &terraformVersions{
+			clients: p.clientFactory,
+		}
+		return ds.ReadDataSource(ctx, req)
 	}
 	return &tfprotov5.ReadDataSourceResponse{
 		Diagnostics: []*tfprotov5.Diagnostic{
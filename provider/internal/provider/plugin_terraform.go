@@ -13,6 +13,32 @@ type terraform struct {
 }
 
 func (t *terraform) workspaceType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":                    tftypes.String,
+			"name":                  tftypes.String,
+			"agent_pool_id":         tftypes.String,
+			"allow_destroy_plan":    tftypes.Bool,
+			"auto_apply":            tftypes.Bool,
+			"description":           tftypes.String,
+			"execution_mode":        tftypes.String,
+			"file_triggers_enabled": tftypes.Bool,
+			"locked":                tftypes.Bool,
+			"queue_all_runs":        tftypes.Bool,
+			"speculative_enabled":   tftypes.Bool,
+			"terraform_version":     tftypes.String,
+			"trigger_prefixes": tftypes.List{
+				ElementType: tftypes.String,
+			},
+			"working_directory": tftypes.String,
+			"vcs_repo":          t.vcsRepoType(),
+		},
+	}
+}
+
+// workspaceTypeV1 is the type of workspace state written by version 1 of the
+// schema, before workspaces had a locked attribute.
+func (t *terraform) workspaceTypeV1() tftypes.Type {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
 			"id":                    tftypes.String,
@@ -48,7 +74,7 @@ func (t *terraform) vcsRepoType() tftypes.Type {
 
 func (t *terraform) schema() *tfprotov5.Schema {
 	return &tfprotov5.Schema{
-		Version: 1,
+		Version: 2,
 		Block: &tfprotov5.SchemaBlock{
 			Attributes: []*tfprotov5.SchemaAttribute{
 				{
@@ -127,6 +153,11 @@ func (t *terraform) schema() *tfprotov5.Schema {
 					Optional: true,
 					Computed: true,
 				},
+				{
+					Name:     "locked",
+					Type:     tftypes.Bool,
+					Computed: true,
+				},
 			},
 			BlockTypes: []*tfprotov5.SchemaNestedBlock{
 				{
@@ -201,9 +232,10 @@ func (t *terraform) ValidateResourceTypeConfig(ctx context.Context, req *tfproto
 			},
 		}, nil
 	}
-	if values["execution_mode"].IsKnown() && !values["execution_mode"].IsNull() && values["agent_pool_id"].IsKnown() && !values["agent_pool_id"].IsNull() {
-		var execMode string
-		err = values["execution_mode"].As(&execMode)
+	// an empty agent_pool_id is the same as not setting one
+	var agentPoolID string
+	if values["agent_pool_id"].IsKnown() && !values["agent_pool_id"].IsNull() {
+		err = values["agent_pool_id"].As(&agentPoolID)
 		if err != nil {
 			return &tfprotov5.ValidateResourceTypeConfigResponse{
 				Diagnostics: []*tfprotov5.Diagnostic{
@@ -213,15 +245,17 @@ func (t *terraform) ValidateResourceTypeConfig(ctx context.Context, req *tfproto
 						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
 						Attribute: &tftypes.AttributePath{
 							Steps: []tftypes.AttributePathStep{
-								tftypes.AttributeName("execution_mode"),
+								tftypes.AttributeName("agent_pool_id"),
 							},
 						},
 					},
 				},
 			}, nil
 		}
-		var agentPoolID string
-		err = values["agent_pool_id"].As(&agentPoolID)
+	}
+	if values["execution_mode"].IsKnown() && !values["execution_mode"].IsNull() {
+		var execMode string
+		err = values["execution_mode"].As(&execMode)
 		if err != nil {
 			return &tfprotov5.ValidateResourceTypeConfigResponse{
 				Diagnostics: []*tfprotov5.Diagnostic{
@@ -229,6 +263,38 @@ func (t *terraform) ValidateResourceTypeConfig(ctx context.Context, req *tfproto
 						Severity: tfprotov5.DiagnosticSeverityError,
 						Summary:  "Unexpected configuration format",
 						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("execution_mode"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if execMode != dadcorp.TerraformExecutionModeRemote && execMode != dadcorp.TerraformExecutionModeLocal && execMode != dadcorp.TerraformExecutionModeAgent {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Invalid execution mode",
+						Detail:   `execution_mode must be "remote", "local", or "agent".`,
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("execution_mode"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if execMode == dadcorp.TerraformExecutionModeAgent && values["agent_pool_id"].IsKnown() && agentPoolID == "" {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Missing agent pool",
+						Detail:   "agent_pool_id must be set when execution_mode is \"agent\".",
 						Attribute: &tftypes.AttributePath{
 							Steps: []tftypes.AttributePathStep{
 								tftypes.AttributeName("agent_pool_id"),
@@ -238,7 +304,7 @@ func (t *terraform) ValidateResourceTypeConfig(ctx context.Context, req *tfproto
 				},
 			}, nil
 		}
-		if execMode != "agent" && agentPoolID != "" {
+		if execMode != dadcorp.TerraformExecutionModeAgent && agentPoolID != "" {
 			return &tfprotov5.ValidateResourceTypeConfigResponse{
 				Diagnostics: []*tfprotov5.Diagnostic{
 					{
@@ -261,6 +327,50 @@ func (t *terraform) ValidateResourceTypeConfig(ctx context.Context, req *tfproto
 func (t *terraform) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
 	switch req.Version {
 	case 1:
+		val, err := req.RawState.Unmarshal(t.workspaceTypeV1())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		state := map[string]tftypes.Value{}
+		err = val.As(&state)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		// version 1 predates workspace locking, and the next refresh
+		// will pick up the real value
+		state["locked"] = tftypes.NewValue(tftypes.Bool, false)
+		dv, err := tfprotov5.NewDynamicValue(t.workspaceType(), tftypes.NewValue(t.workspaceType(), state))
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	case 2:
 		val, err := req.RawState.Unmarshal(t.workspaceType())
 		if err != nil {
 			return &tfprotov5.UpgradeResourceStateResponse{
@@ -399,6 +509,7 @@ func (t *terraform) ReadResource(ctx context.Context, req *tfprotov5.ReadResourc
 		"description":           tftypes.NewValue(tftypes.String, workspace.Description),
 		"execution_mode":        tftypes.NewValue(tftypes.String, workspace.ExecutionMode),
 		"file_triggers_enabled": tftypes.NewValue(tftypes.Bool, workspace.FileTriggersEnabled),
+		"locked":                tftypes.NewValue(tftypes.Bool, workspace.Locked),
 		"queue_all_runs":        tftypes.NewValue(tftypes.Bool, workspace.QueueAllRuns),
 		"speculative_enabled":   tftypes.NewValue(tftypes.Bool, workspace.SpeculativeEnabled),
 		"terraform_version":     tftypes.NewValue(tftypes.String, workspace.TerraformVersion),
@@ -551,6 +662,9 @@ func (t *terraform) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanR
 	if newState["working_directory"].IsNull() {
 		newState["working_directory"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
 	}
+	if newState["locked"].IsNull() {
+		newState["locked"] = tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue)
+	}
 	if !newState["vcs_repo"].IsNull() && newState["vcs_repo"].IsKnown() {
 		vcs := map[string]tftypes.Value{}
 		err = newState["vcs_repo"].As(&vcs)
@@ -1167,6 +1281,7 @@ func (t *terraform) ApplyResourceChange(ctx context.Context, req *tfprotov5.Appl
 		"name":           plannedState["name"],
 		"agent_pool_id":  plannedState["agent_pool_id"],
 		"queue_all_runs": plannedState["queue_all_runs"],
+		"locked":         tftypes.NewValue(tftypes.Bool, workspace.Locked),
 	}
 	if plannedState["allow_destroy_plan"].IsKnown() && !plannedState["allow_destroy_plan"].IsNull() {
 		finalState["allow_destroy_plan"] = plannedState["allow_destroy_plan"]
@@ -1283,6 +1398,7 @@ func (t *terraform) ImportResourceState(ctx context.Context, req *tfprotov5.Impo
 		"description":           tftypes.NewValue(tftypes.String, workspace.Description),
 		"execution_mode":        tftypes.NewValue(tftypes.String, workspace.ExecutionMode),
 		"file_triggers_enabled": tftypes.NewValue(tftypes.Bool, workspace.FileTriggersEnabled),
+		"locked":                tftypes.NewValue(tftypes.Bool, workspace.Locked),
 		"queue_all_runs":        tftypes.NewValue(tftypes.Bool, workspace.QueueAllRuns),
 		"speculative_enabled":   tftypes.NewValue(tftypes.Bool, workspace.SpeculativeEnabled),
 		"terraform_version":     tftypes.NewValue(tftypes.String, workspace.TerraformVersion),
@@ -0,0 +1,534 @@
+package provider
+
+import (
+	"context"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformAgentPool struct {
+	clients clientFactory
+}
+
+func (t *terraformAgentPool) agentPoolType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":   tftypes.String,
+			"name": tftypes.String,
+		},
+	}
+}
+
+func (t *terraformAgentPool) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "name",
+					Type:     tftypes.String,
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformAgentPool) stateValue(pool dadcorp.TerraformAgentPool) tftypes.Value {
+	return tftypes.NewValue(t.agentPoolType(), map[string]tftypes.Value{
+		"id":   tftypes.NewValue(tftypes.String, pool.ID),
+		"name": tftypes.NewValue(tftypes.String, pool.Name),
+	})
+}
+
+func (t *terraformAgentPool) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.agentPoolType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.agentPoolType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (t *terraformAgentPool) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.agentPoolType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformAgentPool) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.agentPoolType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	pool, err := client.Terraform.AgentPools.Get(ctx, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformAgentPoolNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), tftypes.NewValue(t.agentPoolType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing agent pool from state",
+							Detail:   "An unexpected error was encountered removing the agent pool from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving agent pool",
+					Detail:   "The provider was unable to retrieve the agent pool.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), t.stateValue(pool))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating agent pool in state",
+					Detail:   "An unexpected error was encountered updating the agent pool from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformAgentPool) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.agentPoolType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if newState["id"].IsNull() {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), tftypes.NewValue(t.agentPoolType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState: &dv,
+	}, nil
+}
+
+func (t *terraformAgentPool) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.agentPoolType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.agentPoolType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're deleting the agent pool
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var id string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = client.Terraform.AgentPools.Delete(ctx, id)
+		if err != nil && err != dadcorp.ErrTerraformAgentPoolNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting agent pool",
+						Detail:   "The provider was unable to delete the agent pool.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), tftypes.NewValue(t.agentPoolType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// if plannedStateVal is not null, we're creating or updating the
+	// agent pool so let's get access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var pool dadcorp.TerraformAgentPool
+	err = plannedState["name"].As(&pool.Name)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("name"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	// if priorStateVal is not null, we're updating the agent pool
+	if !priorStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		err = priorState["id"].As(&pool.ID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		pool, err = client.Terraform.AgentPools.Update(ctx, pool)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error updating the agent pool",
+						Detail:   "The provider was unable to update the agent pool.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	} else {
+		// if priorStateVal is null, we're creating the agent pool
+		pool, err = client.Terraform.AgentPools.Create(ctx, pool)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error creating the agent pool",
+						Detail:   "The provider was unable to create the agent pool.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), tftypes.NewValue(t.agentPoolType(), map[string]tftypes.Value{
+		"id":   tftypes.NewValue(tftypes.String, pool.ID),
+		"name": plannedState["name"],
+	}))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformAgentPool) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	pool, err := client.Terraform.AgentPools.Get(ctx, req.ID)
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving agent pool",
+					Detail:   "The provider was unable to retrieve the agent pool.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentPoolType(), t.stateValue(pool))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,669 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformAgentToken struct {
+	clients clientFactory
+}
+
+func (t *terraformAgentToken) agentTokenType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":            tftypes.String,
+			"agent_pool_id": tftypes.String,
+			"description":   tftypes.String,
+			"token":         tftypes.String,
+		},
+	}
+}
+
+func (t *terraformAgentToken) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "agent_pool_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "description",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:      "token",
+					Type:      tftypes.String,
+					Computed:  true,
+					Sensitive: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformAgentToken) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.agentTokenType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (t *terraformAgentToken) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.agentTokenType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformAgentToken) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id, agentPoolID string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = state["agent_pool_id"].As(&agentPoolID)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("agent_pool_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	token, err := client.Terraform.AgentPools.Tokens.Get(ctx, agentPoolID, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformAgentTokenNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing agent token from state",
+							Detail:   "An unexpected error was encountered removing the agent token from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving agent token",
+					Detail:   "The provider was unable to retrieve the agent token.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// the token itself is only returned when it's created, so the best we
+	// can do is keep whatever is in state
+	dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, token.ID),
+		"agent_pool_id": tftypes.NewValue(tftypes.String, token.AgentPoolID),
+		"description":   tftypes.NewValue(tftypes.String, token.Description),
+		"token":         state["token"],
+	}))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating agent token in state",
+					Detail:   "An unexpected error was encountered updating the agent token from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformAgentToken) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorVal, err := req.PriorState.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if newState["description"].IsNull() {
+		newState["description"] = tftypes.NewValue(tftypes.String, "")
+	}
+	var requiresReplace []*tftypes.AttributePath
+	if !priorVal.IsNull() {
+		oldState := map[string]tftypes.Value{}
+		err = priorVal.As(&oldState)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		// tokens can't be changed once they're issued, so any change
+		// means issuing a new token
+		for _, attr := range []string{"agent_pool_id", "description"} {
+			if !newState[attr].IsKnown() {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+				continue
+			}
+			var oldValue, newValue string
+			err = oldState[attr].As(&oldValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			err = newState[attr].As(&newValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			if (attr == "agent_pool_id" && !strings.EqualFold(oldValue, newValue)) || (attr == "description" && oldValue != newValue) {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+			}
+		}
+	}
+	if newState["id"].IsNull() || len(requiresReplace) > 0 {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		newState["token"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState:    &dv,
+		RequiresReplace: requiresReplace,
+	}, nil
+}
+
+func (t *terraformAgentToken) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.agentTokenType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're revoking the agent token
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var id, agentPoolID string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = priorState["agent_pool_id"].As(&agentPoolID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("agent_pool_id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = client.Terraform.AgentPools.Tokens.Delete(ctx, agentPoolID, id)
+		if err != nil && err != dadcorp.ErrTerraformAgentTokenNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error revoking agent token",
+						Detail:   "The provider was unable to revoke the agent token.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// every change to an agent token replaces it, so if there's a prior
+	// state there's nothing to do but keep the plan
+	if !priorStateVal.IsNull() {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: req.PlannedState,
+		}, nil
+	}
+
+	// if priorStateVal is null, we're issuing the agent token so let's get
+	// access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var token dadcorp.TerraformAgentToken
+	err = plannedState["agent_pool_id"].As(&token.AgentPoolID)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("agent_pool_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["description"].As(&token.Description)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("description"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	token, err = client.Terraform.AgentPools.Tokens.Create(ctx, token)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error issuing the agent token",
+					Detail:   "The provider was unable to issue the agent token.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, token.ID),
+		"agent_pool_id": plannedState["agent_pool_id"],
+		"description":   plannedState["description"],
+		"token":         tftypes.NewValue(tftypes.String, token.Token),
+	}))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformAgentToken) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	parts := strings.Split(req.ID, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Invalid import ID",
+					Detail:   `Agent tokens must be imported using IDs like "AGENT_POOL_ID/TOKEN_ID".`,
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	token, err := client.Terraform.AgentPools.Tokens.Get(ctx, parts[0], parts[1])
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving agent token",
+					Detail:   "The provider was unable to retrieve the agent token.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// imported tokens never get their token back; it's only available
+	// when the token is issued
+	dv, err := tfprotov5.NewDynamicValue(t.agentTokenType(), tftypes.NewValue(t.agentTokenType(), map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, token.ID),
+		"agent_pool_id": tftypes.NewValue(tftypes.String, token.AgentPoolID),
+		"description":   tftypes.NewValue(tftypes.String, token.Description),
+		"token":         tftypes.NewValue(tftypes.String, nil),
+	}))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
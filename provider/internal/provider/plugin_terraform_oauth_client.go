@@ -0,0 +1,807 @@
+package provider
+
+import (
+	"context"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformOAuthClient struct {
+	clients clientFactory
+}
+
+func (t *terraformOAuthClient) oauthClientType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":               tftypes.String,
+			"name":             tftypes.String,
+			"service_provider": tftypes.String,
+			"http_url":         tftypes.String,
+			"api_url":          tftypes.String,
+			"oauth_token":      tftypes.String,
+			"oauth_token_id":   tftypes.String,
+		},
+	}
+}
+
+func (t *terraformOAuthClient) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "name",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "service_provider",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "http_url",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:     "api_url",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:      "oauth_token",
+					Type:      tftypes.String,
+					Required:  true,
+					Sensitive: true,
+				},
+				{
+					Name:     "oauth_token_id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformOAuthClient) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.oauthClientType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	values := map[string]tftypes.Value{}
+	err = val.As(&values)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if values["service_provider"].IsKnown() && !values["service_provider"].IsNull() {
+		var serviceProvider string
+		err = values["service_provider"].As(&serviceProvider)
+		if err != nil {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("service_provider"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		switch serviceProvider {
+		case dadcorp.TerraformVCSProviderGitHub, dadcorp.TerraformVCSProviderGitLabHosted,
+			dadcorp.TerraformVCSProviderBitbucketHosted, dadcorp.TerraformVCSProviderAzureDevOpsService:
+		case dadcorp.TerraformVCSProviderGitHubEnterprise, dadcorp.TerraformVCSProviderGitLabEnterprise,
+			dadcorp.TerraformVCSProviderBitbucketServer:
+			// self-hosted providers have no sensible default URLs
+			for _, attr := range []string{"http_url", "api_url"} {
+				if values[attr].IsKnown() && values[attr].IsNull() {
+					return &tfprotov5.ValidateResourceTypeConfigResponse{
+						Diagnostics: []*tfprotov5.Diagnostic{
+							{
+								Severity: tfprotov5.DiagnosticSeverityError,
+								Summary:  "Missing URL",
+								Detail:   attr + " must be set for self-hosted service providers.",
+								Attribute: &tftypes.AttributePath{
+									Steps: []tftypes.AttributePathStep{
+										tftypes.AttributeName(attr),
+									},
+								},
+							},
+						},
+					}, nil
+				}
+			}
+		default:
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Invalid service provider",
+						Detail:   `service_provider must be one of "github", "github_enterprise", "gitlab_hosted", "gitlab_enterprise", "bitbucket_hosted", "bitbucket_server", or "azure_devops_services".`,
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("service_provider"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (t *terraformOAuthClient) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.oauthClientType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformOAuthClient) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	oauthClient, err := client.Terraform.OAuthClients.Get(ctx, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformOAuthClientNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing OAuth client from state",
+							Detail:   "An unexpected error was encountered removing the OAuth client from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving OAuth client",
+					Detail:   "The provider was unable to retrieve the OAuth client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// the OAuth token is write-only, so the best we can do is trust that
+	// the token in state is still the token on the server
+	dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, oauthClient.ID),
+		"name":             tftypes.NewValue(tftypes.String, oauthClient.Name),
+		"service_provider": tftypes.NewValue(tftypes.String, oauthClient.ServiceProvider),
+		"http_url":         tftypes.NewValue(tftypes.String, oauthClient.HTTPURL),
+		"api_url":          tftypes.NewValue(tftypes.String, oauthClient.APIURL),
+		"oauth_token":      state["oauth_token"],
+		"oauth_token_id":   tftypes.NewValue(tftypes.String, oauthClient.OAuthTokenID),
+	}))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating OAuth client in state",
+					Detail:   "An unexpected error was encountered updating the OAuth client from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformOAuthClient) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	configVal, err := req.Config.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	config := map[string]tftypes.Value{}
+	err = configVal.As(&config)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorVal, err := req.PriorState.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// when the service provider changes, URLs that weren't configured need
+	// to be defaulted again by the server, so the ones in state are stale
+	serviceProviderChanged := priorVal.IsNull()
+	if !priorVal.IsNull() {
+		oldState := map[string]tftypes.Value{}
+		err = priorVal.As(&oldState)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		if !newState["service_provider"].IsKnown() {
+			serviceProviderChanged = true
+		} else {
+			var oldServiceProvider, newServiceProvider string
+			err = oldState["service_provider"].As(&oldServiceProvider)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("service_provider"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			err = newState["service_provider"].As(&newServiceProvider)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("service_provider"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			serviceProviderChanged = oldServiceProvider != newServiceProvider
+		}
+	}
+	for _, attr := range []string{"http_url", "api_url"} {
+		if config[attr].IsNull() && serviceProviderChanged {
+			newState[attr] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		}
+	}
+	if newState["id"].IsNull() {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	if newState["oauth_token_id"].IsNull() {
+		newState["oauth_token_id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState: &dv,
+	}, nil
+}
+
+func (t *terraformOAuthClient) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.oauthClientType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorState := map[string]tftypes.Value{}
+	if !priorStateVal.IsNull() {
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	}
+
+	// if plannedStateVal is null, we're deleting the OAuth client
+	if plannedStateVal.IsNull() {
+		var id string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = client.Terraform.OAuthClients.Delete(ctx, id)
+		if err != nil && err != dadcorp.ErrTerraformOAuthClientNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting OAuth client",
+						Detail:   "The provider was unable to delete the OAuth client.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// if plannedStateVal is not null, we're creating or updating the
+	// OAuth client so let's get access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var oauthClient dadcorp.TerraformOAuthClient
+	for attr, target := range map[string]*string{
+		"name":             &oauthClient.Name,
+		"service_provider": &oauthClient.ServiceProvider,
+		"oauth_token":      &oauthClient.OAuthToken,
+	} {
+		err = plannedState[attr].As(target)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected planned state format",
+						Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName(attr),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	// unknown URLs are left empty, so the server fills in the defaults
+	// for the service provider
+	for attr, target := range map[string]*string{
+		"http_url": &oauthClient.HTTPURL,
+		"api_url":  &oauthClient.APIURL,
+	} {
+		if !plannedState[attr].IsKnown() {
+			continue
+		}
+		err = plannedState[attr].As(target)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected planned state format",
+						Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName(attr),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	// if priorStateVal is not null, we're updating the OAuth client
+	if !priorStateVal.IsNull() {
+		err = priorState["id"].As(&oauthClient.ID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		// only rotate the OAuth token if it actually changed
+		var oldToken string
+		if !priorState["oauth_token"].IsNull() {
+			err = priorState["oauth_token"].As(&oldToken)
+			if err != nil {
+				return &tfprotov5.ApplyResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("oauth_token"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+		}
+		if oldToken == oauthClient.OAuthToken {
+			oauthClient.OAuthToken = ""
+		}
+		oauthClient, err = client.Terraform.OAuthClients.Update(ctx, oauthClient)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error updating the OAuth client",
+						Detail:   "The provider was unable to update the OAuth client.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	} else {
+		// if priorStateVal is null, we're creating the OAuth client
+		oauthClient, err = client.Terraform.OAuthClients.Create(ctx, oauthClient)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error creating the OAuth client",
+						Detail:   "The provider was unable to create the OAuth client.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, oauthClient.ID),
+		"name":             plannedState["name"],
+		"service_provider": plannedState["service_provider"],
+		"http_url":         tftypes.NewValue(tftypes.String, oauthClient.HTTPURL),
+		"api_url":          tftypes.NewValue(tftypes.String, oauthClient.APIURL),
+		"oauth_token":      plannedState["oauth_token"],
+		"oauth_token_id":   tftypes.NewValue(tftypes.String, oauthClient.OAuthTokenID),
+	}))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformOAuthClient) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	oauthClient, err := client.Terraform.OAuthClients.Get(ctx, req.ID)
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving OAuth client",
+					Detail:   "The provider was unable to retrieve the OAuth client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// the OAuth token is write-only, so imported clients will rotate their
+	// token to whatever is in the configuration on the next apply
+	dv, err := tfprotov5.NewDynamicValue(t.oauthClientType(), tftypes.NewValue(t.oauthClientType(), map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, oauthClient.ID),
+		"name":             tftypes.NewValue(tftypes.String, oauthClient.Name),
+		"service_provider": tftypes.NewValue(tftypes.String, oauthClient.ServiceProvider),
+		"http_url":         tftypes.NewValue(tftypes.String, oauthClient.HTTPURL),
+		"api_url":          tftypes.NewValue(tftypes.String, oauthClient.APIURL),
+		"oauth_token":      tftypes.NewValue(tftypes.String, nil),
+		"oauth_token_id":   tftypes.NewValue(tftypes.String, oauthClient.OAuthTokenID),
+	}))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
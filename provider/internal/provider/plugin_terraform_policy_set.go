@@ -0,0 +1,939 @@
+package provider
+
+import (
+	"context"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformPolicySet struct {
+	clients clientFactory
+}
+
+func (t *terraformPolicySet) policySetType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":          tftypes.String,
+			"name":        tftypes.String,
+			"description": tftypes.String,
+			"workspace_ids": tftypes.List{
+				ElementType: tftypes.String,
+			},
+			"policy": tftypes.List{
+				ElementType: t.policyType(),
+			},
+		},
+	}
+}
+
+func (t *terraformPolicySet) policyType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"name":                     tftypes.String,
+			"enforcement_level":        tftypes.String,
+			"forbidden_resource_types": tftypes.List{ElementType: tftypes.String},
+			"required_tags":            tftypes.List{ElementType: tftypes.String},
+		},
+	}
+}
+
+func (t *terraformPolicySet) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "name",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "description",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:     "workspace_ids",
+					Type:     tftypes.List{ElementType: tftypes.String},
+					Optional: true,
+					Computed: true,
+				},
+			},
+			BlockTypes: []*tfprotov5.SchemaNestedBlock{
+				{
+					TypeName: "policy",
+					Nesting:  tfprotov5.SchemaNestedBlockNestingModeList,
+					MinItems: 1,
+					Block: &tfprotov5.SchemaBlock{
+						Attributes: []*tfprotov5.SchemaAttribute{
+							{
+								Name:     "name",
+								Type:     tftypes.String,
+								Required: true,
+							},
+							{
+								Name:     "enforcement_level",
+								Type:     tftypes.String,
+								Optional: true,
+								Computed: true,
+							},
+							{
+								Name:     "forbidden_resource_types",
+								Type:     tftypes.List{ElementType: tftypes.String},
+								Optional: true,
+								Computed: true,
+							},
+							{
+								Name:     "required_tags",
+								Type:     tftypes.List{ElementType: tftypes.String},
+								Optional: true,
+								Computed: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type terraformPolicyRule struct {
+	dadcorp.TerraformPolicyRule
+}
+
+func (p *terraformPolicyRule) FromTerraform5Value(val tftypes.Value) error {
+	v := map[string]tftypes.Value{}
+	err := val.As(&v)
+	if err != nil {
+		return err
+	}
+
+	err = v["name"].As(&p.Name)
+	if err != nil {
+		return err
+	}
+
+	err = v["enforcement_level"].As(&p.EnforcementLevel)
+	if err != nil {
+		return err
+	}
+
+	var types []tftypes.Value
+	err = v["forbidden_resource_types"].As(&types)
+	if err != nil {
+		return err
+	}
+	p.ForbiddenResourceTypes = make([]string, 0, len(types))
+	for _, typ := range types {
+		var s string
+		err = typ.As(&s)
+		if err != nil {
+			return err
+		}
+		p.ForbiddenResourceTypes = append(p.ForbiddenResourceTypes, s)
+	}
+
+	var tags []tftypes.Value
+	err = v["required_tags"].As(&tags)
+	if err != nil {
+		return err
+	}
+	p.RequiredTags = make([]string, 0, len(tags))
+	for _, tag := range tags {
+		var s string
+		err = tag.As(&s)
+		if err != nil {
+			return err
+		}
+		p.RequiredTags = append(p.RequiredTags, s)
+	}
+
+	return nil
+}
+
+func (t *terraformPolicySet) stateValue(set dadcorp.TerraformPolicySet) tftypes.Value {
+	workspaceIDs := make([]tftypes.Value, 0, len(set.WorkspaceIDs))
+	for _, id := range set.WorkspaceIDs {
+		workspaceIDs = append(workspaceIDs, tftypes.NewValue(tftypes.String, id))
+	}
+	policies := make([]tftypes.Value, 0, len(set.Policies))
+	for _, policy := range set.Policies {
+		types := make([]tftypes.Value, 0, len(policy.ForbiddenResourceTypes))
+		for _, typ := range policy.ForbiddenResourceTypes {
+			types = append(types, tftypes.NewValue(tftypes.String, typ))
+		}
+		tags := make([]tftypes.Value, 0, len(policy.RequiredTags))
+		for _, tag := range policy.RequiredTags {
+			tags = append(tags, tftypes.NewValue(tftypes.String, tag))
+		}
+		policies = append(policies, tftypes.NewValue(t.policyType(), map[string]tftypes.Value{
+			"name":                     tftypes.NewValue(tftypes.String, policy.Name),
+			"enforcement_level":        tftypes.NewValue(tftypes.String, policy.EnforcementLevel),
+			"forbidden_resource_types": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, types),
+			"required_tags":            tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tags),
+		}))
+	}
+	return tftypes.NewValue(t.policySetType(), map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, set.ID),
+		"name":          tftypes.NewValue(tftypes.String, set.Name),
+		"description":   tftypes.NewValue(tftypes.String, set.Description),
+		"workspace_ids": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, workspaceIDs),
+		"policy":        tftypes.NewValue(tftypes.List{ElementType: t.policyType()}, policies),
+	})
+}
+
+func (t *terraformPolicySet) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.policySetType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.policySetType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	config := map[string]tftypes.Value{}
+	err = val.As(&config)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if config["policy"].IsNull() || !config["policy"].IsKnown() {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+	}
+	var policies []tftypes.Value
+	err = config["policy"].As(&policies)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("policy"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	var diags []*tfprotov5.Diagnostic
+	for pos, policy := range policies {
+		if !policy.IsKnown() {
+			continue
+		}
+		p := map[string]tftypes.Value{}
+		err = policy.As(&p)
+		if err != nil {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("policy"),
+								tftypes.ElementKeyInt(pos),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if p["enforcement_level"].IsNull() || !p["enforcement_level"].IsKnown() {
+			continue
+		}
+		var level string
+		err = p["enforcement_level"].As(&level)
+		if err != nil {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("policy"),
+								tftypes.ElementKeyInt(pos),
+								tftypes.AttributeName("enforcement_level"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		switch level {
+		case dadcorp.TerraformPolicyAdvisory, dadcorp.TerraformPolicySoftMandatory, dadcorp.TerraformPolicyHardMandatory:
+		default:
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Invalid enforcement level",
+				Detail:   "enforcement_level must be \"advisory\", \"soft-mandatory\", or \"hard-mandatory\".",
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName("policy"),
+						tftypes.ElementKeyInt(pos),
+						tftypes.AttributeName("enforcement_level"),
+					},
+				},
+			})
+		}
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{
+		Diagnostics: diags,
+	}, nil
+}
+
+func (t *terraformPolicySet) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.policySetType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.policySetType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformPolicySet) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.policySetType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	set, err := client.Terraform.PolicySets.Get(ctx, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformPolicySetNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.policySetType(), tftypes.NewValue(t.policySetType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing policy set from state",
+							Detail:   "An unexpected error was encountered removing the policy set from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving policy set",
+					Detail:   "The provider was unable to retrieve the policy set.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.policySetType(), t.stateValue(set))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating policy set in state",
+					Detail:   "An unexpected error was encountered updating the policy set from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformPolicySet) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.policySetType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if newState["id"].IsNull() {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	if newState["description"].IsNull() {
+		newState["description"] = tftypes.NewValue(tftypes.String, "")
+	}
+	if newState["workspace_ids"].IsNull() {
+		newState["workspace_ids"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{})
+	}
+	if !newState["policy"].IsNull() && newState["policy"].IsKnown() {
+		var policies []tftypes.Value
+		err = newState["policy"].As(&policies)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected state format",
+						Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("policy"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		for pos, policy := range policies {
+			if !policy.IsKnown() {
+				continue
+			}
+			p := map[string]tftypes.Value{}
+			err = policy.As(&p)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("policy"),
+									tftypes.ElementKeyInt(pos),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			if p["enforcement_level"].IsNull() {
+				p["enforcement_level"] = tftypes.NewValue(tftypes.String, dadcorp.TerraformPolicyAdvisory)
+			}
+			if p["forbidden_resource_types"].IsNull() {
+				p["forbidden_resource_types"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{})
+			}
+			if p["required_tags"].IsNull() {
+				p["required_tags"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{})
+			}
+			policies[pos] = tftypes.NewValue(t.policyType(), p)
+		}
+		newState["policy"] = tftypes.NewValue(tftypes.List{ElementType: t.policyType()}, policies)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.policySetType(), tftypes.NewValue(t.policySetType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState: &dv,
+	}, nil
+}
+
+func (t *terraformPolicySet) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.policySetType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.policySetType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're deleting the policy set
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var id string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = client.Terraform.PolicySets.Delete(ctx, id)
+		if err != nil && err != dadcorp.ErrTerraformPolicySetNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting policy set",
+						Detail:   "The provider was unable to delete the policy set.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.policySetType(), tftypes.NewValue(t.policySetType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// if plannedStateVal is not null, we're creating or updating the
+	// policy set so let's get access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var set dadcorp.TerraformPolicySet
+	err = plannedState["name"].As(&set.Name)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("name"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["description"].As(&set.Description)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("description"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	var workspaceIDs []tftypes.Value
+	err = plannedState["workspace_ids"].As(&workspaceIDs)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_ids"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	set.WorkspaceIDs = make([]string, 0, len(workspaceIDs))
+	for pos, workspaceID := range workspaceIDs {
+		var id string
+		err = workspaceID.As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected planned state format",
+						Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("workspace_ids"),
+								tftypes.ElementKeyInt(pos),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		set.WorkspaceIDs = append(set.WorkspaceIDs, id)
+	}
+	var policies []tftypes.Value
+	err = plannedState["policy"].As(&policies)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("policy"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	set.Policies = make([]dadcorp.TerraformPolicyRule, 0, len(policies))
+	for pos, policy := range policies {
+		var rule terraformPolicyRule
+		err = policy.As(&rule)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected planned state format",
+						Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("policy"),
+								tftypes.ElementKeyInt(pos),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		set.Policies = append(set.Policies, rule.TerraformPolicyRule)
+	}
+	// if priorStateVal is not null, we're updating the policy set
+	if !priorStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		err = priorState["id"].As(&set.ID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		set, err = client.Terraform.PolicySets.Update(ctx, set)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error updating the policy set",
+						Detail:   "The provider was unable to update the policy set.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	} else {
+		// if priorStateVal is null, we're creating the policy set
+		set, err = client.Terraform.PolicySets.Create(ctx, set)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error creating the policy set",
+						Detail:   "The provider was unable to create the policy set.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.policySetType(), tftypes.NewValue(t.policySetType(), map[string]tftypes.Value{
+		"id":            tftypes.NewValue(tftypes.String, set.ID),
+		"name":          plannedState["name"],
+		"description":   plannedState["description"],
+		"workspace_ids": plannedState["workspace_ids"],
+		"policy":        plannedState["policy"],
+	}))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformPolicySet) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	set, err := client.Terraform.PolicySets.Get(ctx, req.ID)
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving policy set",
+					Detail:   "The provider was unable to retrieve the policy set.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.policySetType(), t.stateValue(set))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,686 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformRunTrigger struct {
+	clients clientFactory
+}
+
+func (t *terraformRunTrigger) runTriggerType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":                  tftypes.String,
+			"workspace_id":        tftypes.String,
+			"source_workspace_id": tftypes.String,
+		},
+	}
+}
+
+func (t *terraformRunTrigger) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "workspace_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "source_workspace_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformRunTrigger) stateValue(trigger dadcorp.TerraformRunTrigger) tftypes.Value {
+	return tftypes.NewValue(t.runTriggerType(), map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, trigger.ID),
+		"workspace_id":        tftypes.NewValue(tftypes.String, trigger.WorkspaceID),
+		"source_workspace_id": tftypes.NewValue(tftypes.String, trigger.SourceWorkspaceID),
+	})
+}
+
+func (t *terraformRunTrigger) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.runTriggerType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	config := map[string]tftypes.Value{}
+	err = val.As(&config)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !config["workspace_id"].IsKnown() || !config["source_workspace_id"].IsKnown() || config["workspace_id"].IsNull() || config["source_workspace_id"].IsNull() {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+	}
+	var workspaceID, sourceWorkspaceID string
+	err = config["workspace_id"].As(&workspaceID)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = config["source_workspace_id"].As(&sourceWorkspaceID)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("source_workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	if strings.EqualFold(workspaceID, sourceWorkspaceID) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Run trigger would create a cycle",
+					Detail:   "A workspace can't trigger runs in itself.",
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("source_workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (t *terraformRunTrigger) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.runTriggerType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformRunTrigger) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	trigger, err := client.Terraform.RunTriggers.Get(ctx, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformRunTriggerNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), tftypes.NewValue(t.runTriggerType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing run trigger from state",
+							Detail:   "An unexpected error was encountered removing the run trigger from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving run trigger",
+					Detail:   "The provider was unable to retrieve the run trigger.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), t.stateValue(trigger))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating run trigger in state",
+					Detail:   "An unexpected error was encountered updating the run trigger from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformRunTrigger) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorVal, err := req.PriorState.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var requiresReplace []*tftypes.AttributePath
+	if !priorVal.IsNull() {
+		oldState := map[string]tftypes.Value{}
+		err = priorVal.As(&oldState)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		// run triggers can't be changed, only replaced
+		for _, attr := range []string{"workspace_id", "source_workspace_id"} {
+			if !newState[attr].IsKnown() {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+				continue
+			}
+			var oldValue, newValue string
+			err = oldState[attr].As(&oldValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			err = newState[attr].As(&newValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			if !strings.EqualFold(oldValue, newValue) {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+			}
+		}
+	}
+	if newState["id"].IsNull() || len(requiresReplace) > 0 {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), tftypes.NewValue(t.runTriggerType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState:    &dv,
+		RequiresReplace: requiresReplace,
+	}, nil
+}
+
+func (t *terraformRunTrigger) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.runTriggerType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're deleting the run trigger
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var id string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = client.Terraform.RunTriggers.Delete(ctx, id)
+		if err != nil && err != dadcorp.ErrTerraformRunTriggerNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting run trigger",
+						Detail:   "The provider was unable to delete the run trigger.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), tftypes.NewValue(t.runTriggerType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// every change to a run trigger replaces it, so if there's a prior
+	// state there's nothing to do but keep the plan
+	if !priorStateVal.IsNull() {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: req.PlannedState,
+		}, nil
+	}
+
+	// if priorStateVal is null, we're creating the run trigger so let's get
+	// access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var trigger dadcorp.TerraformRunTrigger
+	err = plannedState["workspace_id"].As(&trigger.WorkspaceID)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["source_workspace_id"].As(&trigger.SourceWorkspaceID)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("source_workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	trigger, err = client.Terraform.RunTriggers.Create(ctx, trigger)
+	if err != nil {
+		if err == dadcorp.ErrTerraformRunTriggerCycle {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Run trigger would create a cycle",
+						Detail:   "Runs applied in the workspace already trigger runs, directly or indirectly, in the source workspace. Adding this run trigger would make them trigger each other forever.",
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("source_workspace_id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating the run trigger",
+					Detail:   "The provider was unable to create the run trigger.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), tftypes.NewValue(t.runTriggerType(), map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, trigger.ID),
+		"workspace_id":        plannedState["workspace_id"],
+		"source_workspace_id": plannedState["source_workspace_id"],
+	}))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformRunTrigger) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	trigger, err := client.Terraform.RunTriggers.Get(ctx, req.ID)
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving run trigger",
+					Detail:   "The provider was unable to retrieve the run trigger.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.runTriggerType(), t.stateValue(trigger))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,1025 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+type terraformVariable struct {
+	clients clientFactory
+}
+
+func (t *terraformVariable) variableType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":              tftypes.String,
+			"workspace_id":    tftypes.String,
+			"variable_set_id": tftypes.String,
+			"key":             tftypes.String,
+			"value":           tftypes.String,
+			"description":     tftypes.String,
+			"category":        tftypes.String,
+			"hcl":             tftypes.Bool,
+			"sensitive":       tftypes.Bool,
+		},
+	}
+}
+
+func (t *terraformVariable) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "workspace_id",
+					Type:     tftypes.String,
+					Optional: true,
+				},
+				{
+					Name:     "variable_set_id",
+					Type:     tftypes.String,
+					Optional: true,
+				},
+				{
+					Name:     "key",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:      "value",
+					Type:      tftypes.String,
+					Optional:  true,
+					Sensitive: true,
+				},
+				{
+					Name:     "description",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:     "category",
+					Type:     tftypes.String,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:     "hcl",
+					Type:     tftypes.Bool,
+					Optional: true,
+					Computed: true,
+				},
+				{
+					Name:     "sensitive",
+					Type:     tftypes.Bool,
+					Optional: true,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+// variableService returns the client service for the workspace or variable
+// set the variable belongs to, along with that parent's ID.
+func (t *terraformVariable) variableService(client *dadcorp.Client, workspaceID, variableSetID string) (*dadcorp.TerraformVariablesService, string) {
+	if variableSetID != "" {
+		return client.Terraform.VariableSets.Variables, variableSetID
+	}
+	return client.Terraform.Variables, workspaceID
+}
+
+func (t *terraformVariable) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	values := map[string]tftypes.Value{}
+	err = val.As(&values)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if values["workspace_id"].IsKnown() && values["variable_set_id"].IsKnown() && values["workspace_id"].IsNull() == values["variable_set_id"].IsNull() {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Invalid variable parent",
+					Detail:   "Exactly one of workspace_id and variable_set_id must be set.",
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	if values["category"].IsKnown() && !values["category"].IsNull() {
+		var category string
+		err = values["category"].As(&category)
+		if err != nil {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("category"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if category != dadcorp.TerraformVariableCategoryTerraform && category != dadcorp.TerraformVariableCategoryEnv {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Invalid category",
+						Detail:   `category must be "terraform" or "env".`,
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("category"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if category == dadcorp.TerraformVariableCategoryEnv && values["hcl"].IsKnown() && !values["hcl"].IsNull() {
+			var hcl bool
+			err = values["hcl"].As(&hcl)
+			if err != nil {
+				return &tfprotov5.ValidateResourceTypeConfigResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected configuration format",
+							Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("hcl"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			if hcl {
+				return &tfprotov5.ValidateResourceTypeConfigResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Invalid hcl",
+							Detail:   "hcl can't be set on environment variables.",
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("hcl"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+		}
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (t *terraformVariable) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(t.variableType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.variableType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (t *terraformVariable) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var id, workspaceID, variableSetID string
+	err = state["id"].As(&id)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = state["workspace_id"].As(&workspaceID)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = state["variable_set_id"].As(&variableSetID)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("variable_set_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	service, parentID := t.variableService(client, workspaceID, variableSetID)
+	variable, err := service.Get(ctx, parentID, id)
+	if err != nil {
+		if err == dadcorp.ErrTerraformVariableNotFound {
+			dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing variable from state",
+							Detail:   "An unexpected error was encountered removing the variable from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving variable",
+					Detail:   "The provider was unable to retrieve the variable.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	newState := map[string]tftypes.Value{
+		"id":              tftypes.NewValue(tftypes.String, variable.ID),
+		"workspace_id":    state["workspace_id"],
+		"variable_set_id": state["variable_set_id"],
+		"key":             tftypes.NewValue(tftypes.String, variable.Key),
+		"value":           tftypes.NewValue(tftypes.String, variable.Value),
+		"description":     tftypes.NewValue(tftypes.String, variable.Description),
+		"category":        tftypes.NewValue(tftypes.String, variable.Category),
+		"hcl":             tftypes.NewValue(tftypes.Bool, variable.HCL),
+		"sensitive":       tftypes.NewValue(tftypes.Bool, variable.Sensitive),
+	}
+	// sensitive values can't be read back, so the best we can do is trust
+	// that the value in state is still the value on the server
+	if variable.Sensitive || (variable.Value == "" && state["value"].IsNull()) {
+		newState["value"] = state["value"]
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), newState))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating variable in state",
+					Detail:   "An unexpected error was encountered updating the variable from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (t *terraformVariable) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorVal, err := req.PriorState.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var requiresReplace []*tftypes.AttributePath
+	if !priorVal.IsNull() {
+		oldState := map[string]tftypes.Value{}
+		err = priorVal.As(&oldState)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		// variables can't move between workspaces and variable sets, so
+		// changing either parent means replacing the variable
+		for _, attr := range []string{"workspace_id", "variable_set_id"} {
+			if !newState[attr].IsKnown() {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+				continue
+			}
+			var oldParent, newParent string
+			err = oldState[attr].As(&oldParent)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			err = newState[attr].As(&newParent)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			if !strings.EqualFold(oldParent, newParent) {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+			}
+		}
+	}
+	if newState["id"].IsNull() || len(requiresReplace) > 0 {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	if newState["description"].IsNull() {
+		newState["description"] = tftypes.NewValue(tftypes.String, "")
+	}
+	if newState["category"].IsNull() {
+		newState["category"] = tftypes.NewValue(tftypes.String, dadcorp.TerraformVariableCategoryTerraform)
+	}
+	if newState["hcl"].IsNull() {
+		newState["hcl"] = tftypes.NewValue(tftypes.Bool, false)
+	}
+	if newState["sensitive"].IsNull() {
+		newState["sensitive"] = tftypes.NewValue(tftypes.Bool, false)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState:    &dv,
+		RequiresReplace: requiresReplace,
+	}, nil
+}
+
+func (t *terraformVariable) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(t.variableType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're deleting the variable
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var id, workspaceID, variableSetID string
+		err = priorState["id"].As(&id)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = priorState["workspace_id"].As(&workspaceID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("workspace_id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		err = priorState["variable_set_id"].As(&variableSetID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("variable_set_id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		service, parentID := t.variableService(client, workspaceID, variableSetID)
+		err = service.Delete(ctx, parentID, id)
+		if err != nil && err != dadcorp.ErrTerraformVariableNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting variable",
+						Detail:   "The provider was unable to delete the variable.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	// if plannedStateVal is not null, we're creating or updating the
+	// variable so let's get access to the planned state
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	var variable dadcorp.TerraformVariable
+	err = plannedState["workspace_id"].As(&variable.WorkspaceID)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["variable_set_id"].As(&variable.VariableSetID)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("variable_set_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["key"].As(&variable.Key)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("key"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["value"].As(&variable.Value)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("value"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["description"].As(&variable.Description)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("description"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["category"].As(&variable.Category)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("category"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["hcl"].As(&variable.HCL)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("hcl"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	err = plannedState["sensitive"].As(&variable.Sensitive)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("sensitive"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	service, _ := t.variableService(client, variable.WorkspaceID, variable.VariableSetID)
+	// if priorStateVal is not null, we're updating the variable
+	if !priorStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		err = priorState["id"].As(&variable.ID)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		variable, err = service.Update(ctx, variable)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error updating the variable",
+						Detail:   "The provider was unable to update the variable.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	} else {
+		// if priorStateVal is null, we're creating the variable
+		variable, err = service.Create(ctx, variable)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error creating the variable",
+						Detail:   "The provider was unable to create the variable.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+	}
+	// everything but the ID comes from the plan, because the API won't
+	// return the values of sensitive variables
+	finalState := map[string]tftypes.Value{
+		"id":              tftypes.NewValue(tftypes.String, variable.ID),
+		"workspace_id":    plannedState["workspace_id"],
+		"variable_set_id": plannedState["variable_set_id"],
+		"key":             plannedState["key"],
+		"value":           plannedState["value"],
+		"description":     plannedState["description"],
+		"category":        plannedState["category"],
+		"hcl":             plannedState["hcl"],
+		"sensitive":       plannedState["sensitive"],
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), finalState))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+// ImportResourceState imports variables using IDs in the form
+// "workspaces/WORKSPACE_ID/VARIABLE_ID" or "varsets/VARIABLE_SET_ID/VARIABLE_ID".
+// The values of sensitive variables can't be imported.
+func (t *terraformVariable) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	parts := strings.Split(req.ID, "/")
+	if len(parts) != 3 || (parts[0] != "workspaces" && parts[0] != "varsets") || parts[1] == "" || parts[2] == "" {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Invalid import ID",
+					Detail:   `Variables must be imported using IDs like "workspaces/WORKSPACE_ID/VARIABLE_ID" or "varsets/VARIABLE_SET_ID/VARIABLE_ID".`,
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var workspaceID, variableSetID string
+	if parts[0] == "varsets" {
+		variableSetID = parts[1]
+	} else {
+		workspaceID = parts[1]
+	}
+	service, parentID := t.variableService(client, workspaceID, variableSetID)
+	variable, err := service.Get(ctx, parentID, parts[2])
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving variable",
+					Detail:   "The provider was unable to retrieve the variable.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	workspaceIDVal := tftypes.NewValue(tftypes.String, nil)
+	if variable.WorkspaceID != "" {
+		workspaceIDVal = tftypes.NewValue(tftypes.String, variable.WorkspaceID)
+	}
+	variableSetIDVal := tftypes.NewValue(tftypes.String, nil)
+	if variable.VariableSetID != "" {
+		variableSetIDVal = tftypes.NewValue(tftypes.String, variable.VariableSetID)
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.variableType(), tftypes.NewValue(t.variableType(), map[string]tftypes.Value{
+		"id":              tftypes.NewValue(tftypes.String, variable.ID),
+		"workspace_id":    workspaceIDVal,
+		"variable_set_id": variableSetIDVal,
+		"key":             tftypes.NewValue(tftypes.String, variable.Key),
+		"value":           tftypes.NewValue(tftypes.String, variable.Value),
+		"description":     tftypes.NewValue(tftypes.String, variable.Description),
+		"category":        tftypes.NewValue(tftypes.String, variable.Category),
+		"hcl":             tftypes.NewValue(tftypes.Bool, variable.HCL),
+		"sensitive":       tftypes.NewValue(tftypes.Bool, variable.Sensitive),
+	}))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
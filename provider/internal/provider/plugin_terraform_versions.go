@@ -0,0 +1,252 @@
+package provider
+
+import (
+	"context"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+// terraformVersions is a data source listing the Terraform versions in the
+// catalog, newest first. By default, beta and deprecated versions are left
+// out, the same way a workspace's version constraint would ignore them.
+type terraformVersions struct {
+	clients clientFactory
+}
+
+func (t *terraformVersions) versionsType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":                 tftypes.String,
+			"constraint":         tftypes.String,
+			"include_beta":       tftypes.Bool,
+			"include_deprecated": tftypes.Bool,
+			"versions":           tftypes.List{ElementType: tftypes.String},
+			"latest":             tftypes.String,
+		},
+	}
+}
+
+func (t *terraformVersions) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "constraint",
+					Type:     tftypes.String,
+					Optional: true,
+				},
+				{
+					Name:     "include_beta",
+					Type:     tftypes.Bool,
+					Optional: true,
+				},
+				{
+					Name:     "include_deprecated",
+					Type:     tftypes.Bool,
+					Optional: true,
+				},
+				{
+					Name:     "versions",
+					Type:     tftypes.List{ElementType: tftypes.String},
+					Computed: true,
+				},
+				{
+					Name:     "latest",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformVersions) ValidateDataSourceConfig(ctx context.Context, req *tfprotov5.ValidateDataSourceConfigRequest) (*tfprotov5.ValidateDataSourceConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.versionsType())
+	if err != nil {
+		return &tfprotov5.ValidateDataSourceConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.versionsType()) {
+		return &tfprotov5.ValidateDataSourceConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ValidateDataSourceConfigResponse{}, nil
+}
+
+func (t *terraformVersions) ReadDataSource(ctx context.Context, req *tfprotov5.ReadDataSourceRequest) (*tfprotov5.ReadDataSourceResponse, error) {
+	val, err := req.Config.Unmarshal(t.versionsType())
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	config := map[string]tftypes.Value{}
+	err = val.As(&config)
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var filter dadcorp.TerraformVersionFilter
+	if !config["constraint"].IsNull() {
+		err = config["constraint"].As(&filter.Constraint)
+		if err != nil {
+			return &tfprotov5.ReadDataSourceResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("constraint"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	if !config["include_beta"].IsNull() {
+		err = config["include_beta"].As(&filter.IncludeBeta)
+		if err != nil {
+			return &tfprotov5.ReadDataSourceResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("include_beta"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	if !config["include_deprecated"].IsNull() {
+		err = config["include_deprecated"].As(&filter.IncludeDeprecated)
+		if err != nil {
+			return &tfprotov5.ReadDataSourceResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("include_deprecated"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	versions, err := client.Terraform.Versions.List(ctx, filter)
+	if err != nil {
+		if err == dadcorp.ErrTerraformVersionConstraint {
+			return &tfprotov5.ReadDataSourceResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Invalid version constraint",
+						Detail:   "The constraint must be a comma-separated list of versions with optional operators, like \"~> 1.5\" or \">= 1.3, < 1.6\".",
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("constraint"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving Terraform versions",
+					Detail:   "The provider was unable to retrieve the Terraform version catalog.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	values := make([]tftypes.Value, 0, len(versions))
+	for _, version := range versions {
+		values = append(values, tftypes.NewValue(tftypes.String, version.Version))
+	}
+	var latest string
+	if len(versions) > 0 {
+		latest = versions[0].Version
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.versionsType(), tftypes.NewValue(t.versionsType(), map[string]tftypes.Value{
+		"id":                 tftypes.NewValue(tftypes.String, "terraform_versions"),
+		"constraint":         config["constraint"],
+		"include_beta":       config["include_beta"],
+		"include_deprecated": config["include_deprecated"],
+		"versions":           tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, values),
+		"latest":             tftypes.NewValue(tftypes.String, latest),
+	}))
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning data source state",
+					Detail:   "The data source encountered an unexpected error returning its state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadDataSourceResponse{
+		State: &dv,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+// terraformWorkspaceOutputs is a data source exposing the outputs of a
+// workspace's current state. Output values are JSON encoded, because they
+// can be of any type; use jsondecode to get at them.
+type terraformWorkspaceOutputs struct {
+	clients clientFactory
+}
+
+func (t *terraformWorkspaceOutputs) outputsType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":                  tftypes.String,
+			"workspace_id":        tftypes.String,
+			"values":              tftypes.Map{AttributeType: tftypes.String},
+			"nonsensitive_values": tftypes.Map{AttributeType: tftypes.String},
+		},
+	}
+}
+
+func (t *terraformWorkspaceOutputs) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "workspace_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:      "values",
+					Type:      tftypes.Map{AttributeType: tftypes.String},
+					Computed:  true,
+					Sensitive: true,
+				},
+				{
+					Name:     "nonsensitive_values",
+					Type:     tftypes.Map{AttributeType: tftypes.String},
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func (t *terraformWorkspaceOutputs) ValidateDataSourceConfig(ctx context.Context, req *tfprotov5.ValidateDataSourceConfigRequest) (*tfprotov5.ValidateDataSourceConfigResponse, error) {
+	val, err := req.Config.Unmarshal(t.outputsType())
+	if err != nil {
+		return &tfprotov5.ValidateDataSourceConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(t.outputsType()) {
+		return &tfprotov5.ValidateDataSourceConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ValidateDataSourceConfigResponse{}, nil
+}
+
+func (t *terraformWorkspaceOutputs) ReadDataSource(ctx context.Context, req *tfprotov5.ReadDataSourceRequest) (*tfprotov5.ReadDataSourceResponse, error) {
+	val, err := req.Config.Unmarshal(t.outputsType())
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	config := map[string]tftypes.Value{}
+	err = val.As(&config)
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var workspaceID string
+	err = config["workspace_id"].As(&workspaceID)
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The data source got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("workspace_id"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := t.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// a workspace that hasn't had any state uploaded yet just has no
+	// outputs
+	outputs, err := client.Terraform.StateVersions.Outputs(ctx, workspaceID)
+	if err != nil && err != dadcorp.ErrTerraformStateVersionNotFound {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving workspace outputs",
+					Detail:   "The provider was unable to retrieve the workspace's outputs.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	values := map[string]tftypes.Value{}
+	nonsensitiveValues := map[string]tftypes.Value{}
+	for _, output := range outputs {
+		values[output.Name] = tftypes.NewValue(tftypes.String, string(output.Value))
+		if !output.Sensitive {
+			nonsensitiveValues[output.Name] = tftypes.NewValue(tftypes.String, string(output.Value))
+		}
+	}
+	dv, err := tfprotov5.NewDynamicValue(t.outputsType(), tftypes.NewValue(t.outputsType(), map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, workspaceID),
+		"workspace_id":        tftypes.NewValue(tftypes.String, workspaceID),
+		"values":              tftypes.NewValue(tftypes.Map{AttributeType: tftypes.String}, values),
+		"nonsensitive_values": tftypes.NewValue(tftypes.Map{AttributeType: tftypes.String}, nonsensitiveValues),
+	}))
+	if err != nil {
+		return &tfprotov5.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning data source state",
+					Detail:   "The data source encountered an unexpected error returning its state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadDataSourceResponse{
+		State: &dv,
+	}, nil
+}
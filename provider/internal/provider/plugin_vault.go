@@ -2,6 +2,11 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	dadcorp "dadcorp.dev/client"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
@@ -13,6 +18,22 @@ type vault struct {
 }
 
 func (v *vault) clusterType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":                tftypes.String,
+			"name":              tftypes.String,
+			"region":            tftypes.String,
+			"default_lease_ttl": tftypes.String,
+			"max_lease_ttl":     tftypes.String,
+			"sealed":            tftypes.Bool,
+			"tcp_listener":      v.tcpListenerType(),
+		},
+	}
+}
+
+// clusterTypeV1 is the type of cluster state written by version 1 of the
+// schema, before clusters had a sealed attribute.
+func (v *vault) clusterTypeV1() tftypes.Type {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
 			"id":                tftypes.String,
@@ -36,7 +57,7 @@ func (v *vault) tcpListenerType() tftypes.Type {
 
 func (v *vault) schema() *tfprotov5.Schema {
 	return &tfprotov5.Schema{
-		Version: 1,
+		Version: 2,
 		Block: &tfprotov5.SchemaBlock{
 			Attributes: []*tfprotov5.SchemaAttribute{
 				{
@@ -66,6 +87,11 @@ func (v *vault) schema() *tfprotov5.Schema {
 					Optional: true,
 					Computed: true,
 				},
+				{
+					Name:     "sealed",
+					Type:     tftypes.Bool,
+					Computed: true,
+				},
 			},
 			BlockTypes: []*tfprotov5.SchemaNestedBlock{
 				{
@@ -130,6 +156,11 @@ func (v *vault) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.V
 			},
 		}, nil
 	}
+	if diags := append(validateVaultLeaseTTLs(values), v.validateTCPListener(values)...); len(diags) > 0 {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: diags,
+		}, nil
+	}
 	if values["region"].IsKnown() {
 		var region string
 		err = values["region"].As(&region)
@@ -199,9 +230,197 @@ func (v *vault) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.V
 	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
 }
 
+// validateTCPListener returns diagnostics for the addresses in the
+// tcp_listener block in values if either isn't a host:port address, or if
+// they're the same. Ports need to be set; leaving an address unset has the
+// API assign it a free port instead. Unknown and unset addresses are
+// skipped.
+func (v *vault) validateTCPListener(values map[string]tftypes.Value) []*tfprotov5.Diagnostic {
+	if !values["tcp_listener"].IsKnown() || values["tcp_listener"].IsNull() {
+		return nil
+	}
+	tcp := map[string]tftypes.Value{}
+	err := values["tcp_listener"].As(&tcp)
+	if err != nil {
+		return []*tfprotov5.Diagnostic{
+			{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Unexpected configuration format",
+				Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName("tcp_listener"),
+					},
+				},
+			},
+		}
+	}
+	addresses := map[string]string{}
+	var diags []*tfprotov5.Diagnostic
+	for _, attr := range []string{"address", "cluster_address"} {
+		if !tcp[attr].IsKnown() || tcp[attr].IsNull() {
+			continue
+		}
+		var address string
+		err := tcp[attr].As(&address)
+		if err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Unexpected configuration format",
+				Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName("tcp_listener"),
+						tftypes.AttributeName(attr),
+					},
+				},
+			})
+			continue
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err == nil {
+			var p int
+			p, err = strconv.Atoi(port)
+			if err == nil && (host == "" || p < 1 || p > 65535) {
+				err = errors.New("invalid host or port")
+			}
+		}
+		if err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Invalid listener address",
+				Detail:   "Listener addresses must be host:port addresses with a port from 1 to 65535, like \"10.0.0.1:8200\". Leave the address unset to have a free port assigned.",
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName("tcp_listener"),
+						tftypes.AttributeName(attr),
+					},
+				},
+			})
+			continue
+		}
+		addresses[attr] = address
+	}
+	if addresses["address"] != "" && strings.EqualFold(addresses["address"], addresses["cluster_address"]) {
+		diags = append(diags, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Conflicting listener addresses",
+			Detail:   "address and cluster_address must not be the same.",
+			Attribute: &tftypes.AttributePath{
+				Steps: []tftypes.AttributePathStep{
+					tftypes.AttributeName("tcp_listener"),
+					tftypes.AttributeName("cluster_address"),
+				},
+			},
+		})
+	}
+	return diags
+}
+
+// validateVaultLeaseTTLs returns diagnostics for the default_lease_ttl and
+// max_lease_ttl in values if either isn't a positive duration, or if the
+// default is longer than the max. Unknown and unset TTLs are skipped.
+func validateVaultLeaseTTLs(values map[string]tftypes.Value) []*tfprotov5.Diagnostic {
+	ttls := map[string]time.Duration{}
+	var diags []*tfprotov5.Diagnostic
+	for _, attr := range []string{"default_lease_ttl", "max_lease_ttl"} {
+		if !values[attr].IsKnown() || values[attr].IsNull() {
+			continue
+		}
+		var s string
+		err := values[attr].As(&s)
+		if err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Unexpected configuration format",
+				Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				},
+			})
+			continue
+		}
+		ttl, err := time.ParseDuration(s)
+		if err != nil || ttl <= 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Invalid lease TTL",
+				Detail:   "Lease TTLs must be positive durations, like \"1h\" or \"768h\".",
+				Attribute: &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				},
+			})
+			continue
+		}
+		ttls[attr] = ttl
+	}
+	defaultTTL, hasDefault := ttls["default_lease_ttl"]
+	maxTTL, hasMax := ttls["max_lease_ttl"]
+	if hasDefault && hasMax && defaultTTL > maxTTL {
+		diags = append(diags, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Default lease TTL exceeds max lease TTL",
+			Detail:   "default_lease_ttl must not be longer than max_lease_ttl.",
+			Attribute: &tftypes.AttributePath{
+				Steps: []tftypes.AttributePathStep{
+					tftypes.AttributeName("default_lease_ttl"),
+				},
+			},
+		})
+	}
+	return diags
+}
+
 func (v *vault) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
 	switch req.Version {
 	case 1:
+		val, err := req.RawState.Unmarshal(v.clusterTypeV1())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		state := map[string]tftypes.Value{}
+		err = val.As(&state)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		// version 1 predates sealing, and clusters start out unsealed
+		state["sealed"] = tftypes.NewValue(tftypes.Bool, false)
+		dv, err := tfprotov5.NewDynamicValue(v.clusterType(), tftypes.NewValue(v.clusterType(), state))
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	case 2:
 		val, err := req.RawState.Unmarshal(v.clusterType())
 		if err != nil {
 			return &tfprotov5.UpgradeResourceStateResponse{
@@ -333,6 +552,7 @@ func (v *vault) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceReq
 		"region":            tftypes.NewValue(tftypes.String, cluster.Region),
 		"default_lease_ttl": tftypes.NewValue(tftypes.String, cluster.DefaultLeaseTTL),
 		"max_lease_ttl":     tftypes.NewValue(tftypes.String, cluster.MaxLeaseTTL),
+		"sealed":            tftypes.NewValue(tftypes.Bool, cluster.SealStatus.Sealed),
 		"tcp_listener": tftypes.NewValue(v.tcpListenerType(), map[string]tftypes.Value{
 			"address":         tftypes.NewValue(tftypes.String, cluster.TCPListener.Address),
 			"cluster_address": tftypes.NewValue(tftypes.String, cluster.TCPListener.ClusterAddress),
@@ -389,6 +609,9 @@ func (v *vault) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResou
 	if newState["max_lease_ttl"].IsNull() {
 		newState["max_lease_ttl"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
 	}
+	if newState["sealed"].IsNull() {
+		newState["sealed"] = tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue)
+	}
 	if !newState["tcp_listener"].IsNull() && newState["tcp_listener"].IsKnown() {
 		tcp := map[string]tftypes.Value{}
 		err = newState["tcp_listener"].As(&tcp)
@@ -771,6 +994,7 @@ func (v *vault) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyRes
 	} else {
 		finalTCP["cluster_address"] = tftypes.NewValue(tftypes.String, cluster.TCPListener.ClusterAddress)
 	}
+	finalState["sealed"] = tftypes.NewValue(tftypes.Bool, cluster.SealStatus.Sealed)
 	finalState["tcp_listener"] = tftypes.NewValue(v.tcpListenerType(), finalTCP)
 	dv, err := tfprotov5.NewDynamicValue(v.clusterType(), tftypes.NewValue(v.clusterType(), finalState))
 	if err != nil {
@@ -820,6 +1044,7 @@ func (v *vault) ImportResourceState(ctx context.Context, req *tfprotov5.ImportRe
 		"region":            tftypes.NewValue(tftypes.String, cluster.Region),
 		"default_lease_ttl": tftypes.NewValue(tftypes.String, cluster.DefaultLeaseTTL),
 		"max_lease_ttl":     tftypes.NewValue(tftypes.String, cluster.MaxLeaseTTL),
+		"sealed":            tftypes.NewValue(tftypes.Bool, cluster.SealStatus.Sealed),
 		"tcp_listener": tftypes.NewValue(v.tcpListenerType(), map[string]tftypes.Value{
 			"address":         tftypes.NewValue(tftypes.String, cluster.TCPListener.Address),
 			"cluster_address": tftypes.NewValue(tftypes.String, cluster.TCPListener.ClusterAddress),
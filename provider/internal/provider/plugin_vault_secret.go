@@ -0,0 +1,854 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	dadcorp "dadcorp.dev/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tftypes"
+)
+
+// vaultSecret manages a secret in a Vault cluster's KV store. The secret's
+// data is write-only: it's never read back from the API, so changes made
+// outside Terraform are only noticed through the secret's version.
+type vaultSecret struct {
+	clients clientFactory
+}
+
+func (v *vaultSecret) secretType() tftypes.Type {
+	return tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"id":               tftypes.String,
+			"cluster_id":       tftypes.String,
+			"path":             tftypes.String,
+			"access_policy_id": tftypes.String,
+			"data":             tftypes.Map{AttributeType: tftypes.String},
+			"version":          tftypes.Number,
+		},
+	}
+}
+
+func (v *vaultSecret) schema() *tfprotov5.Schema {
+	return &tfprotov5.Schema{
+		Version: 1,
+		Block: &tfprotov5.SchemaBlock{
+			Attributes: []*tfprotov5.SchemaAttribute{
+				{
+					Name:     "id",
+					Type:     tftypes.String,
+					Computed: true,
+				},
+				{
+					Name:     "cluster_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "path",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:     "access_policy_id",
+					Type:     tftypes.String,
+					Required: true,
+				},
+				{
+					Name:      "data",
+					Type:      tftypes.Map{AttributeType: tftypes.String},
+					Required:  true,
+					Sensitive: true,
+				},
+				{
+					Name:     "version",
+					Type:     tftypes.Number,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+// vaultSecretData converts a data attribute to the map the client expects.
+func vaultSecretData(val tftypes.Value) (map[string]string, error) {
+	values := map[string]tftypes.Value{}
+	err := val.As(&values)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	for k, v := range values {
+		var s string
+		err = v.As(&s)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = s
+	}
+	return data, nil
+}
+
+// validVaultSecretPath reports whether path can name a secret: it needs at
+// least one segment, and none of its segments can be empty, "." or "..".
+func validVaultSecretPath(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *vaultSecret) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
+	val, err := req.Config.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if !val.Is(v.secretType()) {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.",
+				},
+			},
+		}, nil
+	}
+	values := map[string]tftypes.Value{}
+	err = val.As(&values)
+	if err != nil {
+		return &tfprotov5.ValidateResourceTypeConfigResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected configuration format",
+					Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if values["path"].IsKnown() && !values["path"].IsNull() {
+		var path string
+		err = values["path"].As(&path)
+		if err != nil {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("path"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		if !validVaultSecretPath(path) {
+			return &tfprotov5.ValidateResourceTypeConfigResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Invalid secret path",
+						Detail:   `Secret paths can't be empty, start or end with "/", or contain empty, "." or ".." segments.`,
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("path"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	return &tfprotov5.ValidateResourceTypeConfigResponse{}, nil
+}
+
+func (v *vaultSecret) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	switch req.Version {
+	case 1:
+		val, err := req.RawState.Unmarshal(v.secretType())
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(v.secretType(), val)
+		if err != nil {
+			return &tfprotov5.UpgradeResourceStateResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected configuration format",
+						Detail:   "The resource got a configuration that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.UpgradeResourceStateResponse{
+			UpgradedState: &dv,
+		}, nil
+	default:
+		return &tfprotov5.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state version",
+					Detail:   "The provider doesn't know how to upgrade from the current state version. Try an earlier releae of the provider.",
+				},
+			},
+		}, nil
+	}
+}
+
+func (v *vaultSecret) ReadResource(ctx context.Context, req *tfprotov5.ReadResourceRequest) (*tfprotov5.ReadResourceResponse, error) {
+	val, err := req.CurrentState.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	state := map[string]tftypes.Value{}
+	err = val.As(&state)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	var clusterID, path, accessPolicyID string
+	for attr, dst := range map[string]*string{"cluster_id": &clusterID, "path": &path, "access_policy_id": &accessPolicyID} {
+		err = state[attr].As(dst)
+		if err != nil {
+			return &tfprotov5.ReadResourceResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected state format",
+						Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName(attr),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	var version *big.Float
+	err = state["version"].As(&version)
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("version"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	client, err := v.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	secret, err := client.Vault.Secrets.GetMetadata(dadcorp.WithAccessPolicy(ctx, accessPolicyID), clusterID, path)
+	if err != nil {
+		if err == dadcorp.ErrVaultSecretNotFound || err == dadcorp.ErrVaultClusterNotFound {
+			dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), nil))
+			if err != nil {
+				return &tfprotov5.ReadResourceResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error removing secret from state",
+							Detail:   "An unexpected error was encountered removing the secret from state. This is an error with the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.ReadResourceResponse{
+				NewState: &dv,
+			}, nil
+		}
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving secret",
+					Detail:   "The provider was unable to retrieve the secret.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// the data is never read back, so if anything but the version we
+	// wrote is current, or the version we wrote was deleted, forget the
+	// data so the next plan writes it again
+	data := state["data"]
+	current := true
+	if version == nil || !version.IsInt() {
+		current = false
+	} else if n, _ := version.Int64(); int(n) != secret.CurrentVersion {
+		current = false
+	}
+	for _, ver := range secret.Versions {
+		if ver.Version == secret.CurrentVersion && (ver.DeletedAt != nil || ver.Destroyed) {
+			current = false
+		}
+	}
+	if !current {
+		data = tftypes.NewValue(tftypes.Map{AttributeType: tftypes.String}, nil)
+	}
+	dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, secret.ClusterID+"/"+secret.Path),
+		"cluster_id":       state["cluster_id"],
+		"path":             tftypes.NewValue(tftypes.String, secret.Path),
+		"access_policy_id": state["access_policy_id"],
+		"data":             data,
+		"version":          tftypes.NewValue(tftypes.Number, big.NewFloat(float64(secret.CurrentVersion))),
+	}))
+	if err != nil {
+		return &tfprotov5.ReadResourceResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error updating secret in state",
+					Detail:   "An unexpected error was encountered updating the secret from state. This is an error with the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ReadResourceResponse{
+		NewState: &dv,
+	}, nil
+}
+
+func (v *vaultSecret) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	val, err := req.ProposedNewState.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	// if the proposed new state is null, we're being destroyed and there's
+	// nothing to plan
+	if val.IsNull() {
+		return &tfprotov5.PlanResourceChangeResponse{
+			PlannedState: req.ProposedNewState,
+		}, nil
+	}
+	newState := map[string]tftypes.Value{}
+	err = val.As(&newState)
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected state format",
+					Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorVal, err := req.PriorState.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if priorVal.IsNull() {
+		newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		newState["version"] = tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)
+	} else {
+		oldState := map[string]tftypes.Value{}
+		err = priorVal.As(&oldState)
+		if err != nil {
+			return &tfprotov5.PlanResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var requiresReplace []*tftypes.AttributePath
+		// a secret can't be moved, only replaced
+		for _, attr := range []string{"cluster_id", "path"} {
+			if !newState[attr].IsKnown() {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+				continue
+			}
+			var oldValue, newValue string
+			err = oldState[attr].As(&oldValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			err = newState[attr].As(&newValue)
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			// cluster IDs are case insensitive, but secret paths aren't
+			if (attr == "cluster_id" && !strings.EqualFold(oldValue, newValue)) || (attr == "path" && oldValue != newValue) {
+				requiresReplace = append(requiresReplace, &tftypes.AttributePath{
+					Steps: []tftypes.AttributePathStep{
+						tftypes.AttributeName(attr),
+					},
+				})
+			}
+		}
+		if len(requiresReplace) > 0 {
+			newState["id"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+			newState["version"] = tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)
+			dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), newState))
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Error returning updated plan",
+							Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+						},
+					},
+				}, nil
+			}
+			return &tfprotov5.PlanResourceChangeResponse{
+				PlannedState:    &dv,
+				RequiresReplace: requiresReplace,
+			}, nil
+		}
+		// writing new data adds a new version
+		changed := !newState["data"].IsFullyKnown() || oldState["data"].IsNull()
+		if !changed {
+			oldData, err := vaultSecretData(oldState["data"])
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("data"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			newData, err := vaultSecretData(newState["data"])
+			if err != nil {
+				return &tfprotov5.PlanResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected state format",
+							Detail:   "The resource got a state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName("data"),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+			changed = len(oldData) != len(newData)
+			for k, val := range newData {
+				if old, ok := oldData[k]; !ok || old != val {
+					changed = true
+				}
+			}
+		}
+		if changed {
+			newState["version"] = tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)
+		}
+	}
+	dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), newState))
+	if err != nil {
+		return &tfprotov5.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated plan",
+					Detail:   "The resource encountered an unexpected error returning the updated plan. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.PlanResourceChangeResponse{
+		PlannedState: &dv,
+	}, nil
+}
+
+func (v *vaultSecret) ApplyResourceChange(ctx context.Context, req *tfprotov5.ApplyResourceChangeRequest) (*tfprotov5.ApplyResourceChangeResponse, error) {
+	plannedStateVal, err := req.PlannedState.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	priorStateVal, err := req.PriorState.Unmarshal(v.secretType())
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected prior state format",
+					Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	client, err := v.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if plannedStateVal is null, we're deleting the secret and all its
+	// versions
+	if plannedStateVal.IsNull() {
+		priorState := map[string]tftypes.Value{}
+		err = priorStateVal.As(&priorState)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected prior state format",
+						Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		var clusterID, path, accessPolicyID string
+		for attr, dst := range map[string]*string{"cluster_id": &clusterID, "path": &path, "access_policy_id": &accessPolicyID} {
+			err = priorState[attr].As(dst)
+			if err != nil {
+				return &tfprotov5.ApplyResourceChangeResponse{
+					Diagnostics: []*tfprotov5.Diagnostic{
+						{
+							Severity: tfprotov5.DiagnosticSeverityError,
+							Summary:  "Unexpected prior state format",
+							Detail:   "The resource got a prior state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+							Attribute: &tftypes.AttributePath{
+								Steps: []tftypes.AttributePathStep{
+									tftypes.AttributeName(attr),
+								},
+							},
+						},
+					},
+				}, nil
+			}
+		}
+		err = client.Vault.Secrets.DeleteMetadata(dadcorp.WithAccessPolicy(ctx, accessPolicyID), clusterID, path)
+		if err != nil && err != dadcorp.ErrVaultSecretNotFound && err != dadcorp.ErrVaultClusterNotFound {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error deleting secret",
+						Detail:   "The provider was unable to delete the secret.\n\nError:\n" + err.Error(),
+					},
+				},
+			}, nil
+		}
+		dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), nil))
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Error returning updated state",
+						Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: &dv,
+		}, nil
+	}
+
+	plannedState := map[string]tftypes.Value{}
+	err = plannedStateVal.As(&plannedState)
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+
+	// if the version is known, the plan didn't change the data and only
+	// the access policy used to manage the secret changed, so there's
+	// nothing to write
+	if plannedState["version"].IsKnown() {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			NewState: req.PlannedState,
+		}, nil
+	}
+
+	var clusterID, path, accessPolicyID string
+	for attr, dst := range map[string]*string{"cluster_id": &clusterID, "path": &path, "access_policy_id": &accessPolicyID} {
+		err = plannedState[attr].As(dst)
+		if err != nil {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Unexpected planned state format",
+						Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName(attr),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+	}
+	data, err := vaultSecretData(plannedState["data"])
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Unexpected planned state format",
+					Detail:   "The resource got a planned state that did not match its schema. This may indicate an error in the provider.\n\nError: " + err.Error(),
+					Attribute: &tftypes.AttributePath{
+						Steps: []tftypes.AttributePathStep{
+							tftypes.AttributeName("data"),
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	secret, err := client.Vault.Secrets.Write(dadcorp.WithAccessPolicy(ctx, accessPolicyID), clusterID, path, dadcorp.VaultSecretWrite{
+		Data: data,
+	})
+	if err != nil {
+		if err == dadcorp.ErrAccessPolicyDenied {
+			return &tfprotov5.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov5.Diagnostic{
+					{
+						Severity: tfprotov5.DiagnosticSeverityError,
+						Summary:  "Access denied",
+						Detail:   "The access policy doesn't allow writing secrets at that path in the Vault cluster.",
+						Attribute: &tftypes.AttributePath{
+							Steps: []tftypes.AttributePathStep{
+								tftypes.AttributeName("access_policy_id"),
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error writing secret",
+					Detail:   "The provider was unable to write the secret.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	plannedState["id"] = tftypes.NewValue(tftypes.String, secret.ClusterID+"/"+secret.Path)
+	plannedState["version"] = tftypes.NewValue(tftypes.Number, big.NewFloat(float64(secret.CurrentVersion)))
+	dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), plannedState))
+	if err != nil {
+		return &tfprotov5.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning updated state",
+					Detail:   "The resource encountered an unexpected error returning the updated state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ApplyResourceChangeResponse{
+		NewState: &dv,
+	}, nil
+}
+
+// ImportResourceState imports a secret's metadata. Its data is write-only,
+// so it's left out of state, and the next apply writes the configured data
+// as a new version.
+func (v *vaultSecret) ImportResourceState(ctx context.Context, req *tfprotov5.ImportResourceStateRequest) (*tfprotov5.ImportResourceStateResponse, error) {
+	parts := strings.SplitN(req.ID, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || !validVaultSecretPath(parts[2]) {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Invalid import ID",
+					Detail:   `Secrets must be imported using IDs like "ACCESS_POLICY_ID/CLUSTER_ID/PATH". The access policy must allow reading the secret.`,
+				},
+			},
+		}, nil
+	}
+	client, err := v.clients.NewClient()
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error creating client",
+					Detail:   "The provider was unable to create a client.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	secret, err := client.Vault.Secrets.GetMetadata(dadcorp.WithAccessPolicy(ctx, parts[0]), parts[1], parts[2])
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error retrieving secret",
+					Detail:   "The provider was unable to retrieve the secret.\n\nError:\n" + err.Error(),
+				},
+			},
+		}, nil
+	}
+	dv, err := tfprotov5.NewDynamicValue(v.secretType(), tftypes.NewValue(v.secretType(), map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, secret.ClusterID+"/"+secret.Path),
+		"cluster_id":       tftypes.NewValue(tftypes.String, secret.ClusterID),
+		"path":             tftypes.NewValue(tftypes.String, secret.Path),
+		"access_policy_id": tftypes.NewValue(tftypes.String, parts[0]),
+		"data":             tftypes.NewValue(tftypes.Map{AttributeType: tftypes.String}, nil),
+		"version":          tftypes.NewValue(tftypes.Number, big.NewFloat(float64(secret.CurrentVersion))),
+	}))
+	if err != nil {
+		return &tfprotov5.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov5.Diagnostic{
+				{
+					Severity: tfprotov5.DiagnosticSeverityError,
+					Summary:  "Error returning resource state",
+					Detail:   "The resource encountered an unexpected error returning the imported state. This indicates an error in the provider.\n\nError: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	return &tfprotov5.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov5.ImportedResource{
+			{
+				TypeName: req.TypeName,
+				State:    &dv,
+			},
+		},
+	}, nil
+}
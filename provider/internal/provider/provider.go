@@ -22,22 +22,36 @@ func New() *schema.Provider {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"dadcorp_consul_cluster": resourceConsulCluster(),
-			"dadcorp_ip":             resourceIP(),
-			"dadcorp_nomad_cluster":  resourceNomadCluster(),
+			"dadcorp_consul_cluster":  resourceConsulCluster(),
+			"dadcorp_consul_key":      resourceConsulKey(),
+			"dadcorp_consul_service":  resourceConsulService(),
+			"dadcorp_ip":              resourceIP(),
+			"dadcorp_ip_pool":         resourceIPPool(),
+			"dadcorp_nomad_cluster":   resourceNomadCluster(),
+			"dadcorp_nomad_job":       resourceNomadJob(),
+			"dadcorp_nomad_node_pool": resourceNomadNodePool(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
 type clientFactory struct {
-	username, password string
+	username, password    string
+	organization, project string
 }
 
 func (c *clientFactory) NewClient() (*dadcorp.Client, error) {
-	return dadcorp.NewClient(baseURL, c.username, c.password)
+	return dadcorp.NewClient(baseURL, c.username, c.password, c.organization, c.project)
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
@@ -49,8 +63,18 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 	if os.Getenv("DADCORP_PASSWORD") != "" {
 		password = os.Getenv("DADCORP_PASSWORD")
 	}
+	organization := d.Get("organization").(string)
+	if os.Getenv("DADCORP_ORGANIZATION") != "" {
+		organization = os.Getenv("DADCORP_ORGANIZATION")
+	}
+	project := d.Get("project").(string)
+	if os.Getenv("DADCORP_PROJECT") != "" {
+		project = os.Getenv("DADCORP_PROJECT")
+	}
 	return &clientFactory{
-		username: username,
-		password: password,
+		username:     username,
+		password:     password,
+		organization: organization,
+		project:      project,
 	}, nil
 }
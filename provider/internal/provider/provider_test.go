@@ -5,6 +5,8 @@ import (
 	"os"
 	"testing"
 
+	dadcorp "dadcorp.dev/client"
+
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	tfmux "github.com/hashicorp/terraform-plugin-mux"
 )
@@ -30,4 +32,29 @@ func testAccPreCheck(t *testing.T) {
 	if os.Getenv("DADCORP_PASSWORD") == "" {
 		t.Fatalf("DADCORP_PASSWORD must be set")
 	}
+	if os.Getenv("DADCORP_ORGANIZATION") == "" {
+		t.Fatalf("DADCORP_ORGANIZATION must be set")
+	}
+	if os.Getenv("DADCORP_PROJECT") == "" {
+		t.Fatalf("DADCORP_PROJECT must be set")
+	}
+	testAccCreateProject(t, os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"))
+}
+
+// testAccCreateProject creates the organization org and its project project,
+// unless they already exist, so the tests can run against any dadcorpd.
+func testAccCreateProject(t *testing.T, org, project string) {
+	client, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), org, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, err = client.Organizations.Create(ctx, dadcorp.Organization{ID: org, Name: org})
+	if err != nil && err != dadcorp.ErrOrganizationAlreadyExists {
+		t.Fatalf("error creating organization %s: %s", org, err)
+	}
+	_, err = client.Projects.Create(ctx, dadcorp.Project{ID: project, Organization: org, Name: project})
+	if err != nil && err != dadcorp.ErrProjectAlreadyExists {
+		t.Fatalf("error creating project %s: %s", project, err)
+	}
 }
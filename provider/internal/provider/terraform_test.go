@@ -1,9 +1,17 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 
+	dadcorp "dadcorp.dev/client"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccTerraformWorkspace_basic(t *testing.T) {
@@ -35,9 +43,25 @@ func TestAccTerraformWorkspace_basic(t *testing.T) {
 
 func testAccConfigTerraformWorkspace_basic() string {
 	return `
+resource "dadcorp_terraform_agent_pool" "test" {
+  name = "test workspace pool"
+}
+
+resource "dadcorp_terraform_oauth_client" "test" {
+  name = "test workspace github"
+  service_provider = "github"
+  oauth_token = "test-github-token"
+}
+
+resource "dadcorp_terraform_oauth_client" "other" {
+  name = "test workspace gitlab"
+  service_provider = "gitlab_hosted"
+  oauth_token = "test-gitlab-token"
+}
+
 resource "dadcorp_terraform_workspace" "test" {
   name = "test workspace"
-  agent_pool_id = "test-pool"
+  agent_pool_id = dadcorp_terraform_agent_pool.test.id
   allow_destroy_plan = true
   auto_apply = false
   description = "test workspace from Terraform"
@@ -50,7 +74,7 @@ resource "dadcorp_terraform_workspace" "test" {
   working_directory = "my_dir"
 
   vcs_repo {
-    oauth_token_id = "test-token"
+    oauth_token_id = dadcorp_terraform_oauth_client.test.oauth_token_id
     branch = "master"
     ingress_submodules = true
     identifier = "hashicorp/test"
@@ -61,6 +85,22 @@ resource "dadcorp_terraform_workspace" "test" {
 
 func testAccConfigTerraformWorkspace_updated() string {
 	return `
+resource "dadcorp_terraform_agent_pool" "test" {
+  name = "test workspace pool"
+}
+
+resource "dadcorp_terraform_oauth_client" "test" {
+  name = "test workspace github"
+  service_provider = "github"
+  oauth_token = "test-github-token"
+}
+
+resource "dadcorp_terraform_oauth_client" "other" {
+  name = "test workspace gitlab"
+  service_provider = "gitlab_hosted"
+  oauth_token = "test-gitlab-token"
+}
+
 resource "dadcorp_terraform_workspace" "test" {
   name = "test workspace updated"
   allow_destroy_plan = false
@@ -75,7 +115,7 @@ resource "dadcorp_terraform_workspace" "test" {
   working_directory = "my_other_dir"
 
   vcs_repo {
-    oauth_token_id = "test-token-2"
+    oauth_token_id = dadcorp_terraform_oauth_client.other.oauth_token_id
     branch = "main"
     ingress_submodules = false
     identifier = "hashicorp/test-repo"
@@ -83,3 +123,886 @@ resource "dadcorp_terraform_workspace" "test" {
 }
 `
 }
+
+func TestAccTerraformVariable_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformVariable_basic(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_variable.test",
+				ImportState:       true,
+				ImportStateIdFunc: testAccTerraformVariableImportID("dadcorp_terraform_variable.test", "workspaces", "workspace_id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:            "dadcorp_terraform_variable.secret",
+				ImportState:             true,
+				ImportStateIdFunc:       testAccTerraformVariableImportID("dadcorp_terraform_variable.secret", "varsets", "variable_set_id"),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"value"},
+			},
+			{
+				Config: testAccConfigTerraformVariable_updated(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_variable.test",
+				ImportState:       true,
+				ImportStateIdFunc: testAccTerraformVariableImportID("dadcorp_terraform_variable.test", "workspaces", "workspace_id"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testAccTerraformVariableImportID builds the ID to import a variable with,
+// because variables are imported through the workspace or variable set that
+// holds them.
+func testAccTerraformVariableImportID(name, parentType, parentAttr string) resource.ImportStateIdFunc {
+	return func(s *sdkterraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", name)
+		}
+		return parentType + "/" + rs.Primary.Attributes[parentAttr] + "/" + rs.Primary.ID, nil
+	}
+}
+
+func testAccConfigTerraformVariable_basic() string {
+	return `
+resource "dadcorp_terraform_workspace" "test" {
+  name = "test variable workspace"
+}
+
+resource "dadcorp_terraform_variable_set" "test" {
+  name = "test variable set"
+  description = "shared variables from Terraform"
+  workspace_ids = [dadcorp_terraform_workspace.test.id]
+}
+
+resource "dadcorp_terraform_variable" "test" {
+  workspace_id = dadcorp_terraform_workspace.test.id
+  key = "region"
+  value = "us-east-1"
+  description = "the region to deploy to"
+}
+
+resource "dadcorp_terraform_variable" "secret" {
+  variable_set_id = dadcorp_terraform_variable_set.test.id
+  key = "API_TOKEN"
+  value = "hunter2"
+  category = "env"
+  sensitive = true
+}
+`
+}
+
+func testAccConfigTerraformVariable_updated() string {
+	return `
+resource "dadcorp_terraform_workspace" "test" {
+  name = "test variable workspace"
+}
+
+resource "dadcorp_terraform_variable_set" "test" {
+  name = "test variable set"
+  description = "shared variables from Terraform"
+  workspace_ids = [dadcorp_terraform_workspace.test.id]
+}
+
+resource "dadcorp_terraform_variable" "test" {
+  workspace_id = dadcorp_terraform_workspace.test.id
+  key = "regions"
+  value = "[\"us-east-1\", \"eu-west-1\"]"
+  hcl = true
+}
+
+resource "dadcorp_terraform_variable" "secret" {
+  variable_set_id = dadcorp_terraform_variable_set.test.id
+  key = "API_TOKEN"
+  value = "hunter3"
+  category = "env"
+  sensitive = true
+}
+`
+}
+
+func TestAccTerraformVariableSet_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformVariableSet_basic(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_variable_set.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigTerraformVariableSet_updated(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_variable_set.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccConfigTerraformVariableSet_basic() string {
+	return `
+resource "dadcorp_terraform_workspace" "one" {
+  name = "test variable set workspace one"
+}
+
+resource "dadcorp_terraform_workspace" "two" {
+  name = "test variable set workspace two"
+}
+
+resource "dadcorp_terraform_variable_set" "test" {
+  name = "test shared variables"
+  workspace_ids = [dadcorp_terraform_workspace.one.id]
+}
+`
+}
+
+func testAccConfigTerraformVariableSet_updated() string {
+	return `
+resource "dadcorp_terraform_workspace" "one" {
+  name = "test variable set workspace one"
+}
+
+resource "dadcorp_terraform_workspace" "two" {
+  name = "test variable set workspace two"
+}
+
+resource "dadcorp_terraform_variable_set" "test" {
+  name = "test shared variables updated"
+  description = "attached to both workspaces"
+  workspace_ids = [
+    dadcorp_terraform_workspace.one.id,
+    dadcorp_terraform_workspace.two.id,
+  ]
+}
+`
+}
+
+func TestAccTerraformWorkspace_deleted(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		CheckDestroy:             testAccTerraformCheckDeleted,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformWorkspace_deleted(),
+			},
+		},
+	})
+}
+
+// testAccTerraformCheckDeleted checks that destroyed workspaces are only
+// marked as deleted, so they can be undeleted, while destroyed variable sets,
+// which aren't soft deleted, are gone for good.
+func testAccTerraformCheckDeleted(s *sdkterraform.State) error {
+	ctx := context.Background()
+	client, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"))
+	if err != nil {
+		return err
+	}
+	workspaces, err := client.Terraform.Workspaces.List(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, rs := range s.RootModule().Resources {
+		switch rs.Type {
+		case "dadcorp_terraform_workspace":
+			_, err = client.Terraform.Workspaces.Get(ctx, rs.Primary.ID)
+			if err != dadcorp.ErrTerraformWorkspaceNotFound {
+				return fmt.Errorf("expected workspace %s to be deleted, got %v", rs.Primary.ID, err)
+			}
+			var listed bool
+			for _, workspace := range workspaces {
+				if workspace.ID == rs.Primary.ID && workspace.DeletedAt != nil {
+					listed = true
+				}
+			}
+			if !listed {
+				return fmt.Errorf("expected workspace %s to be listed as deleted", rs.Primary.ID)
+			}
+		case "dadcorp_terraform_variable_set":
+			_, err = client.Terraform.VariableSets.Get(ctx, rs.Primary.ID)
+			if err != dadcorp.ErrTerraformVariableSetNotFound {
+				return fmt.Errorf("expected variable set %s to be removed, got %v", rs.Primary.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func testAccConfigTerraformWorkspace_deleted() string {
+	return `
+resource "dadcorp_terraform_workspace" "test" {
+  name = "test workspace deleted"
+}
+
+resource "dadcorp_terraform_variable_set" "test" {
+  name = "test variables deleted"
+  workspace_ids = [dadcorp_terraform_workspace.test.id]
+}
+`
+}
+
+func TestAccTerraformWorkspace_invalidExecutionMode(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccConfigTerraformWorkspace_executionMode(`execution_mode = "bogus"`),
+				ExpectError: regexp.MustCompile("Invalid execution mode"),
+			},
+			{
+				Config:      testAccConfigTerraformWorkspace_executionMode(`execution_mode = "agent"`),
+				ExpectError: regexp.MustCompile("Missing agent pool"),
+			},
+			{
+				Config:      testAccConfigTerraformWorkspace_executionMode(`execution_mode = "agent"` + "\n" + `  agent_pool_id = ""`),
+				ExpectError: regexp.MustCompile("Missing agent pool"),
+			},
+		},
+	})
+}
+
+func testAccConfigTerraformWorkspace_executionMode(attrs string) string {
+	return `
+resource "dadcorp_terraform_workspace" "invalid" {
+  name = "test workspace invalid execution mode"
+  ` + attrs + `
+}
+`
+}
+
+func TestAccTerraformPolicySet_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformPolicySet_basic(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_terraform_policy_set.test", "policy.#", "1"),
+					resource.TestCheckResourceAttr("dadcorp_terraform_policy_set.test", "policy.0.enforcement_level", "advisory"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_policy_set.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigTerraformPolicySet_updated(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_terraform_policy_set.test", "policy.#", "2"),
+					resource.TestCheckResourceAttr("dadcorp_terraform_policy_set.test", "policy.1.enforcement_level", "hard-mandatory"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_policy_set.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config:      testAccConfigTerraformPolicySet_invalidLevel(),
+				ExpectError: regexp.MustCompile("Invalid enforcement level"),
+			},
+		},
+	})
+}
+
+func testAccConfigTerraformPolicySet_basic() string {
+	return `
+resource "dadcorp_terraform_workspace" "one" {
+  name = "test policy set workspace one"
+}
+
+resource "dadcorp_terraform_workspace" "two" {
+  name = "test policy set workspace two"
+}
+
+resource "dadcorp_terraform_policy_set" "test" {
+  name = "test policies"
+  workspace_ids = [dadcorp_terraform_workspace.one.id]
+
+  policy {
+    name = "require owner tags"
+    required_tags = ["owner"]
+  }
+}
+`
+}
+
+func testAccConfigTerraformPolicySet_updated() string {
+	return `
+resource "dadcorp_terraform_workspace" "one" {
+  name = "test policy set workspace one"
+}
+
+resource "dadcorp_terraform_workspace" "two" {
+  name = "test policy set workspace two"
+}
+
+resource "dadcorp_terraform_policy_set" "test" {
+  name = "test policies updated"
+  description = "attached to both workspaces"
+  workspace_ids = [
+    dadcorp_terraform_workspace.one.id,
+    dadcorp_terraform_workspace.two.id,
+  ]
+
+  policy {
+    name = "require owner tags"
+    enforcement_level = "soft-mandatory"
+    required_tags = ["owner", "cost-center"]
+  }
+
+  policy {
+    name = "no public buckets"
+    enforcement_level = "hard-mandatory"
+    forbidden_resource_types = ["aws_s3_bucket_public_access_block"]
+  }
+}
+`
+}
+
+func testAccConfigTerraformPolicySet_invalidLevel() string {
+	return `
+resource "dadcorp_terraform_policy_set" "invalid" {
+  name = "test policies invalid"
+
+  policy {
+    name = "require owner tags"
+    enforcement_level = "mandatory"
+    required_tags = ["owner"]
+  }
+}
+`
+}
+
+func TestAccTerraformRunTrigger_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformRunTrigger_basic(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("dadcorp_terraform_run_trigger.test", "workspace_id", "dadcorp_terraform_workspace.two", "id"),
+					resource.TestCheckResourceAttrPair("dadcorp_terraform_run_trigger.test", "source_workspace_id", "dadcorp_terraform_workspace.one", "id"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_run_trigger.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccConfigTerraformRunTrigger_updated(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("dadcorp_terraform_run_trigger.test", "workspace_id", "dadcorp_terraform_workspace.three", "id"),
+					resource.TestCheckResourceAttrPair("dadcorp_terraform_run_trigger.test", "source_workspace_id", "dadcorp_terraform_workspace.two", "id"),
+				),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_run_trigger.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config:      testAccConfigTerraformRunTrigger_cycle(),
+				ExpectError: regexp.MustCompile("Run trigger would create a cycle"),
+			},
+		},
+	})
+}
+
+func testAccConfigTerraformRunTrigger_workspaces() string {
+	return `
+resource "dadcorp_terraform_workspace" "one" {
+  name = "test run trigger workspace one"
+}
+
+resource "dadcorp_terraform_workspace" "two" {
+  name = "test run trigger workspace two"
+}
+
+resource "dadcorp_terraform_workspace" "three" {
+  name = "test run trigger workspace three"
+}
+`
+}
+
+func testAccConfigTerraformRunTrigger_basic() string {
+	return testAccConfigTerraformRunTrigger_workspaces() + `
+resource "dadcorp_terraform_run_trigger" "test" {
+  workspace_id        = dadcorp_terraform_workspace.two.id
+  source_workspace_id = dadcorp_terraform_workspace.one.id
+}
+`
+}
+
+func testAccConfigTerraformRunTrigger_updated() string {
+	return testAccConfigTerraformRunTrigger_workspaces() + `
+resource "dadcorp_terraform_run_trigger" "test" {
+  workspace_id        = dadcorp_terraform_workspace.three.id
+  source_workspace_id = dadcorp_terraform_workspace.two.id
+}
+`
+}
+
+func testAccConfigTerraformRunTrigger_cycle() string {
+	return testAccConfigTerraformRunTrigger_updated() + `
+resource "dadcorp_terraform_run_trigger" "cycle" {
+  workspace_id        = dadcorp_terraform_workspace.two.id
+  source_workspace_id = dadcorp_terraform_workspace.three.id
+
+  depends_on = [dadcorp_terraform_run_trigger.test]
+}
+`
+}
+
+func TestAccTerraformAgentPool_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformAgentPool_basic(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_agent_pool.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:            "dadcorp_terraform_agent_token.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateIdFunc:       testAccTerraformAgentTokenImportID("dadcorp_terraform_agent_token.test"),
+				ImportStateVerifyIgnore: []string{"token"},
+			},
+			{
+				Config: testAccConfigTerraformAgentPool_updated(),
+			},
+			{
+				ResourceName:      "dadcorp_terraform_agent_pool.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:            "dadcorp_terraform_agent_token.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateIdFunc:       testAccTerraformAgentTokenImportID("dadcorp_terraform_agent_token.test"),
+				ImportStateVerifyIgnore: []string{"token"},
+			},
+		},
+	})
+}
+
+func testAccTerraformAgentTokenImportID(name string) resource.ImportStateIdFunc {
+	return func(state *sdkterraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", name)
+		}
+		return rs.Primary.Attributes["agent_pool_id"] + "/" + rs.Primary.ID, nil
+	}
+}
+
+func testAccConfigTerraformAgentPool_basic() string {
+	return `
+resource "dadcorp_terraform_agent_pool" "test" {
+  name = "test agent pool"
+}
+
+resource "dadcorp_terraform_agent_token" "test" {
+  agent_pool_id = dadcorp_terraform_agent_pool.test.id
+  description = "test agent"
+}
+`
+}
+
+func testAccConfigTerraformAgentPool_updated() string {
+	return `
+resource "dadcorp_terraform_agent_pool" "test" {
+  name = "test agent pool updated"
+}
+
+resource "dadcorp_terraform_agent_token" "test" {
+  agent_pool_id = dadcorp_terraform_agent_pool.test.id
+  description = "replacement test agent"
+}
+`
+}
+
+func TestAccTerraformOAuthClient_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformOAuthClient_basic(),
+			},
+			{
+				ResourceName:            "dadcorp_terraform_oauth_client.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"oauth_token"},
+			},
+			{
+				Config: testAccConfigTerraformOAuthClient_updated(),
+			},
+			{
+				ResourceName:            "dadcorp_terraform_oauth_client.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"oauth_token"},
+			},
+		},
+	})
+}
+
+func testAccConfigTerraformOAuthClient_basic() string {
+	return `
+resource "dadcorp_terraform_oauth_client" "test" {
+  name = "test oauth client"
+  service_provider = "github"
+  oauth_token = "test-token"
+}
+`
+}
+
+func testAccConfigTerraformOAuthClient_updated() string {
+	return `
+resource "dadcorp_terraform_oauth_client" "test" {
+  name = "test oauth client updated"
+  service_provider = "gitlab_enterprise"
+  http_url = "https://gitlab.example.com"
+  api_url = "https://gitlab.example.com/api/v4"
+  oauth_token = "test-token-rotated"
+}
+`
+}
+
+func TestAccTerraformWorkspaceOutputs_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformWorkspaceOutputs_basic(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_workspace_outputs.test", "values.%", "0"),
+					testAccTerraformUploadState("dadcorp_terraform_workspace.test", `{
+  "version": 4,
+  "serial": 1,
+  "lineage": "test-lineage",
+  "outputs": {
+    "greeting": {"value": "hello", "type": "string"},
+    "password": {"value": "hunter2", "type": "string", "sensitive": true}
+  },
+  "resources": []
+}`),
+				),
+			},
+			{
+				Config: testAccConfigTerraformWorkspaceOutputs_basic(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_workspace_outputs.test", "values.%", "2"),
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_workspace_outputs.test", "values.password", `"hunter2"`),
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_workspace_outputs.test", "nonsensitive_values.%", "1"),
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_workspace_outputs.test", "nonsensitive_values.greeting", `"hello"`),
+				),
+			},
+		},
+	})
+}
+
+func TestAccTerraformWorkspace_locked(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformWorkspace_locked(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_terraform_workspace.test", "locked", "false"),
+					testAccTerraformLockWorkspace("dadcorp_terraform_workspace.test", true),
+				),
+			},
+			{
+				Config: testAccConfigTerraformWorkspace_locked(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_terraform_workspace.test", "locked", "true"),
+					testAccTerraformLockWorkspace("dadcorp_terraform_workspace.test", false),
+				),
+			},
+		},
+	})
+}
+
+// testAccTerraformLockWorkspace locks or force-unlocks the workspace in the
+// named resource, outside of Terraform.
+func testAccTerraformLockWorkspace(name string, lock bool) resource.TestCheckFunc {
+	return func(s *sdkterraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found in state", name)
+		}
+		client, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"))
+		if err != nil {
+			return err
+		}
+		if lock {
+			_, err = client.Terraform.Workspaces.Lock(context.Background(), rs.Primary.ID, "acceptance-tests", "testing workspace locks")
+		} else {
+			_, err = client.Terraform.Workspaces.ForceUnlock(context.Background(), rs.Primary.ID)
+		}
+		return err
+	}
+}
+
+func testAccConfigTerraformWorkspace_locked() string {
+	return `
+resource "dadcorp_terraform_workspace" "test" {
+  name = "test workspace locked"
+}
+`
+}
+
+// TestAccTerraformWorkspace_crossProject checks that workspace IDs chosen by
+// the client can't be used to overwrite or read workspaces in other projects.
+// It talks to the API directly, as Terraform never chooses workspace IDs.
+func TestAccTerraformWorkspace_crossProject(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+	testAccPreCheck(t)
+
+	ctx := context.Background()
+	org, project, otherProject := os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"), os.Getenv("DADCORP_PROJECT")+"-cross-project"
+	testAccCreateProject(t, org, otherProject)
+	mine, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), org, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), org, otherProject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspace, err := mine.Terraform.Workspaces.Create(ctx, dadcorp.TerraformWorkspace{Name: "test workspace cross project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mine.Terraform.Workspaces.Delete(context.Background(), workspace.ID)
+	})
+
+	_, err = theirs.Terraform.Workspaces.Get(ctx, workspace.ID)
+	if err != dadcorp.ErrTerraformWorkspaceNotFound {
+		t.Errorf("expected workspace %s to be hidden from project %s, got %v", workspace.ID, otherProject, err)
+	}
+	clash, err := theirs.Terraform.Workspaces.Create(ctx, dadcorp.TerraformWorkspace{ID: workspace.ID, Name: workspace.Name})
+	if err != dadcorp.ErrTerraformWorkspaceAlreadyExists {
+		t.Errorf("expected creating workspace %s in project %s to be refused, got %v", workspace.ID, otherProject, err)
+	}
+	if err == nil {
+		t.Cleanup(func() {
+			theirs.Terraform.Workspaces.Delete(context.Background(), clash.ID)
+		})
+	}
+	got, err := mine.Terraform.Workspaces.Get(ctx, workspace.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Project != project || got.Name != workspace.Name {
+		t.Errorf("expected workspace %s to be left alone, got %+v", workspace.ID, got)
+	}
+
+	// IDs that aren't taken can still be chosen by the client
+	chosen, err := theirs.Terraform.Workspaces.Create(ctx, dadcorp.TerraformWorkspace{ID: workspace.ID + "-cross-project", Name: workspace.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		theirs.Terraform.Workspaces.Delete(context.Background(), chosen.ID)
+	})
+	if chosen.ID != workspace.ID+"-cross-project" {
+		t.Errorf("expected workspace to be created with ID %s-cross-project, got %s", workspace.ID, chosen.ID)
+	}
+}
+
+// testAccTerraformUploadState uploads state as a new state version of the
+// workspace in the named resource, outside of Terraform.
+func testAccTerraformUploadState(name, state string) resource.TestCheckFunc {
+	return func(s *sdkterraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found in state", name)
+		}
+		client, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"))
+		if err != nil {
+			return err
+		}
+		serial := int64(1)
+		_, err = client.Terraform.StateVersions.Create(context.Background(), dadcorp.TerraformStateVersion{
+			WorkspaceID: rs.Primary.ID,
+			Serial:      &serial,
+			State:       []byte(state),
+		})
+		return err
+	}
+}
+
+func testAccConfigTerraformWorkspaceOutputs_basic() string {
+	return `
+resource "dadcorp_terraform_workspace" "test" {
+  name = "test workspace outputs"
+}
+
+data "dadcorp_terraform_workspace_outputs" "test" {
+  workspace_id = dadcorp_terraform_workspace.test.id
+}
+`
+}
+
+func TestAccTerraformVersions_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformVersions("~> 1.5"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_versions.test", "id", "terraform_versions"),
+					resource.TestCheckResourceAttrSet("data.dadcorp_terraform_versions.test", "versions.#"),
+				),
+			},
+			{
+				Config:      testAccConfigTerraformVersions("latest"),
+				ExpectError: regexp.MustCompile("Invalid version constraint"),
+			},
+		},
+	})
+}
+
+func TestAccTerraformVersions_prereleases(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccTerraformAddBetaVersions(t, "2.0.0-beta1", "1.6.0-beta1")
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigTerraformVersions("~> 1.5"),
+				Check:  testAccTerraformVersionsExclude("data.dadcorp_terraform_versions.test", "2.0.0-beta1", "1.6.0-beta1"),
+			},
+			{
+				Config: testAccConfigTerraformVersions(">= 1.3"),
+				Check:  testAccTerraformVersionsExclude("data.dadcorp_terraform_versions.test", "2.0.0-beta1", "1.6.0-beta1"),
+			},
+			{
+				Config: testAccConfigTerraformVersions("1.6.0-beta1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_versions.test", "versions.#", "1"),
+					resource.TestCheckResourceAttr("data.dadcorp_terraform_versions.test", "versions.0", "1.6.0-beta1"),
+				),
+			},
+		},
+	})
+}
+
+// testAccTerraformAddBetaVersions adds versions to the catalog as betas, and
+// removes them again when the test is done.
+func testAccTerraformAddBetaVersions(t *testing.T, versions ...string) {
+	client, err := dadcorp.NewClient(baseURL, os.Getenv("DADCORP_USERNAME"), os.Getenv("DADCORP_PASSWORD"), os.Getenv("DADCORP_ORGANIZATION"), os.Getenv("DADCORP_PROJECT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range versions {
+		version := version
+		_, err = client.Terraform.Versions.Create(context.Background(), dadcorp.TerraformVersion{Version: version, Beta: true})
+		if err == dadcorp.ErrTerraformVersionAlreadyExists {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			client.Terraform.Versions.Delete(context.Background(), version)
+		})
+	}
+}
+
+// testAccTerraformVersionsExclude checks that none of versions are listed by
+// the named dadcorp_terraform_versions data source.
+func testAccTerraformVersionsExclude(name string, versions ...string) resource.TestCheckFunc {
+	return func(s *sdkterraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("data source %s not found in state", name)
+		}
+		for key, value := range rs.Primary.Attributes {
+			if key == "versions.#" || !strings.HasPrefix(key, "versions.") {
+				continue
+			}
+			for _, version := range versions {
+				if value == version {
+					return fmt.Errorf("%s lists %s, which its constraint shouldn't match", name, version)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func testAccConfigTerraformVersions(constraint string) string {
+	return fmt.Sprintf(`
+data "dadcorp_terraform_versions" "test" {
+  constraint = %q
+  include_beta = true
+}
+`, constraint)
+}
@@ -1,9 +1,12 @@
 package provider
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccVaultCluster_basic(t *testing.T) {
@@ -15,6 +18,9 @@ func TestAccVaultCluster_basic(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccConfigVaultCluster_basic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_vault_cluster.test", "sealed", "false"),
+				),
 			},
 			{
 				ResourceName:      "dadcorp_vault_cluster.test",
@@ -33,6 +39,87 @@ func TestAccVaultCluster_basic(t *testing.T) {
 	})
 }
 
+func TestAccVaultCluster_leaseTTLs(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccConfigVaultCluster_leaseTTLs("1 hour", "24h"),
+				ExpectError: regexp.MustCompile("Invalid lease TTL"),
+			},
+			{
+				Config:      testAccConfigVaultCluster_leaseTTLs("48h", "24h"),
+				ExpectError: regexp.MustCompile("Default lease TTL exceeds max lease TTL"),
+			},
+		},
+	})
+}
+
+func testAccConfigVaultCluster_leaseTTLs(defaultTTL, maxTTL string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_vault_cluster" "test" {
+  name = "lease ttl test cluster"
+  region = "us-va-1"
+  default_lease_ttl = %q
+  max_lease_ttl = %q
+
+  tcp_listener {
+    address = "1.2.3.4:8200"
+    cluster_address = "2.3.4.5:8201"
+  }
+}
+`, defaultTTL, maxTTL)
+}
+
+func TestAccVaultCluster_tcpListener(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccConfigVaultCluster_tcpListener("10.43.0.1", "10.43.0.2:8201"),
+				ExpectError: regexp.MustCompile("Invalid listener address"),
+			},
+			{
+				Config:      testAccConfigVaultCluster_tcpListener("10.43.0.1:8200", "10.43.0.1:8200"),
+				ExpectError: regexp.MustCompile("Conflicting listener addresses"),
+			},
+			{
+				Config: testAccConfigVaultCluster_tcpListener("10.43.0.1:8200", "10.43.0.2:8201") + `
+resource "dadcorp_vault_cluster" "conflict" {
+  name = "conflicting listener test cluster"
+  region = "us-va-1"
+
+  tcp_listener {
+    address = "10.43.0.1:8200"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile("already in use in the region"),
+			},
+		},
+	})
+}
+
+func testAccConfigVaultCluster_tcpListener(address, clusterAddress string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_vault_cluster" "test" {
+  name = "listener test cluster"
+  region = "us-va-1"
+
+  tcp_listener {
+    address = %q
+    cluster_address = %q
+  }
+}
+`, address, clusterAddress)
+}
+
 func testAccConfigVaultCluster_basic() string {
 	return `
 resource "dadcorp_vault_cluster" "test" {
@@ -42,8 +129,8 @@ resource "dadcorp_vault_cluster" "test" {
   max_lease_ttl = "24h"
 
   tcp_listener {
-    address = "1.2.3.4"
-    cluster_address = "2.3.4.5"
+    address = "1.2.3.4:8200"
+    cluster_address = "2.3.4.5:8201"
   }
 }
 `
@@ -58,9 +145,86 @@ resource "dadcorp_vault_cluster" "test" {
   max_lease_ttl = "48h"
 
   tcp_listener {
-    address = "2.3.4.5"
-    cluster_address = "3.4.5.6"
+    address = "2.3.4.5:8200"
+    cluster_address = "3.4.5.6:8201"
   }
 }
 `
 }
+
+func TestAccVaultSecret_basic(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfigVaultSecret("app/db", `{ password = "hunter2" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_vault_secret.test", "version", "1"),
+					resource.TestCheckResourceAttr("dadcorp_vault_secret.test", "data.password", "hunter2"),
+				),
+			},
+			{
+				ResourceName:            "dadcorp_vault_secret.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateIdFunc:       testAccVaultSecretImportID("dadcorp_vault_secret.test"),
+				ImportStateVerifyIgnore: []string{"data"},
+			},
+			{
+				Config: testAccConfigVaultSecret("app/db", `{ password = "hunter3", username = "admin" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("dadcorp_vault_secret.test", "version", "2"),
+					resource.TestCheckResourceAttr("dadcorp_vault_secret.test", "data.%", "2"),
+				),
+			},
+			{
+				Config:      testAccConfigVaultSecret("other/db", `{ password = "hunter2" }`),
+				ExpectError: regexp.MustCompile("Access denied"),
+			},
+			{
+				Config:      testAccConfigVaultSecret("app//db", `{ password = "hunter2" }`),
+				ExpectError: regexp.MustCompile("Invalid secret path"),
+			},
+		},
+	})
+}
+
+func testAccVaultSecretImportID(name string) resource.ImportStateIdFunc {
+	return func(state *sdkterraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", name)
+		}
+		return rs.Primary.Attributes["access_policy_id"] + "/" + rs.Primary.Attributes["cluster_id"] + "/" + rs.Primary.Attributes["path"], nil
+	}
+}
+
+func testAccConfigVaultSecret(path, data string) string {
+	return fmt.Sprintf(`
+resource "dadcorp_vault_cluster" "test" {
+  name = "test secrets cluster"
+  region = "us-va-1"
+}
+
+resource "dadcorp_access_policy" "test" {
+  type = "vault"
+  policy_data = {
+    cluster_id = dadcorp_vault_cluster.test.id
+    key = "app/"
+    read = true
+    write = true
+    delete = true
+  }
+}
+
+resource "dadcorp_vault_secret" "test" {
+  cluster_id = dadcorp_vault_cluster.test.id
+  access_policy_id = dadcorp_access_policy.test.id
+  path = %q
+  data = %s
+}
+`, path, data)
+}